@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"shooter/ability"
+	"shooter/hud"
+	"shooter/input"
+	"shooter/player"
+)
+
+// abilityCharge is where an ability of the local player was at when the server last said.
+type abilityCharge struct {
+	ability.Status
+	at time.Time
+}
+
+// abilityEffects are what abilities do on the clients once the server let them go off.
+// Abilities without one only do something on the server, see abilityHandlers. mu must be held.
+var abilityEffects = map[ability.ID]func(g *Game, use UseAbility){
+	ability.SpeedBurst: func(g *Game, use UseAbility) {
+		if use.PlayerID == g.player.ID {
+			g.burstUntil = g.now().Add(ability.Defs[ability.SpeedBurst].Duration)
+		}
+	},
+	ability.Grapple: func(g *Game, use UseAbility) {
+		g.addRope(use)
+	},
+	ability.Adrenaline: func(g *Game, use UseAbility) {
+		if use.PlayerID == g.player.ID && g.player.Health > 0 {
+			g.player.Health = g.player.FullHealth()
+		}
+	},
+}
+
+// updateAbilities asks the server to use the ability, ultimate or a gadget of the player's class
+// once it's charged, it goes off when the server agrees. Grapples pull the player right away.
+func (g *Game) updateAbilities() {
+	if !g.rules.Abilities || g.player.Health <= 0 {
+		return
+	}
+	var id ability.ID
+	switch {
+	case g.input.Ability:
+		id = g.kit.Ability
+	case g.input.Ultimate:
+		id = g.kit.Ultimate
+	case g.input.Grapple:
+		id = ability.Grapple
+	default:
+		return
+	}
+	if g.charge(id, g.now()).Charges == 0 {
+		return
+	}
+	use := UseAbility{PlayerID: g.player.ID, Ability: id, Angle: g.player.Angle}
+	if id == ability.Grapple && !g.fireGrapple(use) {
+		return
+	}
+	g.sendEvent(player.EventTypeAbility, use)
+}
+
+// abilityUsed plays out an ability the server let go off, mu must be held.
+func (g *Game) abilityUsed(use UseAbility) {
+	if effect, ok := abilityEffects[use.Ability]; ok {
+		effect(g, use)
+	}
+}
+
+// setAbilities keeps the local player's charges, mu must be held.
+func (g *Game) setAbilities(s AbilityStatus) {
+	if s.PlayerID != g.player.ID {
+		return
+	}
+	for _, st := range s.Abilities {
+		g.abilities[st.ID] = abilityCharge{Status: st, at: g.now()}
+	}
+}
+
+// charge is where the local player's ability is at by now, counting the charges which came
+// back since the server last said. Abilities start charged and ultimates empty.
+func (g *Game) charge(id ability.ID, now time.Time) ability.Status {
+	def := ability.Defs[id]
+	c, ok := g.abilities[id]
+	if !ok {
+		if def.Ultimate() {
+			return ability.Status{ID: id}
+		}
+		return ability.Status{ID: id, Charges: def.Charges}
+	}
+	s := c.Status
+	if s.Recharge == 0 || def.Cooldown <= 0 {
+		return s
+	}
+	left := s.Recharge - now.Sub(c.at)
+	for left <= 0 && s.Charges < def.Charges {
+		s.Charges++
+		left += def.Cooldown
+	}
+	s.Recharge = left
+	if s.Charges == def.Charges {
+		s.Recharge = 0
+	}
+	return s
+}
+
+// abilitySpeed multiplies the local player's speed during a speed burst.
+func (g *Game) abilitySpeed() float64 {
+	if g.now().Before(g.burstUntil) {
+		return ability.SpeedBurstFactor
+	}
+	return 1
+}
+
+// abilityHUD is the local player's abilities for the HUD, nil on servers without abilities.
+func (g *Game) abilityHUD() []hud.AbilityState {
+	if !g.rules.Abilities || g.observer {
+		return nil
+	}
+	now := g.now()
+	keys := map[ability.ID]input.Action{g.kit.Ability: input.Ability, g.kit.Ultimate: input.Ultimate, ability.Grapple: input.Grapple}
+	var states []hud.AbilityState
+	for _, id := range g.kit.IDs() {
+		def, s := ability.Defs[id], g.charge(id, now)
+		state := hud.AbilityState{Name: def.Name, Key: g.app.input.Key(keys[id]).String(), Charges: s.Charges, MaxCharges: def.Charges}
+		if def.Ultimate() {
+			state.Progress = float64(s.Kills) / float64(def.Kills)
+		} else if s.Recharge > 0 {
+			state.Progress = 1 - s.Recharge.Seconds()/def.Cooldown.Seconds()
+			state.Left = s.Recharge
+		}
+		states = append(states, state)
+	}
+	return states
+}
+
+// setScan shows the enemies the side's recon pulse found on the minimap until it fades, mu must be held.
+func (g *Game) setScan(s Scan) {
+	until := g.now().Add(ability.Defs[ability.Scan].Duration)
+	for _, id := range s.Revealed {
+		g.revealed[id] = until
+	}
+	if s.PlayerID == g.player.ID {
+		g.killfeed.AddMessage(fmt.Sprintf("Recon pulse found %d enemies", len(s.Revealed)))
+	}
+}
+
+// revealedPlayers are the minimap markers of the enemies recon pulses found, as of their last
+// update. Updates of the others never come past walls.
+func (g *Game) revealedPlayers() []hud.MinimapPlayer {
+	now := g.now()
+	var markers []hud.MinimapPlayer
+	for id, until := range g.revealed {
+		p, ok := g.players[id]
+		if !now.Before(until) || !ok || p.Hidden || p.Health <= 0 {
+			continue
+		}
+		markers = append(markers, hud.MinimapPlayer{X: p.X, Y: p.Y, Enemy: true})
+	}
+	return markers
+}
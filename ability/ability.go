@@ -0,0 +1,222 @@
+// Package ability is the abilities of the player classes, their cooldowns and charges.
+// Adding one is a Def here and a handler where it takes effect.
+package ability
+
+import (
+	"slices"
+	"time"
+
+	"shooter/grapple"
+)
+
+// ID names an ability in events and in Defs.
+type ID string
+
+const (
+	// Runs faster for a while
+	SpeedBurst ID = "speed_burst"
+	// Back to full health
+	Adrenaline ID = "adrenaline"
+	// Barricade in front of the player
+	Wall ID = "wall"
+	// Turret in front of the player
+	Turret ID = "turret"
+	// Shows the enemies around the player to their side for a while
+	Scan ID = "scan"
+	// Jammer in front of the player, hiding their side from enemy scans
+	Jammer ID = "jammer"
+	// Hooks the wall the player faces and reels them to it, see package grapple
+	Grapple ID = "grapple"
+)
+
+const (
+	// Speed of a player in a SpeedBurst relative to their normal speed
+	SpeedBurstFactor = 1.6
+	// Farthest from the player an ability places something
+	Reach = 40.0
+	// How far around the player a Scan finds enemies
+	ScanRadius = 700.0
+)
+
+// Def is what an ability does to the player using it. Abilities charge over time with a
+// Cooldown, ultimates with Kills instead.
+type Def struct {
+	Name string
+	// Time for a used charge to come back
+	Cooldown time.Duration
+	// Kills for an ultimate to charge
+	Kills int
+	// Uses in a row before having to wait, at least 1
+	Charges int
+	// How long the effect lasts, 0 for right away
+	Duration time.Duration
+}
+
+// Ultimate is true for abilities charged by kills.
+func (d Def) Ultimate() bool {
+	return d.Kills > 0
+}
+
+var Defs = map[ID]Def{
+	SpeedBurst: {Name: "Speed burst", Cooldown: 12 * time.Second, Charges: 2, Duration: 3 * time.Second},
+	Adrenaline: {Name: "Adrenaline", Kills: 4, Charges: 1},
+	Wall:       {Name: "Wall", Cooldown: 20 * time.Second, Charges: 1},
+	Turret:     {Name: "Turret", Kills: 5, Charges: 1},
+	Scan:       {Name: "Recon pulse", Cooldown: 25 * time.Second, Charges: 1, Duration: 4 * time.Second},
+	Jammer:     {Name: "Jammer", Kills: 3, Charges: 1},
+	Grapple:    {Name: "Grapple", Cooldown: 8 * time.Second, Charges: 1, Duration: grapple.ReelTime},
+}
+
+// Gadgets are the abilities of every class.
+var Gadgets = []ID{Grapple}
+
+// Class is what a player picks to play as, with its Kit.
+type Class string
+
+const (
+	Scout    Class = "scout"
+	Engineer Class = "engineer"
+	Recon    Class = "recon"
+)
+
+// Kit is the ability and the ultimate of a class.
+type Kit struct {
+	Ability  ID
+	Ultimate ID
+}
+
+var Classes = map[Class]Kit{
+	Scout:    {Ability: SpeedBurst, Ultimate: Adrenaline},
+	Engineer: {Ability: Wall, Ultimate: Turret},
+	Recon:    {Ability: Scan, Ultimate: Jammer},
+}
+
+// ClassNames are the classes in the order players pick from.
+var ClassNames = []Class{Scout, Engineer, Recon}
+
+// KitOf returns the kit of the class, unknown classes are scouts.
+func KitOf(c Class) Kit {
+	if kit, ok := Classes[c]; ok {
+		return kit
+	}
+	return Classes[Scout]
+}
+
+// Has is true when the ability is in the kit or a gadget.
+func (k Kit) Has(id ID) bool {
+	return id != "" && (k.Ability == id || k.Ultimate == id || slices.Contains(Gadgets, id))
+}
+
+// IDs are the ability, the ultimate and the gadgets.
+func (k Kit) IDs() []ID {
+	return append([]ID{k.Ability, k.Ultimate}, Gadgets...)
+}
+
+// Status is where a player's ability is at, for the HUD.
+type Status struct {
+	ID      ID  `json:"id"`
+	Charges int `json:"charges"`
+	// Until the next charge comes back, 0 when it isn't charging or is an ultimate
+	Recharge time.Duration `json:"recharge,omitempty"`
+	// Towards the next charge of an ultimate
+	Kills int `json:"kills,omitempty"`
+}
+
+type state struct {
+	charges int
+	// When the next charge comes back
+	next  time.Time
+	kills int
+	// Until when the effect lasts
+	active time.Time
+}
+
+// Tracker keeps the charges of every player's abilities. Abilities start charged,
+// ultimates empty.
+type Tracker struct {
+	players map[string]map[ID]*state
+}
+
+func NewTracker() *Tracker {
+	return &Tracker{players: map[string]map[ID]*state{}}
+}
+
+// state returns the player's ability with the charges which came back by now.
+func (t *Tracker) state(playerID string, id ID, now time.Time) *state {
+	abilities, ok := t.players[playerID]
+	if !ok {
+		abilities = map[ID]*state{}
+		t.players[playerID] = abilities
+	}
+	def := Defs[id]
+	st, ok := abilities[id]
+	if !ok {
+		st = &state{}
+		if !def.Ultimate() {
+			st.charges = def.Charges
+		}
+		abilities[id] = st
+	}
+	for !def.Ultimate() && st.charges < def.Charges && !now.Before(st.next) {
+		st.charges++
+		st.next = st.next.Add(def.Cooldown)
+	}
+	return st
+}
+
+// Use spends a charge of the ability, false when it has none left.
+func (t *Tracker) Use(playerID string, id ID, now time.Time) bool {
+	def, ok := Defs[id]
+	if !ok {
+		return false
+	}
+	st := t.state(playerID, id, now)
+	if st.charges == 0 {
+		return false
+	}
+	if st.charges == def.Charges {
+		st.next = now.Add(def.Cooldown)
+	}
+	st.charges--
+	st.active = now.Add(def.Duration)
+	return true
+}
+
+// Kill charges the player's ultimate, true when that gave it a charge.
+func (t *Tracker) Kill(playerID string, id ID, now time.Time) bool {
+	def := Defs[id]
+	if !def.Ultimate() {
+		return false
+	}
+	st := t.state(playerID, id, now)
+	if st.charges == def.Charges {
+		return false
+	}
+	st.kills++
+	if st.kills < def.Kills {
+		return false
+	}
+	st.kills = 0
+	st.charges++
+	return true
+}
+
+// Active is true while the effect of the player's last use of the ability lasts.
+func (t *Tracker) Active(playerID string, id ID, now time.Time) bool {
+	st, ok := t.players[playerID][id]
+	return ok && now.Before(st.active)
+}
+
+func (t *Tracker) Status(playerID string, id ID, now time.Time) Status {
+	st := t.state(playerID, id, now)
+	s := Status{ID: id, Charges: st.charges, Kills: st.kills}
+	if def := Defs[id]; !def.Ultimate() && st.charges < def.Charges {
+		s.Recharge = st.next.Sub(now)
+	}
+	return s
+}
+
+// Forget drops the player's abilities, they start over when they come back.
+func (t *Tracker) Forget(playerID string) {
+	delete(t.players, playerID)
+}
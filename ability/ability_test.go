@@ -0,0 +1,79 @@
+package ability
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCooldown(t *testing.T) {
+	tr := NewTracker()
+	now := time.Unix(1000, 0)
+	def := Defs[SpeedBurst]
+	for i := 0; i < def.Charges; i++ {
+		if !tr.Use("a", SpeedBurst, now) {
+			t.Fatalf("charge %d wasn't there", i+1)
+		}
+	}
+	if tr.Use("a", SpeedBurst, now) {
+		t.Fatal("used with no charges left")
+	}
+	if !tr.Active("a", SpeedBurst, now.Add(def.Duration-time.Millisecond)) || tr.Active("a", SpeedBurst, now.Add(def.Duration)) {
+		t.Error("speed burst doesn't last its duration")
+	}
+	if s := tr.Status("a", SpeedBurst, now.Add(time.Second)); s.Charges != 0 || s.Recharge != def.Cooldown-time.Second {
+		t.Errorf("status while charging = %+v", s)
+	}
+
+	// Charges come back one cooldown after another
+	if s := tr.Status("a", SpeedBurst, now.Add(def.Cooldown)); s.Charges != 1 || s.Recharge != def.Cooldown {
+		t.Errorf("status after one cooldown = %+v", s)
+	}
+	if s := tr.Status("a", SpeedBurst, now.Add(2*def.Cooldown)); s.Charges != 2 || s.Recharge != 0 {
+		t.Errorf("status after two cooldowns = %+v", s)
+	}
+	if s := tr.Status("b", SpeedBurst, now); s.Charges != def.Charges {
+		t.Errorf("others' charges = %+v", s)
+	}
+}
+
+func TestUltimate(t *testing.T) {
+	tr := NewTracker()
+	now := time.Unix(1000, 0)
+	if tr.Use("a", Turret, now) {
+		t.Fatal("ultimate charged from the start")
+	}
+	for i := 1; i < Defs[Turret].Kills; i++ {
+		if tr.Kill("a", Turret, now) {
+			t.Fatalf("charged after %d kills", i)
+		}
+	}
+	if !tr.Kill("a", Turret, now) {
+		t.Fatal("not charged after enough kills")
+	}
+	// Kills don't count while it's charged
+	tr.Kill("a", Turret, now)
+	if s := tr.Status("a", Turret, now); s.Charges != 1 || s.Kills != 0 {
+		t.Errorf("charged status = %+v", s)
+	}
+	if !tr.Use("a", Turret, now) || tr.Use("a", Turret, now.Add(time.Hour)) {
+		t.Error("ultimate recharged over time")
+	}
+	if tr.Kill("a", Wall, now) {
+		t.Error("kills charged an ability")
+	}
+}
+
+func TestKits(t *testing.T) {
+	for _, c := range ClassNames {
+		kit := Classes[c]
+		if Defs[kit.Ability].Ultimate() || !Defs[kit.Ultimate].Ultimate() {
+			t.Errorf("%s kit %+v mixes up its ability and ultimate", c, kit)
+		}
+	}
+	if KitOf("unknown") != Classes[Scout] || KitOf(Scout).Has("") {
+		t.Error("unknown classes aren't scouts")
+	}
+	if !KitOf(Engineer).Has(Grapple) || KitOf(Engineer).Has(SpeedBurst) {
+		t.Error("engineers don't have the gadgets alone")
+	}
+}
@@ -0,0 +1,633 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"shooter/ability"
+	"shooter/audio"
+	"shooter/config"
+	"shooter/hud"
+	"shooter/input"
+	"shooter/level"
+	"shooter/match"
+	"shooter/player"
+	"shooter/ui"
+	"shooter/weapon"
+)
+
+// Scene is what the App currently shows, a menu or the game itself.
+type Scene interface {
+	Update() error
+	Draw(screen *ebiten.Image)
+}
+
+// App owns everything which outlives a single match and switches between scenes.
+type App struct {
+	cfg   *config.Config
+	audio *audio.Manager
+	music *audio.Music
+	input *input.Controller
+	scene Scene
+	quit  bool
+	// Nil when not in a party
+	party *Party
+	// Party chat being typed
+	partyText string
+
+	// Where the world is drawn in the window, updated in Layout
+	viewport Viewport
+	// End of the last frame, for the FPS limit
+	lastFrame time.Time
+	// Address of the server last joined, for crash bundles
+	server string
+}
+
+var (
+	WindowModes       = []string{"windowed", "fullscreen", "borderless"}
+	GameModes         = []string{string(match.Deathmatch), string(match.GunGame), string(match.Elimination), string(match.Duel), string(match.Practice), string(match.Economy)}
+	FriendlyFireModes = []string{string(match.FriendlyFireOn), string(match.FriendlyFireOff), string(match.FriendlyFireReflect)}
+	TeamKillLimits    = []int{0, 1, 2, 3, 5, 10}
+	RespawnTimes      = []int{0, 1, 3, 10, 20}
+	StartHealths      = []int{0, 50, 150, 200}
+	SpeedFactors      = []float64{0, 0.75, 1.25, 1.5, 2}
+	FragLimits        = []int{0, 5, 10, 30, 50}
+	TimeLimits        = []int{0, 120, 600, 900, 1800}
+	VehicleCounts     = []int{0, 1, 2, 4}
+	Classes           = []string{string(ability.Scout), string(ability.Engineer), string(ability.Recon)}
+	Resolutions       = []string{"1280x720", "1600x900", "1920x1080", "2560x1440"}
+	FPSLimits         = []int{0, 30, 60, 120, 144, 240}
+	TickRates         = []int{30, 60, 120}
+	Sensitivities     = []float64{0.25, 0.5, 0.75, 1, 1.25, 1.5, 2, 3}
+	SendRates         = []int{10, 20, 30, 60}
+	ServerTickRates   = []int{20, 30, 60, 64}
+	PlayerLimits      = []int{0, 2, 4, 8, 16, 32, 64}
+	TagDistances      = []float64{0, 300, 600, 1000, 2000}
+)
+
+// Viewport fits the fixed size world into the window, keeping the aspect ratio with letterboxing.
+type Viewport struct {
+	Scale            float64
+	OffsetX, OffsetY float64
+}
+
+func NewViewport(w, h int) Viewport {
+	scale := math.Min(float64(w)/ScreenWidth, float64(h)/ScreenHeight)
+	return Viewport{
+		Scale:   scale,
+		OffsetX: (float64(w) - ScreenWidth*scale) / 2,
+		OffsetY: (float64(h) - ScreenHeight*scale) / 2,
+	}
+}
+
+func (v Viewport) ToWorld(x, y float64) (float64, float64) {
+	return (x - v.OffsetX) / v.Scale, (y - v.OffsetY) / v.Scale
+}
+
+func (v Viewport) ToScreen(x, y float64) (float64, float64) {
+	return x*v.Scale + v.OffsetX, y*v.Scale + v.OffsetY
+}
+
+// Apply places an image drawn in world coordinates onto the screen.
+func (v Viewport) Apply(op *ebiten.DrawImageOptions) {
+	op.GeoM.Scale(v.Scale, v.Scale)
+	op.GeoM.Translate(v.OffsetX, v.OffsetY)
+}
+
+func NewApp(cfg *config.Config) *App {
+	sounds := audio.NewManager(&cfg.Audio)
+	sounds.Preload(audio.SoundGunshot, audio.SoundReload, audio.SoundFootstep, audio.SoundHit, audio.SoundDeath, audio.SoundExplode)
+
+	return &App{
+		cfg:   cfg,
+		audio: sounds,
+		music: audio.NewMusic(sounds),
+		input: input.NewController(&cfg.Input),
+	}
+}
+
+func (a *App) Update() error {
+	defer reporter.Recover("app update", a.crashed)
+	if a.quit {
+		return ebiten.Termination
+	}
+	a.followParty()
+	return a.scene.Update()
+}
+
+func (a *App) Draw(screen *ebiten.Image) {
+	defer reporter.Recover("app draw", a.crashed)
+	a.scene.Draw(screen)
+	a.limitFPS()
+}
+
+// crashed leaves the scene which panicked for the main menu, the game's connection is closed.
+func (a *App) crashed(path string) {
+	if g, ok := a.scene.(*Game); ok {
+		g.conn.Close()
+	}
+	a.showMainMenu(crashStatus(path))
+}
+
+// netState is the network part of crash bundles, it's called from any goroutine.
+func (a *App) netState() any {
+	return NetState{Server: a.server, FakeNet: fakeNet}
+}
+
+// limitFPS sleeps for the rest of the frame time, ebiten only caps frames with vsync.
+func (a *App) limitFPS() {
+	limit := a.cfg.Video.FPSLimit
+	if a.cfg.Video.VSync || limit <= 0 {
+		return
+	}
+	frame := time.Second / time.Duration(limit)
+	if d := frame - time.Since(a.lastFrame); d > 0 {
+		time.Sleep(d)
+	}
+	a.lastFrame = time.Now()
+}
+
+// applyPacing sets vsync and the tick rate from the config.
+func (a *App) applyPacing() {
+	ebiten.SetVsyncEnabled(a.cfg.Video.VSync)
+	if a.cfg.Video.TPS > 0 {
+		ebiten.SetTPS(a.cfg.Video.TPS)
+	}
+}
+
+// Layout renders at the window's native resolution, the world is scaled by the viewport.
+func (a *App) Layout(outsideWidth, outsideHeight int) (int, int) {
+	s := ebiten.Monitor().DeviceScaleFactor()
+	w, h := int(float64(outsideWidth)*s), int(float64(outsideHeight)*s)
+	a.viewport = NewViewport(w, h)
+	a.input.Layout(w, h)
+	return w, h
+}
+
+// applyVideo sets the window mode and size from the config.
+func (a *App) applyVideo() {
+	switch a.cfg.Video.WindowMode {
+	case "fullscreen":
+		ebiten.SetWindowDecorated(true)
+		ebiten.SetFullscreen(true)
+	case "borderless":
+		ebiten.SetFullscreen(false)
+		ebiten.SetWindowDecorated(false)
+		ebiten.SetWindowPosition(0, 0)
+		ebiten.SetWindowSize(ebiten.Monitor().Size())
+	default:
+		w, h := ScreenWidth, ScreenHeight
+		if _, err := fmt.Sscanf(a.cfg.Video.Resolution, "%dx%d", &w, &h); err != nil {
+			log.Println("Invalid resolution:", a.cfg.Video.Resolution)
+		}
+		ebiten.SetFullscreen(false)
+		ebiten.SetWindowDecorated(true)
+		ebiten.SetWindowSize(w, h)
+	}
+}
+
+// playerKey returns the config.Player.Key, making one the first time.
+func (a *App) playerKey() string {
+	if a.cfg.Player.Key == "" {
+		key, err := config.NewKey()
+		if err != nil {
+			log.Println("Error making a player key:", err)
+			return ""
+		}
+		a.cfg.Player.Key = key
+		a.saveConfig()
+	}
+	return a.cfg.Player.Key
+}
+
+func (a *App) saveConfig() {
+	if err := a.cfg.Save(); err != nil {
+		log.Println("Error saving config:", err)
+	}
+}
+
+// menuScene shows a menu over the background image.
+type menuScene struct {
+	app  *App
+	menu *ui.Menu
+}
+
+func (s *menuScene) Update() error {
+	ebiten.SetCursorMode(ebiten.CursorModeVisible)
+	s.app.music.SetState(audio.MusicMenu)
+	s.app.music.Update()
+	s.menu.Update()
+	return nil
+}
+
+func (s *menuScene) Draw(screen *ebiten.Image) {
+	// Cover the whole window, cropping the background if needed
+	b, bg := screen.Bounds(), bgImage.Bounds()
+	scale := math.Max(float64(b.Dx())/float64(bg.Dx()), float64(b.Dy())/float64(bg.Dy()))
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Scale(scale, scale)
+	op.GeoM.Translate((float64(b.Dx())-float64(bg.Dx())*scale)/2, (float64(b.Dy())-float64(bg.Dy())*scale)/2)
+	op.Filter = ebiten.FilterLinear
+	screen.DrawImage(bgImage, op)
+	s.menu.Draw(screen)
+}
+
+func (a *App) showMenu(menu *ui.Menu) {
+	a.scene = &menuScene{app: a, menu: menu}
+}
+
+func (a *App) showMainMenu(status string) {
+	menu := ui.NewMenu("SHOOTER",
+		ui.TextField("Name", &a.cfg.Player.Name),
+		ui.TextField("Server", &a.cfg.Player.Server),
+		ui.Choice("Skin", player.Skins, &a.cfg.Player.Skin, nil),
+		ui.Choice("Class", Classes, &a.cfg.Player.Class, nil),
+		ui.Button("Loadout", func() {
+			a.showMenu(a.loadoutMenu(func() {
+				a.saveConfig()
+				a.showMainMenu("")
+			}))
+		}),
+		ui.Button("Join game", func() {
+			a.saveConfig()
+			a.join(Hello{Name: a.cfg.Player.Name}, a.cfg.Player.Server, level.Default)
+		}),
+		ui.Button("Watch game", func() {
+			a.saveConfig()
+			a.join(Hello{Name: a.cfg.Player.Name, Observer: true}, a.cfg.Player.Server, level.Default)
+		}),
+		ui.Button("Party", func() {
+			a.saveConfig()
+			a.showMenu(a.partyMenu(""))
+		}),
+		ui.Button("Server info", a.showServerInfo),
+		ui.Button("Host game", a.host),
+		ui.Button("Settings", func() {
+			a.showMenu(a.settingsMenu(func() { a.showMainMenu("") }))
+		}),
+		ui.Button("Quit", func() { a.quit = true }),
+	)
+	menu.Status = status
+	a.showMenu(menu)
+	if a.party != nil {
+		a.party.SetStatus(PartyInMenu)
+	}
+}
+
+func (a *App) join(hello Hello, address, levelName string) {
+	lvl, ok := level.Get(levelName)
+	if !ok {
+		a.showMainMenu("Unknown map: " + levelName)
+		return
+	}
+	if a.party != nil && !hello.Observer {
+		hello.Party = a.party.ID()
+	}
+	hello.Class = ability.Class(a.cfg.Player.Class)
+	hello.Key = a.playerKey()
+	if hello.Observer {
+		hello.Token = a.cfg.Network.ObserverToken
+	}
+
+	a.server = address
+	g, err := NewGame(a, hello, address, lvl)
+	var rejected *RejectedError
+	if errors.As(err, &rejected) {
+		log.Println("Server rejected joining:", err)
+		a.showMainMenu(rejected.Reason)
+		return
+	}
+	if err != nil {
+		log.Println("Failed to connect to server:", err)
+		a.showMainMenu("Failed to connect: " + err.Error())
+		return
+	}
+	a.scene = g
+	if a.party != nil && !hello.Observer {
+		a.party.SetStatus("playing on " + address)
+		a.party.Follow(address)
+	}
+}
+
+// showServerInfo shows the listing of the server in the menu's status line.
+func (a *App) showServerInfo() {
+	a.saveConfig()
+	info, err := queryServer(a.cfg.Player.Server)
+	if err != nil {
+		log.Println("Failed to query server:", err)
+		a.showMainMenu("Failed to connect: " + err.Error())
+		return
+	}
+	a.showMainMenu(info.Listing())
+}
+
+// host starts a server in the background and joins it.
+func (a *App) host() {
+	listener, err := net.Listen("tcp", ServerPort)
+	if err != nil {
+		a.showMainMenu("Failed to start server: " + err.Error())
+		return
+	}
+	go NewServer(level.Default, a.cfg.Network).Serve(listener)
+
+	a.join(Hello{Name: a.cfg.Player.Name}, "localhost"+ServerPort, level.Default)
+}
+
+// settingsMenu edits the config, which is saved when leaving through back.
+func (a *App) settingsMenu(back func()) *ui.Menu {
+	var menu *ui.Menu
+	reopen := func() { a.openMenu(menu) }
+	menu = ui.NewMenu("SETTINGS",
+		ui.Button("Video", func() { a.openMenu(a.videoMenu(reopen)) }),
+		ui.Button("Interface", func() { a.openMenu(a.interfaceMenu(reopen)) }),
+		ui.Button("Audio", func() { a.openMenu(a.audioMenu(reopen)) }),
+		ui.Button("Controls", func() { a.openMenu(a.controlsMenu(reopen)) }),
+		ui.Button("Gamepad", func() { a.openMenu(a.gamepadMenu(reopen)) }),
+		ui.Button("Network", func() { a.openMenu(a.networkMenu(reopen)) }),
+		ui.Button("Back", func() {
+			a.saveConfig()
+			back()
+		}),
+	)
+	menu.Back = func() {
+		a.saveConfig()
+		back()
+	}
+	return menu
+}
+
+func (a *App) videoMenu(back func()) *ui.Menu {
+	return subMenu("VIDEO", back,
+		ui.Choice("Window", WindowModes, &a.cfg.Video.WindowMode, a.applyVideo),
+		ui.Choice("Resolution", Resolutions, &a.cfg.Video.Resolution, a.applyVideo),
+		ui.Toggle("VSync", &a.cfg.Video.VSync, a.applyPacing),
+		ui.Choice("FPS limit (0 = off)", FPSLimits, &a.cfg.Video.FPSLimit, a.applyPacing),
+		ui.Choice("Tick rate", TickRates, &a.cfg.Video.TPS, a.applyPacing),
+		ui.Slider("Screen shake", &a.cfg.Video.ScreenShake, 0.1, nil),
+		ui.Toggle("Record matches", &a.cfg.Video.RecordMatches, nil),
+	)
+}
+
+// loadoutMenu picks the attachment of every weapon, fitted when joining a game.
+func (a *App) loadoutMenu(back func()) *ui.Menu {
+	items := []*ui.Item{}
+	for _, id := range weapon.Loadout {
+		attachment := weapon.Get(id).With(weapon.Attachment(a.cfg.Player.Attachments[string(id)])).Attachment
+		items = append(items, ui.Choice(weapon.Get(id).Name, weapon.Attachments, &attachment, func() {
+			if a.cfg.Player.Attachments == nil {
+				a.cfg.Player.Attachments = map[string]string{}
+			}
+			a.cfg.Player.Attachments[string(id)] = string(attachment)
+		}))
+	}
+	return subMenu("LOADOUT", back, items...)
+}
+
+func (a *App) interfaceMenu(back func()) *ui.Menu {
+	return subMenu("INTERFACE", back,
+		ui.Toggle("Damage numbers", &a.cfg.HUD.ShowDamageNumbers, nil),
+		ui.Choice("Crosshair", hud.CrosshairStyles, &a.cfg.HUD.Crosshair.Style, nil),
+		ui.Choice("Crosshair color", hud.CrosshairColors, &a.cfg.HUD.Crosshair.Color, nil),
+		ui.Slider("Crosshair size", &a.cfg.HUD.Crosshair.Size, 0.1, nil),
+		ui.Toggle("Dynamic crosshair", &a.cfg.HUD.Crosshair.Dynamic, nil),
+		ui.Toggle("Crosshair target distance", &a.cfg.HUD.Crosshair.ShowDistance, nil),
+		ui.Choice("Name tag distance (0 = off)", TagDistances, &a.cfg.HUD.NameTagDistance, nil),
+		ui.Toggle("Frame time breakdown", &a.cfg.HUD.FrameTimes, nil),
+		ui.Toggle("Rejected hits", &a.cfg.HUD.RejectedHits, nil),
+	)
+}
+
+func (a *App) audioMenu(back func()) *ui.Menu {
+	return subMenu("AUDIO", back,
+		ui.Slider("Master volume", &a.cfg.Audio.MasterVolume, 0.1, nil),
+		ui.Slider("Effects volume", &a.cfg.Audio.SFXVolume, 0.1, nil),
+		ui.Slider("Music volume", &a.cfg.Audio.MusicVolume, 0.1, nil),
+	)
+}
+
+func (a *App) gamepadMenu(back func()) *ui.Menu {
+	items := []*ui.Item{
+		ui.Slider("Move deadzone", &a.cfg.Input.MoveDeadzone, 0.05, nil),
+		ui.Slider("Aim deadzone", &a.cfg.Input.AimDeadzone, 0.05, nil),
+		ui.Slider("Aim assist", &a.cfg.Input.AimAssist, 0.1, nil),
+	}
+	for _, action := range input.Actions {
+		if _, ok := a.input.Button(action); !ok {
+			continue
+		}
+		items = append(items, ui.Rebind(actionNames[action],
+			func() string { return a.input.ButtonName(action) },
+			func() bool {
+				b, ok := input.CaptureButton()
+				if ok {
+					a.input.BindButton(action, b)
+				}
+				return ok
+			}))
+	}
+	items = append(items, ui.Button("Reset buttons", func() { a.cfg.Input.GamepadButtons = nil }))
+	return subMenu("GAMEPAD", back, items...)
+}
+
+func (a *App) networkMenu(back func()) *ui.Menu {
+	return subMenu("NETWORK", back,
+		ui.Choice("Updates sent per second", SendRates, &a.cfg.Network.SendRate, nil),
+		ui.Choice("Hosted server updates per second", SendRates, &a.cfg.Network.BroadcastRate, nil),
+		ui.Choice("Hosted server tick rate", ServerTickRates, &a.cfg.Network.TickRate, nil),
+		ui.Choice("Hosted server max players (0 = no limit)", PlayerLimits, &a.cfg.Network.MaxPlayers, nil),
+		ui.Choice("Hosted server bot backfill (0 = none)", PlayerLimits, &a.cfg.Network.Backfill, nil),
+		ui.Choice("Hosted server mode", GameModes, &a.cfg.Network.Mode, nil),
+		ui.Choice("Hosted server friendly fire", FriendlyFireModes, &a.cfg.Network.FriendlyFire, nil),
+		ui.Choice("Hosted server team kills before kick (0 = never)", TeamKillLimits, &a.cfg.Network.TeamKillKick, nil),
+		ui.Toggle("Hosted server shrinking zone", &a.cfg.Network.Zone, nil),
+		ui.Choice("Hosted server respawn seconds (0 = default)", RespawnTimes, &a.cfg.Network.RespawnTime, nil),
+		ui.Choice("Hosted server starting health (0 = default)", StartHealths, &a.cfg.Network.StartHealth, nil),
+		ui.Choice("Hosted server speed multiplier (0 = default)", SpeedFactors, &a.cfg.Network.SpeedFactor, nil),
+		ui.Choice("Hosted server frag limit (0 = default)", FragLimits, &a.cfg.Network.FragLimit, nil),
+		ui.Choice("Hosted server time limit seconds (0 = default)", TimeLimits, &a.cfg.Network.TimeLimit, nil),
+		ui.Toggle("Hosted server infinite ammo", &a.cfg.Network.InfiniteAmmo, nil),
+		ui.Toggle("Hosted server class abilities", &a.cfg.Network.Abilities, nil),
+		ui.Choice("Hosted server buggies", VehicleCounts, &a.cfg.Network.Vehicles, nil),
+		ui.Toggle("Hosted server supply drops", &a.cfg.Network.SupplyDrops, nil),
+		ui.Toggle("Hosted server match logs", &a.cfg.Network.MatchLog, nil),
+		ui.Toggle("Hosted server ranked", &a.cfg.Network.Ranked, nil),
+	)
+}
+
+// partyMenu forms a party, shows who is in it and what they are up to, and the party chat.
+func (a *App) partyMenu(status string) *ui.Menu {
+	back := func() {
+		a.saveConfig()
+		a.showMainMenu("")
+	}
+	items := []*ui.Item{
+		ui.TextField("Leader address", &a.cfg.Player.Party),
+		ui.Button("Host party", func() {
+			a.leaveParty()
+			p, err := HostParty(a.cfg.Player.Name)
+			if err != nil {
+				a.showMenu(a.partyMenu("Failed to host party: " + err.Error()))
+				return
+			}
+			a.party = p
+			a.showMenu(a.partyMenu("Others join with your address and port " + PartyPort))
+		}),
+		ui.Button("Join party", func() {
+			a.leaveParty()
+			p, err := JoinParty(a.cfg.Player.Name, a.cfg.Player.Party)
+			if err != nil {
+				a.showMenu(a.partyMenu("Failed to join party: " + err.Error()))
+				return
+			}
+			a.party = p
+			a.showMenu(a.partyMenu(""))
+		}),
+		ui.Button("Leave party", func() {
+			a.leaveParty()
+			a.showMenu(a.partyMenu(""))
+		}),
+	}
+	for i := range MaxPartySize {
+		items = append(items, &ui.Item{Label: func() string {
+			if a.party == nil {
+				if i == 0 {
+					return "Not in a party"
+				}
+				return ""
+			}
+			state := a.party.State()
+			if i >= len(state.Members) {
+				return ""
+			}
+			m := state.Members[i]
+			if m.Name == state.Leader {
+				return fmt.Sprintf("%s (leader) - %s", m.Name, m.Status)
+			}
+			return fmt.Sprintf("%s - %s", m.Name, m.Status)
+		}})
+	}
+	for i := range PartyChatLines {
+		items = append(items, &ui.Item{Label: func() string {
+			if a.party == nil {
+				return ""
+			}
+			if chat := a.party.Chat(); i < len(chat) {
+				return chat[i]
+			}
+			return ""
+		}})
+	}
+	items = append(items,
+		ui.TextField("Say", &a.partyText),
+		ui.Button("Send", func() {
+			if a.party != nil {
+				a.party.Say(a.partyText)
+			}
+			a.partyText = ""
+		}),
+	)
+	menu := subMenu("PARTY", back, items...)
+	menu.Status = status
+	return menu
+}
+
+func (a *App) leaveParty() {
+	if a.party != nil {
+		a.party.Leave()
+		a.party = nil
+	}
+}
+
+// followParty joins the server the party leader joined, leaving the one being played.
+func (a *App) followParty() {
+	if a.party == nil {
+		return
+	}
+	if a.party.Closed() {
+		a.party = nil
+		return
+	}
+	server, ok := a.party.TakeFollow()
+	if !ok {
+		return
+	}
+	if g, ok := a.scene.(*Game); ok {
+		g.mu.Lock()
+		g.disconnect()
+		g.mu.Unlock()
+	}
+	a.join(Hello{Name: a.cfg.Player.Name}, server, level.Default)
+}
+
+// subMenu is a menu of items with a back button at the end.
+func subMenu(title string, back func(), items ...*ui.Item) *ui.Menu {
+	menu := ui.NewMenu(title, append(items, ui.Button("Back", back))...)
+	menu.Back = back
+	return menu
+}
+
+// openMenu shows the menu as a scene, or as an overlay when in game.
+func (a *App) openMenu(menu *ui.Menu) {
+	if g, ok := a.scene.(*Game); ok {
+		g.overlay = menu
+		return
+	}
+	a.showMenu(menu)
+}
+
+var actionNames = map[input.Action]string{
+	input.MoveUp:     "Move up",
+	input.MoveDown:   "Move down",
+	input.MoveLeft:   "Move left",
+	input.MoveRight:  "Move right",
+	input.Shoot:      "Shoot",
+	input.Aim:        "Aim down sights",
+	input.Reload:     "Reload",
+	input.Sprint:     "Sprint",
+	input.Flashlight: "Flashlight",
+	input.Interact:   "Interact",
+	input.Weapon1:    "Weapon 1",
+	input.Weapon2:    "Weapon 2",
+	input.Weapon3:    "Weapon 3",
+	input.NextWeapon: "Next weapon",
+	input.PrevWeapon: "Previous weapon",
+	input.Pause:      "Menu",
+	input.Ping:       "Ping",
+	input.Grenade:    "Grenade",
+	input.Decoy:      "Throw decoy",
+	input.Deploy:     "Deploy turret",
+	input.Barricade:  "Deploy barricade",
+	input.Vote:       "Vote menu",
+	input.Buy:        "Buy menu",
+	input.Ability:    "Class ability",
+	input.Ultimate:   "Class ultimate",
+	input.Grapple:    "Grappling hook",
+}
+
+// controlsMenu rebinds the keyboard and mouse, selecting an action waits for the new key.
+func (a *App) controlsMenu(back func()) *ui.Menu {
+	items := []*ui.Item{
+		ui.Choice("Mouse sensitivity", Sensitivities, &a.cfg.Input.MouseSensitivity, nil),
+		ui.Slider("Aim smoothing", &a.cfg.Input.AimSmoothing, 0.1, nil),
+		ui.Toggle("Toggle laser sight", &a.cfg.Input.ToggleAim, nil),
+	}
+	for _, action := range input.Actions {
+		items = append(items, ui.Rebind(actionNames[action],
+			func() string { return a.input.Key(action).String() },
+			func() bool {
+				b, ok := input.CaptureKey()
+				if ok {
+					a.input.BindKey(action, b)
+				}
+				return ok
+			}))
+	}
+	items = append(items, ui.Button("Reset keys", func() { a.cfg.Input.Keys = nil }))
+	return subMenu("CONTROLS", back, items...)
+}
@@ -0,0 +1,190 @@
+package audio
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"sync"
+
+	"github.com/hajimehoshi/ebiten/v2/audio"
+	"github.com/hajimehoshi/ebiten/v2/audio/wav"
+
+	"shooter/config"
+	"shooter/utils"
+)
+
+const (
+	SampleRate = 44100
+
+	// Sounds further than this from the listener are not played at all
+	MaxHearingDistance = 1200.0
+	// Within this distance sounds are played at full volume
+	MinFalloffDistance = 100.0
+	// Horizontal offset at which a sound is panned fully to one side
+	PanDistance = 800.0
+)
+
+type Sound string
+
+const (
+	SoundGunshot  Sound = "gunshot"
+	SoundReload   Sound = "reload"
+	SoundFootstep Sound = "footstep"
+	SoundHit      Sound = "hit"
+	SoundDeath    Sound = "death"
+	SoundExplode  Sound = "explosion"
+)
+
+type Manager struct {
+	ctx      *audio.Context
+	settings *config.Audio
+
+	mu        sync.Mutex
+	cache     map[Sound][]byte
+	listenerX float64
+	listenerY float64
+}
+
+func NewManager(settings *config.Audio) *Manager {
+	return &Manager{
+		ctx:      audio.NewContext(SampleRate),
+		settings: settings,
+		cache:    make(map[Sound][]byte),
+	}
+}
+
+// Preload decodes given sounds upfront so the first shot doesn't stutter.
+func (m *Manager) Preload(sounds ...Sound) {
+	for _, s := range sounds {
+		if _, err := m.load(s); err != nil {
+			log.Println("Error loading sound:", err)
+		}
+	}
+}
+
+// load returns decoded 16bit stereo PCM of the sound, decoding it on first use.
+func (m *Manager) load(s Sound) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if pcm, ok := m.cache[s]; ok {
+		return pcm, nil
+	}
+
+	data, err := utils.ReadAsset(fmt.Sprintf("assets/sounds/%s.wav", s))
+	if err != nil {
+		return nil, err
+	}
+	stream, err := wav.DecodeWithSampleRate(SampleRate, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	pcm, err := io.ReadAll(stream)
+	if err != nil {
+		return nil, err
+	}
+
+	m.cache[s] = pcm
+	return pcm, nil
+}
+
+func (m *Manager) SetListener(x, y float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.listenerX, m.listenerY = x, y
+}
+
+// Play plays the sound without any positioning, e.g. for sounds made by the listener.
+func (m *Manager) Play(s Sound) {
+	m.PlayGain(s, 1)
+}
+
+// PlayGain is Play at a fraction of the full volume.
+func (m *Manager) PlayGain(s Sound, gain float64) {
+	m.play(s, gain, 0)
+}
+
+// PlayAt plays the sound as if emitted at x, y in the world.
+func (m *Manager) PlayAt(s Sound, x, y float64) {
+	m.PlayAtGain(s, x, y, 1)
+}
+
+// PlayAtGain is PlayAt at a fraction of the full volume.
+func (m *Manager) PlayAtGain(s Sound, x, y, gain float64) {
+	m.mu.Lock()
+	dx, dy := x-m.listenerX, y-m.listenerY
+	m.mu.Unlock()
+
+	gain *= attenuation(math.Hypot(dx, dy))
+	if gain <= 0 {
+		return
+	}
+	m.play(s, gain, pan(dx))
+}
+
+func (m *Manager) play(s Sound, gain, pan float64) {
+	volume := gain * m.settings.MasterVolume * m.settings.SFXVolume
+	if volume <= 0 {
+		return
+	}
+
+	pcm, err := m.load(s)
+	if err != nil {
+		log.Println("Error loading sound:", err)
+		return
+	}
+
+	p, err := m.ctx.NewPlayer(newPanStream(bytes.NewReader(pcm), pan))
+	if err != nil {
+		log.Println("Error creating audio player:", err)
+		return
+	}
+	p.SetVolume(volume)
+	p.Play()
+}
+
+func attenuation(dist float64) float64 {
+	if dist <= MinFalloffDistance {
+		return 1
+	}
+	if dist >= MaxHearingDistance {
+		return 0
+	}
+	t := (dist - MinFalloffDistance) / (MaxHearingDistance - MinFalloffDistance)
+	return (1 - t) * (1 - t)
+}
+
+func pan(dx float64) float64 {
+	return math.Max(-1, math.Min(1, dx/PanDistance))
+}
+
+// panStream applies stereo panning to 16bit stereo PCM, -1 is full left and 1 is full right.
+type panStream struct {
+	io.ReadSeeker
+	pan float64
+}
+
+func newPanStream(src io.ReadSeeker, pan float64) *panStream {
+	return &panStream{ReadSeeker: src, pan: pan}
+}
+
+func (s *panStream) Read(p []byte) (int, error) {
+	n, err := s.ReadSeeker.Read(p)
+	if s.pan == 0 {
+		return n, err
+	}
+
+	ls := math.Min(1-s.pan, 1)
+	rs := math.Min(1+s.pan, 1)
+	for i := 0; i+3 < n; i += 4 {
+		l := float64(int16(p[i]) | int16(p[i+1])<<8)
+		r := float64(int16(p[i+2]) | int16(p[i+3])<<8)
+		lv := int16(l * ls)
+		rv := int16(r * rs)
+		p[i], p[i+1] = byte(lv), byte(lv>>8)
+		p[i+2], p[i+3] = byte(rv), byte(rv>>8)
+	}
+	return n, err
+}
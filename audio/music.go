@@ -0,0 +1,163 @@
+package audio
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2/audio"
+	"github.com/hajimehoshi/ebiten/v2/audio/vorbis"
+
+	"shooter/utils"
+)
+
+const CrossfadeDuration = 2 * time.Second
+
+type Track string
+
+const (
+	TrackMenu    Track = "menu"
+	TrackAmbient Track = "ambient"
+	// Intensity layer played on top of the ambient track during combat
+	TrackCombat Track = "combat"
+)
+
+type MusicState int
+
+const (
+	MusicSilent MusicState = iota
+	MusicMenu
+	MusicMatch
+	MusicCombat
+)
+
+// musicTrack is a looping track whose volume is faded towards target.
+type musicTrack struct {
+	player *audio.Player
+	volume float64
+	target float64
+}
+
+type Music struct {
+	manager *Manager
+
+	mu         sync.Mutex
+	state      MusicState
+	tracks     map[Track]*musicTrack
+	missing    map[Track]bool
+	lastUpdate time.Time
+}
+
+func NewMusic(manager *Manager) *Music {
+	return &Music{
+		manager: manager,
+		tracks:  make(map[Track]*musicTrack),
+		missing: make(map[Track]bool),
+	}
+}
+
+// SetState changes which tracks should be audible, the change is crossfaded in Update.
+func (m *Music) SetState(state MusicState) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if state == m.state {
+		return
+	}
+	m.state = state
+
+	targets := map[Track]float64{}
+	switch state {
+	case MusicMenu:
+		targets[TrackMenu] = 1
+	case MusicMatch:
+		targets[TrackAmbient] = 1
+	case MusicCombat:
+		targets[TrackAmbient] = 1
+		targets[TrackCombat] = 1
+	}
+
+	for _, name := range []Track{TrackMenu, TrackAmbient, TrackCombat} {
+		t := m.track(name, targets[name] > 0)
+		if t != nil {
+			t.target = targets[name]
+		}
+	}
+}
+
+func (m *Music) State() MusicState {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.state
+}
+
+// Update advances crossfades, should be called once per tick.
+func (m *Music) Update() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	if m.lastUpdate.IsZero() {
+		m.lastUpdate = now
+	}
+	step := float64(now.Sub(m.lastUpdate)) / float64(CrossfadeDuration)
+	m.lastUpdate = now
+
+	volume := m.manager.settings.MasterVolume * m.manager.settings.MusicVolume
+	for _, t := range m.tracks {
+		if t.volume < t.target {
+			t.volume = math.Min(t.target, t.volume+step)
+		} else if t.volume > t.target {
+			t.volume = math.Max(t.target, t.volume-step)
+		}
+
+		t.player.SetVolume(t.volume * volume)
+		if t.volume > 0 && !t.player.IsPlaying() {
+			t.player.Play()
+		} else if t.volume == 0 && t.player.IsPlaying() {
+			t.player.Pause()
+		}
+	}
+}
+
+// track returns the looping player for the track, loading it if create is set.
+// Tracks that fail to load are remembered so missing assets are only logged once.
+func (m *Music) track(name Track, create bool) *musicTrack {
+	if t, ok := m.tracks[name]; ok || !create || m.missing[name] {
+		return t
+	}
+
+	p, err := m.load(name)
+	if err != nil {
+		log.Println("Error loading music:", err)
+		m.missing[name] = true
+		return nil
+	}
+
+	t := &musicTrack{player: p}
+	m.tracks[name] = t
+	return t
+}
+
+func (m *Music) load(name Track) (*audio.Player, error) {
+	data, err := utils.ReadAsset(fmt.Sprintf("assets/music/%s.ogg", name))
+	if err != nil {
+		return nil, err
+	}
+
+	// Decoding happens lazily while the player reads, so tracks are streamed rather than held as PCM
+	stream, err := vorbis.DecodeWithSampleRate(SampleRate, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := m.manager.ctx.NewPlayer(audio.NewInfiniteLoop(stream, stream.Length()))
+	if err != nil {
+		return nil, err
+	}
+	p.SetVolume(0)
+	return p, nil
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"time"
+)
+
+// backfill keeps the server at cfg.Backfill players with bots, adding them as people leave
+// and removing them as people join. The bots connect like the simulate ones, until stop is closed.
+func (s *Server) backfill(addr string, stop <-chan struct{}) {
+	s.mu.Lock()
+	sim := newSimulation(s.world.Level)
+	sim.token = s.botToken
+	s.mu.Unlock()
+	defer func() { sim.leave(len(sim.bots)) }()
+
+	ticker := time.NewTicker(time.Second / simTPS)
+	defer ticker.Stop()
+	for tick := 0; ; tick++ {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+		if tick%simTPS == 0 {
+			s.mu.Lock()
+			wanted := max(s.cfg.Backfill-s.playerCount(), 0)
+			s.mu.Unlock()
+			for len(sim.bots) < wanted {
+				if _, err := sim.join(addr, fmt.Sprintf("bot-%d", len(sim.bots)+1), false); err != nil {
+					log.Println("Backfill bot failed to join:", err)
+					break
+				}
+			}
+			if len(sim.bots) > wanted {
+				sim.leave(len(sim.bots) - wanted)
+			}
+		}
+		sim.tick()
+	}
+}
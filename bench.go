@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"log"
+	"math"
+	"math/rand/v2"
+	"strconv"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+
+	"shooter/config"
+	"shooter/game"
+	"shooter/player"
+	"shooter/render/batch"
+	"shooter/render/effects"
+	"shooter/ui"
+)
+
+const benchDefaultBullets = 2000
+
+var benchView = game.Bounds{MaxX: ScreenWidth, MaxY: ScreenHeight}
+
+// benchScene fills the screen with bullets and particles to compare batched
+// and one by one rendering, B toggles between them.
+type benchScene struct {
+	bullets   []*player.Bullet
+	particles *effects.System
+	batch     *batch.Batch
+	batched   bool
+}
+
+func runBench(args []string) {
+	count := benchDefaultBullets
+	if len(args) > 0 {
+		n, err := strconv.Atoi(args[0])
+		if err != nil {
+			log.Fatal("Invalid bullet count:", args[0])
+		}
+		count = n
+	}
+
+	s := &benchScene{
+		particles: effects.NewSystem(),
+		batch:     batch.New(batch.NewAtlas(AtlasSize)),
+		batched:   true,
+	}
+	for range count {
+		s.bullets = append(s.bullets, s.newBullet())
+	}
+
+	cfg := config.Default()
+	cfg.Video.VSync = false
+	app := NewApp(cfg)
+	app.scene = s
+	app.applyPacing()
+	ebiten.SetWindowSize(ScreenWidth, ScreenHeight)
+	ebiten.SetWindowTitle("Rendering benchmark")
+	if err := ebiten.RunGame(app); err != nil && err != ebiten.Termination {
+		log.Fatal(err)
+	}
+}
+
+func (s *benchScene) newBullet() *player.Bullet {
+	x, y := rand.Float64()*ScreenWidth, rand.Float64()*ScreenHeight
+	return &player.Bullet{X: x, Y: y, EndX: x, EndY: y, Direction: rand.Float64() * 2 * math.Pi, Velocity: 2 + rand.Float64()*4}
+}
+
+func (s *benchScene) Update() error {
+	if inpututil.IsKeyJustPressed(ebiten.KeyB) {
+		s.batched = !s.batched
+	}
+	for i, b := range s.bullets {
+		b.Update()
+		if b.EndX < 0 || b.EndX > ScreenWidth || b.EndY < 0 || b.EndY > ScreenHeight {
+			s.particles.Emit(effects.Sparks, b.EndX, b.EndY, b.Direction+math.Pi)
+			s.bullets[i] = s.newBullet()
+		}
+	}
+	s.particles.Update(1 / float64(ebiten.TPS()))
+	return nil
+}
+
+func (s *benchScene) Draw(screen *ebiten.Image) {
+	calls := 0
+	if s.batched {
+		s.batch.Begin(screen)
+		for _, b := range s.bullets {
+			b.DrawBatch(s.batch)
+		}
+		s.particles.Draw(s.batch, benchView)
+		s.batch.End()
+		calls = s.batch.Calls
+	} else {
+		for _, b := range s.bullets {
+			b.Draw(screen)
+		}
+		calls = len(s.bullets)
+		// Particles have no unbatched path, they are drawn the same in both modes
+		s.batch.Begin(screen)
+		s.particles.Draw(s.batch, benchView)
+		s.batch.End()
+		calls += s.batch.Calls
+	}
+
+	mode := "one by one"
+	if s.batched {
+		mode = "batched"
+	}
+	info := fmt.Sprintf("B: toggle batching\nMode: %s\nBullets: %d  Particles: %d\nDraw calls: %d\nFPS: %0.2f  TPS: %0.2f",
+		mode, len(s.bullets), s.particles.Len(), calls, ebiten.ActualFPS(), ebiten.ActualTPS())
+	ui.DrawText(screen, info, 10, 10, 2, color.White)
+}
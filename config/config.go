@@ -0,0 +1,302 @@
+package config
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+const (
+	appDir   = "shooter"
+	fileName = "config.json"
+)
+
+type Audio struct {
+	MasterVolume float64 `json:"master_volume"`
+	SFXVolume    float64 `json:"sfx_volume"`
+	MusicVolume  float64 `json:"music_volume"`
+}
+
+type Crosshair struct {
+	// One of hud.CrosshairStyles
+	Style string `json:"style"`
+	// One of hud.CrosshairColors
+	Color string `json:"color"`
+	// 0 is the smallest crosshair and 1 the largest
+	Size float64 `json:"size"`
+	// Open the crosshair up with the weapon spread
+	Dynamic bool `json:"dynamic"`
+	// Show how far away what the shot would hit is
+	ShowDistance bool `json:"show_distance"`
+}
+
+type HUD struct {
+	ShowDamageNumbers bool      `json:"show_damage_numbers"`
+	Crosshair         Crosshair `json:"crosshair"`
+	// Names of players further away than this are hidden, 0 hides all
+	NameTagDistance float64 `json:"name_tag_distance"`
+	// Break the frame time down under the frame rates, for spotting performance regressions
+	FrameTimes bool `json:"frame_times"`
+	// Mark own hits the server rejected and count them, for judging how fair the netcode is
+	RejectedHits bool `json:"rejected_hits"`
+}
+
+type Video struct {
+	// Screen shake multiplier, 0 disables it
+	ScreenShake float64 `json:"screen_shake"`
+	// windowed, fullscreen or borderless
+	WindowMode string `json:"window_mode"`
+	// Window size in windowed mode, like 1600x900
+	Resolution string `json:"resolution"`
+	VSync      bool   `json:"vsync"`
+	// Max frames per second when vsync is off, 0 is unlimited
+	FPSLimit int `json:"fps_limit"`
+	// Simulation ticks per second
+	TPS int `json:"tps"`
+	// Save every match to a replay file, for exporting highlights
+	RecordMatches bool `json:"record_matches"`
+}
+
+type Input struct {
+	// Stick deflection below this is ignored, 0 to 1
+	MoveDeadzone float64 `json:"move_deadzone"`
+	AimDeadzone  float64 `json:"aim_deadzone"`
+	// Multiplies mouse movement of the crosshair
+	MouseSensitivity float64 `json:"mouse_sensitivity"`
+	// 0 aims instantly, closer to 1 turns slower towards the target
+	AimSmoothing float64 `json:"aim_smoothing"`
+	// Aim assist strength for sticks and touch, 0 to 1
+	AimAssist float64 `json:"aim_assist"`
+	// The aim button toggles aiming instead of being held
+	ToggleAim bool `json:"toggle_aim"`
+	// Overrides of the default bindings by action name, keys are named like "W" or "MouseLeft"
+	Keys map[string]string `json:"keys,omitempty"`
+	// Values are ebiten standard gamepad buttons
+	GamepadButtons map[string]int `json:"gamepad_buttons,omitempty"`
+}
+
+type Network struct {
+	// Player updates sent per second by the client
+	SendRate int `json:"send_rate"`
+	// Player updates relayed per second by a hosted server
+	BroadcastRate int `json:"broadcast_rate"`
+	// Times a second a hosted server steps the simulation, like 20, 30 or 64. The simulation
+	// itself always runs at 60 steps a second, slower servers take several steps a tick
+	TickRate int `json:"tick_rate"`
+	// Players a hosted server lets in at once, 0 for no limit
+	MaxPlayers int `json:"max_players"`
+	// Bots fill a hosted server up to this many players, leaving as people join. 0 for none
+	Backfill int `json:"backfill"`
+	// Names a hosted server turns away
+	Banned []string `json:"banned,omitempty"`
+	// A hosted server shrinks the play area over each match, damaging players outside it
+	Zone bool `json:"zone"`
+	// Mode of a hosted server, one of match.Modes
+	Mode string `json:"mode"`
+	// Weapon IDs a hosted gun game goes through, empty for the default ones
+	GunGameWeapons []string `json:"gun_game_weapons,omitempty"`
+	// Maps a hosted server plays in order instead of voting, starting over after the last
+	Rotation []RotationEntry `json:"rotation,omitempty"`
+	// What a hosted server's bullets and grenades do to teammates, one of match.FriendlyFireModes
+	FriendlyFire string `json:"friendly_fire"`
+	// Team kills after which a hosted server kicks the player, 0 for never
+	TeamKillKick int `json:"team_kill_kick"`
+	// Rules of a hosted server in any mode, 0 for the defaults. Times are in seconds,
+	// the limits of a rotation entry win over these.
+	RespawnTime  int     `json:"respawn_time"`
+	StartHealth  int     `json:"start_health"`
+	FragLimit    int     `json:"frag_limit"`
+	TimeLimit    int     `json:"time_limit"`
+	SpeedFactor  float64 `json:"speed_factor"`
+	InfiniteAmmo bool    `json:"infinite_ammo"`
+	// Players of a hosted server use the abilities of their class
+	Abilities bool `json:"abilities"`
+	// Buggies a hosted server parks at the spawns of each map
+	Vehicles int `json:"vehicles"`
+	// Long modes of a hosted server drop supply crates, see match.Supplies
+	SupplyDrops bool `json:"supply_drops"`
+	// A hosted server writes a log of every match to the data directory, for stats tools
+	MatchLog bool `json:"match_log"`
+	// Address a dedicated server serves its HTTP API on, like ":8090", empty for none. It
+	// answers requests with the token as a bearer token, and none without one.
+	APIAddress string `json:"api_address,omitempty"`
+	APIToken   string `json:"api_token,omitempty"`
+	// Address a dedicated server serves pprof profiles on, empty for none. Profiles show
+	// a lot about the server, they need the APIToken like the HTTP API. Without one they're
+	// only served on localhost, like "localhost:6060".
+	PprofAddress string `json:"pprof_address,omitempty"`
+	// Chat webhooks a hosted server tells about match starts, results and aces
+	Webhooks []Webhook `json:"webhooks,omitempty"`
+	// A hosted server rates players after each match and lets in those of a similar rating
+	Ranked bool `json:"ranked"`
+	// Secret a hosted server lets observers watch with, empty for no observers. The client
+	// sends it when observing. Observers see everyone, a server sends them everything
+	// ObserverDelay seconds late, 0 for the default.
+	ObserverToken string `json:"observer_token,omitempty"`
+	ObserverDelay int    `json:"observer_delay,omitempty"`
+}
+
+// Webhook is a chat channel's webhook URL, Format is "discord" or "slack".
+type Webhook struct {
+	URL    string `json:"url"`
+	Format string `json:"format"`
+}
+
+// RotationEntry is a map of the rotation and how it's played there.
+type RotationEntry struct {
+	Map string `json:"map"`
+	// One of match.Modes, empty for the server's Mode
+	Mode string `json:"mode,omitempty"`
+	// In seconds, 0 for the default limits
+	TimeLimit int `json:"time_limit,omitempty"`
+	FragLimit int `json:"frag_limit,omitempty"`
+}
+
+type Player struct {
+	Name string `json:"name"`
+	// Last server joined from the menu
+	Server string `json:"server"`
+	// Address of the last party leader joined from the menu
+	Party string `json:"party,omitempty"`
+	// One of player.Skins
+	Skin string `json:"skin"`
+	// One of ability.ClassNames, played on servers with abilities
+	Class string `json:"class"`
+	// One of weapon.Attachments by weapon ID
+	Attachments map[string]string `json:"attachments"`
+	// Secret sent to ranked servers, which tie the name to it the first time it plays there
+	Key string `json:"key,omitempty"`
+}
+
+type Config struct {
+	Player  Player  `json:"player"`
+	Audio   Audio   `json:"audio"`
+	HUD     HUD     `json:"hud"`
+	Video   Video   `json:"video"`
+	Input   Input   `json:"input"`
+	Network Network `json:"network"`
+}
+
+func Default() *Config {
+	return &Config{
+		Player: Player{
+			Name:   "player",
+			Server: "localhost:8080",
+			Skin:   "default",
+			Class:  "scout",
+			Attachments: map[string]string{
+				"rifle":   "none",
+				"pistol":  "none",
+				"shotgun": "none",
+			},
+		},
+		Audio: Audio{
+			MasterVolume: 0.8,
+			SFXVolume:    1.0,
+			MusicVolume:  0.6,
+		},
+		HUD: HUD{
+			ShowDamageNumbers: true,
+			Crosshair: Crosshair{
+				Style:   "cross",
+				Color:   "white",
+				Size:    0.4,
+				Dynamic: true,
+			},
+			NameTagDistance: 600,
+		},
+		Video: Video{
+			ScreenShake: 1.0,
+			WindowMode:  "windowed",
+			Resolution:  "1600x900",
+			VSync:       true,
+			FPSLimit:    144,
+			TPS:         60,
+		},
+		Input: Input{
+			MoveDeadzone:     0.15,
+			AimDeadzone:      0.25,
+			MouseSensitivity: 1,
+			AimAssist:        0.5,
+		},
+		Network: Network{
+			SendRate:      30,
+			BroadcastRate: 20,
+			TickRate:      60,
+			MaxPlayers:    16,
+			Mode:          "deathmatch",
+			FriendlyFire:  "on",
+			TeamKillKick:  3,
+		},
+	}
+}
+
+// Path returns location of the config file inside the user's config directory.
+func Path() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, appDir, fileName), nil
+}
+
+// Load reads the config file, missing file is not an error and yields defaults.
+func Load() (*Config, error) {
+	cfg := Default()
+
+	path, err := Path()
+	if err != nil {
+		return cfg, err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, err
+	}
+
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return Default(), err
+	}
+	return cfg, nil
+}
+
+// DataPath returns where a file of the game other than the config is kept.
+func DataPath(name string) (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, appDir, name), nil
+}
+
+// NewKey returns a random Player.Key.
+func NewKey() (string, error) {
+	key := make([]byte, 16)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(key), nil
+}
+
+func (c *Config) Save() error {
+	path, err := Path()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
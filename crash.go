@@ -0,0 +1,56 @@
+package main
+
+import (
+	"io"
+	"log"
+	"os"
+
+	"shooter/config"
+	"shooter/crash"
+	"shooter/netsim"
+)
+
+// CrashDir is where diagnostics bundles go in the data directory.
+const CrashDir = "crashes"
+
+// NetState is the network part of a diagnostics bundle.
+type NetState struct {
+	// Address of the server joined, for clients
+	Server string `json:"server,omitempty"`
+	// Connected clients, for servers
+	Clients int            `json:"clients,omitempty"`
+	FakeNet netsim.Options `json:"fake_net"`
+}
+
+// reporter writes a bundle for each recovered panic, nil until setupCrashReports.
+var reporter *crash.Reporter
+
+// setupCrashReports keeps the tail of the log and writes bundles with the config and the network state from netState.
+func setupCrashReports(cfg *config.Config, netState func() any) {
+	dir, err := config.DataPath(CrashDir)
+	if err != nil {
+		log.Println("Error finding the crash directory, crashes are only logged:", err)
+	}
+	tail := crash.NewTail(crash.TailLines)
+	log.SetOutput(io.MultiWriter(os.Stderr, tail))
+	reporter = &crash.Reporter{
+		Dir:  dir,
+		Tail: tail,
+		Config: func() any {
+			redacted := *cfg
+			redacted.Network.APIToken = ""
+			redacted.Player.Key = ""
+			redacted.Network.Webhooks = nil
+			return redacted
+		},
+		Net: netState,
+	}
+}
+
+// crashStatus tells the player in the menu that the game recovered from a panic.
+func crashStatus(path string) string {
+	if path == "" {
+		return "Something went wrong, see the log"
+	}
+	return "Something went wrong, diagnostics saved to " + path
+}
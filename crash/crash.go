@@ -0,0 +1,174 @@
+// Package crash recovers panics at the boundaries of the game's subsystems and writes
+// a diagnostics bundle for each, so a bug report has more to go on than "it closed".
+package crash
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// Lines of the log kept for bundles
+	TailLines = 200
+	// A subsystem panicking every tick writes one bundle per interval, not thousands
+	Interval = time.Minute
+)
+
+// Tail keeps the last lines written to it, install it with log.SetOutput next to stderr.
+type Tail struct {
+	mu      sync.Mutex
+	lines   []string
+	max     int
+	partial strings.Builder
+}
+
+func NewTail(max int) *Tail {
+	return &Tail{max: max}
+}
+
+func (t *Tail) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, b := range p {
+		if b != '\n' {
+			t.partial.WriteByte(b)
+			continue
+		}
+		t.lines = append(t.lines, t.partial.String())
+		t.partial.Reset()
+		if len(t.lines) > t.max {
+			t.lines = t.lines[len(t.lines)-t.max:]
+		}
+	}
+	return len(p), nil
+}
+
+// Lines returns a copy of the kept lines, oldest first.
+func (t *Tail) Lines() []string {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]string(nil), t.lines...)
+}
+
+// Bundle is everything known about a panic, written as JSON.
+type Bundle struct {
+	Time      time.Time `json:"time"`
+	Subsystem string    `json:"subsystem"`
+	Panic     string    `json:"panic"`
+	Stack     string    `json:"stack"`
+	GoVersion string    `json:"go_version"`
+	OS        string    `json:"os"`
+	Log       []string  `json:"log,omitempty"`
+	Config    any       `json:"config,omitempty"`
+	Net       any       `json:"net,omitempty"`
+}
+
+// FileName is where the bundle goes in the crash directory.
+func (b Bundle) FileName() string {
+	name := strings.NewReplacer("/", "-", "\\", "-", " ", "-", ":", "-").Replace(b.Subsystem)
+	return fmt.Sprintf("crash-%s-%s.json", b.Time.UTC().Format("20060102-150405"), name)
+}
+
+// Write saves the bundle into dir and returns its path.
+func (b Bundle) Write(dir string) (string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, b.FileName())
+	return path, os.WriteFile(path, data, 0o644)
+}
+
+// Reporter writes bundles of recovered panics. A nil Reporter still recovers, it only logs.
+type Reporter struct {
+	Dir  string
+	Tail *Tail
+	// Config and Net are snapshotted into bundles when set. They are called without any
+	// of the caller's locks held, secrets should be left out.
+	Config func() any
+	Net    func() any
+
+	mu   sync.Mutex
+	last map[string]time.Time
+}
+
+// Recover is deferred at a subsystem boundary, a panic there is reported and then is passed the
+// bundle's path, empty when none was written. The subsystem keeps running after it returns.
+func (r *Reporter) Recover(subsystem string, then func(path string)) {
+	v := recover()
+	if v == nil {
+		return
+	}
+	path := r.Report(subsystem, v, debug.Stack())
+	if then != nil {
+		then(path)
+	}
+}
+
+// Report logs the panic and writes its bundle unless the subsystem already had one within the Interval.
+func (r *Reporter) Report(subsystem string, v any, stack []byte) string {
+	log.Printf("Panic in %s: %v", subsystem, v)
+	if r == nil || r.Dir == "" || !r.due(subsystem, time.Now()) {
+		return ""
+	}
+
+	b := Bundle{
+		Time:      time.Now(),
+		Subsystem: subsystem,
+		Panic:     fmt.Sprint(v),
+		Stack:     string(stack),
+		GoVersion: runtime.Version(),
+		OS:        runtime.GOOS + "/" + runtime.GOARCH,
+		Log:       r.Tail.Lines(),
+	}
+	// A broken snapshot must not lose the rest of the bundle
+	b.Config = snapshot(r.Config)
+	b.Net = snapshot(r.Net)
+
+	path, err := b.Write(r.Dir)
+	if err != nil {
+		log.Println("Error writing crash bundle:", err)
+		return ""
+	}
+	log.Println("Crash bundle written to", path)
+	return path
+}
+
+func (r *Reporter) due(subsystem string, now time.Time) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if last, ok := r.last[subsystem]; ok && now.Sub(last) < Interval {
+		return false
+	}
+	if r.last == nil {
+		r.last = make(map[string]time.Time)
+	}
+	r.last[subsystem] = now
+	return true
+}
+
+func snapshot(f func() any) (v any) {
+	if f == nil {
+		return nil
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			v = fmt.Sprint("snapshot failed: ", p)
+		}
+	}()
+	return f()
+}
@@ -0,0 +1,71 @@
+package crash
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"testing"
+)
+
+func TestTail(t *testing.T) {
+	tail := NewTail(2)
+	fmt.Fprint(tail, "one\ntwo\nthr")
+	fmt.Fprint(tail, "ee\nfour")
+
+	lines := tail.Lines()
+	if len(lines) != 2 || lines[0] != "two" || lines[1] != "three" {
+		t.Errorf("Lines() = %q, want [two three]", lines)
+	}
+}
+
+func TestRecover(t *testing.T) {
+	tail := NewTail(TailLines)
+	fmt.Fprintln(tail, "before the panic")
+	r := &Reporter{
+		Dir:    t.TempDir(),
+		Tail:   tail,
+		Config: func() any { return map[string]int{"fps": 60} },
+		Net:    func() any { panic("no network") },
+	}
+
+	var paths []string
+	for range 2 {
+		func() {
+			defer r.Recover("net/listener", func(path string) { paths = append(paths, path) })
+			panic("boom")
+		}()
+	}
+	if len(paths) != 2 || paths[0] == "" || paths[1] != "" {
+		t.Fatalf("paths = %q, want one bundle and one rate limited", paths)
+	}
+
+	data, err := os.ReadFile(paths[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b Bundle
+	if err := json.Unmarshal(data, &b); err != nil {
+		t.Fatal(err)
+	}
+	if b.Subsystem != "net/listener" || b.Panic != "boom" || b.Stack == "" {
+		t.Errorf("bundle = %+v, want the subsystem, panic and stack", b)
+	}
+	if len(b.Log) != 1 || b.Log[0] != "before the panic" {
+		t.Errorf("Log = %q, want the tail", b.Log)
+	}
+	if b.Config == nil || b.Net == nil {
+		t.Errorf("Config = %v, Net = %v, want both snapshotted", b.Config, b.Net)
+	}
+}
+
+func TestRecoverNil(t *testing.T) {
+	var r *Reporter
+	called := false
+	func() {
+		defer r.Recover("game", func(path string) { called = path == "" })
+		panic("boom")
+	}()
+	if !called {
+		t.Error("nil Reporter did not recover")
+	}
+}
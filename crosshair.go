@@ -0,0 +1,45 @@
+package main
+
+import (
+	"math"
+
+	"shooter/game"
+	"shooter/hud"
+	"shooter/sim"
+)
+
+// AimTraceRange is how far along the aim enemies are looked for.
+const AimTraceRange = 2000.0
+
+// aimTrace follows the aim from the muzzle to the first enemy on it, or to the crosshair when
+// there is none, and tells whether a wall comes first.
+func (g *Game) aimTrace() hud.Aim {
+	mx, my := g.player.MuzzlePosition()
+	dx, dy := math.Cos(g.player.Angle), math.Sin(g.player.Angle)
+	ray := game.Line{X1: mx, Y1: my, X2: mx + dx*AimTraceRange, Y2: my + dy*AimTraceRange}
+
+	target := distance(mx, my, g.input.CrosshairX, g.input.CrosshairY)
+	for _, p := range g.players {
+		if p.Health <= 0 || p.Hidden || p.Team != "" && p.Team == g.player.Team {
+			continue
+		}
+		if t, d := ray.Closest(p.X, p.Y); d <= sim.HitRadius && t > 0 {
+			target = math.Min(target, t*AimTraceRange)
+		}
+	}
+
+	wall := math.Inf(1)
+	var wx, wy float64
+	for _, o := range g.Objects {
+		for _, w := range o.Walls {
+			if x, y, ok := game.Intersection(ray, w); ok && distance(mx, my, x, y) < wall {
+				wall, wx, wy = distance(mx, my, x, y), x, y
+			}
+		}
+	}
+	if wall < target {
+		bx, by := g.toScreen(wx, wy)
+		return hud.Aim{Blocked: true, BlockX: bx, BlockY: by, Distance: wall}
+	}
+	return hud.Aim{Distance: target}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"time"
+
+	"shooter/audio"
+	"shooter/hud"
+	"shooter/player"
+	"shooter/sim"
+)
+
+// RadarBlipTime is how long gunfire stays on the minimap, fading out.
+const RadarBlipTime = 2 * time.Second
+
+type radarBlip struct {
+	x, y  float64
+	heard time.Time
+}
+
+// radar is the gunfire shown on the minimap. Suppressed shots and teammates don't show up,
+// decoys do, there is no telling them apart from real shots.
+type radar []radarBlip
+
+func (r *radar) Add(x, y float64, now time.Time) {
+	kept := (*r)[:0]
+	for _, b := range *r {
+		if now.Sub(b.heard) < RadarBlipTime {
+			kept = append(kept, b)
+		}
+	}
+	*r = append(kept, radarBlip{x: x, y: y, heard: now})
+}
+
+func (r radar) Active(now time.Time) []hud.Blip {
+	var blips []hud.Blip
+	for _, b := range r {
+		if age := now.Sub(b.heard); age < RadarBlipTime {
+			blips = append(blips, hud.Blip{X: b.x, Y: b.y, Alpha: 1 - float64(age)/float64(RadarBlipTime)})
+		}
+	}
+	return blips
+}
+
+// updateDecoy throws a decoy towards the crosshair, it starts faking gunfire where it lands.
+func (g *Game) updateDecoy() {
+	if !g.input.Decoy || g.player.Decoys <= 0 || g.player.Health <= 0 {
+		return
+	}
+	throw := g.grenadeThrow()
+	throw.Kind = sim.Decoy
+	g.player.Decoys--
+	g.addGrenade(throw)
+	g.sendEvent(player.EventTypeGrenade, throw)
+}
+
+// hearGunfire plays a shot out of sight, real or a decoy's, and shows it on the radar.
+func (g *Game) hearGunfire(n Noise) {
+	g.audio.PlayAt(audio.SoundGunshot, n.X, n.Y)
+	g.radar.Add(n.X, n.Y, g.now())
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"shooter/input"
+	"shooter/match"
+	"shooter/player"
+	"shooter/ui"
+	"shooter/weapon"
+)
+
+// setWallet keeps the local player's money and purchases, switching to a weapon they just
+// bought and away from one they lost.
+func (g *Game) setWallet(u WalletUpdate) {
+	if u.PlayerID != g.player.ID {
+		return
+	}
+	for _, id := range u.Wallet.Weapons {
+		if !g.wallet.Owns(id) {
+			g.player.SwitchWeapon(id)
+		}
+	}
+	g.wallet = u.Wallet
+	g.player.Grenades = u.Wallet.Grenades
+	if u.Error != "" {
+		g.killfeed.AddMessage(u.Error)
+	}
+}
+
+// startBuyPhase opens the buy phase of a new economy round, the server has the final word on when it ends.
+func (g *Game) startBuyPhase() {
+	if g.rules.Mode == match.Economy {
+		g.buyUntil = g.now().Add(match.BuyTime)
+	}
+}
+
+// canBuy is true while the local player can spend their money.
+func (g *Game) canBuy() bool {
+	return g.rules.Mode == match.Economy && !g.observer && g.alive[g.player.ID] && g.now().Before(g.buyUntil)
+}
+
+// holdOwnedWeapon keeps economy players from switching to weapons they didn't buy.
+func (g *Game) holdOwnedWeapon() {
+	if g.rules.Mode == match.Economy && !g.wallet.Owns(g.player.Weapon) {
+		g.player.SwitchWeapon(weapon.Pistol)
+	}
+}
+
+// buyMenu lists the shop with prices, buying keeps it open until the buy phase is over.
+func (g *Game) buyMenu() {
+	items := []*ui.Item{
+		{Label: func() string {
+			return fmt.Sprintf("$%d - %ds left", g.wallet.Money, int(time.Until(g.buyUntil).Seconds()))
+		}},
+	}
+	for _, id := range match.Shop {
+		w := weapon.Get(id)
+		items = append(items, &ui.Item{
+			Label: func() string {
+				owned := ""
+				switch {
+				case id == weapon.Armor && g.wallet.Armor > 0:
+					owned = fmt.Sprintf(" (%d)", g.wallet.Armor)
+				case id == weapon.Grenade && g.wallet.Grenades > 0:
+					owned = fmt.Sprintf(" (%d)", g.wallet.Grenades)
+				case slices.Contains(g.wallet.Weapons, id):
+					owned = " (owned)"
+				}
+				return fmt.Sprintf("%s - $%d%s", w.Name, w.Price, owned)
+			},
+			Select: func() {
+				if !g.canBuy() {
+					g.resume()
+					return
+				}
+				g.sendEvent(player.EventTypeBuy, Buy{PlayerID: g.player.ID, Item: id})
+			},
+		})
+	}
+	items = append(items, ui.Button("Back", g.resume))
+	menu := ui.NewMenu("BUY", items...)
+	menu.Back = g.resume
+	g.overlay = menu
+}
+
+// economyObjective shows the player's money and gear, and the buy phase. Empty in other modes.
+func (g *Game) economyObjective() string {
+	if g.rules.Mode != match.Economy {
+		return ""
+	}
+	text := fmt.Sprintf("$%d", g.wallet.Money)
+	if g.wallet.Armor > 0 {
+		text += fmt.Sprintf(" - armor %d", g.wallet.Armor)
+	}
+	if g.canBuy() {
+		text += fmt.Sprintf(" - buy phase %ds, %s to buy", int(time.Until(g.buyUntil).Seconds()), g.app.input.Key(input.Buy))
+	}
+	return text
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"shooter/match"
+)
+
+// setRound follows the elimination series or duels. Players in a new round are back at a
+// spawn point of their own with the round's weapon and ammo, those not in it watch until
+// the next one.
+func (g *Game) setRound(r Round) {
+	g.round = r
+	g.alive = map[string]bool{}
+	for _, id := range r.Alive {
+		g.alive[id] = true
+	}
+	if r.Ended {
+		if r.Winner == "" {
+			g.killfeed.AddMessage(fmt.Sprintf("Round %d is a draw", r.Number))
+		} else {
+			g.killfeed.AddMessage(fmt.Sprintf("%s wins round %d", r.Winner, r.Number))
+		}
+		return
+	}
+	if r.Map != "" && r.Map != g.level.Name {
+		g.verifyMap(r.Map, r.MapHash)
+		g.setLevel(r.Map)
+	}
+	if !g.alive[g.player.ID] {
+		g.player.Health = 0
+		g.death = &death{time: g.now(), spectating: true}
+		return
+	}
+	g.death = nil
+	g.recorder.Reset()
+	g.player.Respawn(g.level.Spawn(slices.Index(r.Alive, g.player.ID)))
+	g.startBuyPhase()
+	if len(g.rules.Weapons) > 0 {
+		g.player.GiveWeapon(g.rules.Weapons[0])
+	}
+	if g.rules.Ammo > 0 {
+		g.player.LimitAmmo(g.rules.Ammo)
+	}
+}
+
+// eliminated takes the victim out of the round, the killer gets a round of ammo for it.
+func (g *Game) eliminated(kill PlayerKilled) {
+	if !g.rules.Rounds() || !g.alive[kill.VictimID] {
+		return
+	}
+	delete(g.alive, kill.VictimID)
+	if kill.KillerID == g.player.ID && kill.VictimID != g.player.ID && g.alive[g.player.ID] && g.rules.Ammo > 0 {
+		g.player.AddAmmo(1)
+	}
+}
+
+// waiting is what the player waits for instead of respawning, empty in modes with respawns.
+func (g *Game) waiting() string {
+	switch {
+	case g.rules.Mode == match.Duel:
+		if i := slices.Index(g.round.Queue, g.player.ID); i >= 0 {
+			return fmt.Sprintf("Number %d in the duel queue", i+1)
+		}
+		return "Waiting for the next duel"
+	case g.rules.Rounds():
+		return "Back in the next round"
+	}
+	return ""
+}
+
+// roundObjective shows the round, who is left in it and the player's wins, empty in modes
+// without rounds.
+func (g *Game) roundObjective() string {
+	if !g.rules.Rounds() {
+		return ""
+	}
+	wins := fmt.Sprintf("%d wins", g.round.Wins[g.player.ID])
+	if g.rules.BestOf > 0 {
+		wins = fmt.Sprintf("%d/%d wins", g.round.Wins[g.player.ID], g.rules.BestOf/2+1)
+	}
+	switch {
+	case g.round.Number == 0:
+		return "Waiting for players"
+	case g.round.Ended:
+		return "Next round starting - " + wins
+	case g.rules.Mode == match.Duel:
+		return fmt.Sprintf("Duel %d: %s - %s", g.round.Number, strings.Join(g.round.Alive, " vs "), wins)
+	default:
+		return fmt.Sprintf("Round %d - %d alive - %s", g.round.Number, len(g.alive), wins)
+	}
+}
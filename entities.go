@@ -0,0 +1,197 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"slices"
+
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"shooter/audio"
+	"shooter/game"
+	"shooter/grenade"
+	"shooter/hud"
+	"shooter/player"
+	"shooter/render/effects"
+	"shooter/render/lighting"
+	"shooter/sim"
+	"shooter/weapon"
+)
+
+// DeployDistance is how far in front of the player entities are placed.
+const DeployDistance = 40.0
+
+// updateDeploy asks the server for a turret or barricade in front of the player, it shows up
+// once the server placed it.
+func (g *Game) updateDeploy() {
+	kind := sim.Turret
+	if g.input.Barricade {
+		kind = sim.Barricade
+	}
+	if !g.input.Deploy && !g.input.Barricade || g.player.Health <= 0 {
+		return
+	}
+	g.sendEvent(player.EventTypeDeploy, Deploy{
+		PlayerID: g.player.ID,
+		Kind:     kind,
+		X:        g.player.X + math.Cos(g.player.Angle)*DeployDistance,
+		Y:        g.player.Y + math.Sin(g.player.Angle)*DeployDistance,
+		Angle:    g.player.Angle,
+	})
+}
+
+// updatePickup asks the server for the nearest dropped weapon in reach, it is handed over
+// once the server agrees nobody got to it first.
+func (g *Game) updatePickup() {
+	if !g.input.Interact || g.player.Health <= 0 {
+		return
+	}
+	var nearest *sim.Entity
+	closest := sim.PickupReach
+	for _, e := range g.entities {
+		if d := math.Hypot(e.X-g.player.X, e.Y-g.player.Y); (e.Kind == sim.WeaponDrop || e.Kind == sim.Crate) && d <= closest {
+			nearest, closest = e, d
+		}
+	}
+	if nearest == nil {
+		return
+	}
+	g.sendEvent(player.EventTypePickup, Pickup{PlayerID: g.player.ID, EntityID: nearest.ID})
+}
+
+// pickUp hands the weapon to the player who got it.
+func (g *Game) pickUp(p Pickup) {
+	// Armor goes to the wallet, see setWallet
+	if p.PlayerID == g.player.ID && p.Weapon != weapon.Armor {
+		g.player.PickUp(p.Weapon, p.Ammo)
+	}
+	if owner, ok := g.playerByID(p.PlayerID); ok {
+		g.audio.PlayAt(audio.SoundReload, owner.X, owner.Y)
+	}
+}
+
+// setEntities replaces the entities with the server's periodic update.
+func (g *Game) setEntities(entities []*sim.Entity) {
+	g.entities = entities
+	g.refreshObjects()
+	g.syncVehicle()
+}
+
+// placeEntity adds an entity the server just placed.
+func (g *Game) placeEntity(e *sim.Entity) {
+	g.entities = slices.DeleteFunc(g.entities, func(old *sim.Entity) bool { return old.ID == e.ID })
+	g.entities = append(g.entities, e)
+	g.refreshObjects()
+	g.syncVehicle()
+	switch e.Kind {
+	case sim.Airdrop:
+		g.killfeed.AddMessage(fmt.Sprintf("Supply drop incoming: %s", weapon.Get(e.Weapon).Name))
+	case sim.Crate:
+		g.particles.Emit(effects.Dust, e.X, e.Y, 0)
+		g.audio.PlayAt(audio.SoundHit, e.X, e.Y)
+	}
+}
+
+// removeEntity takes the entity out of the world, blowing it up when destroyed.
+func (g *Game) removeEntity(r EntityRemoved) {
+	i := slices.IndexFunc(g.entities, func(e *sim.Entity) bool { return e.ID == r.ID })
+	if i < 0 {
+		return
+	}
+	e := g.entities[i]
+	g.entities = slices.Delete(g.entities, i, i+1)
+	g.refreshObjects()
+	g.syncVehicle()
+
+	switch {
+	case r.Destroyed && e.Kind == sim.Turret:
+		g.particles.Emit(effects.Explosion, e.X, e.Y, 0)
+		g.lights.Add(lighting.Explosion(e.X, e.Y))
+		g.audio.PlayAt(audio.SoundExplode, e.X, e.Y)
+	case r.Destroyed:
+		g.particles.Emit(effects.Sparks, e.X, e.Y, e.Angle+math.Pi)
+		g.particles.Emit(effects.Dust, e.X, e.Y, e.Angle+math.Pi)
+	case e.Def().Intangible:
+		// Faded away or picked up
+	default:
+		g.particles.Emit(effects.Dust, e.X, e.Y, e.Angle)
+	}
+}
+
+// refreshObjects adds the walls of barricades to the level's, they block sight and bullets like any wall.
+func (g *Game) refreshObjects() {
+	g.Objects = sim.EntityObjects(g.level.Objects, g.entities)
+}
+
+func (g *Game) drawEntities(screen *ebiten.Image, view game.Bounds) {
+	for _, e := range g.entities {
+		def := e.Def()
+		if !view.Contains(e.X, e.Y, def.Radius+def.Length/2) {
+			continue
+		}
+		health := float64(e.Health) / float64(def.Health)
+		switch e.Kind {
+		case sim.Turret:
+			hud.DrawTurret(screen, e.X, e.Y, e.Angle, def.Radius, health, g.ownerColor(e.OwnerID))
+		case sim.Barricade:
+			hud.DrawBarricade(screen, e.Object(), health, g.ownerColor(e.OwnerID))
+		case sim.Decoy:
+			// A dud grenade to anyone who finds it
+			hud.DrawGrenade(screen, grenade.Point{X: e.X, Y: e.Y})
+		case sim.Corpse:
+			hud.DrawCorpse(screen, e.X, e.Y, def.Radius, g.ownerColor(e.OwnerID))
+		case sim.Jammer:
+			hud.DrawJammer(screen, e.X, e.Y, def.Radius, sim.JammerRadius, g.ownerColor(e.OwnerID))
+		case sim.Door:
+			hud.DrawDoor(screen, e.Object())
+		case sim.Vehicle:
+			s := g.vehicleState(e)
+			hud.DrawVehicle(screen, s.X, s.Y, s.Angle, def.Radius, health)
+		case sim.Airdrop:
+			hud.DrawAirdrop(screen, e.X, e.Y, def.Radius)
+		case sim.Crate:
+			hud.DrawCrate(screen, e.X, e.Y, def.Radius)
+			if e.Weapon != weapon.Armor {
+				player.DrawDropped(screen, e.Weapon, e.X, e.Y, 0)
+			}
+		case sim.WeaponDrop:
+			hud.DrawWeaponDrop(screen, e.X, e.Y, def.Radius)
+			player.DrawDropped(screen, e.Weapon, e.X, e.Y, e.Angle)
+		}
+	}
+}
+
+// spawnEntityBullets adds bullets fired by entities to their owner's, there is no local shot to confirm.
+func (g *Game) spawnEntityBullets(bullets []*player.Bullet) {
+	for _, b := range bullets {
+		owner, ok := g.playerByID(b.OwnerID)
+		if !ok {
+			continue
+		}
+		owner.Bullets = append(owner.Bullets, b)
+		g.audio.PlayAt(audio.SoundGunshot, b.X, b.Y)
+		g.particles.Emit(effects.MuzzleFlash, b.X, b.Y, b.Direction)
+	}
+}
+
+// supplies are where supply crates land or lie, for the minimap.
+func (g *Game) supplies() [][2]float64 {
+	var at [][2]float64
+	for _, e := range g.entities {
+		if e.Kind == sim.Airdrop || e.Kind == sim.Crate {
+			at = append(at, [2]float64{e.X, e.Y})
+		}
+	}
+	return at
+}
+
+// ownerColor is the team or skin color of the player, white for those without one.
+func (g *Game) ownerColor(id string) color.Color {
+	if owner, ok := g.playerByID(id); ok {
+		if c, ok := owner.Tint(); ok {
+			return c
+		}
+	}
+	return color.White
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"log"
+	"os"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"shooter/config"
+	"shooter/killcam"
+	"shooter/level"
+	"shooter/ui"
+)
+
+const (
+	// GIF frames are this fraction of the screen size
+	ExportScale = 0.5
+	// Only every nth recorded frame ends up in the GIF, 60 TPS replays export at 20 fps
+	ExportFrameStep = 3
+)
+
+// exportScene plays a replay clip offscreen as seen by the player who recorded it
+// and writes it to an animated GIF. MP4 would need an encoder outside the standard
+// library, so GIF is the only format for now.
+type exportScene struct {
+	game     *Game
+	playback *killcam.Playback
+	frames   []killcam.Frame
+	frame    int
+	viewer   string
+	out      string
+
+	anim   gif.GIF
+	small  *ebiten.Image
+	pixels []byte
+}
+
+func runExport(args []string) {
+	if len(args) == 0 {
+		fmt.Println("Usage: go run main.go export <replay> [from] [to] [out.gif]")
+		fmt.Println("from and to are offsets into the match like 1m30s, the whole match by default")
+		return
+	}
+	replay, err := killcam.LoadReplay(args[0])
+	if err != nil {
+		log.Fatal("Error loading replay: ", err)
+	}
+	from, to := time.Duration(0), replay.Length()
+	if len(args) > 1 {
+		if from, err = time.ParseDuration(args[1]); err != nil {
+			log.Fatal("Invalid start: ", args[1])
+		}
+	}
+	if len(args) > 2 {
+		if to, err = time.ParseDuration(args[2]); err != nil {
+			log.Fatal("Invalid end: ", args[2])
+		}
+	}
+	out := "replay.gif"
+	if len(args) > 3 {
+		out = args[3]
+	}
+	frames := replay.Clip(from, to)
+	if len(frames) == 0 {
+		log.Fatalf("Nothing recorded between %v and %v, the replay is %v long", from, to, replay.Length().Round(time.Second))
+	}
+	lvl, ok := level.Get(replay.Map)
+	if !ok {
+		log.Println("Unknown map in replay:", replay.Map)
+		lvl, _ = level.Get(level.Default)
+	}
+
+	loadImages()
+	cfg := config.Default()
+	// Export as fast as the GPU allows instead of in real time
+	cfg.Video.VSync = false
+	app := NewApp(cfg)
+	w, h := int(ScreenWidth*ExportScale), int(ScreenHeight*ExportScale)
+	app.scene = &exportScene{
+		game:     newGame(app, replay.Player, lvl),
+		playback: killcam.NewPlayback(frames),
+		frames:   frames,
+		viewer:   replay.Player,
+		out:      out,
+		small:    ebiten.NewImage(w, h),
+		pixels:   make([]byte, 4*w*h),
+	}
+	app.applyPacing()
+	ebiten.SetTPS(ebiten.SyncWithFPS)
+	ebiten.SetWindowSize(w, h)
+	ebiten.SetWindowTitle("Exporting replay")
+	if err := ebiten.RunGame(app); err != nil && err != ebiten.Termination {
+		log.Fatal(err)
+	}
+}
+
+func (s *exportScene) Update() error {
+	if s.playback.Done() {
+		if err := s.save(); err != nil {
+			log.Fatal("Error writing GIF: ", err)
+		}
+		log.Printf("Exported %d frames to %s", len(s.anim.Image), s.out)
+		return ebiten.Termination
+	}
+
+	dt := time.Second / 60
+	if s.frame > 0 {
+		dt = s.frames[s.frame].Time.Sub(s.frames[s.frame-1].Time)
+	}
+	s.playback.Update(dt)
+	s.frame++
+	if (s.frame-1)%ExportFrameStep == 0 {
+		s.capture()
+	}
+	return nil
+}
+
+// capture draws the current frame of the playback and adds it to the GIF.
+func (s *exportScene) capture() {
+	others := s.playback.Players()
+	viewer, ok := others[s.viewer]
+	if !ok {
+		// Replays always have the recording player, unless edited by hand
+		viewer = s.game.player
+	}
+	delete(others, s.viewer)
+	s.game.drawWorld(worldImage, viewer, others)
+
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Scale(ExportScale, ExportScale)
+	op.ColorScale.ScaleWithColor(s.game.lighting.Tint)
+	op.Filter = ebiten.FilterLinear
+	s.small.Fill(color.Black)
+	s.small.DrawImage(worldImage, op)
+	s.small.ReadPixels(s.pixels)
+
+	bounds := s.small.Bounds()
+	rgba := &image.RGBA{Pix: s.pixels, Stride: 4 * bounds.Dx(), Rect: bounds}
+	frame := image.NewPaletted(bounds, palette.Plan9)
+	draw.FloydSteinberg.Draw(frame, bounds, rgba, image.Point{})
+
+	// GIF delays are in hundredths of a second
+	end := min(s.frame-1+ExportFrameStep, len(s.frames)-1)
+	delay := s.frames[end].Time.Sub(s.frames[s.frame-1].Time) / (10 * time.Millisecond)
+	s.anim.Image = append(s.anim.Image, frame)
+	s.anim.Delay = append(s.anim.Delay, max(int(delay), 2))
+}
+
+func (s *exportScene) save() error {
+	f, err := os.Create(s.out)
+	if err != nil {
+		return err
+	}
+	if err := gif.EncodeAll(f, &s.anim); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func (s *exportScene) Draw(screen *ebiten.Image) {
+	screen.DrawImage(s.small, nil)
+	ui.DrawText(screen, fmt.Sprintf("Exporting %d/%d", s.frame, len(s.frames)), 10, 10, 1, color.White)
+}
@@ -0,0 +1,90 @@
+// Package frametime breaks the time of a frame down into the parts which usually regress,
+// averaged over the last frames so the numbers can be read while playing.
+package frametime
+
+import (
+	"sync"
+	"time"
+)
+
+// Section is a part of the frame which is timed.
+type Section int
+
+const (
+	// All of an update, the network send included
+	Simulation Section = iota
+	// Casting the rays of lights against the level
+	Raycasting
+	// Drawing the lit area into the shadow mask, the raycasting left out
+	Shadows
+	// Drawing players, bullets, grenades and placed entities
+	Entities
+	// Encoding and writing messages to the server
+	Network
+
+	sections
+)
+
+var names = [sections]string{"simulation", "raycasting", "shadow draw", "entity draw", "network send"}
+
+func (s Section) String() string {
+	return names[s]
+}
+
+// Window is how many frames are averaged.
+const Window = 60
+
+// Timing is a section's average time per frame.
+type Timing struct {
+	Section Section
+	Time    time.Duration
+}
+
+// Breakdown sums up the time of each section in a frame, the network goroutine may add to it too.
+type Breakdown struct {
+	mu     sync.Mutex
+	frame  [sections]time.Duration
+	window [Window][sections]time.Duration
+	next   int
+	frames int
+}
+
+func (b *Breakdown) Add(s Section, d time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.frame[s] += d
+}
+
+// Since adds the time since start to the section, meant to be deferred with time.Now().
+func (b *Breakdown) Since(s Section, start time.Time) {
+	b.Add(s, time.Since(start))
+}
+
+// EndFrame moves the frame's times into the window and starts the next frame.
+func (b *Breakdown) EndFrame() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.window[b.next] = b.frame
+	b.frame = [sections]time.Duration{}
+	b.next = (b.next + 1) % Window
+	b.frames = min(b.frames+1, Window)
+}
+
+// Average returns the time of each section per frame over the window, in the order of the sections.
+func (b *Breakdown) Average() []Timing {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	timings := make([]Timing, sections)
+	for s := range sections {
+		timings[s].Section = s
+		if b.frames == 0 {
+			continue
+		}
+		var total time.Duration
+		for i := range b.frames {
+			total += b.window[i][s]
+		}
+		timings[s].Time = total / time.Duration(b.frames)
+	}
+	return timings
+}
@@ -0,0 +1,42 @@
+package frametime
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAverage(t *testing.T) {
+	var b Breakdown
+	if got := b.Average(); len(got) != int(sections) || got[Raycasting].Time != 0 {
+		t.Fatalf("Average() before any frame = %v, want zeroes", got)
+	}
+
+	b.Add(Raycasting, 2*time.Millisecond)
+	b.Add(Raycasting, 2*time.Millisecond)
+	b.Add(Network, time.Millisecond)
+	b.EndFrame()
+	b.Add(Raycasting, 2*time.Millisecond)
+	b.EndFrame()
+
+	got := b.Average()
+	if got[Raycasting].Section != Raycasting || got[Raycasting].Time != 3*time.Millisecond {
+		t.Errorf("raycasting = %v, want 3ms", got[Raycasting])
+	}
+	if got[Network].Time != time.Millisecond/2 {
+		t.Errorf("network = %v, want 500µs", got[Network])
+	}
+}
+
+func TestWindow(t *testing.T) {
+	var b Breakdown
+	b.Add(Shadows, time.Second)
+	b.EndFrame()
+	// The slow frame falls out of the window
+	for range Window {
+		b.Add(Shadows, time.Millisecond)
+		b.EndFrame()
+	}
+	if got := b.Average()[Shadows].Time; got != time.Millisecond {
+		t.Errorf("shadows = %v, want 1ms", got)
+	}
+}
@@ -0,0 +1,20 @@
+package game
+
+import "math"
+
+// Bounds is an axis aligned rectangle, used for culling what isn't on screen.
+type Bounds struct {
+	MinX, MinY, MaxX, MaxY float64
+}
+
+// Contains is true when x, y is inside the bounds grown by margin on every side.
+func (b Bounds) Contains(x, y, margin float64) bool {
+	return x >= b.MinX-margin && x <= b.MaxX+margin && y >= b.MinY-margin && y <= b.MaxY+margin
+}
+
+// ContainsLine is true when the bounding box of the line overlaps the bounds grown by margin.
+// Good enough for short lines like bullet trails.
+func (b Bounds) ContainsLine(l Line, margin float64) bool {
+	return math.Max(l.X1, l.X2) >= b.MinX-margin && math.Min(l.X1, l.X2) <= b.MaxX+margin &&
+		math.Max(l.Y1, l.Y2) >= b.MinY-margin && math.Min(l.Y1, l.Y2) <= b.MaxY+margin
+}
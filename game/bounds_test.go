@@ -0,0 +1,36 @@
+package game
+
+import "testing"
+
+func TestBoundsContains(t *testing.T) {
+	b := Bounds{MinX: 0, MinY: 0, MaxX: 100, MaxY: 50}
+	tests := []struct {
+		name   string
+		x, y   float64
+		margin float64
+		want   bool
+	}{
+		{"inside", 50, 25, 0, true},
+		{"edge", 100, 50, 0, true},
+		{"outside", 120, 25, 0, false},
+		{"within margin", 120, 25, 20, true},
+		{"above", 50, -30, 20, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := b.Contains(tt.x, tt.y, tt.margin); got != tt.want {
+				t.Errorf("Contains(%v, %v, %v) = %v, want %v", tt.x, tt.y, tt.margin, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBoundsContainsLine(t *testing.T) {
+	b := Bounds{MinX: 0, MinY: 0, MaxX: 100, MaxY: 50}
+	if !b.ContainsLine(Line{X1: -50, Y1: 25, X2: 150, Y2: 25}, 0) {
+		t.Error("line crossing the bounds is not contained")
+	}
+	if b.ContainsLine(Line{X1: 150, Y1: 25, X2: 300, Y2: 25}, 10) {
+		t.Error("line right of the bounds is contained")
+	}
+}
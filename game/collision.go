@@ -0,0 +1,42 @@
+package game
+
+import "math"
+
+// Clearance is the distance from x, y to the closest wall of the objects.
+func Clearance(x, y float64, objects []Object) float64 {
+	closest := math.Inf(1)
+	for _, o := range objects {
+		for _, w := range o.Walls {
+			_, d := w.Closest(x, y)
+			closest = math.Min(closest, d)
+		}
+	}
+	return closest
+}
+
+// Move moves a circle of radius from x, y by dx, dy, sliding along the walls it runs into instead
+// of going through them. It goes in steps no longer than radius so it can't skip over a wall,
+// each axis on its own, and stops within a sixteenth of radius of the wall. A circle already
+// closer to a wall than radius, like after a door closed on it, may still move away from it.
+func Move(x, y, dx, dy, radius float64, objects []Object) (float64, float64) {
+	steps := math.Ceil(math.Hypot(dx, dy) / radius)
+	if steps == 0 || math.IsInf(steps, 0) || math.IsNaN(steps) {
+		return x, y
+	}
+	clearance := Clearance(x, y, objects)
+	step := func(x, y, sx, sy float64) (float64, float64) {
+		for range 4 {
+			if c := Clearance(x+sx, y+sy, objects); c >= radius || c >= clearance {
+				clearance = c
+				return x + sx, y + sy
+			}
+			sx, sy = sx/2, sy/2
+		}
+		return x, y
+	}
+	for range int(steps) {
+		x, y = step(x, y, dx/steps, 0)
+		x, y = step(x, y, 0, dy/steps)
+	}
+	return x, y
+}
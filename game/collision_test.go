@@ -0,0 +1,41 @@
+package game
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMove(t *testing.T) {
+	objects := []Object{{Walls: Rect(0, 0, 400, 400)}, {Walls: Rect(100, 100, 50, 50)}}
+	tests := []struct {
+		name         string
+		x, y, dx, dy float64
+		wantX, wantY float64
+	}{
+		{"free", 50, 50, 20, 10, 70, 60},
+		{"into a box", 50, 120, 100, 0, 90, 120},
+		{"through a box", 50, 120, 300, 0, 90, 120},
+		{"sliding along a box", 80, 120, 30, -10, 90, 110},
+		{"out of the level", 380, 200, 50, 0, 390, 200},
+		{"away from a wall it's stuck in", 95, 120, -20, 0, 75, 120},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Walls stop them within a sixteenth of the radius
+			x, y := Move(tt.x, tt.y, tt.dx, tt.dy, 10, objects)
+			if math.Abs(x-tt.wantX) > 1 || math.Abs(y-tt.wantY) > 1 {
+				t.Errorf("Move() = %v, %v, want %v, %v", x, y, tt.wantX, tt.wantY)
+			}
+		})
+	}
+}
+
+func TestClearance(t *testing.T) {
+	objects := []Object{{Walls: Rect(100, 100, 50, 50)}}
+	if got := Clearance(90, 120, objects); got != 10 {
+		t.Errorf("Clearance() = %v, want 10", got)
+	}
+	if got := Clearance(0, 0, nil); !math.IsInf(got, 1) {
+		t.Errorf("Clearance() without walls = %v, want +Inf", got)
+	}
+}
@@ -1,6 +1,7 @@
 package game
 
 import (
+	"image/color"
 	"math"
 )
 
@@ -21,6 +22,16 @@ func (l *Line) Angle() float64 {
 	return math.Atan2(l.Y2-l.Y1, l.X2-l.X1)
 }
 
+// Closest returns how far along the line, 0 to 1, the point closest to x, y is and its distance.
+func (l Line) Closest(x, y float64) (float64, float64) {
+	dx, dy := l.X2-l.X1, l.Y2-l.Y1
+	t := 0.0
+	if l2 := dx*dx + dy*dy; l2 > 0 {
+		t = math.Max(0, math.Min(1, ((x-l.X1)*dx+(y-l.Y1)*dy)/l2))
+	}
+	return t, math.Hypot(x-(l.X1+t*dx), y-(l.Y1+t*dy))
+}
+
 func Rect(x, y, w, h float64) []Line {
 	return []Line{
 		{x, y, x, y + h},
@@ -57,12 +68,20 @@ func Intersection(l1, l2 Line) (float64, float64, bool) {
 
 type Object struct {
 	Walls []Line
+	// Looks of the object, it's only outlined when both are empty. The texture is tinted by
+	// the fill color when there is one.
+	Fill    color.RGBA
+	Texture string
 }
 
 func (o Object) Points() [][2]float64 {
+	return o.AppendPoints(nil)
+}
+
+// AppendPoints appends the corners of the object to points, reusing its memory.
+func (o Object) AppendPoints(points [][2]float64) [][2]float64 {
 	// Get one of the endpoints for all segments,
 	// + the startpoint of the first one, for non-closed paths
-	var points [][2]float64
 	for _, wall := range o.Walls {
 		points = append(points, [2]float64{wall.X2, wall.Y2})
 	}
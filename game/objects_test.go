@@ -0,0 +1,27 @@
+package game
+
+import (
+	"math"
+	"testing"
+)
+
+func TestLineClosest(t *testing.T) {
+	l := Line{X1: 0, Y1: 0, X2: 100, Y2: 0}
+	tests := []struct {
+		name         string
+		x, y         float64
+		wantT, wantD float64
+	}{
+		{"above middle", 50, 10, 0.5, 10},
+		{"before start", -30, 40, 0, 50},
+		{"past end", 130, 0, 1, 30},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotT, gotD := l.Closest(tt.x, tt.y)
+			if math.Abs(gotT-tt.wantT) > 1e-9 || math.Abs(gotD-tt.wantD) > 1e-9 {
+				t.Errorf("Closest(%v, %v) = %v, %v, want %v, %v", tt.x, tt.y, gotT, gotD, tt.wantT, tt.wantD)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package game
+
+import (
+	"math"
+	"slices"
+)
+
+// Rays are cast just past both sides of each corner, to see what's behind it
+var cornerOffsets = [2]float64{-0.001, 0.001}
+
+// Raycaster casts the rays lighting is made of. It keeps its buffers between casts so
+// casting every frame doesn't allocate, the rays it returns are valid until the next Cast.
+type Raycaster struct {
+	// Walls of the objects last cast against and their corners, which only change with the walls
+	walls   []Line
+	corners [][2]float64
+	angles  []float64
+	rays    []Line
+}
+
+// Cast returns the rays from cx, cy past the corners of the objects up to the closest wall, sorted by angle.
+func (r *Raycaster) Cast(cx, cy, length float64, objects []Object) []Line {
+	// Each ray goes in the direction it was cast, so sorting the angles sorts the rays
+	r.refresh(objects)
+	r.angles = r.angles[:0]
+	for _, p := range r.corners {
+		angle := math.Atan2(p[1]-cy, p[0]-cx)
+		for _, offset := range cornerOffsets {
+			r.angles = append(r.angles, normalizeAngle(angle+offset))
+		}
+	}
+	slices.Sort(r.angles)
+
+	r.rays = r.rays[:0]
+	for _, angle := range r.angles {
+		if ray, ok := CastRay(cx, cy, length, angle, objects); ok {
+			r.rays = append(r.rays, ray)
+		}
+	}
+	return r.rays
+}
+
+// refresh takes the corners of the objects again when their walls aren't the ones last cast against,
+// true when they changed.
+func (r *Raycaster) refresh(objects []Object) bool {
+	n := 0
+	for _, o := range objects {
+		for _, wall := range o.Walls {
+			if n >= len(r.walls) || r.walls[n] != wall {
+				return r.rebuild(objects)
+			}
+			n++
+		}
+	}
+	if n != len(r.walls) {
+		return r.rebuild(objects)
+	}
+	return false
+}
+
+func (r *Raycaster) rebuild(objects []Object) bool {
+	r.walls, r.corners = r.walls[:0], r.corners[:0]
+	for _, o := range objects {
+		r.walls = append(r.walls, o.Walls...)
+		r.corners = o.AppendPoints(r.corners)
+	}
+	return true
+}
+
+// Visibility is the rays of a light kept from one frame to the next, they are
+// only cast again when the light moves or a wall does, like a door or a barricade.
+type Visibility struct {
+	raycaster Raycaster
+	x, y      float64
+	length    float64
+	cast      bool
+}
+
+// Cast returns the rays like Raycaster.Cast, those of the last call when nothing changed since.
+func (v *Visibility) Cast(cx, cy, length float64, objects []Object) []Line {
+	changed := v.raycaster.refresh(objects)
+	if v.cast && !changed && cx == v.x && cy == v.y && length == v.length {
+		return v.raycaster.rays
+	}
+	v.x, v.y, v.length, v.cast = cx, cy, length, true
+	return v.raycaster.Cast(cx, cy, length, objects)
+}
+
+// CastRay returns the ray from cx, cy in direction of angle ending at the closest wall, if one is within length.
+func CastRay(cx, cy, length, angle float64, objects []Object) (Line, bool) {
+	ray := NewRay(cx, cy, length, angle)
+	closest := math.Inf(1)
+	var end [2]float64
+	for _, o := range objects {
+		for _, wall := range o.Walls {
+			px, py, ok := Intersection(ray, wall)
+			if !ok {
+				continue
+			}
+			if d2 := (cx-px)*(cx-px) + (cy-py)*(cy-py); d2 < closest {
+				closest, end = d2, [2]float64{px, py}
+			}
+		}
+	}
+	if math.IsInf(closest, 1) {
+		return Line{}, false
+	}
+	return Line{X1: cx, Y1: cy, X2: end[0], Y2: end[1]}, true
+}
+
+// normalizeAngle wraps angle into (-Pi, Pi], where Line.Angle is.
+func normalizeAngle(angle float64) float64 {
+	if angle > math.Pi {
+		return angle - 2*math.Pi
+	}
+	if angle <= -math.Pi {
+		return angle + 2*math.Pi
+	}
+	return angle
+}
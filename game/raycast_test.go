@@ -0,0 +1,123 @@
+package game
+
+import (
+	"math"
+	"sort"
+	"testing"
+)
+
+// boxes lays out a grid of boxes inside walls around the screen, like a level.
+func boxes(n int) []Object {
+	objects := []Object{{Walls: Rect(0, 0, 1280, 720)}}
+	for i := range n {
+		x, y := 100+float64(i%8)*140, 80+float64(i/8)*160
+		objects = append(objects, Object{Walls: Rect(x, y, 60, 40)})
+	}
+	return objects
+}
+
+// castRaysSlowly is how the rays were cast before Raycaster, sorting whole lines by their angle.
+func castRaysSlowly(cx, cy, length float64, objects []Object) []Line {
+	rays := []Line{}
+	for _, obj := range objects {
+		for _, p := range obj.Points() {
+			l := Line{X1: cx, Y1: cy, X2: p[0], Y2: p[1]}
+			for _, offset := range cornerOffsets {
+				if ray, ok := CastRay(cx, cy, length, l.Angle()+offset, objects); ok {
+					rays = append(rays, ray)
+				}
+			}
+		}
+	}
+	sort.Slice(rays, func(i, j int) bool {
+		return rays[i].Angle() < rays[j].Angle()
+	})
+	return rays
+}
+
+func TestRaycasterCast(t *testing.T) {
+	objects := boxes(16)
+	var r Raycaster
+	for _, c := range [][2]float64{{640, 360}, {20, 20}, {1250, 700}, {170, 60}} {
+		got := r.Cast(c[0], c[1], 2000, objects)
+		want := castRaysSlowly(c[0], c[1], 2000, objects)
+		if len(got) != len(want) {
+			t.Fatalf("Cast(%v) = %d rays, want %d", c, len(got), len(want))
+		}
+		for i := range got {
+			if math.Abs(got[i].X2-want[i].X2) > 1e-6 || math.Abs(got[i].Y2-want[i].Y2) > 1e-6 {
+				t.Errorf("Cast(%v) ray %d = %v, want %v", c, i, got[i], want[i])
+			}
+		}
+	}
+}
+
+func TestRaycasterAllocations(t *testing.T) {
+	objects := boxes(16)
+	var r Raycaster
+	r.Cast(640, 360, 2000, objects)
+	if allocs := testing.AllocsPerRun(100, func() { r.Cast(600, 300, 2000, objects) }); allocs != 0 {
+		t.Errorf("Cast allocates %v times once warmed up, want 0", allocs)
+	}
+}
+
+func TestCastRayMisses(t *testing.T) {
+	objects := []Object{{Walls: Rect(100, 100, 10, 10)}}
+	if _, ok := CastRay(0, 0, 50, 0, objects); ok {
+		t.Error("CastRay() hit a wall out of reach")
+	}
+	ray, ok := CastRay(0, 105, 500, 0, objects)
+	if !ok || ray.X2 != 100 || ray.Y2 != 105 {
+		t.Errorf("CastRay() = %v, %v, want the closest wall at 100, 105", ray, ok)
+	}
+}
+
+func BenchmarkRaycasterCast(b *testing.B) {
+	objects := boxes(32)
+	var r Raycaster
+	b.ReportAllocs()
+	for i := range b.N {
+		r.Cast(640+float64(i%50), 360, 2000, objects)
+	}
+}
+
+func BenchmarkCastRaysSlowly(b *testing.B) {
+	objects := boxes(32)
+	b.ReportAllocs()
+	for i := range b.N {
+		castRaysSlowly(640+float64(i%50), 360, 2000, objects)
+	}
+}
+
+func TestVisibilityCache(t *testing.T) {
+	objects := boxes(16)
+	var v Visibility
+	first := v.Cast(640, 360, 2000, objects)
+	if again := v.Cast(640, 360, 2000, objects); &again[0] != &first[0] || len(again) != len(first) {
+		t.Error("Cast() from the same place cast the rays again")
+	}
+	if allocs := testing.AllocsPerRun(100, func() { v.Cast(640, 360, 2000, objects) }); allocs != 0 {
+		t.Errorf("cached Cast allocates %v times, want 0", allocs)
+	}
+
+	want := castRaysSlowly(600, 360, 2000, objects)
+	if got := v.Cast(600, 360, 2000, objects); len(got) != len(want) || got[0] != want[0] {
+		t.Errorf("Cast() after moving = %d rays, want %d", len(got), len(want))
+	}
+
+	// A door closing in front of the light is a wall moving
+	moved := append(boxes(16), Object{Walls: Rect(580, 340, 10, 40)})
+	want = castRaysSlowly(600, 360, 2000, moved)
+	if got := v.Cast(600, 360, 2000, moved); len(got) != len(want) {
+		t.Errorf("Cast() after a wall moved = %d rays, want %d", len(got), len(want))
+	}
+}
+
+func BenchmarkVisibilityStill(b *testing.B) {
+	objects := boxes(32)
+	var v Visibility
+	b.ReportAllocs()
+	for range b.N {
+		v.Cast(640, 360, 2000, objects)
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"shooter/grapple"
+	"shooter/hud"
+)
+
+// rope is another player's grapple, drawn until they were reeled in.
+type rope struct {
+	x, y  float64
+	until time.Time
+}
+
+// fireGrapple hooks the wall the local player faces and starts reeling them in right away,
+// the server checks the hook with the use. False when there is no wall in range.
+func (g *Game) fireGrapple(use UseAbility) bool {
+	hook, ok := grapple.Fire(g.player.X, g.player.Y, use.Angle, g.Objects)
+	if !ok {
+		return false
+	}
+	reel := grapple.Reel(g.player.X, g.player.Y, hook)
+	g.reel, g.reelStart = &reel, g.now()
+	return true
+}
+
+// updateReel pulls the local player along the grapple in its fixed ticks, whatever the
+// game's tick rate, so they end up where the server expects.
+func (g *Game) updateReel() {
+	if g.reel == nil {
+		return
+	}
+	if g.player.Health <= 0 {
+		g.reel = nil
+		return
+	}
+	ticks := int(g.now().Sub(g.reelStart) * grapple.TPS / time.Second)
+	for g.reel.Ticks < ticks && !g.reel.Done {
+		g.reel.Step(g.Objects)
+	}
+	g.player.X, g.player.Y = g.reel.X, g.reel.Y
+	if g.reel.Done {
+		g.reel = nil
+	}
+}
+
+// addRope shows another player's grapple, mu must be held.
+func (g *Game) addRope(use UseAbility) {
+	if use.PlayerID != g.player.ID {
+		g.ropes[use.PlayerID] = rope{x: use.X, y: use.Y, until: g.now().Add(grapple.ReelTime)}
+	}
+}
+
+func (g *Game) drawRopes(screen *ebiten.Image) {
+	if g.reel != nil {
+		hud.DrawRope(screen, g.player.X, g.player.Y, g.reel.Hook.X, g.reel.Hook.Y)
+	}
+	now := g.now()
+	for id, r := range g.ropes {
+		p, ok := g.players[id]
+		if !now.Before(r.until) || !ok || p.Hidden || p.Health <= 0 {
+			delete(g.ropes, id)
+			continue
+		}
+		hud.DrawRope(screen, p.X, p.Y, r.x, r.y)
+	}
+}
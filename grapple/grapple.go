@@ -0,0 +1,107 @@
+// Package grapple is the grappling hook. Like grenades it runs in fixed ticks and only depends
+// on where it was fired and the walls, so the player reeling in and the server agree on the way.
+package grapple
+
+import (
+	"math"
+	"time"
+
+	"shooter/game"
+)
+
+const (
+	// Physics ticks per second, whatever the game's tick rate
+	TPS = 60
+	// Farthest wall the hook attaches to
+	Range = 500.0
+	// Pulled this far towards the hook per tick
+	ReelSpeed = 14.0
+	// Players stop this far from the wall, about their radius
+	Standoff = 16.0
+	// Ticks to reel in from the farthest wall, Range/ReelSpeed rounded up. The pull ends after
+	// that whatever happens.
+	MaxTicks = 36
+	ReelTime = MaxTicks * time.Second / TPS
+)
+
+// Hook is where the hook attached and where the player stops in front of it.
+type Hook struct {
+	X, Y         float64
+	StopX, StopY float64
+}
+
+// Fire casts the hook from x, y towards angle, it attaches to the first wall within Range.
+func Fire(x, y, angle float64, objects []game.Object) (Hook, bool) {
+	ray := game.NewRay(x, y, Range, angle)
+	closest := math.Inf(1)
+	var hook Hook
+	for _, o := range objects {
+		for _, wall := range o.Walls {
+			if hx, hy, ok := game.Intersection(ray, wall); ok && math.Hypot(hx-x, hy-y) < closest {
+				closest = math.Hypot(hx-x, hy-y)
+				hook.X, hook.Y = hx, hy
+			}
+		}
+	}
+	if math.IsInf(closest, 1) {
+		return Hook{}, false
+	}
+	stop := math.Max(0, closest-Standoff)
+	hook.StopX, hook.StopY = x+math.Cos(angle)*stop, y+math.Sin(angle)*stop
+	return hook, true
+}
+
+// State is a player being reeled in.
+type State struct {
+	X, Y  float64
+	Hook  Hook
+	Ticks int
+	// Arrived at the stop, blocked on the way or out of ticks
+	Done bool
+}
+
+// Reel starts pulling the player at x, y towards the hook.
+func Reel(x, y float64, hook Hook) State {
+	return State{X: x, Y: y, Hook: hook}
+}
+
+// Step pulls the player one tick towards the stop. Walls in the way, like a barricade put up
+// after the hook attached, stop them Standoff short of it.
+func (s *State) Step(objects []game.Object) {
+	if s.Done {
+		return
+	}
+	s.Ticks++
+	dx, dy := s.Hook.StopX-s.X, s.Hook.StopY-s.Y
+	d := math.Hypot(dx, dy)
+	if d == 0 {
+		s.Done = true
+		return
+	}
+	dx, dy = dx/d, dy/d
+	step := math.Min(ReelSpeed, d)
+	// Looking Standoff further keeps the player that far from walls
+	ahead := game.NewRay(s.X, s.Y, step+Standoff, math.Atan2(dy, dx))
+	for _, o := range objects {
+		for _, wall := range o.Walls {
+			if hx, hy, ok := game.Intersection(ahead, wall); ok {
+				step = math.Min(step, math.Max(0, math.Hypot(hx-s.X, hy-s.Y)-Standoff))
+				s.Done = true
+			}
+		}
+	}
+	s.X, s.Y = s.X+dx*step, s.Y+dy*step
+	if step == d || s.Ticks >= MaxTicks {
+		s.Done = true
+	}
+}
+
+// Path is where the player is on every tick of the pull, ending where they stop.
+func Path(s State, objects []game.Object) [][2]float64 {
+	var points [][2]float64
+	for !s.Done {
+		s.Step(objects)
+		points = append(points, [2]float64{s.X, s.Y})
+	}
+	return points
+}
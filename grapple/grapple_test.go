@@ -0,0 +1,47 @@
+package grapple
+
+import (
+	"math"
+	"testing"
+
+	"shooter/game"
+)
+
+func TestFire(t *testing.T) {
+	wall := game.Object{Walls: []game.Line{{X1: 300, Y1: -500, X2: 300, Y2: 500}}}
+	hook, ok := Fire(0, 0, 0, []game.Object{wall})
+	if !ok || hook.X != 300 || hook.Y != 0 || hook.StopX != 300-Standoff {
+		t.Errorf("Fire() = %+v, %v, want hooked at 300, 0", hook, ok)
+	}
+	if _, ok := Fire(0, 0, math.Pi, []game.Object{wall}); ok {
+		t.Error("hooked with no wall behind")
+	}
+	far := game.Object{Walls: []game.Line{{X1: Range + 1, Y1: -500, X2: Range + 1, Y2: 500}}}
+	if _, ok := Fire(0, 0, 0, []game.Object{far}); ok {
+		t.Error("hooked a wall out of range")
+	}
+}
+
+func TestReel(t *testing.T) {
+	wall := game.Object{Walls: []game.Line{{X1: 300, Y1: -500, X2: 300, Y2: 500}}}
+	objects := []game.Object{wall}
+	hook, _ := Fire(0, 0, 0, objects)
+	path := Path(Reel(0, 0, hook), objects)
+	end := path[len(path)-1]
+	if math.Abs(end[0]-hook.StopX) > 1e-9 || end[1] != 0 {
+		t.Errorf("reeled to %v, want %v, %v", end, hook.StopX, hook.StopY)
+	}
+	if want := int(math.Ceil(hook.StopX / ReelSpeed)); len(path) != want {
+		t.Errorf("reeled in %d ticks, want %d", len(path), want)
+	}
+	if again := Path(Reel(0, 0, hook), objects); again[len(again)-1] != end {
+		t.Error("same pull ended somewhere else")
+	}
+
+	// A barricade put up on the way stops the pull in front of it
+	barricade := game.Object{Walls: []game.Line{{X1: 100, Y1: -50, X2: 100, Y2: 50}}}
+	path = Path(Reel(0, 0, hook), append(objects, barricade))
+	if end := path[len(path)-1]; end[0] > 100-Standoff+1e-9 || end[0] < 100-Standoff-ReelSpeed {
+		t.Errorf("stopped at %v, want just in front of the barricade", end)
+	}
+}
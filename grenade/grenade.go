@@ -0,0 +1,139 @@
+// Package grenade is the flight of thrown grenades. It runs in fixed ticks and only depends
+// on the throw, so the aiming preview, every client and the server all get the same path.
+package grenade
+
+import (
+	"math"
+
+	"shooter/game"
+)
+
+const (
+	// Physics ticks per second, whatever the game's tick rate
+	TPS = 60
+	// Ticks from the throw to the explosion
+	Fuse = 150
+
+	// Horizontal speed of a full power throw, per tick
+	ThrowSpeed = 12.0
+	// Upwards speed every throw starts with, per tick
+	ThrowLift = 6.0
+	// Crosshair distance giving a full power throw
+	MaxThrowDistance = 600.0
+	Gravity          = 0.35
+	// Speed kept when bouncing off walls and the ground
+	Bounciness = 0.5
+	// Horizontal speed kept on each ground bounce and each tick rolling
+	BounceFriction = 0.8
+	RollFriction   = 0.95
+	// Falling slower than this doesn't bounce anymore
+	MinBounceSpeed = 1.0
+	// Distance kept from a wall after bouncing off it, so the next tick doesn't cross it
+	wallGap = 0.5
+)
+
+// State is a grenade in flight, Z is the height above the ground.
+type State struct {
+	X, Y, Z    float64
+	VX, VY, VZ float64
+}
+
+// Throw starts a grenade at x, y towards angle, power is from 0 to 1.
+func Throw(x, y, angle, power float64) State {
+	speed := ThrowSpeed * math.Max(0, math.Min(1, power))
+	return State{X: x, Y: y, VX: math.Cos(angle) * speed, VY: math.Sin(angle) * speed, VZ: ThrowLift}
+}
+
+// Power is the throw power landing the grenade roughly distance away.
+func Power(distance float64) float64 {
+	return math.Max(0, math.Min(1, distance/MaxThrowDistance))
+}
+
+// Step moves the grenade one tick, bouncing off the walls and the ground. It returns
+// whether it bounced.
+func (s *State) Step(objects []game.Object) bool {
+	bounced := false
+	x0, y0 := s.X, s.Y
+	s.X += s.VX
+	s.Y += s.VY
+
+	move := game.Line{X1: x0, Y1: y0, X2: s.X, Y2: s.Y}
+	closest := math.Inf(1)
+	var hit game.Line
+	var hx, hy float64
+	for _, o := range objects {
+		for _, wall := range o.Walls {
+			if x, y, ok := game.Intersection(move, wall); ok && math.Hypot(x-x0, y-y0) < closest {
+				closest = math.Hypot(x-x0, y-y0)
+				hit, hx, hy = wall, x, y
+			}
+		}
+	}
+	if !math.IsInf(closest, 1) {
+		// Wall normal pointing back to where the grenade came from
+		nx, ny := -(hit.Y2 - hit.Y1), hit.X2-hit.X1
+		l := math.Hypot(nx, ny)
+		nx, ny = nx/l, ny/l
+		if (x0-hx)*nx+(y0-hy)*ny < 0 {
+			nx, ny = -nx, -ny
+		}
+		dot := s.VX*nx + s.VY*ny
+		s.VX = (s.VX - 2*dot*nx) * Bounciness
+		s.VY = (s.VY - 2*dot*ny) * Bounciness
+		s.X, s.Y = hx+nx*wallGap, hy+ny*wallGap
+		bounced = true
+	}
+
+	if s.Z > 0 || s.VZ > 0 {
+		s.VZ -= Gravity
+		s.Z += s.VZ
+		if s.Z <= 0 {
+			s.Z = 0
+			s.VX *= BounceFriction
+			s.VY *= BounceFriction
+			if -s.VZ > MinBounceSpeed {
+				s.VZ = -s.VZ * Bounciness
+				bounced = true
+			} else {
+				s.VZ = 0
+			}
+		}
+	} else {
+		s.VX *= RollFriction
+		s.VY *= RollFriction
+	}
+	return bounced
+}
+
+type Point struct {
+	X, Y, Z float64
+}
+
+// Path is where a grenade is on every tick from its throw, it explodes at the last point.
+type Path struct {
+	Points []Point
+	// Where it hit walls or the ground
+	Bounces []Point
+}
+
+// Simulate follows the grenade until its fuse runs out.
+func Simulate(s State, objects []game.Object) Path {
+	p := Path{Points: make([]Point, 0, Fuse+1)}
+	p.Points = append(p.Points, Point{s.X, s.Y, s.Z})
+	for range Fuse {
+		if s.Step(objects) {
+			p.Bounces = append(p.Bounces, Point{s.X, s.Y, s.Z})
+		}
+		p.Points = append(p.Points, Point{s.X, s.Y, s.Z})
+	}
+	return p
+}
+
+// At returns the point tick ticks after the throw, the explosion point once the fuse ran out.
+func (p Path) At(tick int) Point {
+	return p.Points[max(0, min(tick, len(p.Points)-1))]
+}
+
+func (p Path) End() Point {
+	return p.Points[len(p.Points)-1]
+}
@@ -0,0 +1,47 @@
+package grenade
+
+import (
+	"math"
+	"testing"
+
+	"shooter/game"
+)
+
+func TestSimulateLands(t *testing.T) {
+	p := Simulate(Throw(0, 0, 0, 1), nil)
+	if len(p.Points) != Fuse+1 {
+		t.Fatalf("len(Points) = %d, want %d", len(p.Points), Fuse+1)
+	}
+	end := p.End()
+	if end.Z != 0 || end.Y != 0 || end.X <= 0 {
+		t.Errorf("End() = %+v, want on the ground ahead of the throw", end)
+	}
+	if len(p.Bounces) == 0 {
+		t.Error("no ground bounces")
+	}
+	if again := Simulate(Throw(0, 0, 0, 1), nil); again.End() != end {
+		t.Errorf("second run ended at %+v, first at %+v", again.End(), end)
+	}
+}
+
+func TestSimulateBouncesOffWalls(t *testing.T) {
+	wall := game.Object{Walls: []game.Line{{X1: 100, Y1: -500, X2: 100, Y2: 500}}}
+	p := Simulate(Throw(0, 0, 0, 1), []game.Object{wall})
+	for _, pt := range p.Points {
+		if pt.X >= 100 {
+			t.Fatalf("grenade went through the wall at %+v", pt)
+		}
+	}
+	if end := p.End(); end.X >= 100 || math.Abs(end.Y) > 1e-9 {
+		t.Errorf("End() = %+v, want back in front of the wall", end)
+	}
+}
+
+func TestPower(t *testing.T) {
+	if got := Power(MaxThrowDistance * 2); got != 1 {
+		t.Errorf("Power(far) = %v, want 1", got)
+	}
+	if got := Power(MaxThrowDistance / 2); got != 0.5 {
+		t.Errorf("Power(half) = %v, want 0.5", got)
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"shooter/audio"
+	"shooter/grenade"
+	"shooter/hud"
+	"shooter/player"
+	"shooter/render/effects"
+	"shooter/render/lighting"
+	"shooter/sim"
+)
+
+// ExplosionTrauma is the screen shake of an explosion right next to the player.
+const ExplosionTrauma = 0.8
+
+// GrenadeThrow is sent by the thrower and relayed to everyone, who all simulate the same flight.
+// The server deals the damage when it explodes.
+type GrenadeThrow struct {
+	PlayerID string  `json:"player_id"`
+	X        float64 `json:"x"`
+	Y        float64 `json:"y"`
+	Angle    float64 `json:"angle"`
+	Power    float64 `json:"power"`
+	// Entity the throw lands as instead of exploding, empty for grenades
+	Kind sim.EntityKind `json:"kind,omitempty"`
+}
+
+func (t GrenadeThrow) State() grenade.State {
+	return grenade.Throw(t.X, t.Y, t.Angle, t.Power)
+}
+
+// thrownGrenade is a grenade in flight, drawn along its path until it explodes.
+type thrownGrenade struct {
+	path   grenade.Path
+	thrown time.Time
+	// Decoys land quietly, looking just like grenades in flight
+	decoy bool
+}
+
+func (t *thrownGrenade) tick(now time.Time) int {
+	return int(now.Sub(t.thrown) * grenade.TPS / time.Second)
+}
+
+// updateGrenade aims a grenade while the key is held and throws it on release.
+func (g *Game) updateGrenade() {
+	switch {
+	case g.input.Grenade && !g.aimingGrenade && g.player.Grenades > 0 && g.player.Health > 0:
+		g.aimingGrenade = true
+	case !g.input.Grenade && g.aimingGrenade:
+		g.aimingGrenade = false
+		if g.player.Health <= 0 {
+			return
+		}
+		throw := g.grenadeThrow()
+		g.player.Grenades--
+		g.addGrenade(throw)
+		g.sendEvent(player.EventTypeGrenade, throw)
+	}
+}
+
+// grenadeThrow is a throw from the player towards the crosshair.
+func (g *Game) grenadeThrow() GrenadeThrow {
+	d := distance(g.player.X, g.player.Y, g.input.CrosshairX, g.input.CrosshairY)
+	return GrenadeThrow{
+		PlayerID: g.player.ID,
+		X:        g.player.X,
+		Y:        g.player.Y,
+		Angle:    g.input.AimAngle,
+		Power:    grenade.Power(d),
+	}
+}
+
+func (g *Game) addGrenade(t GrenadeThrow) {
+	g.grenades = append(g.grenades, &thrownGrenade{path: grenade.Simulate(t.State(), g.Objects), thrown: g.now(), decoy: t.Kind == sim.Decoy})
+}
+
+// updateGrenades shows the explosions of grenades whose fuse ran out.
+func (g *Game) updateGrenades() {
+	now := g.now()
+	kept := g.grenades[:0]
+	for _, t := range g.grenades {
+		if t.tick(now) < len(t.path.Points)-1 {
+			kept = append(kept, t)
+			continue
+		}
+		if t.decoy {
+			continue
+		}
+		end := t.path.End()
+		g.particles.Emit(effects.Explosion, end.X, end.Y, 0)
+		g.lights.Add(lighting.Explosion(end.X, end.Y))
+		g.camera.AddTraumaAt(ExplosionTrauma, distance(g.player.X, g.player.Y, end.X, end.Y))
+		g.audio.PlayAt(audio.SoundExplode, end.X, end.Y)
+	}
+	g.grenades = kept
+}
+
+func (g *Game) drawGrenades(screen *ebiten.Image) {
+	now := g.now()
+	for _, t := range g.grenades {
+		hud.DrawGrenade(screen, t.path.At(t.tick(now)))
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"shooter/ability"
+	"shooter/match"
+	"shooter/weapon"
+)
+
+// setRules starts playing by the server's rules, everyone starts over in the gun game and
+// elimination waits for the first round. Health from the rules comes with the next respawn.
+func (g *Game) setRules(r match.Rules) {
+	g.rules = r
+	g.gunGame = map[string]int{}
+	g.round, g.alive = Round{}, nil
+	g.wallet, g.buyUntil = match.Wallet{}, time.Time{}
+	g.abilities, g.burstUntil, g.revealed = map[ability.ID]abilityCharge{}, time.Time{}, map[string]time.Time{}
+	g.reel, g.ropes = nil, map[string]rope{}
+	g.player.WeaponLocked, g.player.NoReload = false, false
+	g.player.StartHealth, g.player.InfiniteAmmo = r.Health, r.InfiniteAmmo
+	g.giveGunGameWeapon()
+}
+
+// setProgress moves a gun game player on to their next weapon.
+func (g *Game) setProgress(p GunGameProgress) {
+	g.gunGame[p.PlayerID] = p.Kills
+	if p.PlayerID == g.player.ID {
+		g.giveGunGameWeapon()
+	}
+}
+
+// giveGunGameWeapon hands the local player the weapon they are at in the gun game, other
+// modes let them pick their own.
+func (g *Game) giveGunGameWeapon() {
+	if g.rules.Mode != match.GunGame {
+		return
+	}
+	if w, ok := g.rules.GunGameWeapon(g.gunGame[g.player.ID]); ok {
+		g.player.GiveWeapon(w)
+	}
+}
+
+// gunGameObjective shows the player's weapon out of all of them and who is ahead, empty in other modes.
+func (g *Game) gunGameObjective() string {
+	if g.rules.Mode != match.GunGame {
+		return ""
+	}
+	total := len(g.rules.Weapons)
+	kills := g.gunGame[g.player.ID]
+	w, ok := g.rules.GunGameWeapon(kills)
+	if !ok {
+		return "Gun game finished"
+	}
+	text := fmt.Sprintf("%s %d/%d", weapon.Get(w).Name, kills+1, total)
+	leader, most := "", kills
+	for id, k := range g.gunGame {
+		// Ties go to the smaller ID, so the leader doesn't flicker between frames
+		if k > most || k == most && leader != "" && id < leader {
+			leader, most = id, k
+		}
+	}
+	if leader != "" {
+		text += fmt.Sprintf(" - %s leads %d/%d", leader, most+1, total)
+	}
+	return text
+}
@@ -0,0 +1,102 @@
+// Package heatmap collects where players kill and die on a map, for map authors looking for
+// choke points and unfair spawns. It draws them as an image or hands them out as JSON.
+package heatmap
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"shooter/game"
+)
+
+// Cell is the side of a square of the map counted together, in pixels.
+const Cell = 40.0
+
+// Heatmap is where kills and deaths happened on a map.
+type Heatmap struct {
+	Map    string       `json:"map"`
+	Width  float64      `json:"width"`
+	Height float64      `json:"height"`
+	Kills  [][2]float64 `json:"kills"`
+	Deaths [][2]float64 `json:"deaths"`
+}
+
+func New(mapName string, width, height float64) *Heatmap {
+	return &Heatmap{Map: mapName, Width: width, Height: height, Kills: [][2]float64{}, Deaths: [][2]float64{}}
+}
+
+// Kill records where a killer stood.
+func (h *Heatmap) Kill(x, y float64) {
+	h.Kills = append(h.Kills, [2]float64{x, y})
+}
+
+// Death records where a player died.
+func (h *Heatmap) Death(x, y float64) {
+	h.Deaths = append(h.Deaths, [2]float64{x, y})
+}
+
+// Grid counts the points in each Cell of the map, by row and column. Points off the map count
+// at its edge.
+func (h *Heatmap) Grid(points [][2]float64) [][]int {
+	cols, rows := int(math.Ceil(h.Width/Cell)), int(math.Ceil(h.Height/Cell))
+	grid := make([][]int, rows)
+	for r := range grid {
+		grid[r] = make([]int, cols)
+	}
+	if cols == 0 || rows == 0 {
+		return grid
+	}
+	for _, p := range points {
+		c := min(cols-1, max(0, int(p[0]/Cell)))
+		r := min(rows-1, max(0, int(p[1]/Cell)))
+		grid[r][c]++
+	}
+	return grid
+}
+
+var (
+	wallColor  = color.RGBA{200, 200, 200, 255}
+	floorColor = color.RGBA{20, 20, 24, 255}
+)
+
+// Image draws the map's walls with deaths in red and kills in blue, brighter the more there
+// were in a cell, one pixel per scale pixels of the map.
+func (h *Heatmap) Image(objects []game.Object, scale float64) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, int(h.Width/scale), int(h.Height/scale)))
+	deaths, kills := h.Grid(h.Deaths), h.Grid(h.Kills)
+	most := 1
+	for r := range deaths {
+		for c := range deaths[r] {
+			most = max(most, deaths[r][c], kills[r][c])
+		}
+	}
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			r := min(len(deaths)-1, int(float64(y)*scale/Cell))
+			c := min(len(deaths[r])-1, int(float64(x)*scale/Cell))
+			d, k := float64(deaths[r][c])/float64(most), float64(kills[r][c])/float64(most)
+			img.SetRGBA(x, y, color.RGBA{
+				R: uint8(float64(floorColor.R) + d*(255-float64(floorColor.R))),
+				G: floorColor.G,
+				B: uint8(float64(floorColor.B) + k*(255-float64(floorColor.B))),
+				A: 255,
+			})
+		}
+	}
+	for _, o := range objects {
+		for _, w := range o.Walls {
+			drawLine(img, w.X1/scale, w.Y1/scale, w.X2/scale, w.Y2/scale, wallColor)
+		}
+	}
+	return img
+}
+
+func drawLine(img *image.RGBA, x1, y1, x2, y2 float64, c color.RGBA) {
+	steps := int(math.Max(math.Abs(x2-x1), math.Abs(y2-y1))) + 1
+	for i := 0; i <= steps; i++ {
+		t := float64(i) / float64(steps)
+		img.SetRGBA(int(x1+(x2-x1)*t), int(y1+(y2-y1)*t), c)
+	}
+}
@@ -0,0 +1,43 @@
+package heatmap
+
+import (
+	"testing"
+
+	"shooter/game"
+)
+
+func TestGrid(t *testing.T) {
+	h := New("test", 200, 100)
+	h.Death(10, 10)
+	h.Death(30, 30)
+	h.Death(199, 99)
+	// Off the map counts at the edge
+	h.Death(-50, 500)
+	grid := h.Grid(h.Deaths)
+	if len(grid) != 3 || len(grid[0]) != 5 {
+		t.Fatalf("grid is %dx%d, want 5 columns and 3 rows", len(grid[0]), len(grid))
+	}
+	if grid[0][0] != 2 || grid[2][4] != 1 || grid[2][0] != 1 {
+		t.Errorf("grid = %v", grid)
+	}
+}
+
+func TestImage(t *testing.T) {
+	h := New("test", 400, 200)
+	h.Death(100, 100)
+	h.Kill(300, 100)
+	walls := []game.Object{{Walls: []game.Line{{X1: 0, Y1: 0, X2: 400, Y2: 0}}}}
+	img := h.Image(walls, 2)
+	if b := img.Bounds(); b.Dx() != 200 || b.Dy() != 100 {
+		t.Fatalf("image is %v, want 200x100", b)
+	}
+	if c := img.RGBAAt(50, 50); c.R != 255 || c.B != floorColor.B {
+		t.Errorf("death cell is %v, want full red", c)
+	}
+	if c := img.RGBAAt(150, 50); c.B != 255 || c.R != floorColor.R {
+		t.Errorf("kill cell is %v, want full blue", c)
+	}
+	if c := img.RGBAAt(100, 0); c != wallColor {
+		t.Errorf("wall pixel is %v", c)
+	}
+}
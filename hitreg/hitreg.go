@@ -0,0 +1,82 @@
+// Package hitreg keeps the hits a client predicted for its own bullets until the server, which
+// decides every hit, confirms or rejects them. The client shows hit feedback right away and
+// counts how often the server disagreed.
+package hitreg
+
+import "time"
+
+// ConfirmTimeout is how long the server has to confirm a predicted hit before it counts as rejected.
+// Generous, a hit confirmed late is still a hit.
+const ConfirmTimeout = time.Second
+
+type prediction struct {
+	// 0 when the bullet hit before the server gave it its ID
+	bulletID uint64
+	victimID string
+	at       time.Time
+}
+
+// Stats counts the predicted hits by how the server decided them.
+type Stats struct {
+	Predicted int
+	Confirmed int
+	Rejected  int
+	// Hits the server confirmed without them being predicted
+	Unpredicted int
+}
+
+// Ledger holds the predicted hits still waiting for the server.
+type Ledger struct {
+	pending []prediction
+	Stats   Stats
+}
+
+// Predict notes a hit on victimID the client saw its bullet make at now.
+func (l *Ledger) Predict(bulletID uint64, victimID string, now time.Time) {
+	l.pending = append(l.pending, prediction{bulletID: bulletID, victimID: victimID, at: now})
+	l.Stats.Predicted++
+}
+
+// Confirm takes a hit on victimID by the server and reports whether it was predicted, so its
+// feedback was shown already. The oldest prediction on the victim is the one confirmed.
+func (l *Ledger) Confirm(victimID string) bool {
+	for i, p := range l.pending {
+		if p.victimID == victimID {
+			l.pending = append(l.pending[:i], l.pending[i+1:]...)
+			l.Stats.Confirmed++
+			return true
+		}
+	}
+	l.Stats.Unpredicted++
+	return false
+}
+
+// Destroyed takes the server destroying bullet bulletID after hitting victimID, none for a wall
+// or running out of range, and reports whether that rejects a hit predicted for it.
+func (l *Ledger) Destroyed(bulletID uint64, victimID string) bool {
+	if bulletID == 0 {
+		return false
+	}
+	for i, p := range l.pending {
+		if p.bulletID != bulletID || p.victimID == victimID {
+			continue
+		}
+		l.pending = append(l.pending[:i], l.pending[i+1:]...)
+		l.Stats.Rejected++
+		return true
+	}
+	return false
+}
+
+// Expire rejects the predictions the server didn't confirm in ConfirmTimeout and returns how many.
+func (l *Ledger) Expire(now time.Time) int {
+	n := 0
+	for i := len(l.pending) - 1; i >= 0; i-- {
+		if now.Sub(l.pending[i].at) > ConfirmTimeout {
+			l.pending = append(l.pending[:i], l.pending[i+1:]...)
+			n++
+		}
+	}
+	l.Stats.Rejected += n
+	return n
+}
@@ -0,0 +1,51 @@
+package hitreg
+
+import (
+	"testing"
+	"time"
+)
+
+func TestConfirm(t *testing.T) {
+	var l Ledger
+	now := time.Now()
+	l.Predict(0, "bob", now)
+	l.Predict(7, "bob", now)
+
+	if !l.Confirm("bob") || !l.Confirm("bob") {
+		t.Error("Confirm() of predicted hits = false, want true")
+	}
+	if l.Confirm("bob") {
+		t.Error("Confirm() of a third hit = true, want it unpredicted")
+	}
+	if l.Expire(now.Add(2*ConfirmTimeout)) != 0 {
+		t.Error("Expire() rejected confirmed hits")
+	}
+	want := Stats{Predicted: 2, Confirmed: 2, Unpredicted: 1}
+	if l.Stats != want {
+		t.Errorf("Stats = %+v, want %+v", l.Stats, want)
+	}
+}
+
+func TestReject(t *testing.T) {
+	var l Ledger
+	now := time.Now()
+	l.Predict(7, "bob", now)
+	l.Predict(8, "bob", now)
+	l.Predict(0, "eve", now)
+
+	if l.Destroyed(7, "bob") {
+		t.Error("Destroyed() hitting the predicted victim rejected the hit")
+	}
+	if !l.Destroyed(8, "") {
+		t.Error("Destroyed() by a wall didn't reject the hit")
+	}
+	if l.Expire(now.Add(ConfirmTimeout/2)) != 0 {
+		t.Error("Expire() rejected a hit still in time")
+	}
+	if n := l.Expire(now.Add(2 * ConfirmTimeout)); n != 2 {
+		t.Errorf("Expire() = %d, want the 2 hits never confirmed", n)
+	}
+	if l.Stats.Rejected != 3 {
+		t.Errorf("Stats.Rejected = %d, want 3", l.Stats.Rejected)
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"time"
+
+	"shooter/audio"
+	"shooter/player"
+)
+
+// predictHit shows the hit marker and plays the hit sound for an own bullet hitting victim at
+// x, y without waiting a round trip for the server, which then confirms or rejects it. mu must be held.
+func (g *Game) predictHit(b *player.Bullet, victim *player.Player, x, y float64) {
+	g.hits.Predict(b.ID, victim.ID, g.now())
+	g.feedback.HitConfirmed()
+	g.audio.PlayAt(audio.SoundHit, x, y)
+}
+
+// confirmHit takes the server's word on an own hit of victim, the feedback is only shown
+// if it wasn't predicted. mu must be held.
+func (g *Game) confirmHit(victim *player.Player) {
+	if g.hits.Confirm(victim.ID) {
+		return
+	}
+	g.feedback.HitConfirmed()
+	g.audio.PlayAt(audio.SoundHit, victim.X, victim.Y)
+}
+
+// expireHits rejects predicted hits the server never confirmed, mu must be held.
+func (g *Game) expireHits(now time.Time) {
+	if g.hits.Expire(now) > 0 {
+		g.hitRejected()
+	}
+}
+
+// hitRejected marks a predicted hit the server rejected when the HUD setting is on. Players
+// don't see it otherwise, the marker already flashed and taking it back would only confuse.
+func (g *Game) hitRejected() {
+	if g.app.cfg.HUD.RejectedHits {
+		g.feedback.HitRejected()
+	}
+}
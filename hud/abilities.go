@@ -0,0 +1,74 @@
+package hud
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+
+	"shooter/ui"
+)
+
+var (
+	abilityReadyColor    = color.RGBA{80, 170, 255, 200}
+	abilityChargingColor = color.RGBA{80, 170, 255, 80}
+)
+
+// AbilityState is an ability or ultimate of the player's class.
+type AbilityState struct {
+	Name string
+	// Bound to it
+	Key                 string
+	Charges, MaxCharges int
+	// Towards the next charge, 0 to 1
+	Progress float64
+	// Until the next charge, 0 when it doesn't come with time
+	Left time.Duration
+}
+
+// AbilityBar shows the player's abilities side by side, a box filling up as each charges.
+type AbilityBar struct {
+	Box float64
+}
+
+const abilityGap = 8
+
+func (w *AbilityBar) Size(s *State) (float64, float64) {
+	if len(s.Abilities) == 0 {
+		return 0, 0
+	}
+	_, th := ui.TextSize("A")
+	return float64(len(s.Abilities))*(w.Box+abilityGap) - abilityGap, w.Box + th
+}
+
+func (w *AbilityBar) Draw(screen *ebiten.Image, s *State, x, y, scale float64) {
+	box := w.Box * scale
+	for i, a := range s.Abilities {
+		bx := x + float64(i)*(box+abilityGap*scale)
+		vector.DrawFilledRect(screen, float32(bx), float32(y), float32(box), float32(box), panelColor, false)
+		if a.Charges > 0 {
+			vector.DrawFilledRect(screen, float32(bx), float32(y), float32(box), float32(box), abilityReadyColor, false)
+		} else {
+			fill := box * math.Max(0, math.Min(1, a.Progress))
+			vector.DrawFilledRect(screen, float32(bx), float32(y+box-fill), float32(box), float32(fill), abilityChargingColor, false)
+		}
+		vector.StrokeRect(screen, float32(bx), float32(y), float32(box), float32(box), 1, textColor, false)
+		ui.DrawText(screen, a.Key, bx+3*scale, y+2*scale, scale*0.8, dimTextColor)
+
+		label := ""
+		switch {
+		case a.Charges == 0 && a.Left > 0:
+			label = fmt.Sprintf("%.0f", math.Ceil(a.Left.Seconds()))
+		case a.MaxCharges > 1:
+			label = fmt.Sprintf("x%d", a.Charges)
+		}
+		tw, th := ui.TextSize(label)
+		ui.DrawText(screen, label, bx+(box-tw*scale)/2, y+(box-th*scale)/2, scale, textColor)
+
+		nw, _ := ui.TextSize(a.Name)
+		ui.DrawText(screen, a.Name, bx+(box-nw*scale*0.8)/2, y+box, scale*0.8, dimTextColor)
+	}
+}
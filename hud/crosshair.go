@@ -0,0 +1,116 @@
+package hud
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+
+	"shooter/config"
+	"shooter/ui"
+)
+
+const (
+	crosshairMinSize = 4.0
+	crosshairMaxSize = 20.0
+	crosshairMaxGap  = 80.0
+	crosshairWidth   = 2.0
+)
+
+var CrosshairStyles = []string{"cross", "dot", "circle", "cross_dot"}
+
+var crosshairColors = map[string]color.RGBA{
+	"white":  {255, 255, 255, 255},
+	"green":  {80, 255, 80, 255},
+	"red":    {255, 60, 60, 255},
+	"yellow": {255, 230, 60, 255},
+	"cyan":   {60, 230, 255, 255},
+}
+
+// Ordered for the settings menu
+var CrosshairColors = []string{"white", "green", "red", "yellow", "cyan"}
+
+var (
+	crosshairOutline = color.RGBA{0, 0, 0, 160}
+	// Tint of the crosshair when a wall is in the way of the shot
+	crosshairBlocked = color.RGBA{255, 140, 40, 255}
+)
+
+// Aim is what a shot along the aim would hit first, in screen space.
+type Aim struct {
+	// A wall comes before what's aimed at, at BlockX, BlockY
+	Blocked        bool
+	BlockX, BlockY float64
+	// World distance to what the shot hits, not shown when 0
+	Distance float64
+}
+
+// DrawCrosshair draws the crosshair at x, y. spread is the max angle offset with recoil bloom
+// and distance how far the crosshair is from the muzzle, so the gap covers where bullets can land.
+// A blocked aim tints it and marks the wall in the way.
+func DrawCrosshair(screen *ebiten.Image, settings *config.Crosshair, x, y, spread, distance float64, aim Aim) {
+	clr, ok := crosshairColors[settings.Color]
+	if !ok {
+		clr = crosshairColors["white"]
+	}
+	if aim.Blocked {
+		clr = crosshairBlocked
+		drawBlockMarker(screen, aim.BlockX, aim.BlockY)
+	}
+	size := crosshairMinSize + settings.Size*(crosshairMaxSize-crosshairMinSize)
+	gap := size / 2
+	if settings.Dynamic {
+		gap = math.Max(gap, math.Min(crosshairMaxGap, distance*math.Tan(spread)))
+	}
+
+	// Outline first so it stays visible on bright backgrounds
+	for _, c := range []struct {
+		clr   color.RGBA
+		width float32
+	}{{crosshairOutline, crosshairWidth + 2}, {clr, crosshairWidth}} {
+		switch settings.Style {
+		case "dot":
+			drawCrosshairDot(screen, x, y, c.width, c.clr)
+		case "circle":
+			vector.StrokeCircle(screen, float32(x), float32(y), float32(gap), c.width, c.clr, true)
+			drawCrosshairDot(screen, x, y, c.width, c.clr)
+		case "cross_dot":
+			drawCrosshairLines(screen, x, y, gap, size, c.width, c.clr)
+			drawCrosshairDot(screen, x, y, c.width, c.clr)
+		default:
+			drawCrosshairLines(screen, x, y, gap, size, c.width, c.clr)
+		}
+	}
+	if settings.ShowDistance && aim.Distance > 0 {
+		ui.DrawText(screen, fmt.Sprintf("%.0f px", aim.Distance), x+gap+size+4, y+4, 1, clr)
+	}
+}
+
+// drawBlockMarker crosses out where a wall stops the shot.
+func drawBlockMarker(screen *ebiten.Image, x, y float64) {
+	const r = 5
+	for _, c := range []struct {
+		clr   color.RGBA
+		width float32
+	}{{crosshairOutline, crosshairWidth + 2}, {crosshairBlocked, crosshairWidth}} {
+		vector.StrokeLine(screen, float32(x-r), float32(y-r), float32(x+r), float32(y+r), c.width, c.clr, true)
+		vector.StrokeLine(screen, float32(x-r), float32(y+r), float32(x+r), float32(y-r), c.width, c.clr, true)
+	}
+}
+
+func drawCrosshairLines(screen *ebiten.Image, x, y, gap, length float64, width float32, clr color.Color) {
+	for i := 0; i < 4; i++ {
+		a := float64(i) * math.Pi / 2
+		dx, dy := math.Cos(a), math.Sin(a)
+		vector.StrokeLine(screen,
+			float32(x+dx*gap), float32(y+dy*gap),
+			float32(x+dx*(gap+length)), float32(y+dy*(gap+length)),
+			width, clr, true)
+	}
+}
+
+func drawCrosshairDot(screen *ebiten.Image, x, y float64, width float32, clr color.Color) {
+	vector.DrawFilledCircle(screen, float32(x), float32(y), width, clr, true)
+}
@@ -0,0 +1,78 @@
+package hud
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+
+	"shooter/ui"
+)
+
+var (
+	deathOverlayColor = color.RGBA{40, 0, 0, 120}
+	killcamColor      = color.RGBA{230, 60, 60, 255}
+)
+
+// Death describes how the local player died.
+type Death struct {
+	Killer   string
+	Weapon   string
+	Distance float64
+	// Time left until respawn
+	Respawn time.Duration
+	// Killcam is playing
+	Killcam bool
+	// What the player waits for in modes without respawns, empty when they respawn
+	Waiting string
+	// Joined during a round, there is no death to show
+	Spectating bool
+}
+
+// DrawDeathScreen draws who killed the player and the respawn countdown.
+func DrawDeathScreen(screen *ebiten.Image, d Death) {
+	b := screen.Bounds()
+	sw, sh := float64(b.Dx()), float64(b.Dy())
+	scale := sh / 900
+
+	vector.DrawFilledRect(screen, 0, 0, float32(sw), float32(sh), deathOverlayColor, false)
+
+	lines := []struct {
+		text  string
+		scale float64
+		clr   color.Color
+	}{
+		{"YOU DIED", 4, killcamColor},
+		{fmt.Sprintf("Killed by %s", d.Killer), 2, textColor},
+		{fmt.Sprintf("%s from %.0f px", d.Weapon, d.Distance), 2, dimTextColor},
+		{fmt.Sprintf("Respawn in %d", int(math.Ceil(d.Respawn.Seconds()))), 2, textColor},
+	}
+	if d.Killer == "" {
+		lines[1].text = "Killed"
+		if d.Distance == 0 {
+			lines[2].text = d.Weapon
+		}
+	}
+	if d.Waiting != "" {
+		lines[3].text = d.Waiting
+	}
+	if d.Spectating {
+		lines = lines[:2]
+		lines[0].text, lines[0].clr = "SPECTATING", textColor
+		lines[1].text = d.Waiting
+	}
+
+	y := sh * 0.6
+	for _, l := range lines {
+		w, _ := ui.TextSize(l.text)
+		ui.DrawText(screen, l.text, (sw-w*l.scale*scale)/2, y, l.scale*scale, l.clr)
+		y += (ui.LineHeight*l.scale + 10) * scale
+	}
+
+	if d.Killcam {
+		ui.DrawText(screen, "KILLCAM", 20*scale, sh*0.2, 3*scale, killcamColor)
+	}
+}
@@ -0,0 +1,145 @@
+package hud
+
+import (
+	"image/color"
+	"math"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+
+	"shooter/game"
+)
+
+var (
+	turretBaseColor   = color.RGBA{50, 50, 55, 255}
+	turretBarrelColor = color.RGBA{30, 30, 30, 255}
+)
+
+// DrawTurret draws a turret in world space with its barrel towards angle, a ring in its owner's
+// color and its health underneath.
+func DrawTurret(screen *ebiten.Image, x, y, angle, radius, health float64, owner color.Color) {
+	vector.DrawFilledCircle(screen, float32(x), float32(y), float32(radius), turretBaseColor, true)
+	vector.StrokeCircle(screen, float32(x), float32(y), float32(radius), 2, owner, true)
+	ex, ey := x+math.Cos(angle)*radius*1.6, y+math.Sin(angle)*radius*1.6
+	vector.StrokeLine(screen, float32(x), float32(y), float32(ex), float32(ey), 5, turretBarrelColor, true)
+
+	w := float32(radius * 2)
+	bx, by := float32(x-radius), float32(y+radius+4)
+	vector.DrawFilledRect(screen, bx, by, w, 3, color.RGBA{0, 0, 0, 160}, false)
+	vector.DrawFilledRect(screen, bx, by, w*float32(math.Max(0, health)), 3, color.RGBA{80, 220, 80, 255}, false)
+}
+
+var barricadeColor = color.RGBA{110, 95, 70, 255}
+
+// DrawBarricade draws the barricade's footprint, darker the more damaged it is, outlined in its owner's color.
+func DrawBarricade(screen *ebiten.Image, footprint game.Object, health float64, owner color.Color) {
+	if len(footprint.Walls) != 4 {
+		return
+	}
+	// The short sides are the ends of the wall
+	x1, y1 := (footprint.Walls[1].X1+footprint.Walls[1].X2)/2, (footprint.Walls[1].Y1+footprint.Walls[1].Y2)/2
+	x2, y2 := (footprint.Walls[3].X1+footprint.Walls[3].X2)/2, (footprint.Walls[3].Y1+footprint.Walls[3].Y2)/2
+	width := math.Hypot(footprint.Walls[1].X2-footprint.Walls[1].X1, footprint.Walls[1].Y2-footprint.Walls[1].Y1)
+
+	shade := 0.4 + 0.6*math.Max(0, health)
+	c := color.RGBA{uint8(float64(barricadeColor.R) * shade), uint8(float64(barricadeColor.G) * shade), uint8(float64(barricadeColor.B) * shade), 255}
+	vector.StrokeLine(screen, float32(x1), float32(y1), float32(x2), float32(y2), float32(width), c, true)
+	for _, wall := range footprint.Walls {
+		vector.StrokeLine(screen, float32(wall.X1), float32(wall.Y1), float32(wall.X2), float32(wall.Y2), 1.5, owner, true)
+	}
+}
+
+var (
+	corpseColor = color.RGBA{40, 36, 34, 255}
+	bloodColor  = color.RGBA{90, 10, 10, 170}
+	pickupColor = color.RGBA{255, 220, 120, 140}
+)
+
+// DrawCorpse draws a body lying in a pool of blood, with a ring in the dead player's color.
+func DrawCorpse(screen *ebiten.Image, x, y, radius float64, owner color.Color) {
+	vector.DrawFilledCircle(screen, float32(x+radius*0.3), float32(y+radius*0.2), float32(radius*1.3), bloodColor, true)
+	vector.DrawFilledCircle(screen, float32(x), float32(y), float32(radius*0.8), corpseColor, true)
+	vector.StrokeCircle(screen, float32(x), float32(y), float32(radius*0.8), 1.5, owner, true)
+}
+
+var jammerColor = color.RGBA{160, 60, 220, 255}
+
+// DrawJammer draws a jammer in its owner's color and the faint edge of the area it hides.
+func DrawJammer(screen *ebiten.Image, x, y, radius, area float64, owner color.Color) {
+	vector.DrawFilledCircle(screen, float32(x), float32(y), float32(radius), jammerColor, true)
+	vector.StrokeCircle(screen, float32(x), float32(y), float32(radius), 1.5, owner, true)
+	vector.StrokeCircle(screen, float32(x), float32(y), float32(area), 1, color.RGBA{160, 60, 220, 60}, true)
+}
+
+// DrawWeaponDrop marks a weapon on the ground as something to pick up.
+func DrawWeaponDrop(screen *ebiten.Image, x, y, radius float64) {
+	vector.StrokeCircle(screen, float32(x), float32(y), float32(radius), 1.5, pickupColor, true)
+}
+
+var ropeColor = color.RGBA{200, 190, 160, 255}
+
+// DrawRope draws a grapple rope from the player to where the hook attached.
+func DrawRope(screen *ebiten.Image, x, y, hookX, hookY float64) {
+	vector.StrokeLine(screen, float32(x), float32(y), float32(hookX), float32(hookY), 1.5, ropeColor, true)
+	vector.DrawFilledCircle(screen, float32(hookX), float32(hookY), 3, ropeColor, true)
+}
+
+var (
+	vehicleColor      = color.RGBA{170, 120, 40, 255}
+	vehicleWheelColor = color.RGBA{25, 25, 25, 255}
+)
+
+// DrawVehicle draws a buggy facing angle, radius long from its center to the bumper, and its
+// health underneath.
+func DrawVehicle(screen *ebiten.Image, x, y, angle, radius, health float64) {
+	dx, dy := math.Cos(angle), math.Sin(angle)
+	for _, w := range [][2]float64{{0.6, 0.55}, {0.6, -0.55}, {-0.6, 0.55}, {-0.6, -0.55}} {
+		wx, wy := x+(dx*w[0]-dy*w[1])*radius, y+(dy*w[0]+dx*w[1])*radius
+		vector.DrawFilledCircle(screen, float32(wx), float32(wy), float32(radius*0.25), vehicleWheelColor, true)
+	}
+	// A thick line is a rectangle turned its way
+	vector.StrokeLine(screen, float32(x-dx*radius), float32(y-dy*radius), float32(x+dx*radius), float32(y+dy*radius), float32(radius*1.1), vehicleColor, false)
+	vector.StrokeLine(screen, float32(x+dx*radius*0.3), float32(y+dy*radius*0.3), float32(x+dx*radius*0.7), float32(y+dy*radius*0.7), float32(radius*0.8), turretBarrelColor, false)
+
+	w := float32(radius * 2)
+	bx, by := float32(x-radius), float32(y+radius+4)
+	vector.DrawFilledRect(screen, bx, by, w, 3, color.RGBA{0, 0, 0, 160}, false)
+	vector.DrawFilledRect(screen, bx, by, w*float32(math.Max(0, health)), 3, color.RGBA{80, 220, 80, 255}, false)
+}
+
+var doorColor = color.RGBA{90, 90, 100, 255}
+
+// DrawDoor draws a closed door of the map like a barricade of nobody's which never wears.
+func DrawDoor(screen *ebiten.Image, footprint game.Object) {
+	if len(footprint.Walls) != 4 {
+		return
+	}
+	x1, y1 := (footprint.Walls[1].X1+footprint.Walls[1].X2)/2, (footprint.Walls[1].Y1+footprint.Walls[1].Y2)/2
+	x2, y2 := (footprint.Walls[3].X1+footprint.Walls[3].X2)/2, (footprint.Walls[3].Y1+footprint.Walls[3].Y2)/2
+	width := math.Hypot(footprint.Walls[1].X2-footprint.Walls[1].X1, footprint.Walls[1].Y2-footprint.Walls[1].Y1)
+	vector.StrokeLine(screen, float32(x1), float32(y1), float32(x2), float32(y2), float32(width), doorColor, true)
+	// A seam where it opens
+	mx, my := (x1+x2)/2, (y1+y2)/2
+	vector.StrokeLine(screen, float32(mx+(y1-y2)*0.04), float32(my-(x1-x2)*0.04), float32(mx-(y1-y2)*0.04), float32(my+(x1-x2)*0.04), 1.5, color.Black, true)
+}
+
+var (
+	airdropColor = color.RGBA{255, 220, 40, 255}
+	crateColor   = color.RGBA{120, 90, 50, 255}
+)
+
+// DrawAirdrop marks where a supply crate is about to land with a pulsing ring and a flare
+// in the middle, hard to miss from anywhere on screen.
+func DrawAirdrop(screen *ebiten.Image, x, y, radius float64) {
+	pulse := 0.5 + 0.5*math.Sin(float64(time.Now().UnixMilli())/150)
+	vector.StrokeCircle(screen, float32(x), float32(y), float32(radius*(0.6+0.4*pulse)), 3, airdropColor, true)
+	vector.StrokeCircle(screen, float32(x), float32(y), float32(radius), 1.5, color.RGBA{255, 220, 40, 120}, true)
+	vector.DrawFilledCircle(screen, float32(x), float32(y), 5, color.RGBA{255, 80, 40, 255}, true)
+}
+
+// DrawCrate draws a supply crate, radius from its center to the sides.
+func DrawCrate(screen *ebiten.Image, x, y, radius float64) {
+	vector.DrawFilledRect(screen, float32(x-radius), float32(y-radius), float32(2*radius), float32(2*radius), crateColor, false)
+	vector.StrokeRect(screen, float32(x-radius), float32(y-radius), float32(2*radius), float32(2*radius), 2, airdropColor, false)
+}
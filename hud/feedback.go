@@ -0,0 +1,185 @@
+package hud
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+
+	"shooter/config"
+	"shooter/game"
+)
+
+const (
+	HitMarkerDuration       = 150 * time.Millisecond
+	DamageNumberDuration    = time.Second
+	DamageIndicatorDuration = 1500 * time.Millisecond
+
+	hitMarkerGap    = 4.0
+	hitMarkerLength = 6.0
+
+	damageNumberRise = 30.0
+
+	indicatorMargin   = 40.0
+	indicatorSpread   = 0.35 // half of the arc width in radians
+	indicatorSegments = 12
+
+	// Damage numbers and health bars are drawn this far from their anchor at most
+	cullMargin = 60.0
+)
+
+var (
+	hitMarkerColor       = color.White
+	rejectedMarkerColor  = color.RGBA{255, 60, 60, 255}
+	damageIndicatorColor = color.RGBA{220, 0, 0, 255}
+)
+
+type damageNumber struct {
+	x, y    float64
+	image   *ebiten.Image
+	created time.Time
+}
+
+type damageIndicator struct {
+	angle   float64
+	created time.Time
+}
+
+// Feedback collects short-lived combat feedback: hit markers, damage numbers and damage direction.
+type Feedback struct {
+	settings *config.HUD
+
+	mu             sync.Mutex
+	hitMarkerUntil time.Time
+	rejectedUntil  time.Time
+	numbers        []*damageNumber
+	indicators     []*damageIndicator
+}
+
+func NewFeedback(settings *config.HUD) *Feedback {
+	return &Feedback{settings: settings}
+}
+
+// HitConfirmed flashes the hit marker around the crosshair.
+func (f *Feedback) HitConfirmed() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.hitMarkerUntil = time.Now().Add(HitMarkerDuration)
+}
+
+// HitRejected flashes the hit marker in red, for a hit the server didn't agree with.
+func (f *Feedback) HitRejected() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.hitMarkerUntil = time.Time{}
+	f.rejectedUntil = time.Now().Add(HitMarkerDuration)
+}
+
+// DamageDealt spawns a floating damage number at the victim's position.
+func (f *Feedback) DamageDealt(x, y float64, damage int) {
+	if !f.settings.ShowDamageNumbers {
+		return
+	}
+
+	text := fmt.Sprintf("%d", damage)
+	img := ebiten.NewImage(len(text)*6+2, 16)
+	ebitenutil.DebugPrint(img, text)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.numbers = append(f.numbers, &damageNumber{x: x, y: y, image: img, created: time.Now()})
+}
+
+// DamageTaken shows an indicator pointing towards angle, the direction damage came from.
+func (f *Feedback) DamageTaken(angle float64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.indicators = append(f.indicators, &damageIndicator{angle: angle, created: time.Now()})
+}
+
+// Update drops expired feedback.
+func (f *Feedback) Update() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for i := len(f.numbers) - 1; i >= 0; i-- {
+		if time.Since(f.numbers[i].created) > DamageNumberDuration {
+			f.numbers[i].image.Deallocate()
+			f.numbers = append(f.numbers[:i], f.numbers[i+1:]...)
+		}
+	}
+	for i := len(f.indicators) - 1; i >= 0; i-- {
+		if time.Since(f.indicators[i].created) > DamageIndicatorDuration {
+			f.indicators = append(f.indicators[:i], f.indicators[i+1:]...)
+		}
+	}
+}
+
+// DrawWorld draws feedback placed in the world, i.e. damage numbers.
+func (f *Feedback) DrawWorld(screen *ebiten.Image, view game.Bounds) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, n := range f.numbers {
+		if !view.Contains(n.x, n.y, cullMargin) {
+			continue
+		}
+		t := math.Min(1, float64(time.Since(n.created))/float64(DamageNumberDuration))
+
+		op := &ebiten.DrawImageOptions{}
+		op.GeoM.Translate(n.x-float64(n.image.Bounds().Dx())/2, n.y-30-t*damageNumberRise)
+		op.ColorScale.ScaleAlpha(float32(1 - t))
+		screen.DrawImage(n.image, op)
+	}
+}
+
+// DrawScreen draws feedback attached to the screen, cursorX and cursorY is the crosshair position.
+func (f *Feedback) DrawScreen(screen *ebiten.Image, cursorX, cursorY float64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	marker := func(clr color.Color) {
+		for _, a := range []float64{math.Pi / 4, 3 * math.Pi / 4, 5 * math.Pi / 4, 7 * math.Pi / 4} {
+			x1 := cursorX + math.Cos(a)*hitMarkerGap
+			y1 := cursorY + math.Sin(a)*hitMarkerGap
+			x2 := cursorX + math.Cos(a)*(hitMarkerGap+hitMarkerLength)
+			y2 := cursorY + math.Sin(a)*(hitMarkerGap+hitMarkerLength)
+			vector.StrokeLine(screen, float32(x1), float32(y1), float32(x2), float32(y2), 2, clr, true)
+		}
+	}
+	if now := time.Now(); now.Before(f.hitMarkerUntil) {
+		marker(hitMarkerColor)
+	} else if now.Before(f.rejectedUntil) {
+		marker(rejectedMarkerColor)
+	}
+
+	bounds := screen.Bounds()
+	cx, cy := float64(bounds.Dx())/2, float64(bounds.Dy())/2
+	radius := math.Min(cx, cy) - indicatorMargin
+
+	for _, ind := range f.indicators {
+		t := math.Min(1, float64(time.Since(ind.created))/float64(DamageIndicatorDuration))
+		a := 1 - t
+		clr := color.RGBA{
+			uint8(float64(damageIndicatorColor.R) * a),
+			uint8(float64(damageIndicatorColor.G) * a),
+			uint8(float64(damageIndicatorColor.B) * a),
+			uint8(float64(damageIndicatorColor.A) * a),
+		}
+
+		step := 2 * indicatorSpread / indicatorSegments
+		for i := 0; i < indicatorSegments; i++ {
+			a1 := ind.angle - indicatorSpread + float64(i)*step
+			a2 := a1 + step
+			vector.StrokeLine(screen,
+				float32(cx+math.Cos(a1)*radius), float32(cy+math.Sin(a1)*radius),
+				float32(cx+math.Cos(a2)*radius), float32(cy+math.Sin(a2)*radius),
+				6, clr, true)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package hud
+
+import (
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+
+	"shooter/grenade"
+)
+
+var (
+	grenadeArcColor    = color.RGBA{255, 255, 255, 200}
+	grenadeShadowColor = color.RGBA{0, 0, 0, 90}
+	grenadeBounceColor = color.RGBA{255, 200, 80, 255}
+	grenadeBlastColor  = color.RGBA{255, 80, 40, 160}
+)
+
+// Every nth point of the path is drawn as a dot of the preview arc
+const grenadeArcStep = 3
+
+// DrawGrenadeArc previews a throw in world space: the flight as dots lifted by their
+// height over a ground shadow, the bounce points and the blast radius where it explodes.
+func DrawGrenadeArc(screen *ebiten.Image, path grenade.Path, blastRadius float64) {
+	for i := 0; i < len(path.Points); i += grenadeArcStep {
+		p := path.Points[i]
+		vector.DrawFilledCircle(screen, float32(p.X), float32(p.Y), 2, grenadeShadowColor, true)
+		vector.DrawFilledCircle(screen, float32(p.X), float32(p.Y-p.Z), 2, grenadeArcColor, true)
+	}
+	for _, b := range path.Bounces {
+		vector.StrokeCircle(screen, float32(b.X), float32(b.Y-b.Z), 5, 1.5, grenadeBounceColor, true)
+	}
+	end := path.End()
+	vector.StrokeCircle(screen, float32(end.X), float32(end.Y), float32(blastRadius), 2, grenadeBlastColor, true)
+}
+
+// DrawGrenade draws a grenade in flight with its shadow on the ground.
+func DrawGrenade(screen *ebiten.Image, p grenade.Point) {
+	vector.DrawFilledCircle(screen, float32(p.X), float32(p.Y), 4, grenadeShadowColor, true)
+	vector.DrawFilledCircle(screen, float32(p.X), float32(p.Y-p.Z), 4, color.RGBA{60, 80, 50, 255}, true)
+}
@@ -0,0 +1,63 @@
+package hud
+
+import (
+	"image/color"
+	"math"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+
+	"shooter/game"
+	"shooter/level"
+)
+
+var hazardColors = map[level.HazardKind]color.NRGBA{
+	level.Fire: {255, 110, 20, 255},
+	level.Acid: {120, 230, 40, 255},
+	level.Mud:  {100, 70, 40, 255},
+}
+
+// Spacing of the animated specks, embers, bubbles or ripples, across a hazard
+const hazardSpeckSpacing = 40.0
+
+// DrawHazards draws the hazard areas in world space, a pulsing fill with specks moving
+// in the way of their kind: embers rise, bubbles pop and mud ripples.
+func DrawHazards(screen *ebiten.Image, hazards []level.Hazard, now time.Time, view game.Bounds) {
+	t := float64(now.UnixMilli()%100000) / 1000
+	for _, h := range hazards {
+		cx, cy := h.X+h.Width/2, h.Y+h.Height/2
+		if !view.Contains(cx, cy, math.Max(h.Width, h.Height)) {
+			continue
+		}
+		c := hazardColors[h.Kind]
+		fill := c
+		fill.A = uint8(90 + 30*math.Sin(t*3))
+		vector.DrawFilledRect(screen, float32(h.X), float32(h.Y), float32(h.Width), float32(h.Height), fill, false)
+
+		for x := h.X + hazardSpeckSpacing/2; x < h.X+h.Width; x += hazardSpeckSpacing {
+			for y := h.Y + hazardSpeckSpacing/2; y < h.Y+h.Height; y += hazardSpeckSpacing {
+				// Every speck gets its own phase from where it is
+				phase := math.Mod(x*0.37+y*0.61, 2*math.Pi)
+				drawHazardSpeck(screen, h, x, y, t+phase, c)
+			}
+		}
+	}
+}
+
+func drawHazardSpeck(screen *ebiten.Image, h level.Hazard, x, y, t float64, c color.NRGBA) {
+	switch h.Kind {
+	case level.Fire:
+		// Rising and fading, wrapping around to the bottom
+		rise := math.Mod(t*30, hazardSpeckSpacing)
+		c.A = uint8(255 * (1 - rise/hazardSpeckSpacing))
+		vector.DrawFilledCircle(screen, float32(x), float32(y-rise+hazardSpeckSpacing/2), 2.5, c, true)
+	case level.Acid:
+		r := 1 + 4*math.Max(0, math.Sin(t*2))
+		vector.StrokeCircle(screen, float32(x), float32(y), float32(r), 1.5, c, true)
+	default:
+		r := math.Mod(t*6, hazardSpeckSpacing/2)
+		c.A = uint8(200 * (1 - 2*r/hazardSpeckSpacing))
+		vector.StrokeCircle(screen, float32(x), float32(y), float32(r), 1, c, true)
+	}
+}
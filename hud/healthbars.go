@@ -0,0 +1,109 @@
+package hud
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+
+	"shooter/game"
+)
+
+const (
+	healthBarWidth  = 36.0
+	healthBarHeight = 4.0
+	healthBarOffset = 34.0 // above the player's center
+
+	LowHealthThreshold = 0.25
+	vignettePulse      = 1200 * time.Millisecond
+)
+
+var (
+	friendlyColor = color.RGBA{60, 140, 255, 255}
+	enemyColor    = color.RGBA{230, 50, 50, 255}
+	teamColors    = map[string]color.RGBA{
+		"red":  {230, 50, 50, 255},
+		"blue": {60, 140, 255, 255},
+	}
+)
+
+type BarPlayer struct {
+	Name      string
+	X, Y      float64
+	Health    int
+	MaxHealth int
+	Team      string
+	Friendly  bool
+	// In line of sight and within name tag distance
+	Visible bool
+}
+
+// BarRules are decided by the game mode.
+type BarRules struct {
+	HideFullHealthEnemies bool
+}
+
+func barColor(p BarPlayer) color.RGBA {
+	if c, ok := teamColors[p.Team]; ok {
+		return c
+	}
+	if p.Friendly {
+		return friendlyColor
+	}
+	return enemyColor
+}
+
+// DrawHealthBars draws mini health bars above players in world space.
+func DrawHealthBars(screen *ebiten.Image, players []BarPlayer, rules BarRules, view game.Bounds) {
+	for _, p := range players {
+		if p.Health <= 0 || p.MaxHealth <= 0 || !view.Contains(p.X, p.Y, cullMargin) {
+			continue
+		}
+		if rules.HideFullHealthEnemies && !p.Friendly && p.Health >= p.MaxHealth {
+			continue
+		}
+
+		frac := math.Min(1, float64(p.Health)/float64(p.MaxHealth))
+		x := float32(p.X - healthBarWidth/2)
+		y := float32(p.Y - healthBarOffset)
+		vector.DrawFilledRect(screen, x, y, healthBarWidth, healthBarHeight, panelColor, false)
+		vector.DrawFilledRect(screen, x, y, float32(healthBarWidth*frac), healthBarHeight, barColor(p), false)
+	}
+}
+
+// Red edges fading to a transparent center, stretched over the screen
+var vignetteImage = func() *ebiten.Image {
+	const w, h = 320, 180
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := range h {
+		for x := range w {
+			dx := (float64(x) - w/2) / (w / 2)
+			dy := (float64(y) - h/2) / (h / 2)
+			d := math.Min(1, math.Hypot(dx, dy)/math.Sqrt2)
+			a := math.Pow(math.Max(0, (d-0.45)/0.55), 1.5)
+			img.SetRGBA(x, y, color.RGBA{uint8(200 * a), 0, 0, uint8(255 * a)})
+		}
+	}
+	return ebiten.NewImageFromImage(img)
+}()
+
+// DrawLowHealthVignette pulses red around the screen edges while health is below LowHealthThreshold.
+func DrawLowHealthVignette(screen *ebiten.Image, health, maxHealth int) {
+	if health <= 0 || maxHealth <= 0 || float64(health)/float64(maxHealth) >= LowHealthThreshold {
+		return
+	}
+
+	phase := float64(time.Now().UnixMilli()%vignettePulse.Milliseconds()) / float64(vignettePulse.Milliseconds())
+	alpha := 0.55 + 0.35*math.Sin(phase*2*math.Pi)
+
+	b := screen.Bounds()
+	vb := vignetteImage.Bounds()
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Scale(float64(b.Dx())/float64(vb.Dx()), float64(b.Dy())/float64(vb.Dy()))
+	op.ColorScale.ScaleAlpha(float32(alpha))
+	op.Filter = ebiten.FilterLinear
+	screen.DrawImage(vignetteImage, op)
+}
@@ -0,0 +1,82 @@
+package hud
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// Layout is designed for this resolution and scaled to the actual screen height.
+const ReferenceHeight = 900.0
+
+type Anchor int
+
+const (
+	TopLeft Anchor = iota
+	TopCenter
+	TopRight
+	CenterLeft
+	Center
+	CenterRight
+	BottomLeft
+	BottomCenter
+	BottomRight
+)
+
+// Widget is a HUD element drawn at a position computed by the HUD.
+type Widget interface {
+	// Size in reference pixels for the current state
+	Size(state *State) (float64, float64)
+	Draw(screen *ebiten.Image, state *State, x, y, scale float64)
+}
+
+type placement struct {
+	widget  Widget
+	anchor  Anchor
+	offsetX float64
+	offsetY float64
+}
+
+// HUD places widgets relative to screen anchors. Offsets point inwards from the anchor
+// and are given in reference pixels, so the layout holds for any resolution.
+type HUD struct {
+	widgets []placement
+}
+
+func New() *HUD {
+	return &HUD{}
+}
+
+func (h *HUD) Add(w Widget, anchor Anchor, offsetX, offsetY float64) {
+	h.widgets = append(h.widgets, placement{widget: w, anchor: anchor, offsetX: offsetX, offsetY: offsetY})
+}
+
+func (h *HUD) Draw(screen *ebiten.Image, state *State) {
+	b := screen.Bounds()
+	sw, sh := float64(b.Dx()), float64(b.Dy())
+	scale := sh / ReferenceHeight
+
+	for _, p := range h.widgets {
+		w, ht := p.widget.Size(state)
+		w, ht = w*scale, ht*scale
+		ox, oy := p.offsetX*scale, p.offsetY*scale
+
+		var x, y float64
+		switch p.anchor % 3 {
+		case 0:
+			x = ox
+		case 1:
+			x = (sw-w)/2 + ox
+		case 2:
+			x = sw - w - ox
+		}
+		switch p.anchor / 3 {
+		case 0:
+			y = oy
+		case 1:
+			y = (sh-ht)/2 + oy
+		case 2:
+			y = sh - ht - oy
+		}
+
+		p.widget.Draw(screen, state, x, y, scale)
+	}
+}
@@ -0,0 +1,64 @@
+package hud
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"shooter/game"
+	"shooter/ui"
+)
+
+const (
+	// Above the health bar
+	nameTagOffset = healthBarOffset + 6
+	// Alpha gained or lost per second when a player comes into or goes out of sight
+	nameTagFadeSpeed = 4.0
+)
+
+// NameTags draws player names above their health bars, colored like the bars. Tags fade
+// out while players are out of sight and come back when they are seen again.
+type NameTags struct {
+	alpha map[string]float64
+}
+
+func NewNameTags() *NameTags {
+	return &NameTags{alpha: map[string]float64{}}
+}
+
+// Update fades the tags dt seconds towards the players' visibility.
+func (t *NameTags) Update(players []BarPlayer, dt float64) {
+	seen := make(map[string]bool, len(players))
+	for _, p := range players {
+		target := 0.0
+		if p.Visible && p.Health > 0 {
+			target = 1
+		}
+		a := t.alpha[p.Name]
+		if a < target {
+			a = math.Min(target, a+nameTagFadeSpeed*dt)
+		} else {
+			a = math.Max(target, a-nameTagFadeSpeed*dt)
+		}
+		t.alpha[p.Name] = a
+		seen[p.Name] = true
+	}
+	for name := range t.alpha {
+		if !seen[name] {
+			delete(t.alpha, name)
+		}
+	}
+}
+
+func (t *NameTags) Draw(screen *ebiten.Image, players []BarPlayer, view game.Bounds) {
+	for _, p := range players {
+		a := t.alpha[p.Name]
+		if a <= 0 || !view.Contains(p.X, p.Y, cullMargin) {
+			continue
+		}
+		c := barColor(p)
+		w, h := ui.TextSize(p.Name)
+		ui.DrawText(screen, p.Name, p.X-w/2, p.Y-nameTagOffset-h, 1, color.NRGBA{c.R, c.G, c.B, uint8(255 * a)})
+	}
+}
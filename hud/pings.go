@@ -0,0 +1,75 @@
+package hud
+
+import (
+	"image/color"
+	"math"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+
+	"shooter/game"
+	"shooter/ping"
+	"shooter/ui"
+)
+
+const (
+	pingRadius      = 14.0
+	pingWheelRadius = 70.0
+)
+
+var (
+	pingColors = map[ping.Kind]color.RGBA{
+		ping.GoHere:       {80, 200, 255, 255},
+		ping.Danger:       {255, 180, 40, 255},
+		ping.EnemySpotted: {255, 60, 60, 255},
+	}
+	pingLabels = map[ping.Kind]string{
+		ping.GoHere:       "GO",
+		ping.Danger:       "DANGER",
+		ping.EnemySpotted: "ENEMY",
+	}
+)
+
+func pingColor(k ping.Kind, alpha float64) color.NRGBA {
+	c := pingColors[k]
+	return color.NRGBA{c.R, c.G, c.B, uint8(255 * alpha)}
+}
+
+// DrawPings draws markers in world space, a pulsing ring with the kind and who placed it.
+func DrawPings(screen *ebiten.Image, markers []ping.Marker, view game.Bounds) {
+	now := time.Now()
+	for _, m := range markers {
+		if !view.Contains(m.X, m.Y, pingWheelRadius) {
+			continue
+		}
+		a := m.Alpha(now)
+		clr := pingColor(m.Kind, a)
+		pulse := 1 + 0.2*math.Sin(now.Sub(m.Created).Seconds()*6)
+		vector.StrokeCircle(screen, float32(m.X), float32(m.Y), float32(pingRadius*pulse), 2, clr, true)
+		vector.DrawFilledCircle(screen, float32(m.X), float32(m.Y), 3, clr, true)
+
+		label := pingLabels[m.Kind] + " " + m.Owner
+		w, h := ui.TextSize(label)
+		ui.DrawText(screen, label, m.X-w/2, m.Y-pingRadius-h-4, 1, clr)
+	}
+}
+
+// DrawPingWheel draws the kinds around x, y in screen space with the selected one highlighted.
+func DrawPingWheel(screen *ebiten.Image, x, y float64, selected ping.Kind) {
+	scale := float64(screen.Bounds().Dy()) / ReferenceHeight
+	r := pingWheelRadius * scale
+	vector.DrawFilledCircle(screen, float32(x), float32(y), float32(r*1.5), panelColor, true)
+	for i, k := range ping.Kinds {
+		// Same directions as ping.WheelKind, clockwise from the top
+		a := float64(i) * 2 * math.Pi / float64(len(ping.Kinds))
+		kx, ky := x+math.Sin(a)*r, y-math.Cos(a)*r
+		alpha := 0.5
+		if k == selected {
+			alpha = 1
+		}
+		label := pingLabels[k]
+		w, h := ui.TextSize(label)
+		ui.DrawText(screen, label, kx-w*scale/2, ky-h*scale/2, scale, pingColor(k, alpha))
+	}
+}
@@ -0,0 +1,75 @@
+package hud
+
+import (
+	"time"
+
+	"shooter/frametime"
+	"shooter/game"
+	"shooter/hitreg"
+	"shooter/match"
+	"shooter/ping"
+	"shooter/weapon"
+	"shooter/zone"
+)
+
+type MinimapPlayer struct {
+	X, Y  float64
+	Local bool
+	// Found by a recon pulse
+	Enemy bool
+}
+
+// Blip is gunfire heard on the radar, fading out to Alpha 0.
+type Blip struct {
+	X, Y  float64
+	Alpha float64
+}
+
+// State is everything the HUD shows, filled by the game once per frame.
+type State struct {
+	Health    int
+	MaxHealth int
+
+	WeaponName   string
+	Ammo         int
+	MagazineSize int
+	Reloading    bool
+	Grenades     int
+	Decoys       int
+
+	// Minimap
+	WorldWidth  float64
+	WorldHeight float64
+	Objects     []game.Object
+	Players     []MinimapPlayer
+	Pings       []ping.Marker
+	Blips       []Blip
+	// Where supply crates land or lie, everyone sees them
+	Supplies [][2]float64
+	// Nil without the zone modifier
+	Zone *zone.State
+
+	Objective string
+
+	// Ability and ultimate of the player's class, nil on servers without abilities
+	Abilities []AbilityState
+
+	// Live scores, only shown to observers
+	Scores []match.PlayerStats
+	// Own shot stats and the held weapon, only set in practice
+	Practice *match.PlayerStats
+	WeaponID weapon.ID
+
+	// Control prompts for the device in use
+	Controls string
+
+	TPS float64
+	FPS float64
+	// Ticks per second of the server and the time between its snapshots, 0 offline
+	ServerRate       int
+	SnapshotInterval time.Duration
+	// Time per frame of its parts, nil unless turned on in the settings
+	FrameTimes []frametime.Timing
+	// Own hits by how the server decided them, nil unless turned on in the settings
+	Hits *hitreg.Stats
+}
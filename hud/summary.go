@@ -0,0 +1,136 @@
+package hud
+
+import (
+	"fmt"
+	"image/color"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+
+	"shooter/match"
+	"shooter/ui"
+	"shooter/weapon"
+)
+
+var (
+	summaryOverlayColor = color.RGBA{0, 0, 0, 210}
+	winnerColor         = color.RGBA{255, 210, 80, 255}
+)
+
+// Scoreboard columns, x relative to the table's left edge in unscaled pixels
+var summaryColumns = []struct {
+	title string
+	x     float64
+}{
+	{"PLAYER", 0},
+	{"K", 260},
+	{"A", 310},
+	{"D", 360},
+	{"K/D", 410},
+	{"ACC", 490},
+	{"HS", 570},
+	{"STREAK", 630},
+	{"DMG", 730},
+}
+
+const summaryTableWidth = 810.0
+
+// rankBadge is the player's rank and rating after a ranked match, empty otherwise.
+func rankBadge(p match.PlayerStats) string {
+	if p.Rating == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" [%s %d %+d]", match.Rank(p.Rating), p.Rating, p.RatingChange)
+}
+
+// DrawMatchSummary draws the podium, the scoreboard with the weapons of the local player and
+// the next map vote. Observers have no local player.
+func DrawMatchSummary(screen *ebiten.Image, s *match.Summary, local string, votes map[string]int, nextMap time.Duration) {
+	b := screen.Bounds()
+	sw, sh := float64(b.Dx()), float64(b.Dy())
+	scale := sh / 900
+
+	vector.DrawFilledRect(screen, 0, 0, float32(sw), float32(sh), summaryOverlayColor, false)
+
+	centered := func(text string, y, size float64, clr color.Color) {
+		w, _ := ui.TextSize(text)
+		ui.DrawText(screen, text, (sw-w*size*scale)/2, y*scale, size*scale, clr)
+	}
+
+	centered("MATCH OVER", 80, 4, textColor)
+	if s.Winner != "" {
+		centered(fmt.Sprintf("Winner: %s", s.Winner), 150, 3, winnerColor)
+		centered(fmt.Sprintf("MVP: %s", s.MVP), 200, 2, textColor)
+	}
+
+	left := (sw - summaryTableWidth*scale*1.5) / 2
+	y := 260.0
+	for _, c := range summaryColumns {
+		ui.DrawText(screen, c.title, left+c.x*1.5*scale, y*scale, 1.5*scale, dimTextColor)
+	}
+	y += 30
+	for i, p := range s.Players {
+		var clr color.Color = textColor
+		if i == 0 {
+			clr = winnerColor
+		}
+		cells := []string{
+			fmt.Sprintf("%d. %s%s", i+1, p.Name(), rankBadge(p)),
+			fmt.Sprint(p.Kills),
+			fmt.Sprint(p.Assists),
+			fmt.Sprint(p.Deaths),
+			fmt.Sprintf("%.2f", p.KD()),
+			fmt.Sprintf("%.0f%%", p.Accuracy()*100),
+			fmt.Sprint(p.Headshots),
+			fmt.Sprint(p.BestStreak),
+			fmt.Sprint(p.Damage),
+		}
+		for j, c := range summaryColumns {
+			ui.DrawText(screen, cells[j], left+c.x*1.5*scale, y*scale, 1.5*scale, clr)
+		}
+		y += 26
+	}
+	for _, p := range s.Players {
+		if p.ID == local && len(p.Weapons) > 0 {
+			y += 10
+			centered(weaponSummary(p.Weapons), y, 1.5, dimTextColor)
+			y += 26
+		}
+	}
+
+	y = math.Max(y+30, 600)
+	seconds := int(math.Ceil(math.Max(0, nextMap.Seconds())))
+	if s.Next != "" {
+		// The server plays a rotation, there is nothing to vote for
+		centered(fmt.Sprintf("Next map: %s (%ds)", s.Next, seconds), y, 2, textColor)
+		return
+	}
+	centered(fmt.Sprintf("Vote for the next map (%ds)", seconds), y, 2, textColor)
+	y += 40
+	for i, m := range s.Maps {
+		centered(fmt.Sprintf("%d. %s  [%d]", i+1, m, votes[m]), y, 2, dimTextColor)
+		y += 30
+	}
+}
+
+// weaponSummary lists accuracy and damage of each weapon used, the most damaging first.
+func weaponSummary(weapons map[weapon.ID]*match.WeaponStats) string {
+	ids := slices.Collect(maps.Keys(weapons))
+	slices.SortFunc(ids, func(a, b weapon.ID) int {
+		if d := weapons[b].Damage - weapons[a].Damage; d != 0 {
+			return d
+		}
+		return strings.Compare(string(a), string(b))
+	})
+	parts := make([]string, 0, len(ids))
+	for _, id := range ids {
+		w := weapons[id]
+		parts = append(parts, fmt.Sprintf("%s %.0f%% %d HS %d DMG", weapon.Get(id).Name, w.Accuracy()*100, w.Headshots, w.Damage))
+	}
+	return strings.Join(parts, "  |  ")
+}
@@ -0,0 +1,301 @@
+package hud
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+
+	"shooter/ui"
+)
+
+var (
+	textColor         = color.White
+	dimTextColor      = color.RGBA{180, 180, 180, 255}
+	panelColor        = color.RGBA{0, 0, 0, 140}
+	healthColor       = color.RGBA{60, 200, 60, 255}
+	lowHealthColor    = color.RGBA{220, 40, 40, 255}
+	minimapWallColor  = color.RGBA{200, 60, 60, 255}
+	minimapSelfColor  = color.RGBA{0, 255, 0, 255}
+	minimapColor      = color.RGBA{255, 255, 255, 255}
+	minimapBlipColor  = color.RGBA{255, 60, 40, 255}
+	minimapEnemyColor = color.RGBA{255, 150, 0, 255}
+	supplyColor       = color.RGBA{255, 220, 40, 255}
+)
+
+type HealthBar struct {
+	Width, Height float64
+}
+
+func (w *HealthBar) Size(*State) (float64, float64) {
+	return w.Width, w.Height
+}
+
+func (w *HealthBar) Draw(screen *ebiten.Image, s *State, x, y, scale float64) {
+	width, height := w.Width*scale, w.Height*scale
+	frac := 0.0
+	if s.MaxHealth > 0 {
+		frac = math.Max(0, math.Min(1, float64(s.Health)/float64(s.MaxHealth)))
+	}
+
+	clr := healthColor
+	if frac < 0.25 {
+		clr = lowHealthColor
+	}
+
+	vector.DrawFilledRect(screen, float32(x), float32(y), float32(width), float32(height), panelColor, false)
+	vector.DrawFilledRect(screen, float32(x), float32(y), float32(width*frac), float32(height), clr, false)
+	vector.StrokeRect(screen, float32(x), float32(y), float32(width), float32(height), 1, textColor, false)
+
+	label := fmt.Sprintf("%d", max(0, s.Health))
+	tw, th := ui.TextSize(label)
+	ui.DrawText(screen, label, x+(width-tw*scale)/2, y+(height-th*scale)/2, scale, textColor)
+}
+
+type AmmoCounter struct{}
+
+func (w *AmmoCounter) text(s *State) string {
+	if s.Reloading {
+		return fmt.Sprintf("%s  reloading...  Grenades %d  Decoys %d", s.WeaponName, s.Grenades, s.Decoys)
+	}
+	return fmt.Sprintf("%s  %d / %d  Grenades %d  Decoys %d", s.WeaponName, s.Ammo, s.MagazineSize, s.Grenades, s.Decoys)
+}
+
+func (w *AmmoCounter) Size(s *State) (float64, float64) {
+	tw, th := ui.TextSize(w.text(s))
+	return tw * 1.5, th * 1.5
+}
+
+func (w *AmmoCounter) Draw(screen *ebiten.Image, s *State, x, y, scale float64) {
+	var clr color.Color = textColor
+	if s.Ammo == 0 || s.Reloading {
+		clr = lowHealthColor
+	}
+	ui.DrawText(screen, w.text(s), x, y, scale*1.5, clr)
+}
+
+// ShotStats shows the accuracy, headshots and damage of the player and of the held weapon, in practice.
+type ShotStats struct{}
+
+func (w *ShotStats) text(s *State) string {
+	p := s.Practice
+	text := fmt.Sprintf("Accuracy %.0f%% (%d/%d)  Headshots %d  Damage %d", p.Accuracy()*100, p.Hits, p.Shots, p.Headshots, p.Damage)
+	if ws, ok := p.Weapons[s.WeaponID]; ok {
+		text += fmt.Sprintf("  |  %s %.0f%%", s.WeaponName, ws.Accuracy()*100)
+	}
+	return text
+}
+
+func (w *ShotStats) Size(s *State) (float64, float64) {
+	if s.Practice == nil {
+		return 0, 0
+	}
+	tw, th := ui.TextSize(w.text(s))
+	return tw * 1.5, th * 1.5
+}
+
+func (w *ShotStats) Draw(screen *ebiten.Image, s *State, x, y, scale float64) {
+	if s.Practice == nil {
+		return
+	}
+	ui.DrawText(screen, w.text(s), x, y, scale*1.5, dimTextColor)
+}
+
+// Minimap shows the level walls and player markers scaled to Width, keeping the level aspect ratio.
+type Minimap struct {
+	Width float64
+}
+
+func (w *Minimap) Size(s *State) (float64, float64) {
+	if s.WorldWidth == 0 {
+		return 0, 0
+	}
+	return w.Width, w.Width * s.WorldHeight / s.WorldWidth
+}
+
+func (w *Minimap) Draw(screen *ebiten.Image, s *State, x, y, scale float64) {
+	if s.WorldWidth == 0 {
+		return
+	}
+	mw, mh := w.Size(s)
+	mw, mh = mw*scale, mh*scale
+	k := mw / s.WorldWidth
+
+	vector.DrawFilledRect(screen, float32(x), float32(y), float32(mw), float32(mh), panelColor, false)
+	for _, o := range s.Objects {
+		for _, l := range o.Walls {
+			vector.StrokeLine(screen,
+				float32(x+l.X1*k), float32(y+l.Y1*k), float32(x+l.X2*k), float32(y+l.Y2*k),
+				1, minimapWallColor, false)
+		}
+	}
+	if s.Zone != nil {
+		drawMinimapZone(screen, s.Zone, x, y, mw, mh, k)
+	}
+	for _, p := range s.Players {
+		clr := minimapColor
+		switch {
+		case p.Local:
+			clr = minimapSelfColor
+		case p.Enemy:
+			clr = minimapEnemyColor
+		}
+		vector.DrawFilledCircle(screen, float32(x+p.X*k), float32(y+p.Y*k), float32(3*scale), clr, false)
+	}
+	for _, b := range s.Blips {
+		clr := color.NRGBA{minimapBlipColor.R, minimapBlipColor.G, minimapBlipColor.B, uint8(255 * b.Alpha)}
+		vector.DrawFilledCircle(screen, float32(x+b.X*k), float32(y+b.Y*k), float32(4*scale), clr, false)
+	}
+	for _, sp := range s.Supplies {
+		vector.DrawFilledRect(screen, float32(x+sp[0]*k-4*scale), float32(y+sp[1]*k-4*scale), float32(8*scale), float32(8*scale), supplyColor, false)
+	}
+	now := time.Now()
+	for _, p := range s.Pings {
+		vector.StrokeCircle(screen, float32(x+p.X*k), float32(y+p.Y*k), float32(5*scale), 1.5, pingColor(p.Kind, p.Alpha(now)), false)
+	}
+}
+
+const (
+	KillfeedDuration = 6 * time.Second
+	KillfeedMaxLines = 5
+)
+
+type killfeedEntry struct {
+	text    string
+	created time.Time
+}
+
+// Killfeed lists recent kills, newest at the bottom.
+type Killfeed struct {
+	mu      sync.Mutex
+	entries []killfeedEntry
+}
+
+// Add shows a kill as "killer + assists [weapon] victim", killer is empty for deaths to the map
+// like hazards.
+func (w *Killfeed) Add(killer, victim, weapon string, assists ...string) {
+	if killer == "" {
+		w.AddMessage(fmt.Sprintf("[%s] %s", weapon, victim))
+		return
+	}
+	w.AddMessage(fmt.Sprintf("%s [%s] %s", strings.Join(append([]string{killer}, assists...), " + "), weapon, victim))
+}
+
+func (w *Killfeed) AddMessage(text string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.entries = append(w.entries, killfeedEntry{text: text, created: time.Now()})
+	if len(w.entries) > KillfeedMaxLines {
+		w.entries = w.entries[len(w.entries)-KillfeedMaxLines:]
+	}
+}
+
+func (w *Killfeed) active() []killfeedEntry {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for len(w.entries) > 0 && time.Since(w.entries[0].created) > KillfeedDuration {
+		w.entries = w.entries[1:]
+	}
+	return append([]killfeedEntry(nil), w.entries...)
+}
+
+func (w *Killfeed) Size(*State) (float64, float64) {
+	width := 0.0
+	entries := w.active()
+	for _, e := range entries {
+		tw, _ := ui.TextSize(e.text)
+		width = math.Max(width, tw)
+	}
+	return width, float64(len(entries)) * ui.LineHeight
+}
+
+func (w *Killfeed) Draw(screen *ebiten.Image, _ *State, x, y, scale float64) {
+	for i, e := range w.active() {
+		ui.DrawText(screen, e.text, x, y+float64(i)*ui.LineHeight*scale, scale, textColor)
+	}
+}
+
+type ObjectiveStatus struct{}
+
+func (w *ObjectiveStatus) Size(s *State) (float64, float64) {
+	if s.Objective == "" {
+		return 0, 0
+	}
+	tw, th := ui.TextSize(s.Objective)
+	return tw * 1.5, th * 1.5
+}
+
+func (w *ObjectiveStatus) Draw(screen *ebiten.Image, s *State, x, y, scale float64) {
+	if s.Objective == "" {
+		return
+	}
+	ui.DrawText(screen, s.Objective, x, y, scale*1.5, textColor)
+}
+
+// Scores lists kills and deaths of everyone, best first.
+type Scores struct{}
+
+// Column positions in reference pixels
+const (
+	scoresKillsX  = 180.0
+	scoresDeathsX = 230.0
+	scoresWidth   = 270.0
+)
+
+func (w *Scores) Size(s *State) (float64, float64) {
+	if len(s.Scores) == 0 {
+		return 0, 0
+	}
+	return scoresWidth, float64(len(s.Scores)+1) * ui.LineHeight
+}
+
+func (w *Scores) Draw(screen *ebiten.Image, s *State, x, y, scale float64) {
+	if len(s.Scores) == 0 {
+		return
+	}
+	width, height := w.Size(s)
+	vector.DrawFilledRect(screen, float32(x), float32(y), float32(width*scale), float32(height*scale), panelColor, false)
+	row := func(i int, name, kills, deaths string, clr color.Color) {
+		ry := y + float64(i)*ui.LineHeight*scale
+		ui.DrawText(screen, name, x, ry, scale, clr)
+		ui.DrawText(screen, kills, x+scoresKillsX*scale, ry, scale, clr)
+		ui.DrawText(screen, deaths, x+scoresDeathsX*scale, ry, scale, clr)
+	}
+	row(0, "PLAYER", "K", "D", dimTextColor)
+	for i, p := range s.Scores {
+		row(i+1, p.Name(), fmt.Sprint(p.Kills), fmt.Sprint(p.Deaths), textColor)
+	}
+}
+
+// DebugInfo shows controls and frame rates, and the frame time breakdown and hit stats when there are any.
+type DebugInfo struct{}
+
+func (w *DebugInfo) text(s *State) string {
+	text := fmt.Sprintf("%s\nTPS: %0.2f\nFPS: %0.2f", s.Controls, s.TPS, s.FPS)
+	if s.ServerRate > 0 {
+		text += fmt.Sprintf("\nServer: %d Hz, snapshots every %dms", s.ServerRate, s.SnapshotInterval.Milliseconds())
+	}
+	for _, t := range s.FrameTimes {
+		text += fmt.Sprintf("\n%s: %0.2fms", t.Section, float64(t.Time.Microseconds())/1000)
+	}
+	if h := s.Hits; h != nil {
+		text += fmt.Sprintf("\nHits: %d predicted, %d confirmed, %d rejected, %d unpredicted",
+			h.Predicted, h.Confirmed, h.Rejected, h.Unpredicted)
+	}
+	return text
+}
+
+func (w *DebugInfo) Size(s *State) (float64, float64) {
+	return ui.TextSize(w.text(s))
+}
+
+func (w *DebugInfo) Draw(screen *ebiten.Image, s *State, x, y, scale float64) {
+	ui.DrawText(screen, w.text(s), x, y, scale, dimTextColor)
+}
@@ -0,0 +1,34 @@
+package hud
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+
+	"shooter/zone"
+)
+
+var (
+	zoneColor     = color.RGBA{60, 140, 255, 255}
+	nextZoneColor = color.RGBA{255, 255, 255, 160}
+)
+
+// DrawZone draws the edge of the zone in world space and where it shrinks to next.
+func DrawZone(screen *ebiten.Image, s zone.State) {
+	if s.Next != s.Current {
+		vector.StrokeCircle(screen, float32(s.Next.X), float32(s.Next.Y), float32(s.Next.Radius), 1.5, nextZoneColor, true)
+	}
+	vector.StrokeCircle(screen, float32(s.Current.X), float32(s.Current.Y), float32(s.Current.Radius), 4, zoneColor, true)
+}
+
+// drawMinimapZone draws the zone and the next one on the minimap at x, y sized mw by mh,
+// k is the minimap scale. The first zone is larger than the level, it is cut off at the edges.
+func drawMinimapZone(screen *ebiten.Image, s *zone.State, x, y, mw, mh, k float64) {
+	screen = screen.SubImage(image.Rect(int(x), int(y), int(x+mw), int(y+mh))).(*ebiten.Image)
+	if s.Next != s.Current {
+		vector.StrokeCircle(screen, float32(x+s.Next.X*k), float32(y+s.Next.Y*k), float32(s.Next.Radius*k), 1, nextZoneColor, true)
+	}
+	vector.StrokeCircle(screen, float32(x+s.Current.X*k), float32(y+s.Current.Y*k), float32(s.Current.Radius*k), 1.5, zoneColor, true)
+}
@@ -0,0 +1,50 @@
+package input
+
+import "math"
+
+// Aim assist for sticks and touch, never for the mouse. It only bends the aim a
+// little towards an enemy already under the reticle, so it can't aim for the player.
+const (
+	AssistRange = 700.0
+	// Enemies within this angle of the aim, or their radius if wider, count as under the reticle
+	AssistCone   = 0.12
+	AssistRadius = 20.0
+	// Most the aim is pulled towards the target per tick, in radians at full strength
+	MaxMagnetism = 0.03
+	// How much turning slows down over a target at full strength
+	MaxSlowdown = 0.5
+)
+
+// Target is an enemy the game knows to be alive and in line of sight.
+type Target struct {
+	X, Y float64
+}
+
+// Assist adjusts the aim turning from from to to, strength is 0 to 1.
+func Assist(px, py, from, to, strength float64, targets []Target) float64 {
+	if strength <= 0 {
+		return to
+	}
+	strength = math.Min(strength, 1)
+
+	best, bestOff := 0.0, math.Inf(1)
+	for _, t := range targets {
+		d := math.Hypot(t.X-px, t.Y-py)
+		if d > AssistRange || d == 0 {
+			continue
+		}
+		a := math.Atan2(t.Y-py, t.X-px)
+		off := math.Abs(math.Remainder(a-from, 2*math.Pi))
+		if off <= math.Max(AssistCone, math.Atan(AssistRadius/d)) && off < bestOff {
+			best, bestOff = a, off
+		}
+	}
+	if math.IsInf(bestOff, 1) {
+		return to
+	}
+
+	to = from + math.Remainder(to-from, 2*math.Pi)*(1-MaxSlowdown*strength)
+	pull := MaxMagnetism * strength
+	off := math.Remainder(best-to, 2*math.Pi)
+	return to + math.Max(-pull, math.Min(pull, off))
+}
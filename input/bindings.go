@@ -0,0 +1,189 @@
+package input
+
+import (
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// Binding is a key or mouse button, named like ebiten keys ("W", "ShiftLeft", "Digit1") or "Mouse" + button.
+type Binding string
+
+var mouseButtons = map[Binding]ebiten.MouseButton{
+	"MouseLeft":   ebiten.MouseButtonLeft,
+	"MouseRight":  ebiten.MouseButtonRight,
+	"MouseMiddle": ebiten.MouseButtonMiddle,
+	"Mouse4":      ebiten.MouseButton3,
+	"Mouse5":      ebiten.MouseButton4,
+}
+
+var DefaultKeys = map[Action]Binding{
+	MoveUp:     "W",
+	MoveDown:   "S",
+	MoveLeft:   "A",
+	MoveRight:  "D",
+	Shoot:      "MouseLeft",
+	Aim:        "MouseRight",
+	Reload:     "R",
+	Sprint:     "ShiftLeft",
+	Flashlight: "F",
+	Interact:   "E",
+	Weapon1:    "Digit1",
+	Weapon2:    "Digit2",
+	Weapon3:    "Digit3",
+	NextWeapon: "Q",
+	PrevWeapon: "Z",
+	Pause:      "Escape",
+	Ping:       "MouseMiddle",
+	Grenade:    "G",
+	Deploy:     "T",
+	Barricade:  "B",
+	Decoy:      "V",
+	Vote:       "Y",
+	Buy:        "U",
+	Ability:    "X",
+	Ultimate:   "C",
+	Grapple:    "Space",
+}
+
+func (b Binding) key() (ebiten.Key, bool) {
+	var k ebiten.Key
+	if err := k.UnmarshalText([]byte(b)); err != nil {
+		return 0, false
+	}
+	return k, true
+}
+
+func (b Binding) Pressed() bool {
+	if m, ok := mouseButtons[b]; ok {
+		return ebiten.IsMouseButtonPressed(m)
+	}
+	k, ok := b.key()
+	return ok && ebiten.IsKeyPressed(k)
+}
+
+func (b Binding) JustPressed() bool {
+	if m, ok := mouseButtons[b]; ok {
+		return inpututil.IsMouseButtonJustPressed(m)
+	}
+	k, ok := b.key()
+	return ok && inpututil.IsKeyJustPressed(k)
+}
+
+// String shortens key names for prompts, Digit1 is shown as 1.
+func (b Binding) String() string {
+	return strings.TrimPrefix(string(b), "Digit")
+}
+
+// Key returns the keyboard or mouse binding of the action.
+func (c *Controller) Key(a Action) Binding {
+	if b, ok := c.settings.Keys[string(a)]; ok {
+		return Binding(b)
+	}
+	return DefaultKeys[a]
+}
+
+// BindKey binds the action, an action already using the binding gets the old binding of a instead.
+func (c *Controller) BindKey(a Action, b Binding) {
+	old := c.Key(a)
+	if c.settings.Keys == nil {
+		c.settings.Keys = map[string]string{}
+	}
+	for _, other := range Actions {
+		if other != a && c.Key(other) == b {
+			c.settings.Keys[string(other)] = string(old)
+		}
+	}
+	c.settings.Keys[string(a)] = string(b)
+}
+
+// Button returns the gamepad button of the action, false for actions only on the keyboard.
+func (c *Controller) Button(a Action) (ebiten.StandardGamepadButton, bool) {
+	if b, ok := c.settings.GamepadButtons[string(a)]; ok {
+		return ebiten.StandardGamepadButton(b), true
+	}
+	b, ok := DefaultGamepadButtons[a]
+	return b, ok
+}
+
+// BindButton binds the gamepad button, swapping with an action already using it.
+func (c *Controller) BindButton(a Action, b ebiten.StandardGamepadButton) {
+	old, hadOld := c.Button(a)
+	if c.settings.GamepadButtons == nil {
+		c.settings.GamepadButtons = map[string]int{}
+	}
+	for _, other := range Actions {
+		if ob, ok := c.Button(other); ok && other != a && ob == b {
+			if hadOld {
+				c.settings.GamepadButtons[string(other)] = int(old)
+			} else {
+				delete(c.settings.GamepadButtons, string(other))
+			}
+		}
+	}
+	c.settings.GamepadButtons[string(a)] = int(b)
+}
+
+var buttonNames = map[ebiten.StandardGamepadButton]string{
+	ebiten.StandardGamepadButtonRightBottom:      "A",
+	ebiten.StandardGamepadButtonRightRight:       "B",
+	ebiten.StandardGamepadButtonRightLeft:        "X",
+	ebiten.StandardGamepadButtonRightTop:         "Y",
+	ebiten.StandardGamepadButtonFrontTopLeft:     "LB",
+	ebiten.StandardGamepadButtonFrontTopRight:    "RB",
+	ebiten.StandardGamepadButtonFrontBottomLeft:  "LT",
+	ebiten.StandardGamepadButtonFrontBottomRight: "RT",
+	ebiten.StandardGamepadButtonCenterLeft:       "Back",
+	ebiten.StandardGamepadButtonCenterRight:      "Start",
+	ebiten.StandardGamepadButtonLeftStick:        "LS",
+	ebiten.StandardGamepadButtonRightStick:       "RS",
+	ebiten.StandardGamepadButtonLeftTop:          "Up",
+	ebiten.StandardGamepadButtonLeftBottom:       "Down",
+	ebiten.StandardGamepadButtonLeftLeft:         "Left",
+	ebiten.StandardGamepadButtonLeftRight:        "Right",
+	ebiten.StandardGamepadButtonCenterCenter:     "Guide",
+}
+
+// ButtonName returns the Xbox style name of the action's button, "-" when it has none.
+func (c *Controller) ButtonName(a Action) string {
+	b, ok := c.Button(a)
+	if !ok {
+		return "-"
+	}
+	return buttonNames[b]
+}
+
+var (
+	capturedKeys    []ebiten.Key
+	capturedButtons []ebiten.StandardGamepadButton
+)
+
+// CaptureKey returns the key or mouse button pressed this tick, used when rebinding.
+func CaptureKey() (Binding, bool) {
+	for b, m := range mouseButtons {
+		if inpututil.IsMouseButtonJustPressed(m) {
+			return b, true
+		}
+	}
+	capturedKeys = inpututil.AppendJustPressedKeys(capturedKeys[:0])
+	if len(capturedKeys) == 0 {
+		return "", false
+	}
+	text, err := capturedKeys[0].MarshalText()
+	if err != nil {
+		return "", false
+	}
+	return Binding(text), true
+}
+
+// CaptureButton returns the gamepad button pressed this tick on any gamepad.
+func CaptureButton() (ebiten.StandardGamepadButton, bool) {
+	for _, id := range ebiten.AppendGamepadIDs(nil) {
+		capturedButtons = inpututil.AppendJustPressedStandardGamepadButtons(id, capturedButtons[:0])
+		if len(capturedButtons) > 0 {
+			return capturedButtons[0], true
+		}
+	}
+	return 0, false
+}
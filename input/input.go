@@ -0,0 +1,380 @@
+package input
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+
+	"shooter/config"
+)
+
+type Action string
+
+const (
+	MoveUp     Action = "move_up"
+	MoveDown   Action = "move_down"
+	MoveLeft   Action = "move_left"
+	MoveRight  Action = "move_right"
+	Shoot      Action = "shoot"
+	Aim        Action = "aim"
+	Reload     Action = "reload"
+	Sprint     Action = "sprint"
+	Flashlight Action = "flashlight"
+	Interact   Action = "interact"
+	Weapon1    Action = "weapon_1"
+	Weapon2    Action = "weapon_2"
+	Weapon3    Action = "weapon_3"
+	NextWeapon Action = "next_weapon"
+	PrevWeapon Action = "prev_weapon"
+	Pause      Action = "pause"
+	Ping       Action = "ping"
+	Grenade    Action = "grenade"
+	Deploy     Action = "deploy"
+	Barricade  Action = "barricade"
+	Decoy      Action = "decoy"
+	Vote       Action = "vote"
+	Buy        Action = "buy"
+	Ability    Action = "ability"
+	Ultimate   Action = "ultimate"
+	Grapple    Action = "grapple"
+)
+
+// Actions in the order they are listed in the settings
+var Actions = []Action{
+	MoveUp, MoveDown, MoveLeft, MoveRight, Shoot, Aim, Reload, Sprint, Flashlight, Interact,
+	Weapon1, Weapon2, Weapon3, NextWeapon, PrevWeapon, Grenade, Decoy, Deploy, Barricade, Ping, Vote, Buy, Ability, Ultimate, Grapple, Pause,
+}
+
+var weaponSlots = []Action{Weapon1, Weapon2, Weapon3}
+
+type Device int
+
+const (
+	KeyboardMouse Device = iota
+	Gamepad
+	Touch
+)
+
+const (
+	// Analog buttons, like triggers, count as pressed above this
+	TriggerThreshold = 0.5
+	// How far from the player the crosshair sits when aiming with a stick
+	StickCrosshairDistance = 200.0
+	// Farthest the crosshair can get from the player with a high mouse sensitivity
+	MaxCrosshairDistance = 900.0
+)
+
+// Default gamepad buttons, config.Input.GamepadButtons overrides them per action.
+// Movement and aiming are on the sticks.
+var DefaultGamepadButtons = map[Action]ebiten.StandardGamepadButton{
+	Interact:   ebiten.StandardGamepadButtonRightBottom,
+	Shoot:      ebiten.StandardGamepadButtonFrontBottomRight,
+	Aim:        ebiten.StandardGamepadButtonFrontBottomLeft,
+	Reload:     ebiten.StandardGamepadButtonRightLeft,
+	Sprint:     ebiten.StandardGamepadButtonLeftStick,
+	Flashlight: ebiten.StandardGamepadButtonRightTop,
+	NextWeapon: ebiten.StandardGamepadButtonFrontTopRight,
+	PrevWeapon: ebiten.StandardGamepadButtonFrontTopLeft,
+	Pause:      ebiten.StandardGamepadButtonCenterRight,
+	Ping:       ebiten.StandardGamepadButtonLeftTop,
+	Grenade:    ebiten.StandardGamepadButtonLeftBottom,
+	Deploy:     ebiten.StandardGamepadButtonLeftRight,
+	Barricade:  ebiten.StandardGamepadButtonLeftLeft,
+	Decoy:      ebiten.StandardGamepadButtonRightRight,
+	Vote:       ebiten.StandardGamepadButtonCenterLeft,
+	Ability:    ebiten.StandardGamepadButtonRightStick,
+}
+
+// State is the input of a single tick, the same whichever device produced it.
+type State struct {
+	// Movement in [-1, 1] on each axis
+	MoveX, MoveY float64
+	// Aim direction, kept from the last tick when the aim stick is released
+	AimAngle float64
+	// Crosshair position in world coordinates
+	CrosshairX, CrosshairY float64
+
+	Shoot, Aim, Sprint, Reload bool
+	// True only on the tick the button went down
+	ToggleFlashlight, Interact, Pause bool
+	// True on the tick the button went down, asking the server to place a turret or a barricade
+	Deploy, Barricade bool
+	// Held to pick a ping from the wheel, placed on release
+	Ping bool
+	// Held to aim a grenade, thrown on release
+	Grenade bool
+	// True on the tick the button went down, thrown right away
+	Decoy bool
+	// True on the tick the button went down, opening the vote menu
+	Vote bool
+	// True on the tick the button went down, opening the buy menu in economy rounds
+	Buy bool
+	// True on the tick the button went down, using the ability or ultimate of the player's class
+	Ability, Ultimate bool
+	// True on the tick the button went down, firing the grappling hook
+	Grapple bool
+	// Loadout slot to switch to, -1 keeps the current weapon
+	WeaponSlot int
+	// -1 or 1 to cycle through the loadout
+	WeaponCycle int
+}
+
+// Controller turns keyboard, mouse and gamepad input into State and tracks which device is in use.
+type Controller struct {
+	settings *config.Input
+	Device   Device
+	// Set by the game before Update, used for aim assist
+	Targets []Target
+
+	gamepads         []ebiten.GamepadID
+	aimAngle         float64
+	aiming           bool
+	cursorX, cursorY int
+	keys             []ebiten.Key
+	touch            touchState
+	// Cursor and crosshair relative to the player, for the sensitivity
+	cursorRelX, cursorRelY       float64
+	crosshairRelX, crosshairRelY float64
+}
+
+func NewController(settings *config.Input) *Controller {
+	return &Controller{settings: settings}
+}
+
+// Update reads all devices. px, py is the player and cursorX, cursorY the mouse cursor, both in world coordinates.
+func (c *Controller) Update(px, py, cursorX, cursorY float64) State {
+	c.detectDevice()
+
+	state := State{WeaponSlot: -1}
+	var target float64
+	switch c.Device {
+	case Gamepad:
+		target = Assist(px, py, c.aimAngle, c.readGamepad(&state), c.settings.AimAssist, c.Targets)
+	case Touch:
+		target = Assist(px, py, c.aimAngle, c.readTouch(&state), c.settings.AimAssist, c.Targets)
+	default:
+		c.readKeyboardMouse(&state)
+		rx, ry := c.moveCrosshair(cursorX-px, cursorY-py)
+		target = math.Atan2(ry, rx)
+	}
+	c.aimAngle = smoothAngle(c.aimAngle, target, c.settings.AimSmoothing)
+	state.AimAngle = c.aimAngle
+
+	if c.Device != KeyboardMouse {
+		state.CrosshairX = px + math.Cos(c.aimAngle)*StickCrosshairDistance
+		state.CrosshairY = py + math.Sin(c.aimAngle)*StickCrosshairDistance
+	} else {
+		// Keep the crosshair on the smoothed aim line
+		d := math.Hypot(c.crosshairRelX, c.crosshairRelY)
+		state.CrosshairX = px + math.Cos(c.aimAngle)*d
+		state.CrosshairY = py + math.Sin(c.aimAngle)*d
+	}
+	return state
+}
+
+// aim returns whether the aim button counts as held, flipping on each press with toggle aim.
+func (c *Controller) aim(held, pressed bool) bool {
+	if !c.settings.ToggleAim {
+		return held
+	}
+	if pressed {
+		c.aiming = !c.aiming
+	}
+	return c.aiming
+}
+
+// moveCrosshair moves the crosshair by the cursor movement times the sensitivity.
+// Both are relative to the player, so the camera following the player doesn't move it.
+func (c *Controller) moveCrosshair(relX, relY float64) (float64, float64) {
+	sens := c.settings.MouseSensitivity
+	if sens <= 0 || sens == 1 {
+		c.crosshairRelX, c.crosshairRelY = relX, relY
+	} else {
+		c.crosshairRelX += (relX - c.cursorRelX) * sens
+		c.crosshairRelY += (relY - c.cursorRelY) * sens
+		if d := math.Hypot(c.crosshairRelX, c.crosshairRelY); d > MaxCrosshairDistance {
+			c.crosshairRelX *= MaxCrosshairDistance / d
+			c.crosshairRelY *= MaxCrosshairDistance / d
+		}
+	}
+	c.cursorRelX, c.cursorRelY = relX, relY
+	return c.crosshairRelX, c.crosshairRelY
+}
+
+// smoothAngle turns from towards to, taking the short way round.
+func smoothAngle(from, to, smoothing float64) float64 {
+	if smoothing <= 0 {
+		return to
+	}
+	d := math.Remainder(to-from, 2*math.Pi)
+	return from + d*(1-math.Min(smoothing, 0.95))
+}
+
+func (c *Controller) readKeyboardMouse(s *State) {
+	if c.Key(MoveUp).Pressed() {
+		s.MoveY--
+	}
+	if c.Key(MoveDown).Pressed() {
+		s.MoveY++
+	}
+	if c.Key(MoveLeft).Pressed() {
+		s.MoveX--
+	}
+	if c.Key(MoveRight).Pressed() {
+		s.MoveX++
+	}
+	s.Sprint = c.Key(Sprint).Pressed()
+	s.Shoot = c.Key(Shoot).Pressed()
+	s.Aim = c.aim(c.Key(Aim).Pressed(), c.Key(Aim).JustPressed())
+	s.Reload = c.Key(Reload).Pressed()
+	s.ToggleFlashlight = c.Key(Flashlight).JustPressed()
+	s.Interact = c.Key(Interact).JustPressed()
+	s.Pause = c.Key(Pause).JustPressed()
+	s.Ping = c.Key(Ping).Pressed()
+	s.Grenade = c.Key(Grenade).Pressed()
+	s.Decoy = c.Key(Decoy).JustPressed()
+	s.Deploy = c.Key(Deploy).JustPressed()
+	s.Barricade = c.Key(Barricade).JustPressed()
+	s.Vote = c.Key(Vote).JustPressed()
+	s.Buy = c.Key(Buy).JustPressed()
+	s.Ability = c.Key(Ability).JustPressed()
+	s.Ultimate = c.Key(Ultimate).JustPressed()
+	s.Grapple = c.Key(Grapple).JustPressed()
+	for i, a := range weaponSlots {
+		if c.Key(a).Pressed() {
+			s.WeaponSlot = i
+		}
+	}
+	if c.Key(NextWeapon).JustPressed() {
+		s.WeaponCycle = 1
+	}
+	if c.Key(PrevWeapon).JustPressed() {
+		s.WeaponCycle = -1
+	}
+}
+
+// readGamepad returns the aim angle of the right stick, the last one when it's centered.
+func (c *Controller) readGamepad(s *State) float64 {
+	target := c.aimAngle
+	id, ok := c.gamepad()
+	if !ok {
+		return target
+	}
+
+	lx := ebiten.StandardGamepadAxisValue(id, ebiten.StandardGamepadAxisLeftStickHorizontal)
+	ly := ebiten.StandardGamepadAxisValue(id, ebiten.StandardGamepadAxisLeftStickVertical)
+	s.MoveX, s.MoveY = applyDeadzone(lx, ly, c.settings.MoveDeadzone)
+
+	rx := ebiten.StandardGamepadAxisValue(id, ebiten.StandardGamepadAxisRightStickHorizontal)
+	ry := ebiten.StandardGamepadAxisValue(id, ebiten.StandardGamepadAxisRightStickVertical)
+	if math.Hypot(rx, ry) > c.settings.AimDeadzone {
+		target = math.Atan2(ry, rx)
+	}
+
+	s.Shoot = c.pressed(id, Shoot)
+	s.Aim = c.aim(c.pressed(id, Aim), c.justPressed(id, Aim))
+	s.Sprint = c.pressed(id, Sprint)
+	s.Reload = c.pressed(id, Reload)
+	s.ToggleFlashlight = c.justPressed(id, Flashlight)
+	s.Interact = c.justPressed(id, Interact)
+	s.Pause = c.justPressed(id, Pause)
+	s.Ping = c.pressed(id, Ping)
+	s.Grenade = c.pressed(id, Grenade)
+	s.Decoy = c.justPressed(id, Decoy)
+	s.Deploy = c.justPressed(id, Deploy)
+	s.Barricade = c.justPressed(id, Barricade)
+	s.Vote = c.justPressed(id, Vote)
+	s.Buy = c.justPressed(id, Buy)
+	s.Ability = c.justPressed(id, Ability)
+	s.Ultimate = c.justPressed(id, Ultimate)
+	s.Grapple = c.justPressed(id, Grapple)
+	if c.justPressed(id, NextWeapon) {
+		s.WeaponCycle = 1
+	}
+	if c.justPressed(id, PrevWeapon) {
+		s.WeaponCycle = -1
+	}
+	return target
+}
+
+// applyDeadzone zeroes small stick values and rescales the rest to start at 0 just outside the deadzone.
+func applyDeadzone(x, y, deadzone float64) (float64, float64) {
+	l := math.Hypot(x, y)
+	if l <= deadzone || deadzone >= 1 {
+		return 0, 0
+	}
+	scale := math.Min(1, (l-deadzone)/(1-deadzone)) / l
+	return x * scale, y * scale
+}
+
+func (c *Controller) pressed(id ebiten.GamepadID, a Action) bool {
+	b, ok := c.Button(a)
+	return ok && ebiten.StandardGamepadButtonValue(id, b) > TriggerThreshold
+}
+
+func (c *Controller) justPressed(id ebiten.GamepadID, a Action) bool {
+	b, ok := c.Button(a)
+	return ok && inpututil.IsStandardGamepadButtonJustPressed(id, b)
+}
+
+// gamepad returns the first connected gamepad with the standard layout.
+func (c *Controller) gamepad() (ebiten.GamepadID, bool) {
+	c.gamepads = ebiten.AppendGamepadIDs(c.gamepads[:0])
+	for _, id := range c.gamepads {
+		if ebiten.IsStandardGamepadLayoutAvailable(id) {
+			return id, true
+		}
+	}
+	return 0, false
+}
+
+// detectDevice switches to whichever device was used last.
+func (c *Controller) detectDevice() {
+	cx, cy := ebiten.CursorPosition()
+	mouseMoved := cx != c.cursorX || cy != c.cursorY
+	c.cursorX, c.cursorY = cx, cy
+
+	c.keys = inpututil.AppendJustPressedKeys(c.keys[:0])
+	if len(c.keys) > 0 || mouseMoved || ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft) {
+		c.Device = KeyboardMouse
+		return
+	}
+
+	if len(inpututil.AppendJustPressedTouchIDs(nil)) > 0 {
+		c.Device = Touch
+		return
+	}
+
+	id, ok := c.gamepad()
+	if !ok {
+		if c.Device == Gamepad {
+			c.Device = KeyboardMouse
+		}
+		return
+	}
+	lx := ebiten.StandardGamepadAxisValue(id, ebiten.StandardGamepadAxisLeftStickHorizontal)
+	ly := ebiten.StandardGamepadAxisValue(id, ebiten.StandardGamepadAxisLeftStickVertical)
+	rx := ebiten.StandardGamepadAxisValue(id, ebiten.StandardGamepadAxisRightStickHorizontal)
+	ry := ebiten.StandardGamepadAxisValue(id, ebiten.StandardGamepadAxisRightStickVertical)
+	if math.Hypot(lx, ly) > c.settings.MoveDeadzone || math.Hypot(rx, ry) > c.settings.AimDeadzone ||
+		len(inpututil.AppendJustPressedStandardGamepadButtons(id, nil)) > 0 {
+		c.Device = Gamepad
+	}
+}
+
+// Prompts lists the controls of the device, for the HUD.
+func (c *Controller) Prompts() string {
+	if c.Device == Touch {
+		return "Left side: move  Right side: aim, push further to shoot"
+	}
+	if c.Device == Gamepad {
+		return fmt.Sprintf("LS: move  RS: aim  %s: shoot  %s: laser  %s: reload  %s/%s: weapon  %s: flashlight  %s: grenade  %s: decoy  %s/%s: turret/barricade",
+			c.ButtonName(Shoot), c.ButtonName(Aim), c.ButtonName(Reload), c.ButtonName(PrevWeapon), c.ButtonName(NextWeapon), c.ButtonName(Flashlight),
+			c.ButtonName(Grenade), c.ButtonName(Decoy), c.ButtonName(Deploy), c.ButtonName(Barricade))
+	}
+	return fmt.Sprintf("%s%s%s%s: move  %s: reload  %s-%s: weapon  %s: flashlight  %s: grenade  %s: decoy  %s/%s: turret/barricade",
+		c.Key(MoveUp), c.Key(MoveLeft), c.Key(MoveDown), c.Key(MoveRight), c.Key(Reload), c.Key(Weapon1), c.Key(Weapon3), c.Key(Flashlight),
+		c.Key(Grenade), c.Key(Decoy), c.Key(Deploy), c.Key(Barricade))
+}
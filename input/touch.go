@@ -0,0 +1,183 @@
+package input
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+
+	"shooter/ui"
+)
+
+const (
+	// Stick and button sizes at 900 pixels screen height
+	TouchStickRadius  = 110.0
+	TouchButtonRadius = 55.0
+	// The aim stick shoots when pushed further than this, 0 to 1
+	TouchShootThreshold = 0.6
+	touchDeadzone       = 0.15
+)
+
+var (
+	touchBaseColor   = color.RGBA{255, 255, 255, 40}
+	touchKnobColor   = color.RGBA{255, 255, 255, 110}
+	touchButtonColor = color.RGBA{255, 255, 255, 70}
+)
+
+// touchButton sits at X, Y as a fraction of the screen size.
+type touchButton struct {
+	action Action
+	label  string
+	x, y   float64
+}
+
+var touchButtons = []touchButton{
+	{action: Reload, label: "R", x: 0.93, y: 0.45},
+	{action: NextWeapon, label: "W", x: 0.86, y: 0.3},
+	{action: Flashlight, label: "F", x: 0.93, y: 0.15},
+	{action: Pause, label: "||", x: 0.5, y: 0.06},
+}
+
+// touchStick is a virtual stick centered wherever the finger first touched.
+type touchStick struct {
+	id               ebiten.TouchID
+	active           bool
+	originX, originY float64
+	x, y             float64
+}
+
+// value returns the stick deflection, with length up to 1.
+func (s *touchStick) value(radius float64) (float64, float64) {
+	if !s.active {
+		return 0, 0
+	}
+	dx, dy := (s.x-s.originX)/radius, (s.y-s.originY)/radius
+	if l := math.Hypot(dx, dy); l > 1 {
+		dx, dy = dx/l, dy/l
+	}
+	return dx, dy
+}
+
+// touchState tracks the fingers on the screen: the left half moves, the right half aims and shoots.
+type touchState struct {
+	move, aim touchStick
+	// Fingers holding a button
+	buttons  map[ebiten.TouchID]Action
+	ids, new []ebiten.TouchID
+	// Screen size in pixels, from Layout
+	width, height float64
+}
+
+// Layout sets the screen size the touch positions are in.
+func (c *Controller) Layout(w, h int) {
+	c.touch.width, c.touch.height = float64(w), float64(h)
+}
+
+func (t *touchState) scale() float64 {
+	return t.height / 900
+}
+
+func (t *touchState) buttonAt(x, y float64) (Action, bool) {
+	r := TouchButtonRadius * t.scale()
+	for _, b := range touchButtons {
+		if math.Hypot(x-b.x*t.width, y-b.y*t.height) <= r {
+			return b.action, true
+		}
+	}
+	return "", false
+}
+
+// readTouch returns the aim angle of the aim stick, the last one when it's not touched.
+func (c *Controller) readTouch(s *State) float64 {
+	t := &c.touch
+	if t.buttons == nil {
+		t.buttons = map[ebiten.TouchID]Action{}
+	}
+	t.ids = ebiten.AppendTouchIDs(t.ids[:0])
+	t.new = inpututil.AppendJustPressedTouchIDs(t.new[:0])
+
+	for id := range t.buttons {
+		if inpututil.IsTouchJustReleased(id) {
+			delete(t.buttons, id)
+		}
+	}
+	for _, stick := range []*touchStick{&t.move, &t.aim} {
+		if stick.active && inpututil.IsTouchJustReleased(stick.id) {
+			stick.active = false
+		}
+	}
+
+	for _, id := range t.new {
+		ix, iy := ebiten.TouchPosition(id)
+		x, y := float64(ix), float64(iy)
+		if a, ok := t.buttonAt(x, y); ok {
+			t.buttons[id] = a
+			switch a {
+			case Flashlight:
+				s.ToggleFlashlight = true
+			case NextWeapon:
+				s.WeaponCycle = 1
+			case Pause:
+				s.Pause = true
+			}
+			continue
+		}
+		stick := &t.aim
+		if x < t.width/2 {
+			stick = &t.move
+		}
+		if !stick.active {
+			*stick = touchStick{id: id, active: true, originX: x, originY: y, x: x, y: y}
+		}
+	}
+	for _, stick := range []*touchStick{&t.move, &t.aim} {
+		if stick.active {
+			ix, iy := ebiten.TouchPosition(stick.id)
+			stick.x, stick.y = float64(ix), float64(iy)
+		}
+	}
+	for _, a := range t.buttons {
+		if a == Reload {
+			s.Reload = true
+		}
+	}
+
+	radius := TouchStickRadius * t.scale()
+	mx, my := t.move.value(radius)
+	s.MoveX, s.MoveY = applyDeadzone(mx, my, touchDeadzone)
+
+	target := c.aimAngle
+	ax, ay := t.aim.value(radius)
+	if l := math.Hypot(ax, ay); l > touchDeadzone {
+		target = math.Atan2(ay, ax)
+		s.Shoot = l > TouchShootThreshold
+	}
+	return target
+}
+
+// DrawTouch draws the sticks and buttons while touch is the active device.
+func (c *Controller) DrawTouch(screen *ebiten.Image) {
+	if c.Device != Touch {
+		return
+	}
+	t := &c.touch
+	scale := t.scale()
+	for _, stick := range []*touchStick{&t.move, &t.aim} {
+		if !stick.active {
+			continue
+		}
+		dx, dy := stick.value(TouchStickRadius * scale)
+		vector.DrawFilledCircle(screen, float32(stick.originX), float32(stick.originY), float32(TouchStickRadius*scale), touchBaseColor, true)
+		vector.DrawFilledCircle(screen, float32(stick.originX+dx*TouchStickRadius*scale), float32(stick.originY+dy*TouchStickRadius*scale),
+			float32(TouchStickRadius*scale*0.4), touchKnobColor, true)
+	}
+	for _, b := range touchButtons {
+		x, y := float32(b.x*t.width), float32(b.y*t.height)
+		vector.DrawFilledCircle(screen, x, y, float32(TouchButtonRadius*scale), touchButtonColor, true)
+		vector.StrokeCircle(screen, x, y, float32(TouchButtonRadius*scale), 2, touchKnobColor, true)
+		w, h := ui.TextSize(b.label)
+		ui.DrawText(screen, b.label, float64(x)-w*scale, float64(y)-h*scale, 2*scale, color.White)
+	}
+}
@@ -0,0 +1,134 @@
+// Package inputlog records everything a client's simulation is fed, the local input of each tick and
+// the messages of the server between ticks, so a bug seen in a game can be replayed exactly.
+package inputlog
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Record is a line of a log. The first has Join, the rest either Input or Message.
+type Record struct {
+	// Ticks done before it, messages are applied before the tick's input
+	Tick int64 `json:"tick"`
+	// What the client joined with, for setting up the game again
+	Join    json.RawMessage `json:"join,omitempty"`
+	Input   json.RawMessage `json:"input,omitempty"`
+	Message string          `json:"message,omitempty"`
+}
+
+// Writer writes a log as JSON lines, unbuffered so nothing is lost when the game goes down with the bug.
+type Writer struct {
+	f    *os.File
+	enc  *json.Encoder
+	tick int64
+}
+
+// Create starts a log at path beginning with join.
+func Create(path string, join any) (*Writer, error) {
+	data, err := json.Marshal(join)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	w := &Writer{f: f, enc: json.NewEncoder(f)}
+	if err := w.enc.Encode(Record{Join: data}); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return w, nil
+}
+
+// Message records a message of the server applied before the current tick's input.
+func (w *Writer) Message(msg string) error {
+	return w.enc.Encode(Record{Tick: w.tick, Message: msg})
+}
+
+// Input records the input of the current tick and ends it.
+func (w *Writer) Input(state any) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	err = w.enc.Encode(Record{Tick: w.tick, Input: data})
+	w.tick++
+	return err
+}
+
+func (w *Writer) Close() error {
+	return w.f.Close()
+}
+
+// Log is a recorded game read back.
+type Log struct {
+	Join    json.RawMessage
+	Records []Record
+}
+
+func Read(r io.Reader) (*Log, error) {
+	var l Log
+	scanner := bufio.NewScanner(r)
+	// Messages like map data are far longer than the default token size
+	scanner.Buffer(nil, 64<<20)
+	for scanner.Scan() {
+		var rec Record
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return nil, err
+		}
+		if rec.Join != nil {
+			l.Join = rec.Join
+			continue
+		}
+		l.Records = append(l.Records, rec)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if l.Join == nil {
+		return nil, errors.New("input log has no join record")
+	}
+	return &l, nil
+}
+
+func Load(path string) (*Log, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Read(f)
+}
+
+// Playback hands out a log tick by tick.
+type Playback struct {
+	records []Record
+	next    int
+}
+
+func NewPlayback(l *Log) *Playback {
+	return &Playback{records: l.Records}
+}
+
+// Next returns the messages to apply before the next tick and the tick's input, ok is false once
+// the log is over. Messages after the last input are never returned, there is no tick left for them.
+func (p *Playback) Next() (messages []string, input json.RawMessage, ok bool) {
+	for ; p.next < len(p.records); p.next++ {
+		rec := p.records[p.next]
+		if rec.Input != nil {
+			p.next++
+			return messages, rec.Input, true
+		}
+		messages = append(messages, rec.Message)
+	}
+	return nil, nil, false
+}
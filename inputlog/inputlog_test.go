@@ -0,0 +1,72 @@
+package inputlog
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+type state struct {
+	MoveX float64
+	Shoot bool
+}
+
+func TestRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "input.ndjson")
+	w, err := Create(path, map[string]string{"map": "warehouse"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Message("welcome\n")
+	w.Input(state{MoveX: 1})
+	w.Input(state{Shoot: true})
+	w.Message("hit\n")
+	w.Message("killed\n")
+	w.Input(state{})
+	w.Message("too late\n")
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(l.Join) != `{"map":"warehouse"}` {
+		t.Errorf("Join = %s", l.Join)
+	}
+
+	p := NewPlayback(l)
+	want := []struct {
+		messages []string
+		input    state
+	}{
+		{[]string{"welcome\n"}, state{MoveX: 1}},
+		{nil, state{Shoot: true}},
+		{[]string{"hit\n", "killed\n"}, state{}},
+	}
+	for i, w := range want {
+		messages, input, ok := p.Next()
+		if !ok {
+			t.Fatalf("tick %d: log ended early", i)
+		}
+		var s state
+		if err := json.Unmarshal(input, &s); err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(messages, w.messages) || s != w.input {
+			t.Errorf("tick %d: Next() = %q, %+v, want %q, %+v", i, messages, s, w.messages, w.input)
+		}
+	}
+	if _, _, ok := p.Next(); ok {
+		t.Error("Next() after the last input = ok")
+	}
+}
+
+func TestReadWithoutJoin(t *testing.T) {
+	if _, err := Read(strings.NewReader(`{"tick":0,"message":"welcome"}` + "\n")); err == nil {
+		t.Error("Read() of a log without a join record succeeded")
+	}
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"shooter/input"
+	"shooter/inputlog"
+	"shooter/level"
+	"shooter/player"
+	"shooter/wire"
+)
+
+// Set by --record-input and --replay-input
+var recordInput, replayInput string
+
+// InboxSize is how many server messages wait for the next tick while recording input.
+const InboxSize = 4096
+
+// parseInputFlags removes the input log flags from args and sets recordInput and replayInput from them.
+func parseInputFlags(args []string) []string {
+	rest := args[:0:0]
+	for i := 0; i < len(args); i++ {
+		var dst *string
+		switch args[i] {
+		case "--record-input":
+			dst = &recordInput
+		case "--replay-input":
+			dst = &replayInput
+		default:
+			rest = append(rest, args[i])
+			continue
+		}
+		if i+1 >= len(args) {
+			log.Fatalf("Missing file for %s", args[i])
+		}
+		*dst = args[i+1]
+		i++
+	}
+	return rest
+}
+
+// InputJoin is what an input log starts with, enough to set up the same game again.
+type InputJoin struct {
+	Hello   Hello   `json:"hello"`
+	Welcome Welcome `json:"welcome"`
+	// Where the tick clock starts and how fast it runs, see inputLog.now
+	Start time.Time `json:"start"`
+	TPS   int       `json:"tps"`
+}
+
+// inputLog records or replays a game's input. Either way messages of the server are applied at the
+// start of a tick instead of whenever they arrive, and the game runs on the log's tick clock instead
+// of the wall clock, so replaying them before the same ticks is exact.
+type inputLog struct {
+	start time.Time
+	tps   int
+	// Ticks done, the clock moves on by one tick each
+	ticks int64
+	// Messages read by the network goroutine, only recording
+	inbox    chan string
+	writer   *inputlog.Writer
+	playback *inputlog.Playback
+	// Rate of the game before the replay set the recording's, see close
+	restoreTPS int
+	// Input of the tick being replayed
+	next input.State
+}
+
+// recordInput starts writing the game's input log to the --record-input file.
+func (g *Game) recordInput(hello Hello, welcome Welcome) {
+	join := InputJoin{Hello: hello, Welcome: welcome, Start: time.Now(), TPS: ebiten.TPS()}
+	w, err := inputlog.Create(recordInput, join)
+	if err != nil {
+		log.Println("Error recording input:", err)
+		return
+	}
+	log.Println("Recording input to", recordInput)
+	g.inputLog = &inputLog{start: join.Start, tps: join.TPS, inbox: make(chan string, InboxSize), writer: w}
+}
+
+// replayInput plays back the input log at path offline instead of joining a server.
+func (a *App) replayInput(path string) {
+	l, err := inputlog.Load(path)
+	if err != nil {
+		log.Println("Error loading input log:", err)
+		a.showMainMenu("Failed to replay input: " + err.Error())
+		return
+	}
+	var join InputJoin
+	if err := json.Unmarshal(l.Join, &join); err != nil {
+		log.Println("Error unmarshaling input log join:", err)
+		a.showMainMenu("Failed to replay input: " + err.Error())
+		return
+	}
+	// Swapped for the welcome's map by joinedGame, downloaded ones come again with the logged messages
+	lvl, _ := level.Get(level.Default)
+
+	// Whatever the game sends goes nowhere, what it would get back is in the log
+	conn, server := net.Pipe()
+	go io.Copy(io.Discard, server)
+	g := joinedGame(a, join.Hello, join.Welcome, lvl, conn, wire.NewReader(conn))
+	g.inputLog = &inputLog{start: join.Start, tps: join.TPS, playback: inputlog.NewPlayback(l), restoreTPS: ebiten.TPS()}
+	// Movement is scaled by the rate, it has to be the recording's until the replay is over
+	if join.TPS > 0 {
+		ebiten.SetTPS(join.TPS)
+	}
+	log.Println("Replaying input from", path)
+	a.scene = g
+}
+
+// deliver applies the messages of the server due before this tick, mu must not be held.
+func (l *inputLog) deliver(g *Game) {
+	if l.playback == nil {
+		for {
+			select {
+			case msg := <-l.inbox:
+				if err := l.writer.Message(msg); err != nil {
+					log.Println("Error recording input:", err)
+				}
+				g.handleMessage(msg)
+			default:
+				return
+			}
+		}
+	}
+
+	messages, data, ok := l.playback.Next()
+	for _, msg := range messages {
+		g.handleMessage(msg)
+	}
+	if !ok {
+		g.mu.Lock()
+		if g.disconnected == "" {
+			g.disconnected = "Input replay finished"
+		}
+		g.mu.Unlock()
+		return
+	}
+	l.next = input.State{}
+	if err := json.Unmarshal(data, &l.next); err != nil {
+		log.Println("Error unmarshaling replayed input:", err)
+	}
+}
+
+// tick records the input of this tick, or replaces it with the replayed one, and moves the clock on.
+func (l *inputLog) tick(state input.State) input.State {
+	l.ticks++
+	if l.playback != nil {
+		return l.next
+	}
+	if err := l.writer.Input(state); err != nil {
+		log.Println("Error recording input:", err)
+	}
+	return state
+}
+
+// now is the time of the current tick on the log's clock. Games running as fast as the display
+// have no fixed tick, they count at player.BaseTPS.
+func (l *inputLog) now() time.Time {
+	tps := l.tps
+	if tps <= 0 {
+		tps = player.BaseTPS
+	}
+	return l.start.Add(time.Duration(l.ticks) * time.Second / time.Duration(tps))
+}
+
+// now is the time the game simulates at, the wall clock unless an input log brings its own.
+// Everything the simulation reads the time for goes through it, so it replays the same.
+func (g *Game) now() time.Time {
+	if g.inputLog != nil {
+		return g.inputLog.now()
+	}
+	return time.Now()
+}
+
+func (l *inputLog) close() {
+	if l == nil {
+		return
+	}
+	if l.playback != nil {
+		ebiten.SetTPS(l.restoreTPS)
+		return
+	}
+	if err := l.writer.Close(); err != nil {
+		log.Println("Error closing input log:", err)
+	}
+}
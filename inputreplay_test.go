@@ -0,0 +1,25 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"shooter/input"
+	"shooter/inputlog"
+)
+
+func TestInputLogClockCountsTicks(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	g := &Game{inputLog: &inputLog{start: start, tps: 50, playback: &inputlog.Playback{}}}
+	if got := g.now(); !got.Equal(start) {
+		t.Errorf("clock starts at %v, want %v", got, start)
+	}
+	for range 25 {
+		g.inputLog.tick(input.State{})
+	}
+	// The wall clock going on doesn't move it
+	time.Sleep(10 * time.Millisecond)
+	if got, want := g.now(), start.Add(500*time.Millisecond); !got.Equal(want) {
+		t.Errorf("after 25 ticks at 50 TPS the clock is at %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,141 @@
+// Package interp keeps track of the snapshots of the server, so remote players can be placed
+// between and past them whatever rate the server ticks and sends at.
+package interp
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	// Weight of the newest gap between snapshots in the measured interval
+	smoothing = 0.1
+	// Gaps longer than this are lost packets or a stall, not the server's rate
+	maxGap = time.Second
+)
+
+// Clock follows the ticks the server heads its snapshots with.
+type Clock struct {
+	// Of the last snapshot
+	Tick       uint64
+	ServerTime time.Time
+	// Server ticks per second, 0 until the first snapshot
+	Rate int
+
+	received time.Time
+	interval time.Duration
+}
+
+// Snapshot notes a snapshot of the server received at now.
+func (c *Clock) Snapshot(tick uint64, serverTime time.Time, rate int, now time.Time) {
+	if !c.received.IsZero() && tick > c.Tick {
+		gap := now.Sub(c.received)
+		switch {
+		case gap > maxGap:
+		case c.interval == 0:
+			c.interval = gap
+		default:
+			c.interval += time.Duration(smoothing * float64(gap-c.interval))
+		}
+	}
+	c.Tick, c.ServerTime, c.Rate, c.received = tick, serverTime, rate, now
+}
+
+// Interval is the measured time between snapshots, the server's tick before there is anything to measure.
+func (c *Clock) Interval() time.Duration {
+	if c.interval > 0 {
+		return c.interval
+	}
+	if c.Rate > 0 {
+		return time.Second / time.Duration(c.Rate)
+	}
+	return 0
+}
+
+// Since is how long ago the last snapshot came.
+func (c *Clock) Since(now time.Time) time.Duration {
+	if c.received.IsZero() {
+		return 0
+	}
+	return now.Sub(c.received)
+}
+
+const (
+	// Remote players keep going this long past their last update, then stop where they'd be
+	MaxExtrapolation = 200 * time.Millisecond
+	// How long a remote player takes to slide from where they were drawn to where an update puts them
+	BlendTime = 100 * time.Millisecond
+	// Corrections this far are respawns or teleports, not drift, and aren't blended
+	SnapDistance = 120.0
+	// Longest a remote player's aim takes to turn to an update's, however slow the server sends them
+	MaxTurn = 200 * time.Millisecond
+)
+
+// Track places a remote player between their updates by dead reckoning: moving on with the
+// last velocity for a while when updates are late, and blending out the error when they come.
+type Track struct {
+	x, y   float64
+	vx, vy float64
+	at     time.Time
+	// Where the player was drawn minus where the update put them, fading over BlendTime
+	errX, errY float64
+
+	// Aim turns from the angle drawn when the last one came to it, see Aim
+	fromAngle, toAngle float64
+	aimAt              time.Time
+	turn               time.Duration
+}
+
+// Update takes a position and a velocity in pixels per second received at now.
+func (t *Track) Update(x, y, vx, vy float64, now time.Time) {
+	if !t.at.IsZero() {
+		px, py := t.Position(now)
+		t.errX, t.errY = px-x, py-y
+		if dx, dy := t.errX, t.errY; dx*dx+dy*dy > SnapDistance*SnapDistance {
+			t.errX, t.errY = 0, 0
+		}
+	}
+	t.x, t.y, t.vx, t.vy, t.at = x, y, vx, vy, now
+}
+
+// Position is where to draw the player at now.
+func (t *Track) Position(now time.Time) (float64, float64) {
+	since := now.Sub(t.at)
+	dt := min(since, MaxExtrapolation).Seconds()
+	x, y := t.x+t.vx*dt, t.y+t.vy*dt
+	if since < BlendTime {
+		left := 1 - float64(since)/float64(BlendTime)
+		x, y = x+t.errX*left, y+t.errY*left
+	}
+	return x, y
+}
+
+// Aim takes a remote player's aim angle received at now. The drawn aim turns to it over turn,
+// the time until the next update is due, so it's just there when the next one comes.
+func (t *Track) Aim(angle float64, turn time.Duration, now time.Time) {
+	t.fromAngle = angle
+	if !t.aimAt.IsZero() {
+		t.fromAngle = t.Angle(now)
+	}
+	t.toAngle, t.aimAt, t.turn = angle, now, min(turn, MaxTurn)
+}
+
+// Angle is where to draw the player aiming at now.
+func (t *Track) Angle(now time.Time) float64 {
+	since := now.Sub(t.aimAt)
+	if t.turn <= 0 || since >= t.turn {
+		return t.toAngle
+	}
+	return LerpAngle(t.fromAngle, t.toAngle, float64(since)/float64(t.turn))
+}
+
+// LerpAngle goes the fraction f of the way from a to b the shorter way round, in (-Pi, Pi].
+// Lerping the numbers would spin a player aiming from just below Pi to just above -Pi all the way round.
+func LerpAngle(a, b, f float64) float64 {
+	d := math.Remainder(b-a, 2*math.Pi)
+	angle := math.Remainder(a+d*f, 2*math.Pi)
+	if angle == -math.Pi {
+		return math.Pi
+	}
+	return angle
+}
@@ -0,0 +1,119 @@
+package interp
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestClockInterval(t *testing.T) {
+	var c Clock
+	if c.Interval() != 0 {
+		t.Errorf("Interval() before any snapshot = %v, want 0", c.Interval())
+	}
+
+	start := time.Now()
+	c.Snapshot(1, start, 20, start)
+	if got := c.Interval(); got != 50*time.Millisecond {
+		t.Errorf("Interval() after one snapshot = %v, want the rate's 50ms", got)
+	}
+
+	// A server sending slower than it ticks
+	now := start
+	for tick := uint64(2); tick < 100; tick++ {
+		now = now.Add(100 * time.Millisecond)
+		c.Snapshot(tick, now, 20, now)
+	}
+	if got := c.Interval(); got < 95*time.Millisecond || got > 105*time.Millisecond {
+		t.Errorf("Interval() = %v, want about the 100ms measured", got)
+	}
+
+	// A stall isn't the rate
+	now = now.Add(5 * time.Second)
+	c.Snapshot(100, now, 20, now)
+	if got := c.Interval(); got > 105*time.Millisecond {
+		t.Errorf("Interval() after a stall = %v, want it left out", got)
+	}
+	if got := c.Since(now.Add(30 * time.Millisecond)); got != 30*time.Millisecond {
+		t.Errorf("Since() = %v, want 30ms", got)
+	}
+}
+
+func TestTrackExtrapolates(t *testing.T) {
+	var tr Track
+	start := time.Now()
+	tr.Update(100, 100, 200, 0, start)
+
+	if x, y := tr.Position(start.Add(100 * time.Millisecond)); x != 120 || y != 100 {
+		t.Errorf("Position() 100ms on = %v, %v, want 120, 100", x, y)
+	}
+	// A long gap stops the player where MaxExtrapolation took them
+	if x, _ := tr.Position(start.Add(time.Second)); x != 140 {
+		t.Errorf("Position() a second on = %v, want 140", x)
+	}
+}
+
+func TestTrackBlends(t *testing.T) {
+	var tr Track
+	start := time.Now()
+	tr.Update(100, 100, 200, 0, start)
+
+	// The player stopped at 110, they were drawn at 120 when the update came
+	now := start.Add(100 * time.Millisecond)
+	tr.Update(110, 100, 0, 0, now)
+	if x, _ := tr.Position(now); x != 120 {
+		t.Errorf("Position() when the update comes = %v, want 120 where they were drawn", x)
+	}
+	if x, _ := tr.Position(now.Add(BlendTime / 2)); x != 115 {
+		t.Errorf("Position() halfway through blending = %v, want 115", x)
+	}
+	if x, _ := tr.Position(now.Add(BlendTime)); x != 110 {
+		t.Errorf("Position() after blending = %v, want 110", x)
+	}
+
+	// Respawning across the map isn't blended
+	tr.Update(900, 700, 0, 0, now.Add(time.Second))
+	if x, y := tr.Position(now.Add(time.Second)); x != 900 || y != 700 {
+		t.Errorf("Position() after a respawn = %v, %v, want 900, 700", x, y)
+	}
+}
+
+func TestLerpAngle(t *testing.T) {
+	tests := []struct {
+		name    string
+		a, b, f float64
+		want    float64
+	}{
+		{"halfway", 0, 1, 0.5, 0.5},
+		{"across Pi", 3, -3, 0.5, math.Pi},
+		{"across Pi the other way", -3, 3, 0.25, -3 - (2*math.Pi-6)/4},
+		{"more than a turn apart", 0, 2*math.Pi + 0.2, 0.5, 0.1},
+		{"done", 1, -2, 1, -2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := LerpAngle(tt.a, tt.b, tt.f); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("LerpAngle(%v, %v, %v) = %v, want %v", tt.a, tt.b, tt.f, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTrackAim(t *testing.T) {
+	var tr Track
+	start := time.Now()
+	tr.Aim(3, 50*time.Millisecond, start)
+	if got := tr.Angle(start); got != 3 {
+		t.Errorf("Angle() of the first update = %v, want it right away", got)
+	}
+
+	// Aiming past Pi turns the short way, through Pi and not through 0
+	tr.Aim(-3, 50*time.Millisecond, start.Add(50*time.Millisecond))
+	mid := tr.Angle(start.Add(75 * time.Millisecond))
+	if math.Abs(mid) < 3 {
+		t.Errorf("Angle() halfway = %v, want it near Pi", mid)
+	}
+	if got := tr.Angle(start.Add(100 * time.Millisecond)); got != -3 {
+		t.Errorf("Angle() once turned = %v, want -3", got)
+	}
+}
@@ -0,0 +1,134 @@
+package killcam
+
+import (
+	"time"
+
+	"shooter/player"
+	"shooter/weapon"
+)
+
+// Duration of the world history kept for the killcam.
+const Duration = 2 * time.Second
+
+// PlayerState is everything needed to draw a player as it was at one moment.
+type PlayerState struct {
+	ID         string
+	X, Y       float64
+	Angle      float64
+	Health     int
+	Anim       player.AnimState
+	Weapon     weapon.ID
+	Aiming     bool
+	Flashlight bool
+	Team       string
+	Skin       string
+	Bullets    []player.Bullet
+}
+
+func stateOf(p *player.Player) PlayerState {
+	bullets := make([]player.Bullet, len(p.Bullets))
+	for i, b := range p.Bullets {
+		bullets[i] = *b
+	}
+	return PlayerState{
+		ID:         p.ID,
+		X:          p.X,
+		Y:          p.Y,
+		Angle:      p.Angle,
+		Health:     p.Health,
+		Anim:       p.Anim,
+		Weapon:     p.Weapon,
+		Aiming:     p.Aiming,
+		Flashlight: p.Flashlight,
+		Team:       p.Team,
+		Skin:       p.Skin,
+		Bullets:    bullets,
+	}
+}
+
+type Frame struct {
+	Time    time.Time
+	Players []PlayerState
+}
+
+// Recorder keeps snapshots of the last Duration of the game, one per tick.
+type Recorder struct {
+	frames []Frame
+}
+
+func frameOf(now time.Time, players []*player.Player) Frame {
+	frame := Frame{Time: now, Players: make([]PlayerState, 0, len(players))}
+	for _, p := range players {
+		frame.Players = append(frame.Players, stateOf(p))
+	}
+	return frame
+}
+
+func (r *Recorder) Record(now time.Time, players ...*player.Player) {
+	r.frames = append(r.frames, frameOf(now, players))
+
+	drop := 0
+	for drop < len(r.frames) && now.Sub(r.frames[drop].Time) > Duration {
+		drop++
+	}
+	r.frames = r.frames[drop:]
+}
+
+// Clip returns a copy of the recorded frames, oldest first.
+func (r *Recorder) Clip() []Frame {
+	return append([]Frame(nil), r.frames...)
+}
+
+func (r *Recorder) Reset() {
+	r.frames = r.frames[:0]
+}
+
+// Playback replays a clip one frame per tick on ghost players.
+type Playback struct {
+	frames []Frame
+	frame  int
+	ghosts map[string]*player.Player
+}
+
+func NewPlayback(frames []Frame) *Playback {
+	return &Playback{frames: frames, ghosts: map[string]*player.Player{}}
+}
+
+func (p *Playback) Done() bool {
+	return p.frame >= len(p.frames)
+}
+
+// Update moves ghosts to the next frame.
+func (p *Playback) Update(dt time.Duration) {
+	if p.Done() {
+		return
+	}
+	for _, s := range p.frames[p.frame].Players {
+		g, ok := p.ghosts[s.ID]
+		if !ok {
+			g = player.NewPlayer(s.ID, s.X, s.Y)
+			p.ghosts[s.ID] = g
+		}
+		g.X, g.Y, g.Angle, g.Health = s.X, s.Y, s.Angle, s.Health
+		g.Anim, g.Weapon, g.Aiming, g.Flashlight, g.Team, g.Skin = s.Anim, s.Weapon, s.Aiming, s.Flashlight, s.Team, s.Skin
+		g.Bullets = g.Bullets[:0]
+		for i := range s.Bullets {
+			b := s.Bullets[i]
+			g.Bullets = append(g.Bullets, &b)
+		}
+		g.Animate(dt)
+	}
+	p.frame++
+}
+
+// Players returns the ghosts in their current state, players missing from the frame are left out.
+func (p *Playback) Players() map[string]*player.Player {
+	players := map[string]*player.Player{}
+	if p.frame == 0 {
+		return players
+	}
+	for _, s := range p.frames[min(p.frame, len(p.frames))-1].Players {
+		players[s.ID] = p.ghosts[s.ID]
+	}
+	return players
+}
@@ -0,0 +1,68 @@
+package killcam
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"shooter/player"
+)
+
+// ReplayDir is where recorded matches are kept, under the game's data directory.
+const ReplayDir = "replays"
+
+// Replay is a whole match as recorded by one player.
+type Replay struct {
+	Map string `json:"map"`
+	// ID of the player who recorded it
+	Player string  `json:"player"`
+	Frames []Frame `json:"frames"`
+}
+
+func (r *Replay) Record(now time.Time, players ...*player.Player) {
+	r.Frames = append(r.Frames, frameOf(now, players))
+}
+
+// Length is the time between the first and the last frame.
+func (r *Replay) Length() time.Duration {
+	if len(r.Frames) == 0 {
+		return 0
+	}
+	return r.Frames[len(r.Frames)-1].Time.Sub(r.Frames[0].Time)
+}
+
+// Clip returns the frames from from to to, counted from the start of the replay.
+func (r *Replay) Clip(from, to time.Duration) []Frame {
+	var frames []Frame
+	for _, f := range r.Frames {
+		at := f.Time.Sub(r.Frames[0].Time)
+		if at >= from && at <= to {
+			frames = append(frames, f)
+		}
+	}
+	return frames
+}
+
+func (r *Replay) Save(path string) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+func LoadReplay(path string) (*Replay, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var r Replay
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
@@ -0,0 +1,80 @@
+package level
+
+import "shooter/game"
+
+// Duel arenas are a small room in the middle of the warehouse, each with its own cover.
+const (
+	arenaX, arenaY          = 400, 150
+	arenaWidth, arenaHeight = 800, 600
+)
+
+func arenaObjects(cover ...[]game.Line) []game.Object {
+	objects := []game.Object{
+		{Walls: game.Rect(padding, padding, width-2*padding, height-2*padding)},
+		{Walls: game.Rect(arenaX, arenaY, arenaWidth, arenaHeight)},
+	}
+	for _, walls := range cover {
+		objects = append(objects, game.Object{Walls: walls, Texture: WallTexture})
+	}
+	return objects
+}
+
+// Duelists face each other from the short sides
+var arenaSpawns = [][2]float64{
+	{arenaX + 100, height / 2},
+	{arenaX + arenaWidth - 100, height / 2},
+}
+
+var arenas = []*Level{
+	{
+		Name:   "arena_pillars",
+		Width:  width,
+		Height: height,
+		Objects: arenaObjects(
+			game.Rect(700, 300, 50, 80),
+			game.Rect(850, 520, 50, 80),
+		),
+		Lighting: Day,
+		Spawns:   arenaSpawns,
+		Arena:    true,
+	},
+	{
+		Name:   "arena_cross",
+		Width:  width,
+		Height: height,
+		Objects: arenaObjects(
+			game.Rect(780, 330, 40, 240),
+			game.Rect(680, 430, 240, 40),
+		),
+		Lighting: Dusk,
+		Spawns:   arenaSpawns,
+		Arena:    true,
+	},
+	{
+		Name:   "arena_lanes",
+		Width:  width,
+		Height: height,
+		Objects: arenaObjects(
+			game.Rect(580, 330, 440, 20),
+			game.Rect(580, 550, 440, 20),
+		),
+		Lighting: Night,
+		Spawns:   arenaSpawns,
+		Arena:    true,
+	},
+}
+
+func init() {
+	for _, a := range arenas {
+		levels[a.Name] = a
+	}
+}
+
+// Arenas returns the names of the duel arenas in rotation order.
+func Arenas() []string {
+	names := make([]string, len(arenas))
+	for i, a := range arenas {
+		names[i] = a.Name
+	}
+	return names
+}
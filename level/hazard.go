@@ -0,0 +1,49 @@
+package level
+
+// HazardKind is what a hazard area does to players standing in it.
+type HazardKind string
+
+const (
+	Fire HazardKind = "fire"
+	Acid HazardKind = "acid"
+	Mud  HazardKind = "mud"
+)
+
+// HazardEffect is the damage and slowdown of a kind of hazard.
+type HazardEffect struct {
+	// Health lost per second
+	DPS int
+	// Movement speed is multiplied by it, 1 doesn't slow down
+	SpeedFactor float64
+}
+
+var HazardEffects = map[HazardKind]HazardEffect{
+	Fire: {DPS: 20, SpeedFactor: 1},
+	Acid: {DPS: 8, SpeedFactor: 0.75},
+	Mud:  {DPS: 0, SpeedFactor: 0.5},
+}
+
+// Hazard is a rectangular area of the map which hurts or slows down whoever stands in it.
+type Hazard struct {
+	Kind                HazardKind
+	X, Y, Width, Height float64
+}
+
+func (h Hazard) Contains(x, y float64) bool {
+	return x >= h.X && y >= h.Y && x <= h.X+h.Width && y <= h.Y+h.Height
+}
+
+func (h Hazard) Effect() HazardEffect {
+	return HazardEffects[h.Kind]
+}
+
+// SpeedFactor is how much the hazards at x, y slow down movement, overlapping ones add up.
+func (l *Level) SpeedFactor(x, y float64) float64 {
+	factor := 1.0
+	for _, h := range l.Hazards {
+		if h.Contains(x, y) {
+			factor *= h.Effect().SpeedFactor
+		}
+	}
+	return factor
+}
@@ -0,0 +1,186 @@
+package level
+
+import (
+	"image/color"
+	"math/rand/v2"
+	"sort"
+
+	"shooter/game"
+)
+
+// Lighting controls how dark the parts of the map outside of lights and view are.
+type Lighting struct {
+	// 0 is pitch black night, 1 is broad daylight
+	Ambient float64
+	// Multiplied over the whole lit scene
+	Tint color.RGBA
+}
+
+var (
+	Day   = Lighting{Ambient: 1, Tint: color.RGBA{255, 255, 255, 255}}
+	Dusk  = Lighting{Ambient: 0.5, Tint: color.RGBA{255, 200, 170, 255}}
+	Night = Lighting{Ambient: 0.15, Tint: color.RGBA{150, 160, 255, 255}}
+
+	// By the names map scripts switch to them
+	Lightings = map[string]Lighting{"day": Day, "dusk": Dusk, "night": Night}
+)
+
+type Level struct {
+	Name     string
+	Width    float64
+	Height   float64
+	Objects  []game.Object
+	Lighting Lighting
+	// Where players appear after dying
+	Spawns [][2]float64
+	// Areas hurting or slowing down players, simulated by the server
+	Hazards []Hazard
+	// Small map for duels, not up for the map vote
+	Arena bool
+	// Tiled background, nil for the default image
+	Tiles *Tiles
+	// Drawn under the tiles, the default image when there are none
+	Layers []Layer
+	// Decorations which don't collide with anything
+	Props []Prop
+}
+
+const (
+	width   = 1600
+	height  = 900
+	padding = 20
+)
+
+// Texture of the obstacles inside the maps
+const WallTexture = "assets/wall.png"
+
+// Tints the refinery's walls
+var rust = color.RGBA{200, 140, 110, 255}
+
+func warehouseObjects() []game.Object {
+	return []game.Object{{
+		Walls: game.Rect(
+			padding,
+			padding,
+			width-2*padding,
+			height-2*padding,
+		),
+	}, {
+		Walls: game.Rect(
+			width/2-50,
+			height/2+50,
+			100, 100,
+		),
+		Texture: WallTexture,
+	}}
+}
+
+var warehouseSpawns = [][2]float64{
+	{200, 200},
+	{width - 200, 200},
+	{200, height - 200},
+	{width - 200, height - 200},
+}
+
+// Refinery is the warehouse with a fire pit in the middle, acid leaking at the top and
+// mud along the bottom.
+func refineryObjects() []game.Object {
+	return append(warehouseObjects(), game.Object{
+		Walls:   game.Rect(width/2-200, 180, 60, 160),
+		Texture: WallTexture,
+		Fill:    rust,
+	}, game.Object{
+		Walls:   game.Rect(width/2+140, 180, 60, 160),
+		Texture: WallTexture,
+		Fill:    rust,
+	})
+}
+
+var refineryHazards = []Hazard{
+	{Kind: Fire, X: width/2 - 120, Y: height/2 - 110, Width: 240, Height: 120},
+	{Kind: Acid, X: width/2 - 100, Y: padding, Width: 200, Height: 140},
+	{Kind: Mud, X: 400, Y: height - 180, Width: width - 800, Height: 160},
+}
+
+var levels = map[string]*Level{
+	"warehouse": {
+		Name:     "warehouse",
+		Width:    width,
+		Height:   height,
+		Objects:  warehouseObjects(),
+		Lighting: Day,
+		Spawns:   warehouseSpawns,
+	},
+	"refinery": {
+		Name:     "refinery",
+		Width:    width,
+		Height:   height,
+		Objects:  refineryObjects(),
+		Lighting: Dusk,
+		Spawns:   warehouseSpawns,
+		Hazards:  refineryHazards,
+		Tiles:    floorTiles(width, height),
+		Props:    refineryProps,
+	},
+	"warehouse_night": {
+		Name:     "warehouse_night",
+		Width:    width,
+		Height:   height,
+		Objects:  warehouseObjects(),
+		Lighting: Night,
+		Spawns:   warehouseSpawns,
+		// The floor stays behind a little when aiming down sights
+		Layers: []Layer{{Image: "assets/background.png", Depth: 0.5}},
+	},
+}
+
+const Default = "warehouse"
+
+// Get returns the built-in level with the given name.
+func Get(name string) (*Level, bool) {
+	l, ok := levels[name]
+	return l, ok
+}
+
+// Names returns names of all built-in levels, sorted.
+func Names() []string {
+	names := make([]string, 0, len(levels))
+	for name := range levels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Maps returns the names of the built-in levels for full matches, sorted.
+func Maps() []string {
+	var names []string
+	for _, name := range Names() {
+		if !levels[name].Arena {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// SpawnPoints are where players may spawn, the level's spawns or its center when there are none.
+func (l *Level) SpawnPoints() [][2]float64 {
+	if len(l.Spawns) == 0 {
+		return [][2]float64{{l.Width / 2, l.Height / 2}}
+	}
+	return l.Spawns
+}
+
+// Spawn returns the i-th spawn, wrapping around, for players who must not spawn together.
+func (l *Level) Spawn(i int) (float64, float64) {
+	spawns := l.SpawnPoints()
+	s := spawns[i%len(spawns)]
+	return s[0], s[1]
+}
+
+// SpawnPoint returns a random spawn, see SpawnPoints.
+func (l *Level) SpawnPoint() (float64, float64) {
+	spawns := l.SpawnPoints()
+	s := spawns[rand.IntN(len(spawns))]
+	return s[0], s[1]
+}
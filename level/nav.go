@@ -0,0 +1,90 @@
+package level
+
+import "math"
+
+const (
+	// Size of the nav grid's square cells
+	NavCell = 10.0
+	// Cells with their center closer to a wall than this are blocked, about a player's radius
+	NavClearance = 10.0
+)
+
+// NavGrid is where players can walk on a level. Walls and obstacles of the level itself block it,
+// doors and entities coming and going don't.
+type NavGrid struct {
+	cols, rows int
+	blocked    []bool
+}
+
+func NewNavGrid(l *Level) *NavGrid {
+	g := &NavGrid{cols: int(math.Ceil(l.Width / NavCell)), rows: int(math.Ceil(l.Height / NavCell))}
+	g.blocked = make([]bool, g.cols*g.rows)
+	for i := range g.blocked {
+		x, y := g.center(i)
+		blocked := l.InsideObstacle(x, y)
+		for _, o := range l.Objects {
+			blocked = blocked || wallDistance(x, y, o.Walls) < NavClearance
+		}
+		g.blocked[i] = blocked
+	}
+	return g
+}
+
+func (g *NavGrid) center(i int) (float64, float64) {
+	return (float64(i%g.cols) + 0.5) * NavCell, (float64(i/g.cols) + 0.5) * NavCell
+}
+
+// cell returns the index of the cell at x, y, false outside the grid.
+func (g *NavGrid) cell(x, y float64) (int, bool) {
+	c, r := int(math.Floor(x/NavCell)), int(math.Floor(y/NavCell))
+	if c < 0 || r < 0 || c >= g.cols || r >= g.rows {
+		return 0, false
+	}
+	return r*g.cols + c, true
+}
+
+// Blocked is true for points players can't stand on, outside the grid included.
+func (g *NavGrid) Blocked(x, y float64) bool {
+	i, ok := g.cell(x, y)
+	return !ok || g.blocked[i]
+}
+
+// Reach returns where players can walk to from any of the points, blocked ones reach nowhere.
+func (g *NavGrid) Reach(points ...[2]float64) Reach {
+	reached := make([]bool, len(g.blocked))
+	var queue []int
+	for _, p := range points {
+		if i, ok := g.cell(p[0], p[1]); ok && !g.blocked[i] && !reached[i] {
+			reached[i] = true
+			queue = append(queue, i)
+		}
+	}
+	for len(queue) > 0 {
+		i := queue[0]
+		queue = queue[1:]
+		c := i % g.cols
+		for _, n := range []int{i - g.cols, i + g.cols, i - 1, i + 1} {
+			// Left and right neighbours wrap around to the other side of the grid
+			if n < 0 || n >= len(g.blocked) || (n == i-1 && c == 0) || (n == i+1 && c == g.cols-1) {
+				continue
+			}
+			if !g.blocked[n] && !reached[n] {
+				reached[n] = true
+				queue = append(queue, n)
+			}
+		}
+	}
+	return Reach{grid: g, cells: reached}
+}
+
+// Reach is where players can walk to on a NavGrid from where it started.
+type Reach struct {
+	grid  *NavGrid
+	cells []bool
+}
+
+// Contains is true when the cell at x, y was reached.
+func (r Reach) Contains(x, y float64) bool {
+	i, ok := r.grid.cell(x, y)
+	return ok && r.cells[i]
+}
@@ -0,0 +1,34 @@
+package level
+
+import (
+	"testing"
+
+	"shooter/game"
+)
+
+func TestNavGridReach(t *testing.T) {
+	l := &Level{Width: 600, Height: 600, Objects: []game.Object{
+		{Walls: game.Rect(0, 0, 600, 600)},
+		// Splits the level but for a gap at the bottom, with a room closed off on the right
+		{Walls: game.Rect(290, 0, 20, 500)},
+		{Walls: game.Rect(400, 100, 150, 150)},
+	}}
+	reach := NewNavGrid(l).Reach([2]float64{100, 100})
+	tests := []struct {
+		name string
+		x, y float64
+		want bool
+	}{
+		{"start", 100, 100, true},
+		{"around the split", 500, 400, true},
+		{"inside the split", 300, 200, false},
+		{"inside the room", 475, 175, false},
+		{"against a wall", 5, 100, false},
+		{"outside", 700, 100, false},
+	}
+	for _, tt := range tests {
+		if got := reach.Contains(tt.x, tt.y); got != tt.want {
+			t.Errorf("%s: Contains(%v, %v) = %v, want %v", tt.name, tt.x, tt.y, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package level
+
+// Layer is an image covering the whole map under everything else. With a Depth below 1 it
+// stays behind when the camera zooms in, 0 doesn't move at all.
+type Layer struct {
+	Image string
+	Depth float64
+}
+
+// Prop is an image placed on the map for looks only, players and bullets go through it.
+type Prop struct {
+	Image string
+	// Center of the image
+	X, Y float64
+	// In radians
+	Angle float64
+	// 0 is the same as 1
+	Scale float64
+}
+
+const (
+	Crate  = "assets/prop_crate.png"
+	Barrel = "assets/prop_barrel.png"
+)
+
+// Barrels of whatever is leaking by the acid and crates in the shelter of the walls
+var refineryProps = []Prop{
+	{Image: Barrel, X: width/2 - 130, Y: 60},
+	{Image: Barrel, X: width/2 + 128, Y: 66},
+	{Image: Barrel, X: width/2 + 150, Y: 96, Angle: 0.4},
+	{Image: Crate, X: width/2 - 172, Y: 370, Angle: 0.1},
+	{Image: Crate, X: width/2 + 170, Y: 372},
+	{Image: Crate, X: width/2 + 176, Y: 412, Angle: -0.2, Scale: 0.8},
+}
@@ -0,0 +1,62 @@
+package level
+
+import "math/rand/v2"
+
+// Tiles is a background made of squares cut from a tileset image, drawn instead of the
+// stretched background image.
+type Tiles struct {
+	// Asset name of the tileset, cut into Size x Size squares left to right, top to bottom
+	Tileset string
+	Size    int
+	// Width of the map in tiles, Indices wrap after it
+	Columns int
+	// Tileset square of each tile row by row, -1 leaves the tile empty
+	Indices []int
+}
+
+// Rows is the height of the map in tiles.
+func (t *Tiles) Rows() int {
+	if t.Columns <= 0 {
+		return 0
+	}
+	return (len(t.Indices) + t.Columns - 1) / t.Columns
+}
+
+// At returns the tileset square of the tile in the column and row, -1 outside the map.
+func (t *Tiles) At(col, row int) int {
+	i := row*t.Columns + col
+	if col < 0 || col >= t.Columns || row < 0 || i >= len(t.Indices) {
+		return -1
+	}
+	return t.Indices[i]
+}
+
+// Squares of the floor tileset
+const (
+	floorPlain = iota
+	floorCracked
+	floorGrate
+	floorDark
+)
+
+// floorTiles covers a w x h map with concrete slabs, cracked and darker ones and a few grates
+// scattered the same way every time.
+func floorTiles(w, h float64) *Tiles {
+	const size = 32
+	cols, rows := int(w+size-1)/size, int(h+size-1)/size
+	r := rand.New(rand.NewPCG(uint64(cols), uint64(rows)))
+	t := &Tiles{Tileset: "assets/tiles.png", Size: size, Columns: cols, Indices: make([]int, cols*rows)}
+	for i := range t.Indices {
+		switch n := r.IntN(100); {
+		case n < 3:
+			t.Indices[i] = floorGrate
+		case n < 10:
+			t.Indices[i] = floorCracked
+		case n < 25:
+			t.Indices[i] = floorDark
+		default:
+			t.Indices[i] = floorPlain
+		}
+	}
+	return t
+}
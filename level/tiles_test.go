@@ -0,0 +1,21 @@
+package level
+
+import "testing"
+
+func TestFloorTiles(t *testing.T) {
+	tiles := floorTiles(100, 70)
+	if tiles.Columns != 4 || tiles.Rows() != 3 {
+		t.Errorf("%d x %d tiles, want 4 x 3", tiles.Columns, tiles.Rows())
+	}
+	if got := floorTiles(100, 70); got.Indices[5] != tiles.Indices[5] {
+		t.Error("tiles differ between calls")
+	}
+	for _, c := range [][2]int{{-1, 0}, {4, 0}, {0, 3}} {
+		if got := tiles.At(c[0], c[1]); got != -1 {
+			t.Errorf("At(%d, %d) = %d, want -1", c[0], c[1], got)
+		}
+	}
+	if got := tiles.At(1, 2); got != tiles.Indices[9] {
+		t.Errorf("At(1, 2) = %d, want %d", got, tiles.Indices[9])
+	}
+}
@@ -0,0 +1,57 @@
+package level
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"shooter/game"
+)
+
+func TestHash(t *testing.T) {
+	l, _ := Get(Default)
+	if Hash(l) == "" {
+		t.Fatal("empty hash")
+	}
+
+	// A level sent over the network hashes the same
+	data, err := json.Marshal(l)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var received Level
+	if err := json.Unmarshal(data, &received); err != nil {
+		t.Fatal(err)
+	}
+	if Hash(&received) != Hash(l) {
+		t.Error("received level hashes differently")
+	}
+
+	received.Objects = append(received.Objects, game.Object{Walls: game.Rect(10, 10, 20, 20)})
+	if Hash(&received) == Hash(l) {
+		t.Error("extra wall doesn't change the hash")
+	}
+}
+
+func TestLoad(t *testing.T) {
+	l, _ := Get(Default)
+	data, err := json.Marshal(l)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "map.json")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if Hash(loaded) != Hash(l) {
+		t.Error("loaded level differs from the saved one")
+	}
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("loaded a missing file")
+	}
+}
@@ -0,0 +1,166 @@
+package level
+
+import (
+	"fmt"
+	"math"
+	"slices"
+
+	"shooter/game"
+)
+
+const (
+	// Spawns closer to a wall than this would put the player inside it
+	SpawnClearance = 20.0
+	// Spawns closer together than this let players spawn on top of each other
+	MinSpawnSeparation = 300.0
+	// Wall ends closer than this meet, see closed
+	wallJoin = 0.5
+)
+
+// Problem is something wrong with a level, at X, Y.
+type Problem struct {
+	X, Y    float64
+	Message string
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("(%.0f, %.0f): %s", p.X, p.Y, p.Message)
+}
+
+// Validate checks that every spawn is inside the level, outside obstacles and hazards, inside a
+// closed outline of walls, far enough from the other spawns and that players can walk from the
+// first to every other on the NavGrid, and that hazards are known and inside the level.
+func (l *Level) Validate() []Problem {
+	var problems []Problem
+	report := func(s [2]float64, format string, args ...any) {
+		problems = append(problems, Problem{X: s[0], Y: s[1], Message: fmt.Sprintf(format, args...)})
+	}
+
+	if len(l.Spawns) == 0 {
+		problems = append(problems, Problem{Message: "no spawn points"})
+	}
+	// Walking starts from the first spawn not already reported as obstructed
+	nav := NewNavGrid(l)
+	start := slices.IndexFunc(l.Spawns, func(s [2]float64) bool { return !nav.Blocked(s[0], s[1]) })
+	var reach Reach
+	if start >= 0 {
+		reach = nav.Reach(l.Spawns[start])
+	}
+	for i, s := range l.Spawns {
+		x, y := s[0], s[1]
+		if x < 0 || y < 0 || x > l.Width || y > l.Height {
+			report(s, "spawn %d is outside the %.0fx%.0f level", i, l.Width, l.Height)
+		}
+		for j, o := range l.Objects {
+			// The outer wall contains every spawn, only objects some spawns are outside of are obstacles
+			if inside(x, y, o.Walls) && !l.containsAllSpawns(o) {
+				report(s, "spawn %d is inside object %d", i, j)
+			}
+			if d := wallDistance(x, y, o.Walls); d < SpawnClearance {
+				report(s, "spawn %d is %.0f from a wall of object %d, needs %.0f", i, d, j, SpawnClearance)
+			}
+		}
+		if !l.enclosed(x, y) {
+			report(s, "spawn %d is not enclosed, no closed outline of walls is around it", i)
+		}
+		if start >= 0 && !nav.Blocked(x, y) && !reach.Contains(x, y) {
+			report(s, "spawn %d can't be walked to from spawn %d", i, start)
+		}
+		for j, h := range l.Hazards {
+			if h.Contains(x, y) {
+				report(s, "spawn %d is inside hazard %d", i, j)
+			}
+		}
+		for j := i + 1; j < len(l.Spawns); j++ {
+			o := l.Spawns[j]
+			if d := math.Hypot(x-o[0], y-o[1]); d < MinSpawnSeparation {
+				report(s, "spawn %d is %.0f from spawn %d, needs %.0f", i, d, j, MinSpawnSeparation)
+			}
+		}
+	}
+	for i, h := range l.Hazards {
+		at := [2]float64{h.X, h.Y}
+		if _, ok := HazardEffects[h.Kind]; !ok {
+			report(at, "hazard %d is of unknown kind %q", i, h.Kind)
+		}
+		if h.Width <= 0 || h.Height <= 0 || h.X < 0 || h.Y < 0 || h.X+h.Width > l.Width || h.Y+h.Height > l.Height {
+			report(at, "hazard %d is empty or outside the %.0fx%.0f level", i, l.Width, l.Height)
+		}
+	}
+	return problems
+}
+
+// InsideObstacle is true for a point inside one of the objects, other than the outer wall
+// around every spawn.
+func (l *Level) InsideObstacle(x, y float64) bool {
+	for _, o := range l.Objects {
+		if inside(x, y, o.Walls) && !l.containsAllSpawns(o) {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *Level) containsAllSpawns(o game.Object) bool {
+	for _, s := range l.Spawns {
+		if !inside(s[0], s[1], o.Walls) {
+			return false
+		}
+	}
+	return true
+}
+
+// enclosed is true when x, y is inside an object whose walls are closed, nothing gets out of it.
+func (l *Level) enclosed(x, y float64) bool {
+	for _, o := range l.Objects {
+		if closed(o.Walls) && inside(x, y, o.Walls) {
+			return true
+		}
+	}
+	return false
+}
+
+// closed is true when every end of the walls meets the end of exactly one other wall, so they
+// form outlines without gaps.
+func closed(walls []game.Line) bool {
+	if len(walls) < 3 {
+		return false
+	}
+	var ends [][2]float64
+	for _, w := range walls {
+		ends = append(ends, [2]float64{w.X1, w.Y1}, [2]float64{w.X2, w.Y2})
+	}
+	for i, e := range ends {
+		met := 0
+		for j, o := range ends {
+			if i/2 != j/2 && math.Hypot(e[0]-o[0], e[1]-o[1]) < wallJoin {
+				met++
+			}
+		}
+		if met != 1 {
+			return false
+		}
+	}
+	return true
+}
+
+// inside counts crossings of a ray going right from x, y, odd means inside the walls.
+func inside(x, y float64, walls []game.Line) bool {
+	in := false
+	for _, w := range walls {
+		if (w.Y1 > y) != (w.Y2 > y) && x < w.X1+(y-w.Y1)*(w.X2-w.X1)/(w.Y2-w.Y1) {
+			in = !in
+		}
+	}
+	return in
+}
+
+// wallDistance returns the distance from x, y to the closest wall.
+func wallDistance(x, y float64, walls []game.Line) float64 {
+	closest := math.Inf(1)
+	for _, w := range walls {
+		_, d := w.Closest(x, y)
+		closest = math.Min(closest, d)
+	}
+	return closest
+}
@@ -0,0 +1,96 @@
+package level
+
+import (
+	"slices"
+	"testing"
+
+	"shooter/game"
+)
+
+func TestBuiltInLevelsAreValid(t *testing.T) {
+	for _, name := range Names() {
+		l, _ := Get(name)
+		for _, p := range l.Validate() {
+			t.Errorf("%s %v", name, p)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		spawns [][2]float64
+		walls  []game.Object
+		want   int
+	}{
+		{"valid", [][2]float64{{100, 100}, {500, 500}}, []game.Object{{Walls: game.Rect(0, 0, 600, 600)}}, 0},
+		{"inside obstacle", [][2]float64{{100, 100}, {500, 500}},
+			[]game.Object{{Walls: game.Rect(0, 0, 600, 600)}, {Walls: game.Rect(50, 50, 100, 100)}}, 1},
+		{"too close together", [][2]float64{{100, 100}, {150, 100}}, []game.Object{{Walls: game.Rect(0, 0, 600, 600)}}, 1},
+		{"not enclosed", [][2]float64{{100, 100}, {500, 500}}, nil, 2},
+		{"against a wall", [][2]float64{{5, 100}, {500, 500}}, []game.Object{{Walls: game.Rect(0, 0, 600, 600)}}, 1},
+		// A gap far narrower than a player, which no number of rays would find
+		{"not closed", [][2]float64{{100, 100}, {500, 500}}, []game.Object{{Walls: []game.Line{
+			{X1: 0, Y1: 0, X2: 0, Y2: 600}, {X1: 0, Y1: 600, X2: 600, Y2: 600},
+			{X1: 600, Y1: 600, X2: 600, Y2: 0}, {X1: 600, Y1: 0, X2: 1, Y2: 0},
+		}}}, 2},
+		{"walled off", [][2]float64{{100, 100}, {500, 500}},
+			[]game.Object{{Walls: game.Rect(0, 0, 600, 600)}, {Walls: []game.Line{{X1: 0, Y1: 300, X2: 600, Y2: 300}}}}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := &Level{Width: 600, Height: 600, Objects: tt.walls, Spawns: tt.spawns}
+			if got := l.Validate(); len(got) != tt.want {
+				t.Errorf("Validate() = %v, want %d problems", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateHazards(t *testing.T) {
+	walls := []game.Object{{Walls: game.Rect(0, 0, 600, 600)}}
+	spawns := [][2]float64{{100, 100}, {500, 500}}
+	tests := []struct {
+		name   string
+		hazard Hazard
+		want   int
+	}{
+		{"valid", Hazard{Kind: Mud, X: 200, Y: 200, Width: 100, Height: 100}, 0},
+		{"on a spawn", Hazard{Kind: Fire, X: 50, Y: 50, Width: 100, Height: 100}, 1},
+		{"unknown kind", Hazard{Kind: "lava", X: 200, Y: 200, Width: 100, Height: 100}, 1},
+		{"outside", Hazard{Kind: Acid, X: 550, Y: 200, Width: 100, Height: 100}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := &Level{Width: 600, Height: 600, Objects: walls, Spawns: spawns, Hazards: []Hazard{tt.hazard}}
+			if got := l.Validate(); len(got) != tt.want {
+				t.Errorf("Validate() = %v, want %d problems", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSpeedFactor(t *testing.T) {
+	l := &Level{Hazards: []Hazard{
+		{Kind: Mud, X: 0, Y: 0, Width: 100, Height: 100},
+		{Kind: Acid, X: 50, Y: 0, Width: 100, Height: 100},
+	}}
+	if got := l.SpeedFactor(200, 200); got != 1 {
+		t.Errorf("outside = %v, want 1", got)
+	}
+	if got := l.SpeedFactor(75, 50); got != 0.5*0.75 {
+		t.Errorf("overlap = %v, want %v", got, 0.5*0.75)
+	}
+}
+
+func TestMapsLeaveOutArenas(t *testing.T) {
+	maps := Maps()
+	for _, name := range Arenas() {
+		if slices.Contains(maps, name) {
+			t.Errorf("arena %s is up for the map vote", name)
+		}
+	}
+	if len(maps)+len(Arenas()) != len(Names()) {
+		t.Errorf("Maps() = %v, missing levels of %v", maps, Names())
+	}
+}
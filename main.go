@@ -1,22 +1,57 @@
 package main
 
 import (
-	"bufio"
+	"bytes"
+	"cmp"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"image/color"
 	"log"
 	"math"
 	"net"
 	"os"
+	"path/filepath"
+	"slices"
 	"sort"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
+	"shooter/ability"
+	"shooter/audio"
+	"shooter/config"
+	"shooter/frametime"
 	"shooter/game"
+	"shooter/grapple"
+	"shooter/grenade"
+	"shooter/hitreg"
+	"shooter/hud"
+	"shooter/input"
+	"shooter/interp"
+	"shooter/killcam"
+	"shooter/level"
+	"shooter/match"
+	"shooter/netsim"
+	"shooter/ping"
 	"shooter/player"
+	"shooter/render/batch"
+	"shooter/render/camera"
+	"shooter/render/effects"
+	"shooter/render/lighting"
+	"shooter/render/tiles"
+	"shooter/script"
+	"shooter/sim"
+	"shooter/ui"
+	"shooter/utils"
+	"shooter/weapon"
+	"shooter/wire"
+	"shooter/zone"
 
 	"github.com/hajimehoshi/ebiten/v2"
-	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
 	"github.com/hajimehoshi/ebiten/v2/vector"
 )
 
@@ -32,8 +67,32 @@ const (
 	RayCount       = 100    // Number of rays casted for visibility
 	RayLength      = 1600.0 // Maximum ray length
 	ObstacleBorder = 2.0
+
+	// How long combat music keeps playing after the last shot fired or taken
+	CombatMusicTimeout = 8 * time.Second
+
+	// Screen shake
+	ShotTrauma = 0.08
+	HitTrauma  = 0.4
+
+	// Aiming down sights zooms in around a point this far ahead of the player
+	ADSZoom      = 1.3
+	ADSLookAhead = 150.0
+
+	AtlasSize = 256
+
+	// Player sprites with weapons reach this far from the player's center
+	PlayerCullMargin = 80.0
+
+	// Unchanged player updates are still sent this often
+	KeepaliveInterval = time.Second
+	// How long both sides wait for the other's part of the handshake
+	HandshakeTimeout = 5 * time.Second
 )
 
+// Free for all, enemies at full health don't give away anything
+var HealthBarRules = hud.BarRules{HideFullHealthEnemies: true}
+
 type Obstacle struct {
 	X      float64
 	Y      float64
@@ -42,26 +101,257 @@ type Obstacle struct {
 }
 
 type PlayerUpdate struct {
-	ID      string           `json:"id"`
-	X       float64          `json:"x"`
-	Y       float64          `json:"y"`
-	Angle   float64          `json:"angle"`
-	Health  int              `json:"health"`
-	Bullets []*player.Bullet `json:"bullets"`
+	ID     string           `json:"id"`
+	X      float64          `json:"x"`
+	Y      float64          `json:"y"`
+	Angle  float64          `json:"angle"`
+	Health int              `json:"health"`
+	Anim   player.AnimState `json:"anim"`
+	Weapon weapon.ID        `json:"weapon"`
+	Aiming bool             `json:"aiming"`
+	// Fitted to the held weapon
+	Attachment weapon.Attachment `json:"attachment,omitempty"`
+
+	Flashlight bool   `json:"flashlight"`
+	Team       string `json:"team"`
+	Skin       string `json:"skin,omitempty"`
+	Seed       uint64 `json:"seed"`
+	Bot        bool   `json:"bot,omitempty"`
+	// Velocity in pixels per second, for dead reckoning when updates are late
+	VX float64 `json:"vx,omitempty"`
+	VY float64 `json:"vy,omitempty"`
 }
 
 type PlayerHit struct {
-	VictimID string `json:"victim_id"`
-	Damage   int    `json:"damage"`
+	AttackerID string    `json:"attacker_id"`
+	VictimID   string    `json:"victim_id"`
+	Damage     int       `json:"damage"`
+	Weapon     weapon.ID `json:"weapon"`
+	Headshot   bool      `json:"headshot,omitempty"`
+}
+
+// PlayerKilled is sent by the victim, which is the one deciding its health.
+type PlayerKilled struct {
+	KillerID string    `json:"killer_id"`
+	VictimID string    `json:"victim_id"`
+	Weapon   weapon.ID `json:"weapon"`
+	// Filled in by the server, which may also credit the kill to someone else
+	Assists []string `json:"assists,omitempty"`
+}
+
+// Hello is the first message of a client, asking to play as Name.
+type Hello struct {
+	Name string `json:"name"`
+	// player.ProtocolVersion of the client, 0 for clients from before versioning
+	Version int `json:"version"`
+	// Watch the match without playing, with the server's config.Network.ObserverToken
+	Observer bool   `json:"observer,omitempty"`
+	Token    string `json:"token,omitempty"`
+	// Played by the computer, tagged on the scoreboard. Only the server's own bots, which show
+	// its bot token, are taken as bots.
+	Bot bool `json:"bot,omitempty"`
+	// Only asking for the ServerInfo, the server hangs up after answering
+	Info bool `json:"info,omitempty"`
+	// Party of the player, its members play on the same team
+	Party string `json:"party,omitempty"`
+	// Class the player plays as on servers with abilities
+	Class ability.Class `json:"class,omitempty"`
+	// config.Player.Key, ranked servers only let the key which first played under Name use it
+	Key string `json:"key,omitempty"`
+}
+
+// Welcome answers Hello with the ID the client plays as, which is Name unless someone already has it.
+type Welcome struct {
+	ID    string      `json:"id"`
+	Rules match.Rules `json:"rules"`
+	// Map being played and its level.Hash, a client with a different one asks for it
+	Map     string `json:"map"`
+	MapHash string `json:"map_hash"`
+	// weapon.Hash of the server's weapon data
+	WeaponsHash string `json:"weapons_hash,omitempty"`
+	// Team the client plays on, the same for a party
+	Team string `json:"team,omitempty"`
+	// Spread seed of the player's shots, see weapon.SpreadOffset
+	Seed uint64 `json:"seed"`
+}
+
+// Seed is the spread seed of the player's next life, sent when they die and before everyone
+// starts over.
+type Seed struct {
+	Seed uint64 `json:"seed"`
+}
+
+// Reject answers Hello instead of Welcome when the client can't join, right before the server hangs up.
+type Reject struct {
+	Reason string `json:"reason"`
+}
+
+// RejectedError is returned when joining a server which turned the client away.
+type RejectedError struct {
+	Reason string
+}
+
+func (e *RejectedError) Error() string {
+	return e.Reason
+}
+
+// ServerShutdown is sent to everyone right before the server closes the connections.
+type ServerShutdown struct {
+	Reason string `json:"reason"`
+}
+
+type MatchStart struct {
+	Map     string      `json:"map"`
+	MapHash string      `json:"map_hash"`
+	Rules   match.Rules `json:"rules"`
+}
+
+// Ping is a marker placed for the player's team.
+type Ping struct {
+	PlayerID string    `json:"player_id"`
+	Kind     ping.Kind `json:"kind"`
+	X        float64   `json:"x"`
+	Y        float64   `json:"y"`
+}
+
+type MapVote struct {
+	PlayerID string `json:"player_id"`
+	Map      string `json:"map"`
+}
+
+// death is the local player's last death, nil while alive.
+type death struct {
+	killer   string
+	weapon   string
+	distance float64
+	time     time.Time
+	killcam  *killcam.Playback
+	// Joined during a round, waiting for the next without having died
+	spectating bool
 }
 
 type Game struct {
 	player    *player.Player
 	players   map[string]*player.Player
 	obstacles []*Obstacle
+	level     *level.Level
+	// Of the level until its script switches it, see mapEvent
+	lighting  level.Lighting
 	Objects   []game.Object
 	conn      net.Conn
+	reader    *wire.Reader
 	mu        sync.Mutex
+	audio     *audio.Manager
+	music     *audio.Music
+	feedback  *hud.Feedback
+	particles *effects.System
+	camera    *camera.Camera
+	lights    *lighting.Lights
+	hud       *hud.HUD
+	killfeed  *hud.Killfeed
+	nameTags  *hud.NameTags
+
+	app *App
+	// Pause or settings menu shown over the game, nil while playing
+	overlay *ui.Menu
+
+	batch *batch.Batch
+	// Input of the current tick
+	input input.State
+	// Reused for aim assist every tick
+	targets  []input.Target
+	recorder killcam.Recorder
+	death    *death
+	// Whole match, kept when recording matches is on
+	replay *killcam.Replay
+	// Kills and deaths seen since joining, shown to observers
+	scores *match.Tracker
+
+	// Watching without playing, the local player is only a placeholder then
+	observer bool
+	// Player the observer camera follows, empty for the whole map
+	followed string
+
+	pings ping.Markers
+	// Set while the ping key is held, the marker goes where the crosshair was when it went down
+	pinging      bool
+	pingX, pingY float64
+
+	grenades []*thrownGrenade
+	// Set while the grenade key is held, the throw is previewed until it's released
+	aimingGrenade bool
+	// Deployed by players, as of the server's last update
+	entities []*sim.Entity
+	// Gunfire heard recently, real or faked by decoys
+	radar radar
+	rules match.Rules
+	// Kills of each gun game player, which weapon they are at
+	gunGame map[string]int
+	// Elimination round or duel and who is still in it
+	round Round
+	alive map[string]bool
+	// Money and purchases of the economy mode, and when the buy phase of the round ends
+	wallet   match.Wallet
+	buyUntil time.Time
+	// Abilities of the player's class, their charges as of the server's last word and
+	// until when the player's speed burst lasts
+	kit        ability.Kit
+	abilities  map[ability.ID]abilityCharge
+	burstUntil time.Time
+	// Local player being reeled in by their grapple and since when, others' grapples
+	reel      *grapple.State
+	reelStart time.Time
+	ropes     map[string]rope
+	// Local player's prediction of the buggy they drive, nil unless they do
+	drive *driving
+	// Enemies the side's recon pulses found and until when they show on the minimap
+	revealed map[string]time.Time
+	// Nil unless the server has the zone modifier on, see setZone
+	zone        *zone.Zone
+	zoneElapsed time.Duration
+	zoneSynced  time.Time
+
+	// Shown between matches, nil while playing
+	summary *match.Summary
+	votes   match.Vote
+	// Tiles of the level, nil for levels without
+	background *tiles.Background
+	// Map asked from the server with a MapRequest and its announced hash, see verifyMap
+	pendingMap  string
+	pendingHash string
+	// Maps downloaded from the server, played instead of the built-in ones with the same name
+	downloaded map[string]*level.Level
+	// Running vote from the server, nil when there is none
+	poll       *PollStatus
+	pollSynced time.Time
+
+	lastCombat time.Time
+	// Why the server connection ended, set by the network goroutine
+	disconnected string
+	// Path of the crash bundle once the listener panicked, see Update
+	crashed atomic.Pointer[string]
+	// Input recorded or replayed for debugging, nil normally
+	inputLog *inputLog
+	// Where the time of frames goes, shown when the HUD setting is on
+	frames frametime.Breakdown
+	// Buffers of the lights' rays, reused every frame
+	raycaster game.Raycaster
+	cone      []game.Line
+	// Rays of the view around the viewer, one per sample
+	visibility []game.Visibility
+	// Ticks of the server's snapshots, for placing remote players between them
+	clock interp.Clock
+	// Dead reckoning of remote players by ID, see placeRemotes
+	tracks map[string]*interp.Track
+	// Of the local player over the last tick in pixels per second, sent with updates
+	velX, velY float64
+	// Hits of own bullets shown before the server decided them, see hits.go
+	hits hitreg.Ledger
+	// Event types from a newer server, logged once. Only used by the network goroutine.
+	unknownEvents map[player.EventType]bool
+	// Last player update sent, to skip unchanged ones
+	lastSend   time.Time
+	lastUpdate []byte
 }
 
 func NewObstacles() []*Obstacle {
@@ -71,81 +361,396 @@ func NewObstacles() []*Obstacle {
 	}
 }
 
-func collidesWithObstacles(x, y, radius float64, obstacles []*Obstacle) bool {
-	for _, obstacle := range obstacles {
-		if circleRectCollision(x, y, radius, obstacle) {
-			return true
+// castRays returns the rays of a light at cx, cy, valid until the next call.
+func (g *Game) castRays(cx, cy float64, objects []game.Object) []game.Line {
+	return g.raycaster.Cast(cx, cy, rayLength, objects)
+}
+
+// Something large enough to reach all objects
+var rayLength = math.Hypot(float64(ScreenWidth), float64(ScreenHeight))
+
+// castRay returns the ray from cx, cy in direction of angle ending at the closest wall.
+func castRay(cx, cy, angle float64, objects []game.Object) (game.Line, bool) {
+	return game.CastRay(cx, cy, rayLength, angle, objects)
+}
+
+// lineOfSight is true when no wall is between the two points.
+func lineOfSight(x1, y1, x2, y2 float64, objects []game.Object) bool {
+	line := game.Line{X1: x1, Y1: y1, X2: x2, Y2: y2}
+	for _, o := range objects {
+		for _, wall := range o.Walls {
+			if _, _, ok := game.Intersection(line, wall); ok {
+				return false
+			}
 		}
 	}
-	return false
+	return true
 }
 
-func circleRectCollision(cx, cy, radius float64, rect *Obstacle) bool {
-	closestX := math.Max(rect.X, math.Min(cx, rect.X+rect.Width))
-	closestY := math.Max(rect.Y, math.Min(cy, rect.Y+rect.Height))
-	dx := cx - closestX
-	dy := cy - closestY
-	return (dx*dx + dy*dy) < (radius * radius)
+// assistTargets lists the living enemies the player can see, for aim assist.
+func (g *Game) assistTargets() []input.Target {
+	targets := g.targets[:0]
+	for _, p := range g.players {
+		if p.Health <= 0 || p.Hidden || distance(g.player.X, g.player.Y, p.X, p.Y) > input.AssistRange {
+			continue
+		}
+		if lineOfSight(g.player.X, g.player.Y, p.X, p.Y, g.Objects) {
+			targets = append(targets, input.Target{X: p.X, Y: p.Y})
+		}
+	}
+	g.targets = targets
+	return targets
 }
 
-func (g *Game) castRays(cx, cy float64, objects []game.Object) []game.Line {
-	rayLength := math.Hypot(float64(ScreenWidth), float64(ScreenHeight)) // something large enough to reach all objects
-
-	rays := []game.Line{}
-
-	for _, obj := range objects {
-		// Cast two rays per point
-		for _, p := range obj.Points() {
-			l := game.Line{cx, cy, p[0], p[1]}
-			angle := l.Angle()
-
-			for _, offset := range []float64{-0.001, 0.001} {
-				points := [][2]float64{}
-				ray := game.NewRay(cx, cy, rayLength, angle+offset)
-
-				// Unpack all objects
-				for _, o := range objects {
-					for _, wall := range o.Walls {
-						if px, py, ok := game.Intersection(ray, wall); ok {
-							points = append(points, [2]float64{px, py})
-						}
-					}
-				}
-
-				// Find the point closest to start of ray
-				min := math.Inf(1)
-				minI := 0
-				for i, p := range points {
-					d2 := (cx-p[0])*(cx-p[0]) + (cy-p[1])*(cy-p[1])
-					if d2 < min {
-						min = d2
-						minI = i
-					}
-				}
-				if minI < len(points) {
-					rays = append(rays, game.Line{X1: cx, Y1: cy, X2: points[minI][0], Y2: points[minI][1]})
-				}
-			}
+// coneRays returns rays of a cone light sorted from one edge of the cone to the other, valid until the next call.
+func (g *Game) coneRays(light lighting.Light) []game.Line {
+	rays := g.cone[:0]
+	for _, ray := range g.castRays(light.X, light.Y, g.Objects) {
+		if math.Abs(angleDiff(ray.Angle(), light.Direction)) < light.Cone {
+			rays = append(rays, ray)
+		}
+	}
+	for _, edge := range []float64{-light.Cone, light.Cone} {
+		if ray, ok := castRay(light.X, light.Y, light.Direction+edge, g.Objects); ok {
+			rays = append(rays, ray)
 		}
 	}
-	sort.Slice(rays, func(i int, j int) bool {
-		return rays[i].Angle() < rays[j].Angle()
+	slices.SortFunc(rays, func(a, b game.Line) int {
+		return cmp.Compare(angleDiff(a.Angle(), light.Direction), angleDiff(b.Angle(), light.Direction))
 	})
+	g.cone = rays
 	return rays
 }
 
+// angleDiff returns a - b normalized to [-Pi, Pi].
+func angleDiff(a, b float64) float64 {
+	return math.Remainder(a-b, 2*math.Pi)
+}
+
 func (g *Game) Update() error {
+	// Checked before locking, the listener may have panicked holding mu
+	if path := g.crashed.Load(); path != nil {
+		g.conn.Close()
+		g.app.showMainMenu(crashStatus(*path))
+		return nil
+	}
+	if g.inputLog != nil {
+		g.inputLog.deliver(g)
+	}
+	defer g.frames.Since(frametime.Simulation, time.Now())
 	g.mu.Lock()
 	defer g.mu.Unlock()
 
-	collides := collidesWithObstacles(g.player.X, g.player.Y, 10.0, g.obstacles) // FIXME: does not work, player moves thorugh obstacles
+	if g.disconnected != "" {
+		g.saveReplay()
+		g.inputLog.close()
+		g.conn.Close()
+		g.app.showMainMenu(g.disconnected)
+		return nil
+	}
+
+	aimX, aimY := g.cursorWorld()
+	g.app.input.Targets = g.assistTargets()
+	g.input = g.app.input.Update(g.player.X, g.player.Y, aimX, aimY)
+	if g.inputLog != nil {
+		g.input = g.inputLog.tick(g.input)
+	}
+
+	if g.overlay != nil {
+		g.overlay.Update()
+	} else if g.input.Pause {
+		g.pause()
+	} else if g.input.Vote && !g.observer {
+		g.voteMenu()
+	} else if g.input.Buy && g.canBuy() {
+		g.buyMenu()
+	}
+	// Crosshair replaces the cursor while playing
+	if g.overlay != nil || g.observer {
+		ebiten.SetCursorMode(ebiten.CursorModeVisible)
+	} else {
+		ebiten.SetCursorMode(ebiten.CursorModeHidden)
+	}
+
+	g.placeRemotes(g.now())
+	if g.observer {
+		g.updateObserver()
+		return nil
+	}
 
-	g.player.Update(collides)
+	if g.summary != nil && g.overlay == nil {
+		g.updateVote()
+	}
+	if g.overlay == nil {
+		g.updatePing()
+		g.updateGrenade()
+		g.updateDecoy()
+		g.updateDeploy()
+		if !g.updateRide() {
+			g.updatePickup()
+		}
+		g.updateAbilities()
+	}
+
+	prevX, prevY := g.player.X, g.player.Y
+	if g.overlay == nil && g.summary == nil {
+		g.player.SpeedFactor = g.level.SpeedFactor(g.player.X, g.player.Y) * g.rules.Speed() * g.abilitySpeed()
+		g.holdOwnedWeapon()
+		g.driveVehicle()
+		g.player.Update(g.input, g.now())
+		// Walls stop players on foot, the server corrects those going through them anyway
+		g.player.X, g.player.Y = game.Move(prevX, prevY, g.player.X-prevX, g.player.Y-prevY, player.PlayerRadius, g.Objects)
+		g.updateReel()
+		g.pinRider()
+	} else {
+		// Keep the world going while in menu, just ignore input
+		g.player.UpdateBullets()
+	}
+	g.playPlayerSounds(prevX, prevY)
+	tps := float64(ebiten.TPS())
+	g.velX, g.velY = (g.player.X-prevX)*tps, (g.player.Y-prevY)*tps
+	if g.player.HasShot() {
+		g.emitShotEffects(g.player)
+		g.camera.AddTrauma(ShotTrauma)
+		shots := g.player.TakeShots()
+		for _, b := range shots {
+			g.scores.Fired(g.player.ID, b.Weapon, 1)
+		}
+		g.sendEvent(player.EventTypeShoot, Shoot{Bullets: shots})
+	}
+	// Others' bullets are only moved here, the server removes them
+	for _, p := range g.players {
+		p.UpdateBullets()
+	}
 	g.checkBulletCollisions()
+	g.updateGrenades()
+	g.updateMusic()
+	g.expireHits(g.now())
+	g.feedback.Update()
+	g.particles.Update(1 / float64(ebiten.TPS()))
+	g.focusCamera()
+	g.camera.Update(1 / float64(ebiten.TPS()))
+	g.lights.Update(1 / float64(ebiten.TPS()))
+	g.animatePlayers()
+	g.nameTags.Update(g.healthBars(g.player, g.players), 1/float64(ebiten.TPS()))
+	g.updateDeath()
+	if g.app.cfg.Video.RecordMatches {
+		g.replay.Record(g.now(), g.allPlayers()...)
+	}
 	g.sendPlayerUpdate()
 	return nil
 }
 
+func (g *Game) playPlayerSounds(prevX, prevY float64) {
+	g.audio.SetListener(g.player.X, g.player.Y)
+
+	if g.player.HasShot() {
+		g.audio.PlayGain(audio.SoundGunshot, gunshotGain(g.player.CurrentWeapon().Suppressed))
+	}
+	if g.player.HasReloaded() {
+		g.audio.Play(audio.SoundReload)
+	}
+	if g.player.Stepped(distance(prevX, prevY, g.player.X, g.player.Y)) {
+		g.audio.Play(audio.SoundFootstep)
+	}
+}
+
+// gunshotGain is the volume of a shot, suppressed ones are quieter.
+func gunshotGain(suppressed bool) float64 {
+	if suppressed {
+		return weapon.SuppressedVolume
+	}
+	return 1
+}
+
+func (g *Game) emitShotEffects(p *player.Player) {
+	mx, my := p.MuzzlePosition()
+	g.particles.Emit(effects.MuzzleFlash, mx, my, p.Angle)
+	g.lights.Add(lighting.MuzzleFlash(mx, my))
+	// Casings are ejected to the right of the rifle
+	g.particles.Emit(effects.Casing, p.X, p.Y, p.Angle+math.Pi/2)
+}
+
+func (g *Game) animatePlayers() {
+	dt := time.Second / time.Duration(ebiten.TPS())
+	g.player.Animate(dt)
+	for _, p := range g.players {
+		p.Animate(dt)
+	}
+}
+
+// playerByID returns the local player or one of the others.
+func (g *Game) playerByID(id string) (*player.Player, bool) {
+	if id == g.player.ID {
+		return g.player, true
+	}
+	p, ok := g.players[id]
+	return p, ok
+}
+
+// allPlayers returns the local player followed by everyone else.
+func (g *Game) allPlayers() []*player.Player {
+	players := []*player.Player{g.player}
+	for _, p := range g.players {
+		players = append(players, p)
+	}
+	return players
+}
+
+func (g *Game) updateDeath() {
+	if g.death == nil {
+		g.recorder.Record(g.now(), g.allPlayers()...)
+		return
+	}
+
+	if g.death.killcam != nil {
+		g.death.killcam.Update(time.Second / time.Duration(ebiten.TPS()))
+	}
+	// Rounds have no respawns, the next one brings everyone back
+	if g.rules.Rounds() {
+		return
+	}
+	if g.now().Sub(g.death.time) > g.rules.Respawn() {
+		g.player.Respawn(g.level.SpawnPoint())
+		g.death = nil
+		g.recorder.Reset()
+	}
+}
+
+// died starts the death screen and the killcam from the last recorded moments. The server
+// tells everyone of the kill.
+func (g *Game) died(hit PlayerHit) {
+	g.eliminated(PlayerKilled{KillerID: hit.AttackerID, VictimID: hit.VictimID, Weapon: hit.Weapon})
+	g.death = &death{
+		killer: hit.AttackerID,
+		weapon: weapon.Get(hit.Weapon).Name,
+		time:   g.now(),
+	}
+	if attacker, ok := g.players[hit.AttackerID]; ok {
+		g.death.distance = distance(attacker.X, attacker.Y, g.player.X, g.player.Y)
+		g.death.killcam = killcam.NewPlayback(g.recorder.Clip())
+	}
+}
+
+// updatePing shows the ping wheel while the ping key is held and places the marker on release.
+func (g *Game) updatePing() {
+	switch {
+	case g.input.Ping && !g.pinging:
+		g.pinging = true
+		g.pingX, g.pingY = g.input.CrosshairX, g.input.CrosshairY
+	case !g.input.Ping && g.pinging:
+		g.pinging = false
+		p := Ping{PlayerID: g.player.ID, Kind: g.pingKind(), X: g.pingX, Y: g.pingY}
+		g.addPing(p)
+		g.sendEvent(player.EventTypePing, p)
+	}
+}
+
+// pingKind is the kind selected on the wheel by moving the crosshair since the ping key went down.
+func (g *Game) pingKind() ping.Kind {
+	return ping.WheelKind(g.input.CrosshairX-g.pingX, g.input.CrosshairY-g.pingY)
+}
+
+func (g *Game) addPing(p Ping) {
+	g.pings.Add(ping.Marker{Kind: p.Kind, Owner: p.PlayerID, X: p.X, Y: p.Y, Created: g.now()})
+}
+
+// updateVote lets the player vote for the next map with number keys.
+func (g *Game) updateVote() {
+	for i, m := range g.summary.Maps {
+		if i < 9 && inpututil.IsKeyJustPressed(ebiten.Key1+ebiten.Key(i)) {
+			g.votes.Cast(g.player.ID, m)
+			g.sendEvent(player.EventTypeMapVote, MapVote{PlayerID: g.player.ID, Map: m})
+		}
+	}
+}
+
+// setLevel switches to the map picked by the server, unknown ones keep the current map.
+func (g *Game) setLevel(mapName string) {
+	lvl, ok := g.levelNamed(mapName)
+	if !ok {
+		log.Println("Unknown map from server:", mapName)
+		lvl = g.level
+	}
+	g.level = lvl
+	g.lighting = lvl.Lighting
+	g.Objects = lvl.Objects
+	g.background = newBackground(lvl)
+	g.entities = nil
+	g.grenades = nil
+}
+
+// newBackground returns the tiles of the level, nil for levels without.
+func newBackground(lvl *level.Level) *tiles.Background {
+	if lvl.Tiles == nil {
+		return nil
+	}
+	return tiles.New(lvl.Tiles)
+}
+
+// changeMap moves to the map the server switched to in the middle of the match, keeping
+// scores and health. Everyone's bullets are gone.
+func (g *Game) changeMap(change ChangeMap) {
+	g.setLevel(change.Map)
+	g.player.Bullets = g.player.Bullets[:0]
+	if i, ok := change.Spawns[g.player.ID]; ok {
+		g.player.X, g.player.Y = g.level.Spawn(i)
+	}
+	for id, p := range g.players {
+		p.Bullets = p.Bullets[:0]
+		if i, ok := change.Spawns[id]; ok {
+			p.X, p.Y = g.level.Spawn(i)
+		}
+	}
+}
+
+// startMatch switches to the map picked by the server and starts over.
+func (g *Game) startMatch(mapName string) {
+	g.setLevel(mapName)
+	lvl := g.level
+	g.saveReplay()
+	g.scores = match.NewTracker(lvl.Name, g.now())
+	g.summary = nil
+	g.votes = match.Vote{}
+	g.death = nil
+	g.zone = nil
+	g.recorder.Reset()
+	g.player.Respawn(lvl.SpawnPoint())
+}
+
+// saveReplay writes the match recorded so far to the replay directory and starts recording a new one.
+func (g *Game) saveReplay() {
+	replay := g.replay
+	g.replay = &killcam.Replay{Map: g.level.Name, Player: g.player.ID}
+	if len(replay.Frames) == 0 {
+		return
+	}
+	name := fmt.Sprintf("%s-%s.json", replay.Map, replay.Frames[0].Time.Format("20060102-150405"))
+	path, err := config.DataPath(filepath.Join(killcam.ReplayDir, name))
+	if err != nil {
+		log.Println("Error finding replay directory:", err)
+		return
+	}
+	if err := replay.Save(path); err != nil {
+		log.Println("Error saving replay:", err)
+		return
+	}
+	log.Println("Saved replay to", path)
+}
+
+func (g *Game) updateMusic() {
+	if g.player.HasShot() {
+		g.lastCombat = g.now()
+	}
+
+	if g.now().Sub(g.lastCombat) < CombatMusicTimeout {
+		g.music.SetState(audio.MusicCombat)
+	} else {
+		g.music.SetState(audio.MusicMatch)
+	}
+	g.music.Update()
+}
+
 func RemoveIndex[E any](s []E, index int) []E {
 	ret := make([]E, 0)
 	ret = append(ret, s[:index]...)
@@ -159,7 +764,10 @@ func (g *Game) checkBulletCollisions() {
 
 		for _, o := range g.Objects {
 			for _, l := range o.Walls {
-				if _, _, intersects := game.Intersection(l, bullet.Line()); intersects {
+				if px, py, intersects := game.Intersection(l, bullet.Line()); intersects {
+					g.particles.Emit(effects.Sparks, px, py, bullet.Direction+math.Pi)
+					g.particles.Emit(effects.Dust, px, py, bullet.Direction+math.Pi)
+
 					// Remove bullet on object collision
 					if i >= len(g.player.Bullets) {
 						log.Println("Bullet index out of bounds")
@@ -173,7 +781,11 @@ func (g *Game) checkBulletCollisions() {
 
 		// Check bullet collisions with players
 		for _, otherPlayer := range g.players {
-			if otherPlayer.Health <= 0 || otherPlayer.ID == g.player.ID {
+			if otherPlayer.Health <= 0 || otherPlayer.Hidden || otherPlayer.ID == g.player.ID {
+				continue
+			}
+			// Bullets go through teammates without friendly fire
+			if g.rules.FriendlyFire == match.FriendlyFireOff && otherPlayer.Team != "" && otherPlayer.Team == g.player.Team {
 				continue
 			}
 			hitBoxLines := otherPlayer.HitBox().Walls
@@ -183,18 +795,15 @@ func (g *Game) checkBulletCollisions() {
 			})
 
 			for _, l := range hitBoxLines {
-				if _, _, intersects := game.Intersection(l, bullet.Line()); intersects {
-
-					otherPlayer.Health -= 50 // TODO: weapon defines damage
-					if otherPlayer.Health < 0 {
-						otherPlayer.Health = 0
-					}
+				if px, py, intersects := game.Intersection(l, bullet.Line()); intersects {
+					// Damage comes from the server, this only hides the bullet and shows the hit right away
+					g.particles.Emit(effects.Blood, px, py, bullet.Direction)
+					g.predictHit(bullet, otherPlayer, px, py)
 					if i >= len(g.player.Bullets) {
 						log.Println("Bullet index out of bounds")
 						break
 					}
 					g.player.Bullets = append(g.player.Bullets[:i], g.player.Bullets[i+1:]...)
-					g.sendEvent(player.EventTypePlayerHit, PlayerHit{VictimID: otherPlayer.ID, Damage: 20})
 					break
 				}
 			}
@@ -202,70 +811,302 @@ func (g *Game) checkBulletCollisions() {
 	}
 }
 
+// spawnBullets adds bullets confirmed by the server, own ones only get their IDs.
+func (g *Game) spawnBullets(bullets []*player.Bullet) {
+	if len(bullets) > 0 && bullets[0].EntityID != 0 {
+		g.spawnEntityBullets(bullets)
+		return
+	}
+	for _, b := range bullets {
+		if b.OwnerID == g.player.ID {
+			g.player.ConfirmBullet(b)
+			continue
+		}
+		p, ok := g.players[b.OwnerID]
+		if !ok {
+			continue
+		}
+		p.Bullets = append(p.Bullets, b)
+	}
+	if len(bullets) > 0 && bullets[0].OwnerID != g.player.ID {
+		if p, ok := g.players[bullets[0].OwnerID]; ok {
+			p.Angle, p.Weapon = bullets[0].Direction, bullets[0].Weapon
+			g.audio.PlayAtGain(audio.SoundGunshot, p.X, p.Y, gunshotGain(bullets[0].Suppressed))
+			g.emitShotEffects(p)
+			if !bullets[0].Suppressed && (p.Team == "" || p.Team != g.player.Team) {
+				g.radar.Add(p.X, p.Y, g.now())
+			}
+		}
+	}
+}
+
+// destroyBullet removes a bullet the server says hit something, with the impact effects.
+// Own bullets which already hit something locally are gone by then.
+func (g *Game) destroyBullet(d BulletDestroy) {
+	if d.OwnerID == g.player.ID && g.hits.Destroyed(d.ID, d.VictimID) {
+		g.hitRejected()
+	}
+	owner := g.player
+	if d.OwnerID != g.player.ID {
+		owner = g.players[d.OwnerID]
+	}
+	if owner == nil {
+		return
+	}
+	b, ok := owner.RemoveBullet(d.ID)
+	if !ok {
+		return
+	}
+	switch {
+	case d.Wall:
+		g.particles.Emit(effects.Sparks, d.X, d.Y, b.Direction+math.Pi)
+		g.particles.Emit(effects.Dust, d.X, d.Y, b.Direction+math.Pi)
+	case d.VictimID != "":
+		g.particles.Emit(effects.Blood, d.X, d.Y, b.Direction)
+	}
+}
+
 func distance(x1, y1, x2, y2 float64) float64 {
 	return math.Hypot(x2-x1, y2-y1)
 }
 
 var (
+	worldImage    = ebiten.NewImage(ScreenWidth, ScreenHeight)
 	shadowImage   = ebiten.NewImage(ScreenWidth, ScreenHeight)
 	triangleImage = ebiten.NewImage(ScreenWidth, ScreenHeight)
 	bgImage       *ebiten.Image
 )
 
-func rayVertices(x1, y1, x2, y2, x3, y3 float64) []ebiten.Vertex {
-	return []ebiten.Vertex{
-		{DstX: float32(x1), DstY: float32(y1), SrcX: 0, SrcY: 0, ColorR: 1, ColorG: 1, ColorB: 1, ColorA: 1},
-		{DstX: float32(x2), DstY: float32(y2), SrcX: 0, SrcY: 0, ColorR: 1, ColorG: 1, ColorB: 1, ColorA: 1},
-		{DstX: float32(x3), DstY: float32(y3), SrcX: 0, SrcY: 0, ColorR: 1, ColorG: 1, ColorB: 1, ColorA: 1},
+func (g *Game) Draw(screen *ebiten.Image) {
+	defer g.frames.EndFrame()
+	if g.observer {
+		g.drawObserver(screen)
+		return
+	}
+	viewer, others := g.player, g.players
+	if g.death != nil && g.death.killcam != nil && !g.death.killcam.Done() {
+		ghosts := g.death.killcam.Players()
+		if killer, ok := ghosts[g.death.killer]; ok {
+			delete(ghosts, killer.ID)
+			viewer, others = killer, ghosts
+		}
+	}
+	g.drawWorld(worldImage, viewer, others)
+
+	op := &ebiten.DrawImageOptions{}
+	g.camera.Apply(op, ScreenWidth, ScreenHeight)
+	g.app.viewport.Apply(op)
+	op.ColorScale.ScaleWithColor(g.lighting.Tint)
+	op.Filter = ebiten.FilterLinear
+	screen.Fill(color.Black)
+	screen.DrawImage(worldImage, op)
+
+	hud.DrawLowHealthVignette(screen, g.player.Health, g.player.FullHealth())
+	g.drawCrosshair(screen)
+	cx, cy := g.toScreen(g.input.CrosshairX, g.input.CrosshairY)
+	g.feedback.DrawScreen(screen, cx, cy)
+	if g.pinging {
+		px, py := g.toScreen(g.pingX, g.pingY)
+		hud.DrawPingWheel(screen, px, py, g.pingKind())
+	}
+	g.hud.Draw(screen, g.hudState())
+	g.app.input.DrawTouch(screen)
+	if g.summary != nil {
+		hud.DrawMatchSummary(screen, g.summary, g.player.ID, g.votes.Counts(), time.Until(g.summary.NextMap))
+	} else if g.death != nil {
+		hud.DrawDeathScreen(screen, hud.Death{
+			Killer:     g.death.killer,
+			Weapon:     g.death.weapon,
+			Distance:   g.death.distance,
+			Respawn:    g.rules.Respawn() - g.now().Sub(g.death.time),
+			Killcam:    viewer != g.player,
+			Waiting:    g.waiting(),
+			Spectating: g.death.spectating,
+		})
+	}
+
+	if g.overlay != nil {
+		g.overlay.Draw(screen)
 	}
 }
 
-func (g *Game) Draw(screen *ebiten.Image) {
+func (g *Game) pause() {
+	menu := ui.NewMenu("PAUSED",
+		ui.Button("Resume", g.resume),
+		ui.Button("Settings", func() {
+			g.overlay = g.app.settingsMenu(g.pause)
+		}),
+		ui.Button("Disconnect", g.disconnect),
+		ui.Button("Quit", func() { g.app.quit = true }),
+	)
+	menu.Back = g.resume
+	g.overlay = menu
+}
+
+func (g *Game) resume() {
+	g.overlay = nil
+}
+
+func (g *Game) disconnect() {
+	g.saveReplay()
+	g.conn.Close()
+	g.app.showMainMenu("")
+}
+
+func (g *Game) healthBars(viewer *player.Player, others map[string]*player.Player) []hud.BarPlayer {
+	bars := make([]hud.BarPlayer, 0, len(others))
+	for _, p := range others {
+		// Observers see everyone, players only whom they have in sight
+		visible := g.observer
+		if !visible && !p.Hidden && distance(viewer.X, viewer.Y, p.X, p.Y) <= g.app.cfg.HUD.NameTagDistance {
+			visible = lineOfSight(viewer.X, viewer.Y, p.X, p.Y, g.Objects)
+		}
+		bars = append(bars, hud.BarPlayer{
+			Name:      p.ID,
+			X:         p.X,
+			Y:         p.Y,
+			Health:    p.Health,
+			MaxHealth: g.player.FullHealth(),
+			Team:      p.Team,
+			Friendly:  p.Team != "" && p.Team == viewer.Team,
+			Visible:   visible && g.app.cfg.HUD.NameTagDistance > 0,
+		})
+	}
+	return bars
+}
+
+func newHUD(killfeed *hud.Killfeed) *hud.HUD {
+	h := hud.New()
+	h.Add(&hud.DebugInfo{}, hud.TopLeft, 10, 10)
+	h.Add(&hud.ObjectiveStatus{}, hud.TopCenter, 0, 20)
+	h.Add(&hud.Minimap{Width: 240}, hud.TopRight, 20, 20)
+	h.Add(killfeed, hud.TopRight, 20, 170)
+	h.Add(&hud.HealthBar{Width: 220, Height: 22}, hud.BottomLeft, 20, 20)
+	h.Add(&hud.AbilityBar{Box: 48}, hud.BottomLeft, 20, 56)
+	h.Add(&hud.AmmoCounter{}, hud.BottomRight, 20, 20)
+	h.Add(&hud.ShotStats{}, hud.BottomRight, 20, 50)
+	return h
+}
+
+// hudState is the single place where game state is bound to the HUD.
+func (g *Game) hudState() *hud.State {
+	w := g.player.CurrentWeapon()
+	state := &hud.State{
+		Health:       g.player.Health,
+		MaxHealth:    g.player.FullHealth(),
+		WeaponName:   w.Name,
+		Ammo:         g.player.Ammo(),
+		MagazineSize: w.MagazineSize,
+		Reloading:    g.player.Reloading(),
+		Grenades:     g.player.Grenades,
+		Decoys:       g.player.Decoys,
+		WorldWidth:   g.level.Width,
+		WorldHeight:  g.level.Height,
+		Objects:      g.Objects,
+		Players:      append(g.revealedPlayers(), hud.MinimapPlayer{X: g.player.X, Y: g.player.Y, Local: true}),
+		Pings:        g.pings.Active(g.now()),
+		Supplies:     g.supplies(),
+		Blips:        g.radar.Active(g.now()),
+		Controls:     g.app.input.Prompts(),
+		Abilities:    g.abilityHUD(),
+		TPS:          ebiten.ActualTPS(),
+		FPS:          ebiten.ActualFPS(),
+	}
+	if g.app.cfg.HUD.FrameTimes {
+		state.FrameTimes = g.frames.Average()
+	}
+	state.ServerRate, state.SnapshotInterval = g.clock.Rate, g.clock.Interval()
+	if g.app.cfg.HUD.RejectedHits {
+		state.Hits = &g.hits.Stats
+	}
+	if g.rules.Mode == match.Practice {
+		stats := g.scores.Stats(g.player.ID)
+		state.Practice = &stats
+		state.WeaponID = g.player.Weapon
+	}
+	var objectives []string
+	for _, text := range []string{g.gunGameObjective(), g.roundObjective(), g.economyObjective(), g.pollObjective()} {
+		if text != "" {
+			objectives = append(objectives, text)
+		}
+	}
+	if z, ok := g.zoneState(); ok {
+		state.Zone = &z
+		objectives = append(objectives, zoneObjective(z))
+	}
+	state.Objective = strings.Join(objectives, "  |  ")
+	return state
+}
+
+// drawWorld draws the world as seen by viewer, which is the local player unless the killcam is playing.
+func (g *Game) drawWorld(screen *ebiten.Image, viewer *player.Player, others map[string]*player.Player) {
 	// TODO: separate player package for logic and ui
+	screen.Clear()
 	shadowImage.Fill(color.Black)
 
-	rays := g.castRays(g.player.X, g.player.Y, g.Objects)
-
 	opts := &ebiten.DrawTrianglesOptions{}
 	opts.Address = ebiten.AddressRepeat
 	opts.Blend = ebiten.BlendDestinationOut
 
-	screen.DrawImage(bgImage, nil)
+	// Simulation goes on for everything, drawing skips what isn't in view
+	view := g.view()
+	g.drawBackground(screen, view)
+	g.drawFilledObstacles(screen)
+	hud.DrawHazards(screen, g.level.Hazards, g.now(), view)
 
-	for _, bullet := range g.player.Bullets {
-		// vector.DrawFilledCircle(screen, float32(bullet.X), float32(bullet.Y), BulletRadius, color.RGBA{0, 255, 255, 255}, false)
-		bullet.Draw(screen)
-	}
-
-	for _, p := range g.players {
+	// Bullets and particles are drawn in one go after the players
+	entitiesStart := time.Now()
+	g.batch.Begin(screen)
+	for _, p := range others {
 		clr := color.RGBA{255, 0, 0, 255}
 		if p.Health <= 0 {
 			clr = color.RGBA{100, 100, 100, 255}
 		}
-		// ebitenutil.DrawCircle(screen, player.X, player.Y, PlayerRadius, clr)
-		p.Draw(screen)
-		vector.DrawFilledCircle(screen, float32(p.X), float32(p.Y), PlayerRadius, clr, false)
-		// ebitenutil.DebugPrintAt(screen, fmt.Sprintf("%s: %d HP", player.ID, player.Health), int(player.X-20), int(player.Y-30))
+		if !p.Hidden && view.Contains(p.X, p.Y, PlayerCullMargin) {
+			// ebitenutil.DrawCircle(screen, player.X, player.Y, PlayerRadius, clr)
+			p.Draw(screen)
+			vector.DrawFilledCircle(screen, float32(p.X), float32(p.Y), PlayerRadius, clr, false)
+		}
+		// Laser may reach into view from outside
+		if !p.Hidden {
+			g.drawLaser(screen, p)
+		}
 
 		for _, bullet := range p.Bullets {
-			bullet.Draw(screen)
+			if !view.ContainsLine(bullet.Line(), 0) {
+				continue
+			}
+			bullet.DrawBatch(g.batch)
 			// vector.DrawFilledCircle(screen, float32(bullet.X), float32(bullet.Y), BulletRadius, color.RGBA{255, 255, 0, 255}, true)
 		}
 	}
-
-	// laser
-	// laserLength := float64(ScreenWidth)
-	// laserEndX := g.player.X + math.Cos(g.player.Angle)*laserLength
-	// laserEndY := g.player.Y + math.Sin(g.player.Angle)*laserLength
-	// ebitenutil.DrawLine(screen, g.player.X, g.player.Y, laserEndX, laserEndY, color.RGBA{255, 0, 0, 255})
-	// vector.StrokeLine(screen, float32(g.player.X), float32(g.player.Y), float32(laserEndX), float32(laserEndY), 1.0, color.RGBA{255, 0, 0, 255}, true)
-
-	for i, ray := range rays {
-		nextLine := rays[(i+1)%len(rays)]
-
-		v := rayVertices(g.player.X, g.player.Y, nextLine.X2, nextLine.Y2, ray.X2, ray.Y2)
-		shadowImage.DrawTriangles(v, []uint16{0, 1, 2}, triangleImage, opts)
+	g.particles.Draw(g.batch, view)
+	g.batch.End()
+	g.drawGrenades(screen)
+	g.drawEntities(screen, view)
+	g.drawRopes(screen)
+	g.frames.Since(frametime.Entities, entitiesStart)
+
+	bars := g.healthBars(viewer, others)
+	hud.DrawHealthBars(screen, bars, HealthBarRules, view)
+	g.feedback.DrawWorld(screen, view)
+
+	// Observers see everything, there is no fog of war or local player for them
+	pings := g.pings.Active(g.now())
+	if g.observer {
+		g.nameTags.Draw(screen, bars, view)
+		hud.DrawPings(screen, pings, view)
+		g.drawObstacles(screen)
+		g.drawZone(screen)
+		return
 	}
+	sight := lighting.DefaultView.Scaled(g.lighting.Ambient).Lights(viewer.X, viewer.Y)
+	if len(g.visibility) < len(sight) {
+		g.visibility = make([]game.Visibility, len(sight))
+	}
+	g.drawLights(opts, sight, g.visibility)
+	g.drawLights(opts, g.lights.Active(), nil)
+	g.drawLights(opts, g.flashlights(viewer, others), nil)
 
 	// NOTE: dispplay ray casting
 	// for _, ray := range rays {
@@ -274,194 +1115,714 @@ func (g *Game) Draw(screen *ebiten.Image) {
 
 	op := &ebiten.DrawImageOptions{}
 	screen.DrawImage(shadowImage, op)
+	// Tags fade on their own when out of sight, the shadow would hide them at once
+	g.nameTags.Draw(screen, bars, view)
+	hud.DrawPings(screen, pings, view)
+
+	g.drawObstacles(screen)
+	g.drawZone(screen)
+
+	// Draw player
+	viewer.Draw(screen)
+	g.drawLaser(screen, viewer)
+	g.batch.Begin(screen)
+	for _, b := range viewer.Bullets {
+		if view.ContainsLine(b.Line(), 0) {
+			b.DrawBatch(g.batch)
+		}
+	}
+	g.batch.End()
+	if g.aimingGrenade && viewer == g.player {
+		hud.DrawGrenadeArc(screen, grenade.Simulate(g.grenadeThrow().State(), g.Objects), sim.BlastRadius)
+	}
+}
 
-	// Draw obstacles
+// drawObstacles outlines the obstacles which aren't filled, see drawFilledObstacles.
+func (g *Game) drawObstacles(screen *ebiten.Image) {
 	for _, obs := range g.Objects {
+		if filled(obs) {
+			continue
+		}
 		for _, w := range obs.Walls {
 			vector.StrokeLine(screen, float32(w.X1), float32(w.Y1), float32(w.X2), float32(w.Y2), 1, color.RGBA{255, 0, 0, 255}, true)
 		}
 	}
+}
 
-	// Draw player
-	g.player.Draw(screen)
-	for _, b := range g.player.Bullets {
-		b.Draw(screen)
+// view returns the part of the world which ends up on screen. The world image is
+// the whole level for now, this is what moves once the camera follows the player.
+func (g *Game) view() game.Bounds {
+	return game.Bounds{MaxX: ScreenWidth, MaxY: ScreenHeight}
+}
+
+// cursorWorld returns the mouse cursor in world coordinates.
+func (g *Game) cursorWorld() (float64, float64) {
+	cx, cy := ebiten.CursorPosition()
+	return g.camera.ToWorld(g.app.viewport.ToWorld(float64(cx), float64(cy)))
+}
+
+// toScreen places a point of the world on the screen, through the camera zoom.
+func (g *Game) toScreen(x, y float64) (float64, float64) {
+	return g.app.viewport.ToScreen(g.camera.ToScreen(x, y))
+}
+
+// focusCamera zooms in ahead of the player while aiming down sights.
+func (g *Game) focusCamera() {
+	zoom := 1.0
+	if g.player.Aiming && g.player.Health > 0 && g.overlay == nil {
+		zoom = ADSZoom
+		if w := g.player.CurrentWeapon(); w.Zoom > 0 {
+			zoom = w.Zoom
+		}
 	}
+	g.camera.Focus(zoom, g.player.X+math.Cos(g.player.Angle)*ADSLookAhead, g.player.Y+math.Sin(g.player.Angle)*ADSLookAhead)
 }
 
-func (g *Game) Layout(_, _ int) (int, int) {
-	return ScreenWidth, ScreenHeight
+// drawLaser draws the laser sight up to the first wall it hits.
+func (g *Game) drawLaser(screen *ebiten.Image, p *player.Player) {
+	if !p.HasLaser() {
+		return
+	}
+	mx, my := p.MuzzlePosition()
+	ex := mx + math.Cos(p.Angle)*player.LaserLength
+	ey := my + math.Sin(p.Angle)*player.LaserLength
+	if ray, ok := castRay(mx, my, p.Angle, g.Objects); ok && distance(mx, my, ray.X2, ray.Y2) < player.LaserLength {
+		ex, ey = ray.X2, ray.Y2
+	}
+	p.DrawLaser(screen, ex, ey)
 }
 
+// drawCrosshair draws the crosshair at the cursor, hidden while dead or in menus.
+func (g *Game) drawCrosshair(screen *ebiten.Image) {
+	if g.player.Health <= 0 || g.overlay != nil {
+		return
+	}
+	cx, cy := g.toScreen(g.input.CrosshairX, g.input.CrosshairY)
+	mx, my := g.toScreen(g.player.MuzzlePosition())
+	d := distance(mx, my, cx, cy)
+	hud.DrawCrosshair(screen, &g.app.cfg.HUD.Crosshair, cx, cy, g.player.Spread(), d, g.aimTrace())
+}
+
+// drawLights brightens the shadow mask around lights, occluded by the level geometry.
+// Omnidirectional lights with a Visibility in cache keep their rays until they or a wall move.
+func (g *Game) drawLights(opts *ebiten.DrawTrianglesOptions, lights []lighting.Light, cache []game.Visibility) {
+	var vertices []ebiten.Vertex
+	var indices []uint16
+	for i, light := range lights {
+		start := time.Now()
+		var rays []game.Line
+		switch {
+		case light.Cone > 0:
+			rays = g.coneRays(light)
+		case i < len(cache):
+			rays = cache[i].Cast(light.X, light.Y, rayLength, g.Objects)
+		default:
+			rays = g.castRays(light.X, light.Y, g.Objects)
+		}
+		cast := time.Now()
+		g.frames.Add(frametime.Raycasting, cast.Sub(start))
+		vertices, indices = lighting.Vertices(light, rays, vertices[:0], indices[:0])
+		shadowImage.DrawTriangles(vertices, indices, triangleImage, opts)
+		g.frames.Since(frametime.Shadows, cast)
+	}
+}
+
+func (g *Game) flashlights(viewer *player.Player, others map[string]*player.Player) []lighting.Light {
+	lights := []lighting.Light{}
+	if viewer.Flashlight && viewer.Health > 0 {
+		lights = append(lights, flashlight(viewer))
+	}
+	for _, p := range others {
+		if p.Flashlight && p.Health > 0 && !p.Hidden {
+			lights = append(lights, flashlight(p))
+		}
+	}
+	return lights
+}
+
+// flashlight narrows while aiming down sights, which also shows others who is aiming.
+func flashlight(p *player.Player) lighting.Light {
+	if p.Aiming {
+		return lighting.AimedFlashlight(p.X, p.Y, p.Angle)
+	}
+	return lighting.Flashlight(p.X, p.Y, p.Angle)
+}
+
+// sendPlayerUpdate sends at most SendRate updates a second, and only a keepalive while nothing changes.
 func (g *Game) sendPlayerUpdate() {
+	rate := max(1, g.app.cfg.Network.SendRate)
+	if g.now().Sub(g.lastSend) < time.Second/time.Duration(rate) {
+		return
+	}
+
 	update := PlayerUpdate{
-		ID:      g.player.ID,
-		X:       g.player.X,
-		Y:       g.player.Y,
-		Angle:   g.player.Angle,
-		Health:  g.player.Health,
-		Bullets: g.player.Bullets,
+		ID:     g.player.ID,
+		X:      g.player.X,
+		Y:      g.player.Y,
+		Angle:  g.player.Angle,
+		Health: g.player.Health,
+		Anim:   g.player.Anim,
+		Weapon: g.player.Weapon,
+		Aiming: g.player.Aiming,
+
+		Attachment: g.player.CurrentWeapon().Attachment,
+		Flashlight: g.player.Flashlight,
+		Team:       g.player.Team,
+		Skin:       g.player.Skin,
+		Seed:       g.player.Seed,
+		VX:         g.velX,
+		VY:         g.velY,
+	}
+	message, err := encodeEvent(player.EventTypePlayerUpdate, update)
+	if err != nil {
+		log.Println("Error marshaling event:", err)
+		return
+	}
+	if bytes.Equal(message, g.lastUpdate) && g.now().Sub(g.lastSend) < KeepaliveInterval {
+		return
+	}
+	g.lastSend, g.lastUpdate = g.now(), message
+	if _, err := g.conn.Write(message); err != nil {
+		log.Println("Error sending event:", err)
 	}
-	g.sendEvent(player.EventTypePlayerUpdate, update)
 }
 
 func (g *Game) sendEvent(eventType player.EventType, data interface{}) {
+	defer g.frames.Since(frametime.Network, time.Now())
 	// TODO: player creates events, which games sends
-	event := player.Event{Type: eventType}
-	eventData, err := json.Marshal(data)
+	message, err := encodeEvent(eventType, data)
 	if err != nil {
-		log.Println("Error marshaling event data:", err)
+		log.Println("Error marshaling event:", err)
 		return
 	}
-	event.Data = eventData
 
-	message, err := json.Marshal(event)
+	if _, err := g.conn.Write(message); err != nil {
+		log.Println("Error sending event:", err)
+	}
+}
+
+// encodeEvent returns the event as a newline terminated message.
+func encodeEvent(eventType player.EventType, data interface{}) ([]byte, error) {
+	eventData, err := json.Marshal(data)
 	if err != nil {
-		log.Println("Error marshaling event:", err)
-		return
+		return nil, err
 	}
 
-	if _, err := g.conn.Write(append(message, '\n')); err != nil {
-		log.Println("Error sending event:", err)
+	message, err := json.Marshal(player.Event{Type: eventType, Data: eventData})
+	if err != nil {
+		return nil, err
+	}
+	if len(message) > wire.MaxSize {
+		return nil, wire.ErrTooLarge
 	}
+	return wire.Frame(message), nil
 }
 
 func (g *Game) listenForUpdates() {
-	reader := bufio.NewReader(g.conn)
+	defer reporter.Recover("game network", func(path string) { g.crashed.Store(&path) })
 	for {
-		msg, err := reader.ReadString('\n')
+		msg, err := g.reader.ReadString()
 		if err != nil {
 			log.Println("Connection lost:", err)
+			g.mu.Lock()
+			if g.disconnected == "" && errors.Is(err, wire.ErrTooLarge) {
+				g.disconnected = "Connection lost: protocol error"
+			}
+			if g.disconnected == "" {
+				g.disconnected = "Connection lost"
+			}
+			g.mu.Unlock()
 			return
 		}
 
-		var event player.Event
-		if err := json.Unmarshal([]byte(msg), &event); err != nil {
-			log.Println("Error unmarshaling event:", err)
+		if g.inputLog != nil {
+			// Applied at the start of a tick by Update, like when it's replayed
+			g.inputLog.inbox <- msg
 			continue
 		}
+		if !g.handleMessage(msg) {
+			return
+		}
+	}
+}
 
-		switch event.Type {
-		case player.EventTypePlayerUpdate:
-			var update PlayerUpdate
-			if err := json.Unmarshal(event.Data, &update); err != nil {
-				log.Println("Error unmarshaling PlayerUpdate:", err)
-				continue
-			}
+// handleMessage applies a message of the server to the game, false once the server is gone.
+func (g *Game) handleMessage(msg string) bool {
+	var event player.Event
+	if err := json.Unmarshal([]byte(msg), &event); err != nil {
+		log.Println("Error unmarshaling event:", err)
+		return true
+	}
 
-			if update.ID == g.player.ID {
-				continue // Skip self updates
-			}
+	switch event.Type {
+	case player.EventTypePlayerUpdate:
+		var update PlayerUpdate
+		if err := json.Unmarshal(event.Data, &update); err != nil {
+			log.Println("Error unmarshaling PlayerUpdate:", err)
+			return true
+		}
 
-			g.mu.Lock()
-			p, exists := g.players[update.ID]
-			if !exists {
-				p = player.NewPlayer(update.ID, update.X, update.Y)
-				g.players[update.ID] = p
-			}
-			p.X = update.X
-			p.Y = update.Y
-			p.Angle = update.Angle
-			p.Health = update.Health
-			p.Bullets = update.Bullets
-			g.mu.Unlock()
+		if update.ID == g.player.ID {
+			return true // Skip self updates
+		}
 
-		case player.EventTypePlayerHit:
-			var hit PlayerHit
-			if err := json.Unmarshal(event.Data, &hit); err != nil {
-				log.Println("Error unmarshaling PlayerHit:", err)
-				continue
-			}
+		g.mu.Lock()
+		p, exists := g.players[update.ID]
+		if !exists {
+			p = player.NewPlayer(update.ID, update.X, update.Y)
+			g.players[update.ID] = p
+		}
+		if p.Stepped(distance(p.X, p.Y, update.X, update.Y)) {
+			g.audio.PlayAt(audio.SoundFootstep, update.X, update.Y)
+		}
+		if p.Health > 0 && update.Health <= 0 {
+			g.audio.PlayAt(audio.SoundDeath, update.X, update.Y)
+		}
+		p.Hidden = false
+		p.X = update.X
+		p.Y = update.Y
+		p.Angle = update.Angle
+		p.Health = update.Health
+		p.Anim = update.Anim
+		p.Weapon = update.Weapon
+		p.SetAttachment(update.Weapon, update.Attachment)
+		p.Aiming = update.Aiming
+		p.Flashlight = update.Flashlight
+		p.Team = update.Team
+		p.Skin = update.Skin
+		p.Seed = update.Seed
+		g.trackRemote(update, g.now())
+		g.scores.Join(update.ID)
+		if update.Bot {
+			g.scores.JoinBot(update.ID)
+		}
+		g.mu.Unlock()
 
-			g.mu.Lock()
-			if player, exists := g.players[hit.VictimID]; exists {
-				player.Health -= hit.Damage
-				if player.Health < 0 {
-					player.Health = 0
-				}
+	case player.EventTypePlayerKilled:
+		var kill PlayerKilled
+		if err := json.Unmarshal(event.Data, &kill); err != nil {
+			log.Println("Error unmarshaling PlayerKilled:", err)
+			return true
+		}
+		g.mu.Lock()
+		g.scores.Kill(kill.KillerID, kill.VictimID)
+		for _, id := range kill.Assists {
+			g.scores.Assist(id)
+		}
+		g.killfeed.Add(kill.KillerID, kill.VictimID, weapon.Get(kill.Weapon).Name, kill.Assists...)
+		g.eliminated(kill)
+		g.mu.Unlock()
+
+	case player.EventTypePlayerHit:
+		var hit PlayerHit
+		if err := json.Unmarshal(event.Data, &hit); err != nil {
+			log.Println("Error unmarshaling PlayerHit:", err)
+			return true
+		}
+
+		g.mu.Lock()
+		if hit.AttackerID != "" {
+			g.scores.Hit(hit.AttackerID, hit.Weapon, hit.Damage, hit.Headshot)
+		}
+		if victim, exists := g.players[hit.VictimID]; exists {
+			wasAlive := victim.Health > 0
+			victim.Health = max(0, victim.Health-hit.Damage)
+			if hit.AttackerID == g.player.ID {
+				g.confirmHit(victim)
+				g.feedback.DamageDealt(victim.X, victim.Y, hit.Damage)
 			}
-			if hit.VictimID == g.player.ID {
-				g.player.Health -= hit.Damage
+			if wasAlive && victim.Health == 0 {
+				g.audio.PlayAt(audio.SoundDeath, victim.X, victim.Y)
 			}
-			g.mu.Unlock()
 		}
-	}
-}
-
-func startServer() {
-	listener, err := net.Listen("tcp", ServerPort)
-	if err != nil {
-		log.Fatal("Failed to start server:", err)
-	}
-	defer listener.Close()
-	log.Println("Server running on", ServerPort)
-
-	clients := make(map[net.Conn]bool)
-	var mu sync.Mutex
+		if hit.VictimID == g.player.ID {
+			wasAlive := g.player.Health > 0
+			g.player.Health -= hit.Damage
+			g.lastCombat = g.now()
+			g.camera.AddTrauma(HitTrauma)
+			g.audio.Play(audio.SoundHit)
+			if attacker, ok := g.players[hit.AttackerID]; ok {
+				g.feedback.DamageTaken(math.Atan2(attacker.Y-g.player.Y, attacker.X-g.player.X))
+			}
+			if wasAlive && g.player.Health <= 0 && g.summary == nil {
+				g.died(hit)
+				g.audio.Play(audio.SoundDeath)
+			}
+		}
+		g.mu.Unlock()
 
-	for {
-		conn, err := listener.Accept()
-		if err != nil {
-			log.Println("Connection error:", err)
-			continue
+	case player.EventTypeBulletSpawn:
+		var shot Shoot
+		if err := json.Unmarshal(event.Data, &shot); err != nil {
+			log.Println("Error unmarshaling bullet spawn:", err)
+			return true
+		}
+		g.mu.Lock()
+		g.spawnBullets(shot.Bullets)
+		g.mu.Unlock()
+
+	case player.EventTypeBulletDestroy:
+		var destroy BulletDestroy
+		if err := json.Unmarshal(event.Data, &destroy); err != nil {
+			log.Println("Error unmarshaling bullet destroy:", err)
+			return true
+		}
+		g.mu.Lock()
+		g.destroyBullet(destroy)
+		g.mu.Unlock()
+
+	case player.EventTypeServerShutdown:
+		var shutdown ServerShutdown
+		if err := json.Unmarshal(event.Data, &shutdown); err != nil {
+			log.Println("Error unmarshaling ServerShutdown:", err)
+			return true
+		}
+		g.mu.Lock()
+		g.disconnected = shutdown.Reason
+		g.mu.Unlock()
+		return false
+
+	case player.EventTypeMatchEnd:
+		var summary match.Summary
+		if err := json.Unmarshal(event.Data, &summary); err != nil {
+			log.Println("Error unmarshaling match summary:", err)
+			return true
+		}
+		g.mu.Lock()
+		g.summary = &summary
+		g.votes = match.Vote{}
+		if !g.observer {
+			recordCareer(g.player.ID, summary)
 		}
+		g.mu.Unlock()
 
-		mu.Lock()
-		clients[conn] = true
-		mu.Unlock()
-
-		go func(c net.Conn) {
-			reader := bufio.NewReader(c)
-			for {
-				msg, err := reader.ReadString('\n')
-				if err != nil {
-					log.Println("Client disconnected:", err)
-					mu.Lock()
-					delete(clients, c)
-					mu.Unlock()
-					return
-				}
+	case player.EventTypePing:
+		var p Ping
+		if err := json.Unmarshal(event.Data, &p); err != nil {
+			log.Println("Error unmarshaling Ping:", err)
+			return true
+		}
+		g.mu.Lock()
+		g.addPing(p)
+		g.mu.Unlock()
+
+	case player.EventTypeGrenade:
+		var throw GrenadeThrow
+		if err := json.Unmarshal(event.Data, &throw); err != nil {
+			log.Println("Error unmarshaling GrenadeThrow:", err)
+			return true
+		}
+		g.mu.Lock()
+		g.addGrenade(throw)
+		g.mu.Unlock()
+
+	case player.EventTypeEntities:
+		var entities []*sim.Entity
+		if err := json.Unmarshal(event.Data, &entities); err != nil {
+			log.Println("Error unmarshaling entities:", err)
+			return true
+		}
+		g.mu.Lock()
+		g.setEntities(entities)
+		g.mu.Unlock()
+
+	case player.EventTypeEntityPlaced:
+		var e sim.Entity
+		if err := json.Unmarshal(event.Data, &e); err != nil {
+			log.Println("Error unmarshaling entity:", err)
+			return true
+		}
+		g.mu.Lock()
+		g.placeEntity(&e)
+		g.mu.Unlock()
+
+	case player.EventTypeEntityRemoved:
+		var removed EntityRemoved
+		if err := json.Unmarshal(event.Data, &removed); err != nil {
+			log.Println("Error unmarshaling EntityRemoved:", err)
+			return true
+		}
+		g.mu.Lock()
+		g.removeEntity(removed)
+		g.mu.Unlock()
+
+	case player.EventTypeHidden:
+		var hidden Hidden
+		if err := json.Unmarshal(event.Data, &hidden); err != nil {
+			log.Println("Error unmarshaling Hidden:", err)
+			return true
+		}
+		g.mu.Lock()
+		if p, ok := g.players[hidden.ID]; ok {
+			p.Hidden = true
+		}
+		g.mu.Unlock()
 
-				mu.Lock()
-				for client := range clients {
-					if client != c {
-						if _, writeErr := client.Write([]byte(msg)); writeErr != nil {
-							log.Println("Error sending update to client:", writeErr)
-						}
-					}
-				}
-				mu.Unlock()
-			}
-		}(conn)
+	case player.EventTypeWeaponData:
+		var data WeaponData
+		if err := json.Unmarshal(event.Data, &data); err != nil {
+			log.Println("Error unmarshaling WeaponData:", err)
+			return true
+		}
+		g.mu.Lock()
+		g.verifyWeapons(data.Hash)
+		g.mu.Unlock()
+
+	case player.EventTypePickup:
+		var p Pickup
+		if err := json.Unmarshal(event.Data, &p); err != nil {
+			log.Println("Error unmarshaling Pickup:", err)
+			return true
+		}
+		g.mu.Lock()
+		g.pickUp(p)
+		g.mu.Unlock()
+
+	case player.EventTypeNoise:
+		var noise Noise
+		if err := json.Unmarshal(event.Data, &noise); err != nil {
+			log.Println("Error unmarshaling Noise:", err)
+			return true
+		}
+		g.mu.Lock()
+		g.hearGunfire(noise)
+		g.mu.Unlock()
+
+	case player.EventTypeZone:
+		var u ZoneUpdate
+		if err := json.Unmarshal(event.Data, &u); err != nil {
+			log.Println("Error unmarshaling ZoneUpdate:", err)
+			return true
+		}
+		g.mu.Lock()
+		g.setZone(u)
+		g.mu.Unlock()
+
+	case player.EventTypeGunGame:
+		var progress GunGameProgress
+		if err := json.Unmarshal(event.Data, &progress); err != nil {
+			log.Println("Error unmarshaling GunGameProgress:", err)
+			return true
+		}
+		g.mu.Lock()
+		g.setProgress(progress)
+		g.mu.Unlock()
+
+	case player.EventTypeRound:
+		var round Round
+		if err := json.Unmarshal(event.Data, &round); err != nil {
+			log.Println("Error unmarshaling Round:", err)
+			return true
+		}
+		g.mu.Lock()
+		g.setRound(round)
+		g.mu.Unlock()
+
+	case player.EventTypeWallet:
+		var u WalletUpdate
+		if err := json.Unmarshal(event.Data, &u); err != nil {
+			log.Println("Error unmarshaling WalletUpdate:", err)
+			return true
+		}
+		g.mu.Lock()
+		g.setWallet(u)
+		g.mu.Unlock()
+
+	case player.EventTypeAbility:
+		var use UseAbility
+		if err := json.Unmarshal(event.Data, &use); err != nil {
+			log.Println("Error unmarshaling UseAbility:", err)
+			return true
+		}
+		g.mu.Lock()
+		g.abilityUsed(use)
+		g.mu.Unlock()
+
+	case player.EventTypeAbilities:
+		var s AbilityStatus
+		if err := json.Unmarshal(event.Data, &s); err != nil {
+			log.Println("Error unmarshaling AbilityStatus:", err)
+			return true
+		}
+		g.mu.Lock()
+		g.setAbilities(s)
+		g.mu.Unlock()
+
+	case player.EventTypeScan:
+		var scan Scan
+		if err := json.Unmarshal(event.Data, &scan); err != nil {
+			log.Println("Error unmarshaling Scan:", err)
+			return true
+		}
+		g.mu.Lock()
+		g.setScan(scan)
+		g.mu.Unlock()
+
+	case player.EventTypeMapEvent:
+		var e script.Event
+		if err := json.Unmarshal(event.Data, &e); err != nil {
+			log.Println("Error unmarshaling map event:", err)
+			return true
+		}
+		g.mu.Lock()
+		g.mapEvent(e)
+		g.mu.Unlock()
+
+	case player.EventTypeChangeMap:
+		var change ChangeMap
+		if err := json.Unmarshal(event.Data, &change); err != nil {
+			log.Println("Error unmarshaling ChangeMap:", err)
+			return true
+		}
+		g.mu.Lock()
+		g.verifyMap(change.Map, change.MapHash)
+		g.changeMap(change)
+		g.mu.Unlock()
+
+	case player.EventTypeMapData:
+		var data MapData
+		if err := json.Unmarshal(event.Data, &data); err != nil {
+			log.Println("Error unmarshaling MapData:", err)
+			return true
+		}
+		g.mu.Lock()
+		g.setMapData(data)
+		g.mu.Unlock()
+
+	case player.EventTypePoll:
+		var poll PollStatus
+		if err := json.Unmarshal(event.Data, &poll); err != nil {
+			log.Println("Error unmarshaling PollStatus:", err)
+			return true
+		}
+		g.mu.Lock()
+		g.setPoll(poll)
+		g.mu.Unlock()
+
+	case player.EventTypeMapVote:
+		var vote MapVote
+		if err := json.Unmarshal(event.Data, &vote); err != nil {
+			log.Println("Error unmarshaling MapVote:", err)
+			return true
+		}
+		g.mu.Lock()
+		g.votes.Cast(vote.PlayerID, vote.Map)
+		g.mu.Unlock()
+
+	case player.EventTypeMoveCorrection:
+		var correction MoveCorrection
+		if err := json.Unmarshal(event.Data, &correction); err != nil {
+			log.Println("Error unmarshaling MoveCorrection:", err)
+			return true
+		}
+		g.mu.Lock()
+		g.player.X, g.player.Y = correction.X, correction.Y
+		g.mu.Unlock()
+
+	case player.EventTypeSeed:
+		var seed Seed
+		if err := json.Unmarshal(event.Data, &seed); err != nil {
+			log.Println("Error unmarshaling Seed:", err)
+			return true
+		}
+		g.mu.Lock()
+		g.player.Seed = seed.Seed
+		g.mu.Unlock()
+
+	case player.EventTypeTick:
+		var tick Tick
+		if err := json.Unmarshal(event.Data, &tick); err != nil {
+			log.Println("Error unmarshaling Tick:", err)
+			return true
+		}
+		g.mu.Lock()
+		g.clock.Snapshot(tick.Tick, tick.Time, tick.Rate, g.now())
+		g.mu.Unlock()
+
+	case player.EventTypeMatchStart:
+		var start MatchStart
+		if err := json.Unmarshal(event.Data, &start); err != nil {
+			log.Println("Error unmarshaling MatchStart:", err)
+			return true
+		}
+		g.mu.Lock()
+		g.verifyMap(start.Map, start.MapHash)
+		// Respawned by startMatch with the new rules
+		g.setRules(start.Rules)
+		g.startMatch(start.Map)
+		g.mu.Unlock()
+
+	default:
+		if !g.unknownEvents[event.Type] {
+			log.Println("Ignoring unknown event type:", event.Type)
+			g.unknownEvents[event.Type] = true
+		}
 	}
+	return true
 }
 
-const padding = 20
-
-func main() {
-	if len(os.Args) > 1 && os.Args[1] == "server" {
-		startServer()
-		return
+// sayHello sends hello and returns the server's welcome with the player ID it assigned.
+func sayHello(conn net.Conn, reader *wire.Reader, hello Hello) (Welcome, error) {
+	hello.Version = player.ProtocolVersion
+	message, err := encodeEvent(player.EventTypeHello, hello)
+	if err != nil {
+		return Welcome{}, err
 	}
-
-	if len(os.Args) < 3 {
-		fmt.Println("Usage: go run main.go <player_id> <server_ip:port>")
-		return
+	if _, err := conn.Write(message); err != nil {
+		return Welcome{}, err
 	}
 
-	playerID := os.Args[1]
-	serverAddr := os.Args[2]
+	conn.SetReadDeadline(time.Now().Add(HandshakeTimeout))
+	defer conn.SetReadDeadline(time.Time{})
+	msg, err := reader.ReadString()
+	if err != nil {
+		return Welcome{}, err
+	}
+	var event player.Event
+	if err := json.Unmarshal([]byte(msg), &event); err != nil {
+		return Welcome{}, err
+	}
+	if event.Type == player.EventTypeReject {
+		var reject Reject
+		if err := json.Unmarshal(event.Data, &reject); err != nil {
+			return Welcome{}, err
+		}
+		return Welcome{}, &RejectedError{Reason: reject.Reason}
+	}
+	if event.Type != player.EventTypeWelcome {
+		return Welcome{}, fmt.Errorf("unexpected %q from server", event.Type)
+	}
+	var welcome Welcome
+	err = json.Unmarshal(event.Data, &welcome)
+	return welcome, err
+}
 
+func NewGame(app *App, hello Hello, serverAddr string, lvl *level.Level) (*Game, error) {
 	conn, err := net.Dial("tcp", serverAddr)
 	if err != nil {
-		log.Fatal("Failed to connect to server:", err)
+		return nil, err
+	}
+	conn = netsim.Wrap(conn, fakeNet)
+	reader := wire.NewReader(conn)
+	welcome, err := sayHello(conn, reader, hello)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if welcome.ID != hello.Name {
+		log.Printf("Name %q is taken, playing as %q", hello.Name, welcome.ID)
 	}
-	defer conn.Close()
-
-	bgImage, _, _ = ebitenutil.NewImageFromFile("./aa.png")
 
-	triangleImage.Fill(color.White)
+	g := joinedGame(app, hello, welcome, lvl, conn, reader)
+	if recordInput != "" {
+		g.recordInput(hello, welcome)
+	}
+	go g.listenForUpdates()
+	return g, nil
+}
 
+// joinedGame sets up the game of a client the server welcomed on conn.
+func joinedGame(app *App, hello Hello, welcome Welcome, lvl *level.Level, conn net.Conn, reader *wire.Reader) *Game {
 	npcs := map[string]*player.Player{
 		"111": player.NewPlayer("111", 900, 700),
 		"112": player.NewPlayer("112", 900, 750),
@@ -472,34 +1833,170 @@ func main() {
 		// "444": player.NewPlayer("444", 1300, 300),
 	}
 
-	g := &Game{
-		player: player.NewPlayer(playerID, ScreenWidth/2, ScreenHeight/2),
-		// players:   make(map[string]*player.Player),
-		players:   npcs,
-		obstacles: []*Obstacle{},
-		Objects: []game.Object{{
-			Walls: game.Rect(
-				padding,
-				padding,
-				ScreenWidth-2*padding,
-				ScreenHeight-2*padding,
-			),
-		}, {
-			Walls: game.Rect(
-				ScreenWidth/2-50,
-				ScreenHeight/2+50,
-				100, 100,
-			),
-		}},
-		conn: conn,
-		mu:   sync.Mutex{},
+	// Play the server's map, downloaded by verifyMap when it isn't the same as ours
+	if l, ok := level.Get(welcome.Map); ok {
+		lvl = l
+	}
+	g := newGame(app, welcome.ID, lvl)
+	g.player.Skin = app.cfg.Player.Skin
+	for id, a := range app.cfg.Player.Attachments {
+		g.player.SetAttachment(weapon.ID(id), weapon.Attachment(a))
+	}
+	g.setRules(welcome.Rules)
+	g.player.Health = g.player.FullHealth()
+	g.player.Team = welcome.Team
+	g.player.Seed = welcome.Seed
+	g.kit = ability.KitOf(hello.Class)
+	if hello.Observer {
+		g.observer = true
+		g.hud = newObserverHUD(g.killfeed)
+	} else {
+		g.players = npcs
 	}
+	g.conn, g.reader = conn, reader
+	g.verifyMap(welcome.Map, welcome.MapHash)
+	g.verifyWeapons(welcome.WeaponsHash)
+	return g
+}
 
-	go g.listenForUpdates()
+// newGame sets up a game without a server connection.
+func newGame(app *App, playerID string, lvl *level.Level) *Game {
+	killfeed := &hud.Killfeed{}
+	return &Game{
+		player:        player.NewPlayer(playerID, ScreenWidth/2, ScreenHeight/2),
+		players:       make(map[string]*player.Player),
+		obstacles:     []*Obstacle{},
+		level:         lvl,
+		lighting:      lvl.Lighting,
+		Objects:       lvl.Objects,
+		background:    newBackground(lvl),
+		unknownEvents: map[player.EventType]bool{},
+		tracks:        map[string]*interp.Track{},
+		mu:            sync.Mutex{},
+		audio:         app.audio,
+		music:         app.music,
+		feedback:      hud.NewFeedback(&app.cfg.HUD),
+		particles:     effects.NewSystem(),
+		batch:         batch.New(batch.NewAtlas(AtlasSize)),
+		camera:        camera.New(&app.cfg.Video),
+		lights:        lighting.NewLights(),
+		killfeed:      killfeed,
+		nameTags:      hud.NewNameTags(),
+		hud:           newHUD(killfeed),
+		app:           app,
+		replay:        &killcam.Replay{Map: lvl.Name, Player: playerID},
+		scores:        match.NewTracker(lvl.Name, time.Now()),
+	}
+}
+
+// Set by --fake-lag, --fake-jitter and --fake-loss
+var fakeNet netsim.Options
+
+// parseNetFlags removes the fake network flags from args and sets fakeNet from them.
+func parseNetFlags(args []string) []string {
+	rest := args[:0:0]
+	for i := 0; i < len(args); i++ {
+		var dst func(n float64)
+		switch args[i] {
+		case "--fake-lag":
+			dst = func(n float64) { fakeNet.Lag = time.Duration(n * float64(time.Millisecond)) }
+		case "--fake-jitter":
+			dst = func(n float64) { fakeNet.Jitter = time.Duration(n * float64(time.Millisecond)) }
+		case "--fake-loss":
+			dst = func(n float64) { fakeNet.Loss = n / 100 }
+		default:
+			rest = append(rest, args[i])
+			continue
+		}
+		if i+1 >= len(args) {
+			log.Fatalf("Missing value for %s", args[i])
+		}
+		n, err := strconv.ParseFloat(args[i+1], 64)
+		if err != nil {
+			log.Fatalf("Invalid value for %s: %s", args[i], args[i+1])
+		}
+		dst(n)
+		i++
+	}
+	if fakeNet.Enabled() {
+		log.Printf("Faking network: %v lag, %v jitter, %.0f%% loss", fakeNet.Lag, fakeNet.Jitter, fakeNet.Loss*100)
+	}
+	return rest
+}
+
+func loadImages() {
+	bgImage = utils.Image("assets/background.png", ScreenWidth, ScreenHeight)
+	triangleImage.Fill(color.White)
+}
+
+func main() {
+	os.Args = parseNetFlags(os.Args)
+	os.Args = parseInputFlags(os.Args)
+	loadWeaponData()
+	if len(os.Args) > 1 && os.Args[1] == "server" {
+		startServer(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "bench" {
+		runBench(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "simulate" {
+		runSimulate(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "validate" {
+		runValidate(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "export" {
+		runExport(os.Args[2:])
+		return
+	}
+
+	if len(os.Args) == 2 || (len(os.Args) > 1 && os.Args[1] == "help") {
+		fmt.Println("Usage: go run main.go [<player_id> <server_ip:port> [map]]")
+		fmt.Println("       go run main.go observe <server_ip:port>")
+		fmt.Println("       go run main.go server [resume]")
+		fmt.Println("       go run main.go bench [bullets]")
+		fmt.Println("       go run main.go simulate [bots] [ticks]")
+		fmt.Println("       go run main.go validate [map or map.json...]")
+		fmt.Println("       go run main.go export <replay> [from] [to] [out.gif]")
+		fmt.Println("Weapon stats are read from", weapon.DataFile, "in the data directory when it's there")
+		fmt.Println("Network testing flags, for any mode: --fake-lag ms --fake-jitter ms --fake-loss percent")
+		fmt.Println("Debugging flags: --record-input file records a game, --replay-input file plays it back")
+		return
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Println("Error loading config, using defaults:", err)
+	}
+
+	loadImages()
+
+	app := NewApp(cfg)
+	setupCrashReports(cfg, app.netState)
+	if replayInput != "" {
+		app.replayInput(replayInput)
+	} else if len(os.Args) >= 3 && os.Args[1] == "observe" {
+		app.join(Hello{Name: cfg.Player.Name, Observer: true}, os.Args[2], level.Default)
+	} else if len(os.Args) >= 3 {
+		// Skip the menu, handy during development
+		levelName := level.Default
+		if len(os.Args) > 3 {
+			levelName = os.Args[3]
+		}
+		app.join(Hello{Name: os.Args[1]}, os.Args[2], levelName)
+	} else {
+		app.showMainMenu("")
+	}
 
-	ebiten.SetWindowSize(ScreenWidth, ScreenHeight)
+	app.applyVideo()
+	app.applyPacing()
+	ebiten.SetWindowResizingMode(ebiten.WindowResizingModeEnabled)
 	ebiten.SetWindowTitle("2D Multiplayer Top-Down Shooter with Obstacles")
-	if err := ebiten.RunGame(g); err != nil {
+	if err := ebiten.RunGame(app); err != nil && err != ebiten.Termination {
 		log.Fatal(err)
 	}
 }
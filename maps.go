@@ -0,0 +1,57 @@
+package main
+
+import (
+	"log"
+
+	"shooter/level"
+	"shooter/player"
+)
+
+// verifyMap asks the server for its map when the one the game has with the name is missing or
+// differs, so both simulate the same walls. The game switches to it once it arrives.
+func (g *Game) verifyMap(name, hash string) {
+	if hash == "" {
+		return
+	}
+	if lvl, ok := g.downloaded[name]; ok && level.Hash(lvl) == hash {
+		return
+	}
+	if lvl, ok := level.Get(name); ok && level.Hash(lvl) == hash {
+		delete(g.downloaded, name)
+		return
+	}
+	log.Printf("Map %s differs from the server's, downloading it", name)
+	g.pendingMap, g.pendingHash = name, hash
+	g.sendEvent(player.EventTypeMapRequest, MapRequest{PlayerID: g.player.ID, Map: name})
+}
+
+// setMapData keeps the map downloaded from the server and plays it, only the one asked for by
+// verifyMap with the hash it was announced with and nothing wrong with it is taken.
+func (g *Game) setMapData(data MapData) {
+	lvl := data.Level
+	if lvl == nil || g.pendingMap == "" || lvl.Name != g.pendingMap {
+		return
+	}
+	if level.Hash(lvl) != g.pendingHash {
+		log.Printf("Map %s from the server isn't the one it announced, keeping ours", lvl.Name)
+		return
+	}
+	if problems := lvl.Validate(); len(problems) > 0 {
+		log.Printf("Map %s from the server is broken, keeping ours: %v", lvl.Name, problems)
+		return
+	}
+	g.pendingMap, g.pendingHash = "", ""
+	if g.downloaded == nil {
+		g.downloaded = map[string]*level.Level{}
+	}
+	g.downloaded[lvl.Name] = lvl
+	g.setLevel(lvl.Name)
+}
+
+// levelNamed returns the map downloaded from the server with the name, or the built-in one.
+func (g *Game) levelNamed(name string) (*level.Level, bool) {
+	if lvl, ok := g.downloaded[name]; ok {
+		return lvl, true
+	}
+	return level.Get(name)
+}
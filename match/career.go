@@ -0,0 +1,86 @@
+package match
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"shooter/weapon"
+)
+
+// CareerFile is where the client keeps the career, in its data directory
+const CareerFile = "stats.json"
+
+// Career is a player's stats over all the matches they finished, kept between runs.
+type Career struct {
+	Matches    int                        `json:"matches"`
+	Wins       int                        `json:"wins"`
+	Kills      int                        `json:"kills"`
+	Assists    int                        `json:"assists"`
+	Deaths     int                        `json:"deaths"`
+	Shots      int                        `json:"shots"`
+	Hits       int                        `json:"hits"`
+	Headshots  int                        `json:"headshots"`
+	Damage     int                        `json:"damage"`
+	BestStreak int                        `json:"best_streak"`
+	Weapons    map[weapon.ID]*WeaponStats `json:"weapons,omitempty"`
+}
+
+// Add counts a finished match.
+func (c *Career) Add(s PlayerStats, won bool) {
+	c.Matches++
+	if won {
+		c.Wins++
+	}
+	c.Kills += s.Kills
+	c.Assists += s.Assists
+	c.Deaths += s.Deaths
+	c.Shots += s.Shots
+	c.Hits += s.Hits
+	c.Headshots += s.Headshots
+	c.Damage += s.Damage
+	c.BestStreak = max(c.BestStreak, s.BestStreak)
+	if len(s.Weapons) > 0 && c.Weapons == nil {
+		c.Weapons = map[weapon.ID]*WeaponStats{}
+	}
+	for id, w := range s.Weapons {
+		total, ok := c.Weapons[id]
+		if !ok {
+			total = &WeaponStats{}
+			c.Weapons[id] = total
+		}
+		total.Shots += w.Shots
+		total.Hits += w.Hits
+		total.Headshots += w.Headshots
+		total.Damage += w.Damage
+	}
+}
+
+func (c *Career) Save(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// LoadCareer reads the career saved at path, an empty one when there is none yet.
+func LoadCareer(path string) (*Career, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &Career{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var c Career
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
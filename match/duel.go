@@ -0,0 +1,44 @@
+package match
+
+import "slices"
+
+// Queue is the order in which players get to duel, the first two are up next.
+type Queue struct {
+	ids []string
+}
+
+// Sync adds players who joined to the back of the queue and drops those who left.
+func (q *Queue) Sync(players []string) {
+	q.ids = slices.DeleteFunc(q.ids, func(id string) bool { return !slices.Contains(players, id) })
+	for _, id := range players {
+		if !slices.Contains(q.ids, id) {
+			q.ids = append(q.ids, id)
+		}
+	}
+}
+
+// Next returns the players of the next duel, fewer than two when there is no one to duel.
+func (q *Queue) Next() []string {
+	return slices.Clone(q.ids[:min(2, len(q.ids))])
+}
+
+// Waiting returns the players after the next duel's, in order.
+func (q *Queue) Waiting() []string {
+	return slices.Clone(q.ids[min(2, len(q.ids)):])
+}
+
+// Result keeps the winner at the front for the next duel and sends the loser to the back.
+// Both duelists go to the back after a draw.
+func (q *Queue) Result(duelists []string, winner string) {
+	for _, id := range duelists {
+		if id == winner {
+			continue
+		}
+		if i := slices.Index(q.ids, id); i >= 0 {
+			q.ids = append(slices.Delete(q.ids, i, i+1), id)
+		}
+	}
+	if i := slices.Index(q.ids, winner); i > 0 {
+		q.ids = slices.Insert(slices.Delete(q.ids, i, i+1), 0, winner)
+	}
+}
@@ -0,0 +1,31 @@
+package match
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestQueue(t *testing.T) {
+	var q Queue
+	q.Sync([]string{"alice", "bob", "carol"})
+	if next := q.Next(); !slices.Equal(next, []string{"alice", "bob"}) {
+		t.Fatalf("Next() = %v", next)
+	}
+
+	q.Result([]string{"alice", "bob"}, "bob")
+	if next := q.Next(); !slices.Equal(next, []string{"bob", "carol"}) {
+		t.Errorf("after bob won, Next() = %v, want bob to stay", next)
+	}
+	if waiting := q.Waiting(); !slices.Equal(waiting, []string{"alice"}) {
+		t.Errorf("Waiting() = %v, want alice at the back", waiting)
+	}
+
+	q.Sync([]string{"alice", "carol", "dave"})
+	if next := q.Next(); !slices.Equal(next, []string{"carol", "alice"}) {
+		t.Errorf("after bob left, Next() = %v", next)
+	}
+	q.Result([]string{"carol", "alice"}, "")
+	if !slices.Equal(q.ids, []string{"dave", "carol", "alice"}) {
+		t.Errorf("after a draw the queue is %v", q.ids)
+	}
+}
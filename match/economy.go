@@ -0,0 +1,159 @@
+package match
+
+import (
+	"errors"
+	"time"
+
+	"shooter/weapon"
+)
+
+const (
+	// Rounds of an economy match, the first to win more than half wins the match
+	EconomyBestOf = 9
+	StartMoney    = 800
+	MaxMoney      = 9000
+	KillReward    = 300
+	// Paid at the end of a round, the loss bonus keeps losers in the game
+	RoundWinReward  = 3000
+	RoundLossReward = 1400
+	// Players can buy this long after a round starts
+	BuyTime = 15 * time.Second
+	// Armor takes this part of the damage until its points run out
+	ArmorAbsorb = 0.5
+	ArmorPoints = 100
+	// Most grenades a player can carry
+	MaxBoughtGrenades = 2
+)
+
+// Shop is what can be bought in economy rounds, at the weapon data's prices.
+// Everyone has a pistol for free.
+var Shop = []weapon.ID{weapon.Shotgun, weapon.Rifle, weapon.Armor, weapon.Grenade}
+
+var (
+	ErrNotForSale  = errors.New("not for sale")
+	ErrNoMoney     = errors.New("not enough money")
+	ErrAlreadyOwns = errors.New("already bought")
+)
+
+// Wallet is a player's money and what they bought, kept until they die.
+type Wallet struct {
+	Money    int         `json:"money"`
+	Weapons  []weapon.ID `json:"weapons,omitempty"`
+	Armor    int         `json:"armor,omitempty"`
+	Grenades int         `json:"grenades,omitempty"`
+}
+
+// Owns is true for weapons the player may shoot, the pistol and what they bought.
+func (w *Wallet) Owns(id weapon.ID) bool {
+	if id == weapon.Pistol {
+		return true
+	}
+	for _, owned := range w.Weapons {
+		if owned == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Bank is the players' wallets in an economy match.
+type Bank struct {
+	wallets map[string]*Wallet
+}
+
+func NewBank() *Bank {
+	return &Bank{wallets: map[string]*Wallet{}}
+}
+
+// Wallet returns the player's wallet, players start with StartMoney.
+func (b *Bank) Wallet(id string) *Wallet {
+	w, ok := b.wallets[id]
+	if !ok {
+		w = &Wallet{Money: StartMoney}
+		b.wallets[id] = w
+	}
+	return w
+}
+
+// Earn adds money up to MaxMoney.
+func (b *Bank) Earn(id string, amount int) {
+	w := b.Wallet(id)
+	w.Money = min(MaxMoney, w.Money+amount)
+}
+
+// Buy pays for the item of the Shop and gives it to the player.
+func (b *Bank) Buy(id string, item weapon.ID) error {
+	price := weapon.Get(item).Price
+	if price <= 0 || !isShopItem(item) {
+		return ErrNotForSale
+	}
+	w := b.Wallet(id)
+	switch {
+	case item == weapon.Armor && w.Armor >= ArmorPoints,
+		item == weapon.Grenade && w.Grenades >= MaxBoughtGrenades,
+		item != weapon.Armor && item != weapon.Grenade && w.Owns(item):
+		return ErrAlreadyOwns
+	case w.Money < price:
+		return ErrNoMoney
+	}
+	w.Money -= price
+	w.give(item)
+	return nil
+}
+
+// Grant gives the player an item for free, like the loot of a supply crate. Weapons they
+// own already only come with the ammo.
+func (b *Bank) Grant(id string, item weapon.ID) {
+	if w := b.Wallet(id); item == weapon.Armor || item == weapon.Grenade || !w.Owns(item) {
+		w.give(item)
+	}
+}
+
+func (w *Wallet) give(item weapon.ID) {
+	switch item {
+	case weapon.Armor:
+		w.Armor = ArmorPoints
+	case weapon.Grenade:
+		w.Grenades++
+	default:
+		w.Weapons = append(w.Weapons, item)
+	}
+}
+
+func isShopItem(item weapon.ID) bool {
+	for _, id := range Shop {
+		if id == item {
+			return true
+		}
+	}
+	return false
+}
+
+// Absorb is the damage left after the player's armor took its part, which wears it down.
+func (b *Bank) Absorb(id string, damage int) int {
+	w := b.Wallet(id)
+	absorbed := min(w.Armor, int(float64(damage)*ArmorAbsorb))
+	w.Armor -= absorbed
+	return damage - absorbed
+}
+
+// Throw uses up one of the player's grenades, false when they have none.
+func (b *Bank) Throw(id string) bool {
+	w := b.Wallet(id)
+	if w.Grenades <= 0 {
+		return false
+	}
+	w.Grenades--
+	return true
+}
+
+// Die loses what the player bought, their money stays.
+func (b *Bank) Die(id string) {
+	w := b.Wallet(id)
+	w.Weapons, w.Armor, w.Grenades = nil, 0, 0
+}
+
+// Buying is true during the buy phase at the start of a round.
+func (s *Series) Buying(now time.Time) bool {
+	return s.InRound && now.Sub(s.started) < BuyTime
+}
@@ -0,0 +1,80 @@
+package match
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"shooter/weapon"
+)
+
+func TestBankBuy(t *testing.T) {
+	b := NewBank()
+	if err := b.Buy("alice", weapon.Rifle); !errors.Is(err, ErrNoMoney) {
+		t.Errorf("buying a rifle with the starting money: %v, want ErrNoMoney", err)
+	}
+	if err := b.Buy("alice", weapon.Pistol); !errors.Is(err, ErrNotForSale) {
+		t.Errorf("buying a pistol: %v, want ErrNotForSale", err)
+	}
+	if err := b.Buy("alice", weapon.Armor); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Buy("alice", weapon.Armor); !errors.Is(err, ErrAlreadyOwns) {
+		t.Errorf("buying armor twice: %v, want ErrAlreadyOwns", err)
+	}
+	if got, want := b.Wallet("alice").Money, StartMoney-weapon.Get(weapon.Armor).Price; got != want {
+		t.Errorf("money = %d, want %d", got, want)
+	}
+
+	b.Earn("alice", 10*MaxMoney)
+	if got := b.Wallet("alice").Money; got != MaxMoney {
+		t.Errorf("money = %d, want it capped at %d", got, MaxMoney)
+	}
+	if err := b.Buy("alice", weapon.Rifle); err != nil {
+		t.Fatal(err)
+	}
+	if w := b.Wallet("alice"); !w.Owns(weapon.Rifle) || !w.Owns(weapon.Pistol) || w.Owns(weapon.Shotgun) {
+		t.Errorf("owned weapons = %v", w.Weapons)
+	}
+	b.Die("alice")
+	if w := b.Wallet("alice"); w.Owns(weapon.Rifle) || w.Armor != 0 {
+		t.Errorf("wallet after dying = %+v, want the purchases lost", w)
+	}
+}
+
+func TestBankAbsorb(t *testing.T) {
+	b := NewBank()
+	if got := b.Absorb("alice", 50); got != 50 {
+		t.Errorf("damage without armor = %d, want 50", got)
+	}
+	b.Wallet("alice").Armor = 30
+	if got := b.Absorb("alice", 40); got != 20 {
+		t.Errorf("damage with armor = %d, want 20", got)
+	}
+	// The last 10 points of armor take 10 of the 40
+	if got := b.Absorb("alice", 40); got != 30 {
+		t.Errorf("damage with worn armor = %d, want 30", got)
+	}
+}
+
+func TestBankGrant(t *testing.T) {
+	b := NewBank()
+	b.Grant("alice", weapon.Rifle)
+	b.Grant("alice", weapon.Rifle)
+	b.Grant("alice", weapon.Armor)
+	if w := b.Wallet("alice"); len(w.Weapons) != 1 || w.Armor != ArmorPoints || w.Money != StartMoney {
+		t.Errorf("wallet after crates = %+v, want a rifle and armor for free", w)
+	}
+}
+
+func TestBuyPhase(t *testing.T) {
+	now := time.Now()
+	s := NewSeries(EconomyBestOf, 0)
+	if s.Buying(now) {
+		t.Error("buying before the round")
+	}
+	s.Start([]string{"alice", "bob"}, now)
+	if !s.Buying(now.Add(BuyTime/2)) || s.Buying(now.Add(BuyTime)) {
+		t.Error("buy phase isn't the start of the round")
+	}
+}
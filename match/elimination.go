@@ -0,0 +1,154 @@
+package match
+
+import (
+	"slices"
+	"time"
+)
+
+const (
+	// Rounds of an elimination series, the first to win more than half wins the match
+	EliminationBestOf = 5
+	// Rounds each player starts a round with, a kill gives one more
+	EliminationAmmo = 3
+	// A round nobody won by then is a draw
+	RoundTimeLimit = 90 * time.Second
+	// Pause between the end of a round and the next
+	RoundDelay = 4 * time.Second
+)
+
+// Series is the rounds of an elimination match. Nobody respawns during a round, the last
+// player standing wins it.
+type Series struct {
+	// 0 plays rounds until the time limit
+	BestOf int
+	// Rounds per player and round, 0 for unlimited
+	Ammo  int
+	Round int
+	Wins  map[string]int
+	// Who the round started with
+	Players []string
+	// Set while a round is played
+	InRound bool
+	started time.Time
+	alive   map[string]bool
+	ammo    map[string]int
+	// Of the round, see Ace
+	kills map[string]int
+}
+
+func NewSeries(bestOf, ammo int) *Series {
+	return &Series{BestOf: bestOf, Ammo: ammo, Wins: map[string]int{}}
+}
+
+// Start starts the next round with the players, false when there aren't enough of them for one.
+func (s *Series) Start(players []string, now time.Time) bool {
+	if len(players) < 2 {
+		return false
+	}
+	s.Round++
+	s.Players = players
+	s.InRound = true
+	s.started = now
+	s.alive = map[string]bool{}
+	s.ammo = map[string]int{}
+	s.kills = map[string]int{}
+	for _, id := range players {
+		s.alive[id] = true
+		s.ammo[id] = s.Ammo
+	}
+	return true
+}
+
+// Alive returns the players still in the round, sorted.
+func (s *Series) Alive() []string {
+	alive := make([]string, 0, len(s.alive))
+	for id := range s.alive {
+		alive = append(alive, id)
+	}
+	slices.Sort(alive)
+	return alive
+}
+
+// Shoot uses up a round of the player's ammo, false when they are out of the round or ammo.
+func (s *Series) Shoot(id string) bool {
+	if !s.InRound || !s.alive[id] {
+		return false
+	}
+	if s.Ammo == 0 {
+		return true
+	}
+	if s.ammo[id] <= 0 {
+		return false
+	}
+	s.ammo[id]--
+	return true
+}
+
+// Kill takes the victim out of the round and gives the killer a round of ammo. It returns
+// the round's winner once one player is left, ended is set when the round is over.
+func (s *Series) Kill(killer, victim string) (winner string, ended bool) {
+	if !s.InRound || !s.alive[victim] {
+		return "", false
+	}
+	delete(s.alive, victim)
+	if s.alive[killer] && killer != victim {
+		s.ammo[killer]++
+		s.kills[killer]++
+	}
+	if len(s.alive) > 1 {
+		return "", false
+	}
+	s.InRound = false
+	for id := range s.alive {
+		winner = id
+		s.Wins[id]++
+	}
+	return winner, true
+}
+
+// Ace is the player who killed everyone else in the last round of three players or more,
+// empty when nobody did.
+func (s *Series) Ace() string {
+	if len(s.Players) < 3 {
+		return ""
+	}
+	for id, kills := range s.kills {
+		if kills == len(s.Players)-1 {
+			return id
+		}
+	}
+	return ""
+}
+
+// Expired ends the round as a draw once it ran out of time, true when it did.
+func (s *Series) Expired(now time.Time) bool {
+	if !s.InRound || now.Sub(s.started) < RoundTimeLimit {
+		return false
+	}
+	s.InRound = false
+	return true
+}
+
+// Champion is the player who won more than half of the rounds, empty until someone did.
+func (s *Series) Champion() string {
+	if s.BestOf == 0 {
+		return ""
+	}
+	for id, wins := range s.Wins {
+		if wins > s.BestOf/2 {
+			return id
+		}
+	}
+	return ""
+}
+
+// Leader is the player with the most round wins, ties go to the smaller ID. Empty before anyone won one.
+func (s *Series) Leader() string {
+	leader := ""
+	for id, wins := range s.Wins {
+		if best := s.Wins[leader]; leader == "" || wins > best || wins == best && id < leader {
+			leader = id
+		}
+	}
+	return leader
+}
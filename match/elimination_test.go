@@ -0,0 +1,69 @@
+package match
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSeries(t *testing.T) {
+	now := time.Now()
+	s := NewSeries(3, 1)
+	if s.Start([]string{"alice"}, now) {
+		t.Fatal("round started with a single player")
+	}
+	if !s.Start([]string{"alice", "bob", "carol"}, now) {
+		t.Fatal("round didn't start")
+	}
+
+	if !s.Shoot("alice") || s.Shoot("alice") {
+		t.Error("alice should have exactly one round")
+	}
+	if _, ended := s.Kill("alice", "bob"); ended {
+		t.Error("round ended with two players left")
+	}
+	if !s.Shoot("alice") {
+		t.Error("kill didn't give alice a round")
+	}
+	if s.Shoot("bob") {
+		t.Error("bob shot while out of the round")
+	}
+	if winner, ended := s.Kill("carol", "alice"); !ended || winner != "carol" {
+		t.Errorf("Kill() = %q, %v, want carol to win", winner, ended)
+	}
+	if s.InRound || s.Wins["carol"] != 1 || s.Champion() != "" {
+		t.Errorf("after round 1: %+v", s)
+	}
+
+	s.Start([]string{"alice", "carol"}, now)
+	if !s.Expired(now.Add(RoundTimeLimit)) || len(s.Wins) != 1 {
+		t.Error("round didn't end as a draw at the time limit")
+	}
+
+	s.Start([]string{"alice", "carol"}, now)
+	s.Kill("", "alice")
+	if s.Champion() != "carol" || s.Leader() != "carol" || s.Round != 3 {
+		t.Errorf("after round 3: %+v", s)
+	}
+}
+
+func TestAce(t *testing.T) {
+	now := time.Now()
+	s := NewSeries(0, 0)
+	s.Start([]string{"alice", "bob", "carol"}, now)
+	s.Kill("alice", "bob")
+	s.Kill("carol", "alice")
+	if s.Ace() != "" {
+		t.Error("ace without killing everyone")
+	}
+	s.Start([]string{"alice", "bob", "carol"}, now)
+	s.Kill("alice", "bob")
+	s.Kill("alice", "carol")
+	if s.Ace() != "alice" {
+		t.Errorf("Ace() = %q, want alice", s.Ace())
+	}
+	s.Start([]string{"alice", "bob"}, now)
+	s.Kill("alice", "bob")
+	if s.Ace() != "" {
+		t.Error("ace in a round of two")
+	}
+}
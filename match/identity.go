@@ -0,0 +1,70 @@
+package match
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// IdentitiesFile is where a ranked server keeps who owns which name, next to RatingsFile
+const IdentitiesFile = "identities.json"
+
+// Identities tie ranked names to the key of the client that first played under them, so ratings
+// can't be played on or spoiled by someone else using the name. Only a hash of each key is kept.
+// It's trust on first use: whoever joins under a name first owns it, a lost key loses the name.
+type Identities map[string]string
+
+func keyHash(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// Owns is true when the name is unclaimed or was claimed with the key, empty keys own nothing.
+func (ids Identities) Owns(name, key string) bool {
+	if key == "" {
+		return false
+	}
+	hash, ok := ids[name]
+	return !ok || subtle.ConstantTimeCompare([]byte(hash), []byte(keyHash(key))) == 1
+}
+
+// Claim ties an unclaimed name to the key, it returns false when the name was already claimed.
+func (ids Identities) Claim(name, key string) bool {
+	if _, ok := ids[name]; ok || key == "" {
+		return false
+	}
+	ids[name] = keyHash(key)
+	return true
+}
+
+func (ids Identities) Save(path string) error {
+	data, err := json.MarshalIndent(ids, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+// LoadIdentities reads the identities saved at path, none when there are none yet.
+func LoadIdentities(path string) (Identities, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return Identities{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	ids := Identities{}
+	if err := json.Unmarshal(data, &ids); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
@@ -0,0 +1,50 @@
+package match
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestIdentitiesOwns(t *testing.T) {
+	ids := Identities{}
+	if !ids.Owns("alice", "a-key") {
+		t.Error("an unclaimed name isn't owned by the first key")
+	}
+	if !ids.Claim("alice", "a-key") {
+		t.Fatal("claiming an unclaimed name failed")
+	}
+	if ids.Claim("alice", "other-key") {
+		t.Error("a claimed name was claimed again")
+	}
+	if !ids.Owns("alice", "a-key") {
+		t.Error("the claiming key doesn't own the name")
+	}
+	if ids.Owns("alice", "other-key") || ids.Owns("alice", "") {
+		t.Error("another key owns a claimed name")
+	}
+	if ids.Owns("bob", "") || ids.Claim("bob", "") {
+		t.Error("an empty key owns a name")
+	}
+	if ids["alice"] == "a-key" {
+		t.Error("the key is kept instead of its hash")
+	}
+}
+
+func TestIdentitiesSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), IdentitiesFile)
+	ids, err := LoadIdentities(path)
+	if err != nil || len(ids) != 0 {
+		t.Fatalf("LoadIdentities() before saving = %v, %v", ids, err)
+	}
+	ids.Claim("alice", "a-key")
+	if err := ids.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadIdentities(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !loaded.Owns("alice", "a-key") || loaded.Owns("alice", "other-key") {
+		t.Errorf("loaded identities = %v", loaded)
+	}
+}
@@ -0,0 +1,351 @@
+package match
+
+import (
+	"sort"
+	"time"
+
+	"shooter/weapon"
+)
+
+const (
+	FragLimit = 20
+	TimeLimit = 5 * time.Minute
+	// How long dead players wait to respawn in modes with respawns
+	RespawnTime = 5 * time.Second
+	// How long the summary and map vote are shown before the next match starts
+	SummaryDuration = 15 * time.Second
+)
+
+type PlayerStats struct {
+	ID      string `json:"id"`
+	Kills   int    `json:"kills"`
+	Assists int    `json:"assists"`
+	Deaths  int    `json:"deaths"`
+	// Bullets counted by Fired, on servers the ones they accepted
+	Shots      int  `json:"shots"`
+	Hits       int  `json:"hits"`
+	Damage     int  `json:"damage"`
+	Streak     int  `json:"-"`
+	BestStreak int  `json:"best_streak"`
+	Headshots  int  `json:"headshots"`
+	Bot        bool `json:"bot,omitempty"`
+	// Rating after the match on ranked servers and how much it changed
+	Rating       int `json:"rating,omitempty"`
+	RatingChange int `json:"rating_change,omitempty"`
+	// Shots, hits and damage by weapon
+	Weapons map[weapon.ID]*WeaponStats `json:"weapons,omitempty"`
+}
+
+// WeaponStats are a player's shots and hits with one weapon, every bullet counts as a shot.
+type WeaponStats struct {
+	Shots     int `json:"shots"`
+	Hits      int `json:"hits"`
+	Headshots int `json:"headshots"`
+	Damage    int `json:"damage"`
+}
+
+// Accuracy is the fraction of bullets which hit a player.
+func (w *WeaponStats) Accuracy() float64 {
+	if w.Shots == 0 {
+		return 0
+	}
+	return min(1, float64(w.Hits)/float64(w.Shots))
+}
+
+func (s *PlayerStats) weapon(id weapon.ID) *WeaponStats {
+	if s.Weapons == nil {
+		s.Weapons = map[weapon.ID]*WeaponStats{}
+	}
+	w, ok := s.Weapons[id]
+	if !ok {
+		w = &WeaponStats{}
+		s.Weapons[id] = w
+	}
+	return w
+}
+
+// Fired counts bullets of the weapon.
+func (s *PlayerStats) Fired(id weapon.ID, bullets int) {
+	s.Shots += bullets
+	s.weapon(id).Shots += bullets
+}
+
+// Hit counts a hit on a player with the weapon.
+func (s *PlayerStats) Hit(id weapon.ID, damage int, headshot bool) {
+	s.Hits++
+	s.Damage += damage
+	w := s.weapon(id)
+	w.Hits++
+	w.Damage += damage
+	if headshot {
+		s.Headshots++
+		w.Headshots++
+	}
+}
+
+// Name is the player ID, tagged for bots.
+func (s *PlayerStats) Name() string {
+	if s.Bot {
+		return s.ID + " [BOT]"
+	}
+	return s.ID
+}
+
+// KD is kills per death, deathless players get their kill count.
+func (s *PlayerStats) KD() float64 {
+	if s.Deaths == 0 {
+		return float64(s.Kills)
+	}
+	return float64(s.Kills) / float64(s.Deaths)
+}
+
+// Accuracy is the fraction of shots which hit a player.
+func (s *PlayerStats) Accuracy() float64 {
+	if s.Shots == 0 {
+		return 0
+	}
+	return min(1, float64(s.Hits)/float64(s.Shots))
+}
+
+// Summary is sent to clients when the match ends.
+type Summary struct {
+	Winner string `json:"winner"`
+	// Player who dealt the most damage
+	MVP     string        `json:"mvp"`
+	Players []PlayerStats `json:"players"`
+	// Maps to vote for as the next one
+	Maps []string `json:"maps"`
+	// Set instead of Maps when the server plays a rotation
+	Next     string    `json:"next,omitempty"`
+	NextMap  time.Time `json:"next_map"`
+	Duration float64   `json:"duration"` // seconds
+}
+
+// Tracker aggregates stats of a single match.
+type Tracker struct {
+	Map   string
+	Rules Rules
+	// Rounds of elimination and duels, nil in other modes
+	Series *Series
+	// Who duels next, nil in other modes
+	Queue *Queue
+	// Money of the economy mode, nil in other modes
+	Bank    *Bank
+	Started time.Time
+	players map[string]*PlayerStats
+}
+
+func NewTracker(mapName string, now time.Time) *Tracker {
+	return &Tracker{Map: mapName, Rules: Rules{Mode: Deathmatch}, Started: now, players: map[string]*PlayerStats{}}
+}
+
+// SetRules sets what the match is played by, starting a series for the modes played in rounds.
+func (t *Tracker) SetRules(r Rules) {
+	t.Rules = r
+	t.Series, t.Queue, t.Bank = nil, nil, nil
+	if r.Rounds() {
+		t.Series = NewSeries(r.BestOf, r.Ammo)
+	}
+	if r.Mode == Duel {
+		t.Queue = &Queue{}
+	}
+	if r.Mode == Economy {
+		t.Bank = NewBank()
+	}
+}
+
+func (t *Tracker) stats(id string) *PlayerStats {
+	s, ok := t.players[id]
+	if !ok {
+		s = &PlayerStats{ID: id}
+		t.players[id] = s
+	}
+	return s
+}
+
+// State is a match in progress, saved by the server when shutting down.
+type State struct {
+	Map     string        `json:"map"`
+	Rules   Rules         `json:"rules"`
+	Elapsed time.Duration `json:"elapsed"`
+	Players []PlayerStats `json:"players"`
+}
+
+func (t *Tracker) State(now time.Time) State {
+	s := State{Map: t.Map, Rules: t.Rules, Elapsed: now.Sub(t.Started)}
+	for _, p := range t.players {
+		s.Players = append(s.Players, *p)
+	}
+	return s
+}
+
+// Restore continues a saved match from where it stopped.
+func Restore(s State, now time.Time) *Tracker {
+	t := NewTracker(s.Map, now.Add(-s.Elapsed))
+	if s.Rules.Mode != "" {
+		t.SetRules(s.Rules)
+	}
+	for _, p := range s.Players {
+		t.players[p.ID] = &p
+	}
+	return t
+}
+
+// Join adds the player to the scoreboard before they do anything.
+func (t *Tracker) Join(id string) {
+	t.stats(id)
+}
+
+// JoinBot adds a bot to the scoreboard, tagged as one.
+func (t *Tracker) JoinBot(id string) {
+	t.stats(id).Bot = true
+}
+
+// Fired counts bullets the player fired with the weapon.
+func (t *Tracker) Fired(id string, w weapon.ID, bullets int) {
+	t.stats(id).Fired(w, bullets)
+}
+
+func (t *Tracker) Hit(attacker string, w weapon.ID, damage int, headshot bool) {
+	t.stats(attacker).Hit(w, damage, headshot)
+}
+
+// Stats returns a copy of the player's stats so far.
+func (t *Tracker) Stats(id string) PlayerStats {
+	if s, ok := t.players[id]; ok {
+		return *s
+	}
+	return PlayerStats{ID: id}
+}
+
+func (t *Tracker) Kill(killer, victim string) {
+	v := t.stats(victim)
+	v.Deaths++
+	v.Streak = 0
+
+	if killer == "" || killer == victim {
+		return
+	}
+	k := t.stats(killer)
+	k.Kills++
+	k.Streak++
+	k.BestStreak = max(k.BestStreak, k.Streak)
+}
+
+// Assist counts damage the player dealt to someone killed by another.
+func (t *Tracker) Assist(id string) {
+	t.stats(id).Assists++
+}
+
+// Over is true once someone reached the frag limit, went through the gun game weapons,
+// won the elimination series or time ran out. Practice goes on.
+func (t *Tracker) Over(now time.Time) bool {
+	if t.Rules.Mode == Practice {
+		return false
+	}
+	timeLimit := TimeLimit
+	if t.Rules.TimeLimit > 0 {
+		timeLimit = t.Rules.TimeLimit
+	}
+	if now.Sub(t.Started) >= timeLimit {
+		return true
+	}
+	if t.Series != nil && t.Series.BestOf > 0 {
+		return t.Series.Champion() != ""
+	}
+	limit := FragLimit
+	if t.Rules.FragLimit > 0 {
+		limit = t.Rules.FragLimit
+	}
+	if t.Rules.Mode == GunGame {
+		limit = len(t.Rules.Weapons)
+	}
+	for _, s := range t.players {
+		if s.Kills >= limit {
+			return true
+		}
+	}
+	return false
+}
+
+// Kills is how many kills the player has so far, their gun game progress.
+func (t *Tracker) Kills(id string) int {
+	if s, ok := t.players[id]; ok {
+		return s.Kills
+	}
+	return 0
+}
+
+// Summary ranks players by kills, ties broken by fewer deaths. The winner of elimination
+// is who won the most rounds.
+func (t *Tracker) Summary(now time.Time, maps []string) Summary {
+	players := make([]PlayerStats, 0, len(t.players))
+	for _, s := range t.players {
+		players = append(players, *s)
+	}
+	sort.Slice(players, func(i, j int) bool {
+		a, b := players[i], players[j]
+		if a.Kills != b.Kills {
+			return a.Kills > b.Kills
+		}
+		if a.Deaths != b.Deaths {
+			return a.Deaths < b.Deaths
+		}
+		return a.ID < b.ID
+	})
+
+	summary := Summary{
+		Players:  players,
+		Maps:     maps,
+		NextMap:  now.Add(SummaryDuration),
+		Duration: now.Sub(t.Started).Seconds(),
+	}
+	if len(players) > 0 {
+		summary.Winner = players[0].ID
+		mvp := players[0]
+		for _, p := range players[1:] {
+			if p.Damage > mvp.Damage {
+				mvp = p
+			}
+		}
+		summary.MVP = mvp.ID
+	}
+	// Elimination and duels are won by rounds, not kills
+	if t.Series != nil && t.Series.Leader() != "" {
+		summary.Winner = t.Series.Leader()
+	}
+	return summary
+}
+
+// Vote counts map votes, each player has one vote which can be changed.
+type Vote struct {
+	votes map[string]string
+}
+
+func (v *Vote) Cast(playerID, mapName string) {
+	if v.votes == nil {
+		v.votes = map[string]string{}
+	}
+	v.votes[playerID] = mapName
+}
+
+func (v *Vote) Counts() map[string]int {
+	counts := map[string]int{}
+	for _, m := range v.votes {
+		counts[m]++
+	}
+	return counts
+}
+
+// Winner returns the map with the most votes, fallback when nobody voted.
+// Ties go to the map listed first in maps.
+func (v *Vote) Winner(maps []string, fallback string) string {
+	counts := v.Counts()
+	best, bestCount := fallback, 0
+	for _, m := range maps {
+		if counts[m] > bestCount {
+			best, bestCount = m, counts[m]
+		}
+	}
+	return best
+}
@@ -0,0 +1,222 @@
+package match
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"shooter/weapon"
+)
+
+func TestSummary(t *testing.T) {
+	now := time.Now()
+	tr := NewTracker("warehouse", now)
+	tr.Join("carol")
+	tr.Fired("alice", weapon.Rifle, 10)
+	tr.Fired("bob", weapon.Shotgun, 4)
+	for range 4 {
+		tr.Hit("alice", weapon.Rifle, 25, false)
+	}
+	tr.Kill("alice", "bob")
+	tr.Hit("bob", weapon.Shotgun, 90, true)
+	tr.Kill("bob", "alice")
+	tr.Kill("alice", "bob")
+
+	s := tr.Summary(now.Add(time.Minute), []string{"warehouse"})
+	if s.Winner != "alice" {
+		t.Errorf("Winner = %q, want alice", s.Winner)
+	}
+	if s.MVP != "alice" {
+		t.Errorf("MVP = %q, want alice", s.MVP)
+	}
+	if len(s.Players) != 3 {
+		t.Fatalf("len(Players) = %d, want 3", len(s.Players))
+	}
+	alice := s.Players[0]
+	if alice.Kills != 2 || alice.Deaths != 1 || alice.BestStreak != 1 {
+		t.Errorf("alice = %+v", alice)
+	}
+	if got := alice.Accuracy(); got != 0.4 {
+		t.Errorf("Accuracy() = %v, want 0.4", got)
+	}
+	if got := s.Players[2].ID; got != "carol" {
+		t.Errorf("last = %q, want carol", got)
+	}
+}
+
+func TestOver(t *testing.T) {
+	now := time.Now()
+	tr := NewTracker("warehouse", now)
+	if tr.Over(now) {
+		t.Error("fresh match is over")
+	}
+	if !tr.Over(now.Add(TimeLimit)) {
+		t.Error("match not over after time limit")
+	}
+	for range FragLimit {
+		tr.Kill("alice", "bob")
+	}
+	if !tr.Over(now) {
+		t.Error("match not over after frag limit")
+	}
+}
+
+func TestWeaponStats(t *testing.T) {
+	tr := NewTracker("warehouse", time.Now())
+	tr.Fired("alice", weapon.Shotgun, 8)
+	tr.Fired("alice", weapon.Rifle, 4)
+	tr.Hit("alice", weapon.Shotgun, 10, false)
+	tr.Hit("alice", weapon.Shotgun, 10, true)
+	tr.Hit("alice", weapon.Rifle, 25, true)
+
+	s := tr.Stats("alice")
+	if s.Hits != 3 || s.Headshots != 2 || s.Damage != 45 {
+		t.Errorf("stats = %+v, want 3 hits, 2 headshots and 45 damage", s)
+	}
+	shotgun := s.Weapons[weapon.Shotgun]
+	if shotgun.Hits != 2 || shotgun.Headshots != 1 || shotgun.Damage != 20 || shotgun.Accuracy() != 0.25 {
+		t.Errorf("shotgun = %+v, want 2 of 8 hitting with one headshot", shotgun)
+	}
+	if got := s.Weapons[weapon.Rifle].Accuracy(); got != 0.25 {
+		t.Errorf("rifle Accuracy() = %v, want 0.25", got)
+	}
+}
+
+func TestPracticeNeverOver(t *testing.T) {
+	now := time.Now()
+	tr := NewTracker("warehouse", now)
+	tr.SetRules(NewRules(Practice, nil, nil))
+	for range FragLimit {
+		tr.Kill("alice", "bob")
+	}
+	if tr.Over(now.Add(TimeLimit)) {
+		t.Error("practice over")
+	}
+}
+
+func TestOverRuleLimits(t *testing.T) {
+	now := time.Now()
+	tr := NewTracker("warehouse", now)
+	tr.SetRules(Rules{Mode: Deathmatch, TimeLimit: time.Minute, FragLimit: 2})
+	if !tr.Over(now.Add(time.Minute)) {
+		t.Error("match not over after the rules' time limit")
+	}
+	tr.Kill("alice", "bob")
+	if tr.Over(now) {
+		t.Error("match over before the rules' frag limit")
+	}
+	tr.Kill("alice", "bob")
+	if !tr.Over(now) {
+		t.Error("match not over after the rules' frag limit")
+	}
+}
+
+func TestRuleKnobs(t *testing.T) {
+	var r Rules
+	if r.Respawn() != RespawnTime || r.Speed() != 1 || len(r.Knobs()) != 0 {
+		t.Errorf("default rules: respawn %v, speed %v, knobs %v", r.Respawn(), r.Speed(), r.Knobs())
+	}
+	r = Rules{RespawnTime: 2 * time.Second, Health: 150, SpeedFactor: 1.5, InfiniteAmmo: true, Abilities: true, FriendlyFire: FriendlyFireOff}
+	if r.Respawn() != 2*time.Second || r.Speed() != 1.5 {
+		t.Errorf("respawn %v, speed %v, want 2s and 1.5", r.Respawn(), r.Speed())
+	}
+	want := "respawn 2s, 150 health, 1.5x speed, infinite ammo, abilities, friendly fire off"
+	if got := strings.Join(r.Knobs(), ", "); got != want {
+		t.Errorf("Knobs() = %q, want %q", got, want)
+	}
+}
+
+func TestGunGame(t *testing.T) {
+	now := time.Now()
+	tr := NewTracker("warehouse", now)
+	tr.SetRules(NewRules(GunGame, nil, nil))
+	last := len(tr.Rules.Weapons) - 1
+	for range last {
+		tr.Kill("alice", "bob")
+	}
+	if w, ok := tr.Rules.GunGameWeapon(tr.Kills("alice")); !ok || w != tr.Rules.Weapons[last] {
+		t.Errorf("alice has %q, want the last weapon", w)
+	}
+	if tr.Over(now) {
+		t.Error("match over before a kill with the last weapon")
+	}
+	tr.Kill("alice", "bob")
+	if _, ok := tr.Rules.GunGameWeapon(tr.Kills("alice")); ok || !tr.Over(now) {
+		t.Error("match not over after going through the weapons")
+	}
+	if w, _ := tr.Rules.GunGameWeapon(tr.Kills("bob")); w != tr.Rules.Weapons[0] {
+		t.Errorf("bob has %q, want the first weapon", w)
+	}
+}
+
+func TestVoteWinner(t *testing.T) {
+	maps := []string{"a", "b", "c"}
+	var v Vote
+	if got := v.Winner(maps, "a"); got != "a" {
+		t.Errorf("no votes: Winner() = %q, want a", got)
+	}
+	v.Cast("p1", "c")
+	v.Cast("p2", "b")
+	v.Cast("p2", "c")
+	if got := v.Winner(maps, "a"); got != "c" {
+		t.Errorf("Winner() = %q, want c", got)
+	}
+}
+
+func TestRestore(t *testing.T) {
+	now := time.Now()
+	tr := NewTracker("warehouse", now.Add(-time.Minute))
+	tr.Kill("alice", "bob")
+
+	restored := Restore(tr.State(now), now.Add(time.Hour))
+	if restored.Map != "warehouse" {
+		t.Errorf("Map = %q, want warehouse", restored.Map)
+	}
+	if got := now.Add(time.Hour).Sub(restored.Started); got != time.Minute {
+		t.Errorf("elapsed = %v, want %v", got, time.Minute)
+	}
+	s := restored.Summary(now, nil)
+	if s.Winner != "alice" || len(s.Players) != 2 {
+		t.Errorf("Summary() = %+v", s)
+	}
+}
+
+func TestBotName(t *testing.T) {
+	tr := NewTracker("warehouse", time.Now())
+	tr.Join("alice")
+	tr.JoinBot("bot-1")
+	tr.Kill("bot-1", "alice")
+
+	s := tr.Summary(time.Now(), nil)
+	if s.Players[0].Name() != "bot-1 [BOT]" || s.Players[1].Name() != "alice" {
+		t.Errorf("Names = %q, %q", s.Players[0].Name(), s.Players[1].Name())
+	}
+}
+
+func TestEliminationSummary(t *testing.T) {
+	now := time.Now()
+	tr := NewTracker("warehouse", now)
+	tr.SetRules(NewRules(Elimination, nil, nil))
+	tr.Kill("alice", "bob")
+	tr.Kill("alice", "carol")
+	tr.Series.Start([]string{"alice", "bob"}, now)
+	tr.Series.Kill("bob", "alice")
+	if tr.Over(now) {
+		t.Error("over after one round")
+	}
+	if s := tr.Summary(now, nil); s.Winner != "bob" {
+		t.Errorf("Winner = %q, want bob who won the round", s.Winner)
+	}
+}
+
+func TestCareer(t *testing.T) {
+	var c Career
+	c.Add(PlayerStats{Kills: 3, Deaths: 1, BestStreak: 2, Weapons: map[weapon.ID]*WeaponStats{weapon.Rifle: {Shots: 10, Hits: 4}}}, true)
+	c.Add(PlayerStats{Kills: 1, Deaths: 4, BestStreak: 1, Weapons: map[weapon.ID]*WeaponStats{weapon.Rifle: {Shots: 10, Hits: 2}}}, false)
+	if c.Matches != 2 || c.Wins != 1 || c.Kills != 4 || c.Deaths != 5 || c.BestStreak != 2 {
+		t.Errorf("career = %+v", c)
+	}
+	if rifle := c.Weapons[weapon.Rifle]; rifle.Shots != 20 || rifle.Hits != 6 {
+		t.Errorf("rifle = %+v, want 6 of 20", rifle)
+	}
+}
@@ -0,0 +1,99 @@
+package match
+
+import (
+	"slices"
+	"time"
+)
+
+// What players can call a vote on
+type PollKind string
+
+const (
+	// Start a new match on another map
+	PollMap PollKind = "map"
+	// Start a new match in another mode
+	PollMode PollKind = "mode"
+	// Remove a player from the server
+	PollKick PollKind = "kick"
+)
+
+var PollKinds = []PollKind{PollMap, PollMode, PollKick}
+
+const (
+	// How long players have to vote, a poll without a majority by then fails
+	PollDuration = 30 * time.Second
+	// A player waits this long before calling another vote
+	PollCooldown = time.Minute
+)
+
+// Poll is a yes or no vote called by a player, it passes once more than half of the voters
+// said yes. The caller votes yes, the player a kick vote is about can't vote.
+type Poll struct {
+	Kind   PollKind
+	Target string
+	Caller string
+	Ends   time.Time
+	votes  map[string]bool
+}
+
+func NewPoll(kind PollKind, target, caller string, now time.Time) *Poll {
+	p := &Poll{Kind: kind, Target: target, Caller: caller, Ends: now.Add(PollDuration), votes: map[string]bool{}}
+	p.Cast(caller, true)
+	return p
+}
+
+// Cast records the player's vote, which they can change until the poll is decided.
+func (p *Poll) Cast(playerID string, yes bool) {
+	if p.Kind == PollKick && playerID == p.Target {
+		return
+	}
+	p.votes[playerID] = yes
+}
+
+// Tally counts the votes of the voters still around, the kick target isn't one of them.
+func (p *Poll) Tally(voters []string) (yes, no, total int) {
+	for _, id := range voters {
+		if p.Kind == PollKick && id == p.Target {
+			continue
+		}
+		total++
+		if vote, ok := p.votes[id]; ok && vote {
+			yes++
+		} else if ok {
+			no++
+		}
+	}
+	return yes, no, total
+}
+
+// Result decides the poll: passed with a majority of yes, failed once the no votes make
+// that impossible or the time is up.
+func (p *Poll) Result(voters []string, now time.Time) (passed, done bool) {
+	yes, no, total := p.Tally(voters)
+	switch {
+	case yes*2 > total:
+		return true, true
+	case no*2 >= total || !now.Before(p.Ends):
+		return false, true
+	}
+	return false, false
+}
+
+// Voted returns whether the player has voted yet.
+func (p *Poll) Voted(playerID string) bool {
+	_, ok := p.votes[playerID]
+	return ok
+}
+
+// ValidPoll checks a vote can be called: maps and modes must exist, kicks must name a player.
+func ValidPoll(kind PollKind, target string, maps, players []string) bool {
+	switch kind {
+	case PollMap:
+		return slices.Contains(maps, target)
+	case PollMode:
+		return slices.Contains(Modes, Mode(target))
+	case PollKick:
+		return slices.Contains(players, target)
+	}
+	return false
+}
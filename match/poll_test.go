@@ -0,0 +1,72 @@
+package match
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPollResult(t *testing.T) {
+	now := time.Now()
+	voters := []string{"alice", "bob", "carol", "dave"}
+
+	p := NewPoll(PollMap, "warehouse", "alice", now)
+	if _, done := p.Result(voters, now); done {
+		t.Fatal("decided with only the caller's vote")
+	}
+	p.Cast("bob", true)
+	if _, done := p.Result(voters, now); done {
+		t.Fatal("half yes passed, want a majority")
+	}
+	p.Cast("carol", true)
+	if passed, done := p.Result(voters, now); !passed || !done {
+		t.Errorf("Result() = %v, %v with 3/4 yes", passed, done)
+	}
+
+	p = NewPoll(PollMode, "gungame", "alice", now)
+	p.Cast("bob", false)
+	p.Cast("carol", false)
+	if passed, done := p.Result(voters, now); passed || !done {
+		t.Errorf("Result() = %v, %v with half no", passed, done)
+	}
+
+	p = NewPoll(PollMode, "gungame", "alice", now)
+	if passed, done := p.Result(voters, now.Add(PollDuration)); passed || !done {
+		t.Errorf("Result() = %v, %v when expired", passed, done)
+	}
+}
+
+func TestPollKick(t *testing.T) {
+	now := time.Now()
+	voters := []string{"alice", "bob", "carol"}
+	p := NewPoll(PollKick, "carol", "alice", now)
+	p.Cast("carol", false)
+	if yes, no, total := p.Tally(voters); yes != 1 || no != 0 || total != 2 {
+		t.Errorf("Tally() = %d, %d, %d, want the target left out", yes, no, total)
+	}
+	p.Cast("bob", true)
+	if passed, _ := p.Result(voters, now); !passed {
+		t.Error("kick failed with both other players voting yes")
+	}
+}
+
+func TestValidPoll(t *testing.T) {
+	maps, players := []string{"warehouse"}, []string{"alice"}
+	tests := []struct {
+		kind   PollKind
+		target string
+		want   bool
+	}{
+		{PollMap, "warehouse", true},
+		{PollMap, "nowhere", false},
+		{PollMode, "duel", true},
+		{PollMode, "tag", false},
+		{PollKick, "alice", true},
+		{PollKick, "bob", false},
+		{"surrender", "", false},
+	}
+	for _, tt := range tests {
+		if got := ValidPoll(tt.kind, tt.target, maps, players); got != tt.want {
+			t.Errorf("ValidPoll(%q, %q) = %v, want %v", tt.kind, tt.target, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package match
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+)
+
+// RatingsFile is where a ranked server keeps everyone's rating, in its data directory
+const RatingsFile = "ratings.json"
+
+const (
+	// Rating of players new to ranked
+	DefaultRating = 1000
+	// Most a match can move a rating
+	RatingK = 32.0
+)
+
+// Ranks by the rating they start at, the badges on the scoreboard
+var Ranks = []struct {
+	Name string
+	Min  int
+}{
+	{"Bronze", 0},
+	{"Silver", 900},
+	{"Gold", 1100},
+	{"Platinum", 1300},
+	{"Diamond", 1500},
+}
+
+// Rank is the name of the rank the rating is in.
+func Rank(rating int) string {
+	name := Ranks[0].Name
+	for _, r := range Ranks {
+		if rating >= r.Min {
+			name = r.Name
+		}
+	}
+	return name
+}
+
+// Ratings are the Elo ratings of every ranked player by name, kept between matches.
+type Ratings map[string]int
+
+// Get is the player's rating, DefaultRating until they played a ranked match.
+func (r Ratings) Get(id string) int {
+	if rating, ok := r[id]; ok {
+		return rating
+	}
+	return DefaultRating
+}
+
+// Update rates a match as everyone beating those ranked below them, standings best first.
+// It returns how much each rating changed.
+func (r Ratings) Update(standings []string) map[string]int {
+	changes := make(map[string]int, len(standings))
+	if len(standings) < 2 {
+		return changes
+	}
+	// Every player plays everyone else, a match counts as much as a single game
+	k := RatingK / float64(len(standings)-1)
+	for i, a := range standings {
+		delta := 0.0
+		for j, b := range standings {
+			if i == j {
+				continue
+			}
+			expected := 1 / (1 + math.Pow(10, float64(r.Get(b)-r.Get(a))/400))
+			score := 0.0
+			if i < j {
+				score = 1
+			}
+			delta += k * (score - expected)
+		}
+		changes[a] = int(math.Round(delta))
+	}
+	for id, change := range changes {
+		r[id] = r.Get(id) + change
+	}
+	return changes
+}
+
+// Average is the mean rating of the players, DefaultRating for none.
+func (r Ratings) Average(ids []string) int {
+	if len(ids) == 0 {
+		return DefaultRating
+	}
+	total := 0
+	for _, id := range ids {
+		total += r.Get(id)
+	}
+	return total / len(ids)
+}
+
+func (r Ratings) Save(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// LoadRatings reads the ratings saved at path, none when there are none yet.
+func LoadRatings(path string) (Ratings, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return Ratings{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	r := Ratings{}
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
@@ -0,0 +1,49 @@
+package match
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestRatingsUpdate(t *testing.T) {
+	r := Ratings{}
+	changes := r.Update([]string{"alice", "bob", "carol"})
+	if changes["alice"] <= 0 || changes["carol"] >= 0 || changes["bob"] != 0 {
+		t.Errorf("changes = %v, want alice up, bob even and carol down", changes)
+	}
+	if r.Get("alice") != DefaultRating+changes["alice"] || r.Get("dave") != DefaultRating {
+		t.Errorf("ratings = %v", r)
+	}
+
+	// Beating a much stronger player is worth more than beating an equal one
+	r = Ratings{"alice": 1000, "bob": 1400}
+	upset := r.Update([]string{"alice", "bob"})["alice"]
+	even := Ratings{}.Update([]string{"alice", "bob"})["alice"]
+	if upset <= even {
+		t.Errorf("upset win gained %d, even win %d", upset, even)
+	}
+}
+
+func TestRank(t *testing.T) {
+	for rating, want := range map[int]string{0: "Bronze", 899: "Bronze", 1000: "Silver", 1100: "Gold", 2000: "Diamond"} {
+		if got := Rank(rating); got != want {
+			t.Errorf("Rank(%d) = %s, want %s", rating, got, want)
+		}
+	}
+}
+
+func TestRatingsSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), RatingsFile)
+	r, err := LoadRatings(path)
+	if err != nil || len(r) != 0 {
+		t.Fatalf("LoadRatings() before saving = %v, %v", r, err)
+	}
+	r["alice"] = 1234
+	if err := r.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadRatings(path)
+	if err != nil || loaded.Get("alice") != 1234 {
+		t.Errorf("LoadRatings() = %v, %v", loaded, err)
+	}
+}
@@ -0,0 +1,60 @@
+package match
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ResultsFile is where a server keeps the latest finished matches, in its data directory
+const ResultsFile = "results.json"
+
+// Result is a finished match.
+type Result struct {
+	Map     string    `json:"map"`
+	Mode    Mode      `json:"mode"`
+	Ended   time.Time `json:"ended"`
+	Summary Summary   `json:"summary"`
+}
+
+// Results are the latest finished matches, oldest first, kept between runs.
+type Results []Result
+
+// Add returns the results with the match added, keeping the latest n.
+func (r Results) Add(result Result, n int) Results {
+	r = append(r, result)
+	if len(r) > n {
+		r = r[len(r)-n:]
+	}
+	return r
+}
+
+func (r Results) Save(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// LoadResults reads the results saved at path, none when there are none yet.
+func LoadResults(path string) (Results, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return Results{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	r := Results{}
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
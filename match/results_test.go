@@ -0,0 +1,24 @@
+package match
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestResultsSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ResultsFile)
+	r, err := LoadResults(path)
+	if err != nil || len(r) != 0 {
+		t.Fatalf("LoadResults() before saving = %v, %v", r, err)
+	}
+	for _, m := range []string{"warehouse", "refinery", "docks"} {
+		r = r.Add(Result{Map: m}, 2)
+	}
+	if err := r.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadResults(path)
+	if err != nil || len(loaded) != 2 || loaded[0].Map != "refinery" || loaded[1].Map != "docks" {
+		t.Errorf("LoadResults() = %v, %v, want the latest 2", loaded, err)
+	}
+}
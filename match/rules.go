@@ -0,0 +1,167 @@
+package match
+
+import (
+	"fmt"
+	"time"
+
+	"shooter/weapon"
+)
+
+// Mode decides how a match is won.
+type Mode string
+
+const (
+	// Most kills at the frag or time limit wins
+	Deathmatch Mode = "deathmatch"
+	// Every kill moves the killer on to the next of the Weapons, the first to get a kill with the last one wins
+	GunGame Mode = "gungame"
+	// Rounds without respawns and limited ammo, see Series
+	Elimination Mode = "elimination"
+	// Rounds of two players from the Queue in rotating Arenas, the winner stays on
+	Duel Mode = "duel"
+	// Deathmatch which never ends, with shot stats on the HUD
+	Practice Mode = "practice"
+	// Rounds where kills and round wins earn money to spend on weapons, armor and grenades
+	// in the buy phase, see Bank
+	Economy Mode = "economy"
+)
+
+// FriendlyFire is what bullets and grenades do to teammates.
+type FriendlyFire string
+
+const (
+	// Teammates are hurt like enemies
+	FriendlyFireOn FriendlyFire = "on"
+	// Bullets and blasts go through teammates
+	FriendlyFireOff FriendlyFire = "off"
+	// Damage to teammates hurts the shooter instead
+	FriendlyFireReflect FriendlyFire = "reflect"
+)
+
+var FriendlyFireModes = []FriendlyFire{FriendlyFireOn, FriendlyFireOff, FriendlyFireReflect}
+
+var Modes = []Mode{Deathmatch, GunGame, Elimination, Duel, Practice, Economy}
+
+// Weapons of the gun game when the server doesn't list its own
+var DefaultGunGameWeapons = []weapon.ID{weapon.Rifle, weapon.Shotgun, weapon.Pistol}
+
+// Rules are what a match is played by, clients get them when they join and when a match starts.
+type Rules struct {
+	Mode Mode `json:"mode"`
+	// Gun game weapons in order, or the only weapon of elimination
+	Weapons []weapon.ID `json:"weapons,omitempty"`
+	// Rounds per life without reloading, 0 for unlimited ammo
+	Ammo int `json:"ammo,omitempty"`
+	// Any bullet hit kills
+	OneShot bool `json:"one_shot,omitempty"`
+	// Rounds of an elimination series
+	BestOf int `json:"best_of,omitempty"`
+	// Maps duels rotate through
+	Arenas []string `json:"arenas,omitempty"`
+	// Limits of the map in the server's rotation, 0 for TimeLimit and FragLimit
+	TimeLimit time.Duration `json:"time_limit,omitempty"`
+	FragLimit int           `json:"frag_limit,omitempty"`
+	// Empty is FriendlyFireOn
+	FriendlyFire FriendlyFire `json:"friendly_fire,omitempty"`
+	// Knobs of any mode, 0 for the defaults: RespawnTime, full health and normal speed
+	RespawnTime time.Duration `json:"respawn_time,omitempty"`
+	Health      int           `json:"health,omitempty"`
+	SpeedFactor float64       `json:"speed_factor,omitempty"`
+	// Magazines never run empty
+	InfiniteAmmo bool `json:"infinite_ammo,omitempty"`
+	// Players use the ability and ultimate of their class
+	Abilities bool `json:"abilities,omitempty"`
+}
+
+// Respawn is how long dead players wait to come back.
+func (r Rules) Respawn() time.Duration {
+	if r.RespawnTime > 0 {
+		return r.RespawnTime
+	}
+	return RespawnTime
+}
+
+// Speed multiplies how fast players move.
+func (r Rules) Speed() float64 {
+	if r.SpeedFactor > 0 {
+		return r.SpeedFactor
+	}
+	return 1
+}
+
+// Knobs describes the rules set away from their defaults, for server listings.
+func (r Rules) Knobs() []string {
+	var knobs []string
+	if r.RespawnTime > 0 {
+		knobs = append(knobs, fmt.Sprintf("respawn %gs", r.RespawnTime.Seconds()))
+	}
+	if r.Health > 0 {
+		knobs = append(knobs, fmt.Sprintf("%d health", r.Health))
+	}
+	if r.SpeedFactor > 0 && r.SpeedFactor != 1 {
+		knobs = append(knobs, fmt.Sprintf("%gx speed", r.SpeedFactor))
+	}
+	if r.InfiniteAmmo {
+		knobs = append(knobs, "infinite ammo")
+	}
+	if r.Abilities {
+		knobs = append(knobs, "abilities")
+	}
+	if r.FriendlyFire != "" && r.FriendlyFire != FriendlyFireOn {
+		knobs = append(knobs, "friendly fire "+string(r.FriendlyFire))
+	}
+	if r.FragLimit > 0 {
+		knobs = append(knobs, fmt.Sprintf("%d frags", r.FragLimit))
+	}
+	if r.TimeLimit > 0 {
+		knobs = append(knobs, fmt.Sprintf("%g min", r.TimeLimit.Minutes()))
+	}
+	return knobs
+}
+
+// NewRules returns the rules of the mode, unknown modes are deathmatch. Duels take place
+// in the arenas.
+func NewRules(mode Mode, weapons []weapon.ID, arenas []string) Rules {
+	switch mode {
+	case GunGame:
+		if len(weapons) == 0 {
+			weapons = DefaultGunGameWeapons
+		}
+		return Rules{Mode: GunGame, Weapons: weapons}
+	case Elimination:
+		if len(weapons) == 0 {
+			weapons = []weapon.ID{weapon.Pistol}
+		}
+		return Rules{Mode: Elimination, Weapons: weapons[:1], Ammo: EliminationAmmo, OneShot: true, BestOf: EliminationBestOf}
+	case Duel:
+		return Rules{Mode: Duel, Arenas: arenas}
+	case Practice:
+		return Rules{Mode: Practice}
+	case Economy:
+		return Rules{Mode: Economy, BestOf: EconomyBestOf}
+	default:
+		return Rules{Mode: Deathmatch}
+	}
+}
+
+// Rounds is true for modes played in rounds without respawns, see Series.
+func (r Rules) Rounds() bool {
+	return r.Mode == Elimination || r.Mode == Duel || r.Mode == Economy
+}
+
+// Arena is the map of the duel in the round, empty to stay on the match's map.
+func (r Rules) Arena(round int) string {
+	if len(r.Arenas) == 0 || round < 1 {
+		return ""
+	}
+	return r.Arenas[(round-1)%len(r.Arenas)]
+}
+
+// GunGameWeapon is the weapon a gun game player has after the kills, false once they went
+// through them all.
+func (r Rules) GunGameWeapon(kills int) (weapon.ID, bool) {
+	if kills >= len(r.Weapons) {
+		return "", false
+	}
+	return r.Weapons[kills], true
+}
@@ -0,0 +1,47 @@
+package match
+
+import (
+	"time"
+
+	"shooter/weapon"
+)
+
+// Supply is how often a mode drops a supply crate and what's in it.
+type Supply struct {
+	// The first crate comes this far into the match, the next as much later
+	Every time.Duration
+	Loot  []Loot
+}
+
+// Loot is an item of a supply crate, more likely the more Weight it has.
+type Loot struct {
+	Item   weapon.ID
+	Weight int
+}
+
+// Supplies are the long modes' supply drops, the others have none. Armor is only in economy
+// rounds, which keep it in the wallet.
+var Supplies = map[Mode]Supply{
+	Deathmatch: {Every: 2 * time.Minute, Loot: []Loot{{Item: weapon.Rifle, Weight: 3}, {Item: weapon.Shotgun, Weight: 2}}},
+	Practice:   {Every: 2 * time.Minute, Loot: []Loot{{Item: weapon.Rifle, Weight: 1}, {Item: weapon.Shotgun, Weight: 1}}},
+	Economy:    {Every: 75 * time.Second, Loot: []Loot{{Item: weapon.Rifle, Weight: 2}, {Item: weapon.Armor, Weight: 1}}},
+}
+
+// Pick is the item of the loot table at roll, from 0 up to 1.
+func (s Supply) Pick(roll float64) weapon.ID {
+	total := 0
+	for _, l := range s.Loot {
+		total += l.Weight
+	}
+	at := int(roll * float64(total))
+	for _, l := range s.Loot {
+		if at < l.Weight {
+			return l.Item
+		}
+		at -= l.Weight
+	}
+	if len(s.Loot) == 0 {
+		return ""
+	}
+	return s.Loot[len(s.Loot)-1].Item
+}
@@ -0,0 +1,26 @@
+package match
+
+import (
+	"testing"
+
+	"shooter/weapon"
+)
+
+func TestSupplyPick(t *testing.T) {
+	s := Supply{Loot: []Loot{{Item: weapon.Rifle, Weight: 3}, {Item: weapon.Armor, Weight: 1}}}
+	for roll, want := range map[float64]weapon.ID{0: weapon.Rifle, 0.74: weapon.Rifle, 0.75: weapon.Armor, 0.999: weapon.Armor, 1: weapon.Armor} {
+		if got := s.Pick(roll); got != want {
+			t.Errorf("Pick(%v) = %q, want %q", roll, got, want)
+		}
+	}
+	if got := (Supply{}).Pick(0.5); got != "" {
+		t.Errorf("empty loot table gave %q", got)
+	}
+	for mode, s := range Supplies {
+		for _, l := range s.Loot {
+			if l.Item == weapon.Armor && mode != Economy {
+				t.Errorf("%s drops armor outside economy rounds", mode)
+			}
+		}
+	}
+}
@@ -0,0 +1,84 @@
+// Package matchlog is a structured log of everything that happened in a match, written as
+// newline-delimited JSON for stat sites and chat bots to read.
+package matchlog
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"shooter/weapon"
+)
+
+// Type is what an entry is about.
+type Type string
+
+const (
+	MatchStart Type = "match_start"
+	MatchEnd   Type = "match_end"
+	Join       Type = "join"
+	Leave      Type = "leave"
+	Kill       Type = "kill"
+	Pickup     Type = "pickup"
+	// Rounds ending, map events and supply drops landing
+	Objective Type = "objective"
+)
+
+// Entry is one line of the log.
+type Entry struct {
+	Time time.Time `json:"time"`
+	// Seconds into the match
+	Elapsed  float64 `json:"elapsed"`
+	Type     Type    `json:"type"`
+	PlayerID string  `json:"player_id,omitempty"`
+	// Victim of a kill
+	OtherID string    `json:"other_id,omitempty"`
+	Weapon  weapon.ID `json:"weapon,omitempty"`
+	// Where the players of the entry were, or where the objective is at under ""
+	Positions map[string][2]float64 `json:"positions,omitempty"`
+	// Anything else about it, like the summary at the end of the match
+	Data any `json:"data,omitempty"`
+}
+
+// Log is a match's entries in the order they happened.
+type Log struct {
+	Map     string
+	Mode    string
+	Started time.Time
+	entries []Entry
+}
+
+// New starts the log of a match with its first entry.
+func New(mapName, mode string, started time.Time) *Log {
+	l := &Log{Map: mapName, Mode: mode, Started: started}
+	l.Add(started, Entry{Type: MatchStart, Data: map[string]string{"map": mapName, "mode": mode}})
+	return l
+}
+
+// Add appends the entry as of now.
+func (l *Log) Add(now time.Time, e Entry) {
+	e.Time = now
+	e.Elapsed = now.Sub(l.Started).Seconds()
+	l.entries = append(l.entries, e)
+}
+
+func (l *Log) Entries() []Entry {
+	return l.entries
+}
+
+// Write writes the entries one JSON object per line.
+func (l *Log) Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	for _, e := range l.entries {
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FileName names the log by its map and when the match started, they sort by time per map.
+func (l *Log) FileName() string {
+	return fmt.Sprintf("%s-%s.ndjson", l.Map, l.Started.UTC().Format("20060102-150405"))
+}
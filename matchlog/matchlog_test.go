@@ -0,0 +1,45 @@
+package matchlog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestWrite(t *testing.T) {
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	l := New("warehouse", "deathmatch", start)
+	l.Add(start.Add(1500*time.Millisecond), Entry{
+		Type:      Kill,
+		PlayerID:  "alice",
+		OtherID:   "bob",
+		Weapon:    "rifle",
+		Positions: map[string][2]float64{"alice": {10, 20}, "bob": {30, 40}},
+	})
+	l.Add(start.Add(time.Minute), Entry{Type: MatchEnd})
+
+	var buf bytes.Buffer
+	if err := l.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var lines []Entry
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			t.Fatalf("line %q isn't JSON: %v", scanner.Text(), err)
+		}
+		lines = append(lines, e)
+	}
+	if len(lines) != 3 || lines[0].Type != MatchStart || lines[2].Type != MatchEnd {
+		t.Fatalf("lines = %+v, want the start, the kill and the end", lines)
+	}
+	if kill := lines[1]; kill.Elapsed != 1.5 || kill.OtherID != "bob" || kill.Positions["bob"] != [2]float64{30, 40} {
+		t.Errorf("kill = %+v", kill)
+	}
+	if got := l.FileName(); got != "warehouse-20240501-120000.ndjson" {
+		t.Errorf("FileName() = %q", got)
+	}
+}
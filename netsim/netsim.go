@@ -0,0 +1,127 @@
+// Package netsim makes a local connection behave like a bad one, for developing
+// prediction, interpolation and lag compensation on localhost.
+package netsim
+
+import (
+	"encoding/binary"
+	"io"
+	"math/rand/v2"
+	"net"
+	"sync"
+	"time"
+
+	"shooter/wire"
+)
+
+type Options struct {
+	// Added round trip time, half of it in each direction
+	Lag time.Duration
+	// Up to this much is randomly added to or taken off every message's delay, which reorders them
+	Jitter time.Duration
+	// Fraction of messages dropped, 0 to 1
+	Loss float64
+}
+
+func (o Options) Enabled() bool {
+	return o.Lag > 0 || o.Jitter > 0 || o.Loss > 0
+}
+
+func (o Options) delay() time.Duration {
+	d := o.Lag / 2
+	if o.Jitter > 0 {
+		d += time.Duration(rand.Int64N(int64(2*o.Jitter))) - o.Jitter
+	}
+	return max(0, d)
+}
+
+func (o Options) drop() bool {
+	return o.Loss > 0 && rand.Float64() < o.Loss
+}
+
+// Conn delays and drops whole messages, framed by the wire package, so jitter reorders
+// messages and loss drops them without ever cutting one in half.
+type Conn struct {
+	net.Conn
+	opts Options
+
+	writeMu sync.Mutex
+	readMu  sync.Mutex
+	in      *io.PipeReader
+	out     *io.PipeWriter
+	// Written bytes of a message not complete yet, writes don't have to line up with messages
+	framingMu sync.Mutex
+	framing   []byte
+}
+
+// Wrap returns conn unchanged when the options are disabled.
+func Wrap(conn net.Conn, opts Options) net.Conn {
+	if !opts.Enabled() {
+		return conn
+	}
+	in, out := io.Pipe()
+	c := &Conn{Conn: conn, opts: opts, in: in, out: out}
+	go c.receive()
+	return c
+}
+
+// receive reads messages from the real connection and hands them to Read after the delay.
+func (c *Conn) receive() {
+	reader := wire.NewReader(c.Conn)
+	for {
+		msg, err := reader.Read()
+		if err != nil {
+			// Let the delayed messages arrive first
+			time.AfterFunc(c.opts.Lag/2+c.opts.Jitter, func() { c.out.CloseWithError(err) })
+			return
+		}
+		if c.opts.drop() {
+			continue
+		}
+		frame := wire.Frame(msg)
+		time.AfterFunc(c.opts.delay(), func() {
+			c.readMu.Lock()
+			defer c.readMu.Unlock()
+			c.out.Write(frame)
+		})
+	}
+}
+
+func (c *Conn) Read(b []byte) (int, error) {
+	return c.in.Read(b)
+}
+
+// Write always succeeds right away, errors of the delayed writes are lost like the messages.
+// Every complete message written is delayed or dropped on its own.
+func (c *Conn) Write(b []byte) (int, error) {
+	c.framingMu.Lock()
+	c.framing = append(c.framing, b...)
+	var frames [][]byte
+	for len(c.framing) >= wire.HeaderSize {
+		n := wire.HeaderSize + int(binary.BigEndian.Uint32(c.framing))
+		if len(c.framing) < n {
+			break
+		}
+		frames = append(frames, c.framing[:n:n])
+		c.framing = c.framing[n:]
+	}
+	// What's left is copied so the complete frames don't share the buffer with later writes
+	c.framing = append([]byte(nil), c.framing...)
+	c.framingMu.Unlock()
+
+	for _, frame := range frames {
+		if c.opts.drop() {
+			continue
+		}
+		time.AfterFunc(c.opts.delay(), func() {
+			c.writeMu.Lock()
+			defer c.writeMu.Unlock()
+			c.Conn.Write(frame)
+		})
+	}
+	return len(b), nil
+}
+
+func (c *Conn) Close() error {
+	c.in.Close()
+	return c.Conn.Close()
+}
@@ -0,0 +1,83 @@
+package netsim
+
+import (
+	"bytes"
+	"net"
+	"testing"
+	"time"
+
+	"shooter/wire"
+)
+
+func TestWrapDisabledReturnsConn(t *testing.T) {
+	a, b := net.Pipe()
+	defer a.Close()
+	defer b.Close()
+	if Wrap(a, Options{}) != a {
+		t.Error("Wrap() with no options should return the connection itself")
+	}
+}
+
+func TestDelaysMessages(t *testing.T) {
+	a, b := net.Pipe()
+	defer b.Close()
+	conn := Wrap(a, Options{Lag: 100 * time.Millisecond})
+	defer conn.Close()
+
+	start := time.Now()
+	go b.Write(wire.Frame([]byte("hello")))
+	msg, err := wire.NewReader(conn).ReadString()
+	if err != nil || msg != "hello" {
+		t.Fatalf("ReadString() = %q, %v", msg, err)
+	}
+	if d := time.Since(start); d < 50*time.Millisecond {
+		t.Errorf("message arrived after %v, want at least half the lag", d)
+	}
+}
+
+func TestWritesWholeMessages(t *testing.T) {
+	a, b := net.Pipe()
+	defer b.Close()
+	conn := Wrap(a, Options{Lag: 20 * time.Millisecond, Jitter: 10 * time.Millisecond})
+	defer conn.Close()
+
+	// Writes split and join messages anywhere, with a zero length message in between
+	var stream bytes.Buffer
+	for _, msg := range []string{"first", "", "second"} {
+		stream.Write(wire.Frame([]byte(msg)))
+	}
+	data := stream.Bytes()
+	go func() {
+		conn.Write(data[:3])
+		conn.Write(data[3:12])
+		conn.Write(data[12:])
+	}()
+
+	reader := wire.NewReader(b)
+	got := map[string]bool{}
+	for range 3 {
+		msg, err := reader.ReadString()
+		if err != nil {
+			t.Fatalf("ReadString() = %v", err)
+		}
+		got[msg] = true
+	}
+	if !got["first"] || !got[""] || !got["second"] {
+		t.Errorf("read %v, want the three messages in any order", got)
+	}
+}
+
+func TestDropsEverythingAtFullLoss(t *testing.T) {
+	a, b := net.Pipe()
+	defer b.Close()
+	conn := Wrap(a, Options{Loss: 1})
+	defer conn.Close()
+
+	if _, err := conn.Write(wire.Frame([]byte("lost"))); err != nil {
+		t.Fatal(err)
+	}
+	b.SetReadDeadline(time.Now().Add(50 * time.Millisecond))
+	if n, err := b.Read(make([]byte, 16)); err == nil {
+		t.Errorf("read %d bytes, want nothing", n)
+	}
+}
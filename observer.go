@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+
+	"shooter/hud"
+)
+
+// ObserverZoom is how close the camera gets to a followed player.
+const ObserverZoom = 2.0
+
+const observerControls = "1-9: follow player  Tab: next player  0: whole map"
+
+func newObserverHUD(killfeed *hud.Killfeed) *hud.HUD {
+	h := hud.New()
+	h.Add(&hud.DebugInfo{}, hud.TopLeft, 10, 10)
+	h.Add(&hud.ObjectiveStatus{}, hud.TopCenter, 0, 20)
+	h.Add(&hud.Minimap{Width: 320}, hud.TopRight, 20, 20)
+	h.Add(killfeed, hud.TopRight, 20, 230)
+	h.Add(&hud.Scores{}, hud.BottomLeft, 20, 20)
+	return h
+}
+
+// playerIDs returns the IDs of the other players sorted, which is the order of the follow hotkeys.
+func (g *Game) playerIDs() []string {
+	ids := make([]string, 0, len(g.players))
+	for id := range g.players {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+// updateObserver keeps the world going for an observer and switches the camera with hotkeys.
+func (g *Game) updateObserver() {
+	if g.overlay == nil {
+		ids := g.playerIDs()
+		for i, id := range ids[:min(9, len(ids))] {
+			if inpututil.IsKeyJustPressed(ebiten.Key1 + ebiten.Key(i)) {
+				g.followed = id
+			}
+		}
+		if inpututil.IsKeyJustPressed(ebiten.KeyTab) && len(ids) > 0 {
+			// Not following anyone gives -1, so Tab starts at the first player
+			g.followed = ids[(slices.Index(ids, g.followed)+1)%len(ids)]
+		}
+		if inpututil.IsKeyJustPressed(ebiten.Key0) {
+			g.followed = ""
+		}
+	}
+
+	if p, ok := g.players[g.followed]; ok {
+		g.audio.SetListener(p.X, p.Y)
+	} else {
+		g.audio.SetListener(g.level.Width/2, g.level.Height/2)
+	}
+	for _, p := range g.players {
+		p.UpdateBullets()
+	}
+	g.updateGrenades()
+	g.feedback.Update()
+	g.particles.Update(1 / float64(ebiten.TPS()))
+	g.lights.Update(1 / float64(ebiten.TPS()))
+	g.animatePlayers()
+	g.nameTags.Update(g.healthBars(g.player, g.players), 1/float64(ebiten.TPS()))
+}
+
+func (g *Game) drawObserver(screen *ebiten.Image) {
+	g.drawWorld(worldImage, g.player, g.players)
+
+	op := &ebiten.DrawImageOptions{}
+	if p, ok := g.players[g.followed]; ok {
+		// Keep the followed player centered without showing past the edges of the world
+		hw, hh := ScreenWidth/(2*ObserverZoom), ScreenHeight/(2*ObserverZoom)
+		x := math.Max(hw, math.Min(ScreenWidth-hw, p.X))
+		y := math.Max(hh, math.Min(ScreenHeight-hh, p.Y))
+		op.GeoM.Translate(-x, -y)
+		op.GeoM.Scale(ObserverZoom, ObserverZoom)
+		op.GeoM.Translate(ScreenWidth/2, ScreenHeight/2)
+	}
+	g.app.viewport.Apply(op)
+	op.ColorScale.ScaleWithColor(g.lighting.Tint)
+	op.Filter = ebiten.FilterLinear
+	screen.Fill(color.Black)
+	screen.DrawImage(worldImage, op)
+
+	g.hud.Draw(screen, g.observerState())
+	if g.summary != nil {
+		hud.DrawMatchSummary(screen, g.summary, "", g.votes.Counts(), time.Until(g.summary.NextMap))
+	}
+	if g.overlay != nil {
+		g.overlay.Draw(screen)
+	}
+}
+
+// observerState binds what observers see to the HUD: everyone on the minimap and the scores.
+func (g *Game) observerState() *hud.State {
+	objective := "SPECTATING"
+	if _, ok := g.players[g.followed]; ok {
+		objective = fmt.Sprintf("SPECTATING %s", g.followed)
+	}
+	state := &hud.State{
+		WorldWidth:  g.level.Width,
+		WorldHeight: g.level.Height,
+		Objects:     g.Objects,
+		Objective:   objective,
+		Scores:      g.scores.Summary(g.now(), nil).Players,
+		Pings:       g.pings.Active(g.now()),
+		Controls:    observerControls,
+		TPS:         ebiten.ActualTPS(),
+		FPS:         ebiten.ActualFPS(),
+	}
+	if g.app.cfg.HUD.FrameTimes {
+		state.FrameTimes = g.frames.Average()
+	}
+	for _, id := range g.playerIDs() {
+		p := g.players[id]
+		state.Players = append(state.Players, hud.MinimapPlayer{X: p.X, Y: p.Y, Local: id == g.followed})
+	}
+	return state
+}
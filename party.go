@@ -0,0 +1,359 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"slices"
+	"sync"
+
+	"shooter/player"
+	"shooter/wire"
+)
+
+const (
+	// Where the party leader's game listens for members
+	PartyPort = ":8081"
+	// Lines of party chat kept for the menu
+	PartyChatLines = 5
+	// Leader included, others are turned away
+	MaxPartySize = 4
+	// Member statuses
+	PartyInMenu = "in menu"
+)
+
+// PartyMember is someone in the party and what they are up to, members send their own
+// to the leader whenever it changes.
+type PartyMember struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+}
+
+// PartyState is sent by the leader to everyone whenever the party changes.
+type PartyState struct {
+	Leader  string        `json:"leader"`
+	Members []PartyMember `json:"members"`
+}
+
+// PartyChat is a line of party chat, relayed by the leader.
+type PartyChat struct {
+	From string `json:"from"`
+	Text string `json:"text"`
+}
+
+// PartyJoin tells members to follow the leader into the server.
+type PartyJoin struct {
+	Server string `json:"server"`
+}
+
+// Party is a group of players who join servers together. The leader's game hosts it, the
+// other members connect to the leader.
+type Party struct {
+	mu     sync.Mutex
+	name   string
+	state  PartyState
+	chat   []string
+	closed bool
+	// Server the leader joined, for the game to follow, empty when none is pending
+	follow string
+
+	// The leader's
+	listener net.Listener
+	members  map[net.Conn]PartyMember
+	// Members' connections in joining order
+	order []net.Conn
+
+	// A member's, and the host of the leader for servers the leader has on loopback
+	conn       net.Conn
+	leaderHost string
+}
+
+// HostParty starts a party with the player as the leader.
+func HostParty(name string) (*Party, error) {
+	listener, err := net.Listen("tcp", PartyPort)
+	if err != nil {
+		return nil, err
+	}
+	p := &Party{name: name, listener: listener, members: map[net.Conn]PartyMember{}}
+	p.state = PartyState{Leader: name, Members: []PartyMember{{Name: name, Status: PartyInMenu}}}
+	go p.accept()
+	return p, nil
+}
+
+// JoinParty joins the party of the leader at the address.
+func JoinParty(name, address string) (*Party, error) {
+	conn, err := net.DialTimeout("tcp", address, HandshakeTimeout)
+	if err != nil {
+		return nil, err
+	}
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	p := &Party{name: name, conn: conn, leaderHost: host}
+	if err := p.send(conn, player.EventTypePartyMember, PartyMember{Name: name, Status: PartyInMenu}); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	go p.listen(conn)
+	return p, nil
+}
+
+// Leader is true for the party of the player leading it.
+func (p *Party) Leader() bool {
+	return p.listener != nil
+}
+
+// ID is what the party is known by on servers, its leader's name.
+func (p *Party) ID() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.state.Leader
+}
+
+func (p *Party) State() PartyState {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return PartyState{Leader: p.state.Leader, Members: slices.Clone(p.state.Members)}
+}
+
+// Chat is the latest lines of party chat, oldest first.
+func (p *Party) Chat() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return slices.Clone(p.chat)
+}
+
+// Closed is true once the player left the party or the leader went away.
+func (p *Party) Closed() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.closed
+}
+
+// SetStatus tells the party what the player is up to.
+func (p *Party) SetStatus(status string) {
+	if !p.Leader() {
+		p.send(p.conn, player.EventTypePartyMember, PartyMember{Name: p.name, Status: status})
+		return
+	}
+	p.mu.Lock()
+	p.state.Members[0].Status = status
+	p.mu.Unlock()
+	p.sendState()
+}
+
+// Say sends a line of party chat.
+func (p *Party) Say(text string) {
+	if text == "" {
+		return
+	}
+	chat := PartyChat{From: p.name, Text: text}
+	if !p.Leader() {
+		p.send(p.conn, player.EventTypePartyChat, chat)
+		return
+	}
+	p.addChat(chat)
+	p.sendAll(player.EventTypePartyChat, chat)
+}
+
+// Follow has the members join the server the leader joined.
+func (p *Party) Follow(server string) {
+	if p.Leader() {
+		p.sendAll(player.EventTypePartyJoin, PartyJoin{Server: server})
+	}
+}
+
+// TakeFollow returns the server the leader joined once, false when there is none to follow.
+func (p *Party) TakeFollow() (string, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	server := p.follow
+	p.follow = ""
+	return server, server != ""
+}
+
+// Leave leaves the party, which ends it for everyone when it's the leader.
+func (p *Party) Leave() {
+	p.mu.Lock()
+	p.closed = true
+	p.mu.Unlock()
+	if p.Leader() {
+		p.listener.Close()
+		p.mu.Lock()
+		for c := range p.members {
+			c.Close()
+		}
+		p.mu.Unlock()
+		return
+	}
+	p.conn.Close()
+}
+
+func (p *Party) accept() {
+	for {
+		conn, err := p.listener.Accept()
+		if errors.Is(err, net.ErrClosed) {
+			return
+		}
+		if err != nil {
+			log.Println("Party connection error:", err)
+			continue
+		}
+		go p.listen(conn)
+	}
+}
+
+// listen handles the events of a member on the leader's side, or of the leader on a member's.
+func (p *Party) listen(conn net.Conn) {
+	defer func() {
+		conn.Close()
+		p.mu.Lock()
+		_, member := p.members[conn]
+		delete(p.members, conn)
+		p.order = slices.DeleteFunc(p.order, func(c net.Conn) bool { return c == conn })
+		if !p.Leader() {
+			p.closed = true
+		}
+		p.mu.Unlock()
+		if member {
+			p.sendState()
+		}
+	}()
+	reader := wire.NewReader(conn)
+	for {
+		msg, err := reader.ReadString()
+		if err != nil {
+			return
+		}
+		var event player.Event
+		if err := json.Unmarshal([]byte(msg), &event); err != nil {
+			log.Println("Error unmarshaling party event:", err)
+			continue
+		}
+		if err := p.handle(conn, event); err != nil {
+			log.Println("Error handling party event:", err)
+		}
+	}
+}
+
+func (p *Party) handle(conn net.Conn, event player.Event) error {
+	switch event.Type {
+	case player.EventTypePartyMember:
+		var m PartyMember
+		if err := json.Unmarshal(event.Data, &m); err != nil || !p.Leader() {
+			return err
+		}
+		p.mu.Lock()
+		if joined, ok := p.members[conn]; ok {
+			// A member keeps the name it joined with, only its status changes
+			m.Name = joined.Name
+		} else {
+			if len(p.order) >= MaxPartySize-1 {
+				p.mu.Unlock()
+				conn.Close()
+				return fmt.Errorf("party is full, turned away %s", m.Name)
+			}
+			p.order = append(p.order, conn)
+		}
+		p.members[conn] = m
+		p.mu.Unlock()
+		p.sendState()
+	case player.EventTypePartyState:
+		// Only the leader says who is in the party, and it is always in it
+		var state PartyState
+		if err := json.Unmarshal(event.Data, &state); err != nil || p.Leader() {
+			return err
+		}
+		if len(state.Members) == 0 {
+			return errors.New("party state without members")
+		}
+		p.mu.Lock()
+		p.state = state
+		p.mu.Unlock()
+	case player.EventTypePartyChat:
+		var chat PartyChat
+		if err := json.Unmarshal(event.Data, &chat); err != nil {
+			return err
+		}
+		if p.Leader() {
+			// Members speak as who they joined as, not as whoever they claim to be
+			p.mu.Lock()
+			m, ok := p.members[conn]
+			p.mu.Unlock()
+			if !ok {
+				return nil
+			}
+			chat.From = m.Name
+			p.addChat(chat)
+			p.sendAll(player.EventTypePartyChat, chat)
+			return nil
+		}
+		p.addChat(chat)
+	case player.EventTypePartyJoin:
+		var join PartyJoin
+		if err := json.Unmarshal(event.Data, &join); err != nil || p.Leader() {
+			return err
+		}
+		p.mu.Lock()
+		p.follow = p.reachable(join.Server)
+		p.mu.Unlock()
+	}
+	return nil
+}
+
+// reachable is the server address as the member reaches it, a server on the leader's
+// loopback is on the leader's host.
+func (p *Party) reachable(server string) string {
+	host, port, err := net.SplitHostPort(server)
+	if err != nil {
+		return server
+	}
+	if ip := net.ParseIP(host); host == "localhost" || ip != nil && ip.IsLoopback() {
+		return net.JoinHostPort(p.leaderHost, port)
+	}
+	return server
+}
+
+func (p *Party) addChat(chat PartyChat) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.chat = append(p.chat, fmt.Sprintf("%s: %s", chat.From, chat.Text))
+	if len(p.chat) > PartyChatLines {
+		p.chat = p.chat[len(p.chat)-PartyChatLines:]
+	}
+}
+
+// sendState sends the leader's view of the party to the members, in joining order.
+func (p *Party) sendState() {
+	p.mu.Lock()
+	members := []PartyMember{p.state.Members[0]}
+	for _, c := range p.order {
+		members = append(members, p.members[c])
+	}
+	p.state.Members = members
+	p.mu.Unlock()
+	p.sendAll(player.EventTypePartyState, p.State())
+}
+
+// sendAll sends the event to every member, the leader only.
+func (p *Party) sendAll(eventType player.EventType, data any) {
+	p.mu.Lock()
+	conns := slices.Clone(p.order)
+	p.mu.Unlock()
+	for _, c := range conns {
+		p.send(c, eventType, data)
+	}
+}
+
+func (p *Party) send(conn net.Conn, eventType player.EventType, data any) error {
+	msg, err := encodeEvent(eventType, data)
+	if err != nil {
+		return err
+	}
+	_, err = conn.Write(msg)
+	return err
+}
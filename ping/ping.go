@@ -0,0 +1,84 @@
+// Package ping keeps the markers players place in the world to point teammates at something.
+package ping
+
+import (
+	"math"
+	"time"
+)
+
+type Kind string
+
+const (
+	GoHere       Kind = "go"
+	Danger       Kind = "danger"
+	EnemySpotted Kind = "enemy"
+)
+
+// Kinds in wheel order, clockwise starting at the top
+var Kinds = []Kind{GoHere, Danger, EnemySpotted}
+
+const (
+	// How long a marker stays, it fades out over the last FadeTime
+	Lifetime = 6 * time.Second
+	FadeTime = time.Second
+	// Older markers of a player are removed when they place more
+	MaxPerPlayer = 3
+	// Cursor movement since pressing below this picks GoHere without looking at the wheel
+	WheelDeadzone = 30.0
+)
+
+type Marker struct {
+	Kind    Kind
+	Owner   string
+	X, Y    float64
+	Created time.Time
+}
+
+// Alpha is 1 for a fresh marker and fades to 0 when it expires.
+func (m Marker) Alpha(now time.Time) float64 {
+	left := Lifetime - now.Sub(m.Created)
+	return math.Max(0, math.Min(1, float64(left)/float64(FadeTime)))
+}
+
+type Markers struct {
+	markers []Marker
+}
+
+func (ms *Markers) Add(m Marker) {
+	n := 0
+	for i := len(ms.markers) - 1; i >= 0; i-- {
+		if ms.markers[i].Owner != m.Owner {
+			continue
+		}
+		n++
+		if n >= MaxPerPlayer {
+			ms.markers = append(ms.markers[:i], ms.markers[i+1:]...)
+		}
+	}
+	ms.markers = append(ms.markers, m)
+}
+
+// Active drops expired markers and returns the rest, oldest first.
+func (ms *Markers) Active(now time.Time) []Marker {
+	kept := ms.markers[:0]
+	for _, m := range ms.markers {
+		if now.Sub(m.Created) < Lifetime {
+			kept = append(kept, m)
+		}
+	}
+	ms.markers = kept
+	return kept
+}
+
+// WheelKind picks the kind from how far the cursor moved since the ping key went down.
+// Screen y grows downwards, so up is negative dy.
+func WheelKind(dx, dy float64) Kind {
+	if math.Hypot(dx, dy) < WheelDeadzone {
+		return GoHere
+	}
+	// Clockwise from the top, each kind gets an equal slice centered on its direction
+	a := math.Atan2(dx, -dy)
+	slice := 2 * math.Pi / float64(len(Kinds))
+	i := int(math.Floor(a/slice+0.5+float64(len(Kinds)))) % len(Kinds)
+	return Kinds[i]
+}
@@ -0,0 +1,45 @@
+package ping
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWheelKind(t *testing.T) {
+	tests := []struct {
+		dx, dy float64
+		want   Kind
+	}{
+		{0, 0, GoHere},
+		{10, 5, GoHere},
+		{0, -100, GoHere},
+		{90, 50, Danger},
+		{-90, 50, EnemySpotted},
+		{-20, 100, EnemySpotted},
+	}
+	for _, tt := range tests {
+		if got := WheelKind(tt.dx, tt.dy); got != tt.want {
+			t.Errorf("WheelKind(%v, %v) = %q, want %q", tt.dx, tt.dy, got, tt.want)
+		}
+	}
+}
+
+func TestMarkers(t *testing.T) {
+	now := time.Now()
+	var ms Markers
+	for i := range MaxPerPlayer + 1 {
+		ms.Add(Marker{Owner: "alice", X: float64(i), Created: now})
+	}
+	ms.Add(Marker{Owner: "bob", Created: now.Add(-Lifetime)})
+
+	active := ms.Active(now)
+	if len(active) != MaxPerPlayer {
+		t.Fatalf("len(Active()) = %d, want %d", len(active), MaxPerPlayer)
+	}
+	if active[0].X != 1 {
+		t.Errorf("oldest marker X = %v, want 1", active[0].X)
+	}
+	if a := active[0].Alpha(now.Add(Lifetime - FadeTime/2)); a != 0.5 {
+		t.Errorf("Alpha() = %v, want 0.5", a)
+	}
+}
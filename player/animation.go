@@ -0,0 +1,86 @@
+package player
+
+import (
+	"fmt"
+	"time"
+
+	"shooter/render/anim"
+	"shooter/utils"
+)
+
+type AnimState string
+
+const (
+	AnimIdle   AnimState = "idle"
+	AnimWalk   AnimState = "walk"
+	AnimShoot  AnimState = "shoot"
+	AnimReload AnimState = "reload"
+	AnimDeath  AnimState = "death"
+)
+
+// How long after a shot the shoot clip keeps playing
+const shootAnimTime = 150 * time.Millisecond
+
+var clipDefs = []struct {
+	state     AnimState
+	frameTime time.Duration
+	loop      bool
+}{
+	{AnimIdle, 100 * time.Millisecond, true},
+	{AnimWalk, 80 * time.Millisecond, true},
+	{AnimShoot, 50 * time.Millisecond, false},
+	{AnimReload, 100 * time.Millisecond, false},
+	{AnimDeath, 120 * time.Millisecond, false},
+}
+
+// Sprite sheets are shared between players, animators only keep per player playback state.
+var playerClips = loadClips()
+
+// loadClips loads sheets from assets/player/<state>.png with frames as wide as PlayerSprite,
+// clips without a sheet fall back to the static sprite.
+func loadClips() []*anim.Clip {
+	frameWidth := PlayerSprite.Bounds().Dx()
+
+	clips := make([]*anim.Clip, 0, len(clipDefs))
+	for _, def := range clipDefs {
+		sheet, err := utils.LoadImage(fmt.Sprintf("assets/player/%s.png", def.state))
+		if err != nil {
+			sheet = PlayerSprite
+		}
+		clips = append(clips, anim.NewClip(string(def.state), sheet, frameWidth, def.frameTime, def.loop))
+	}
+	return clips
+}
+
+func newAnimator() *anim.Animator {
+	return anim.NewAnimator(playerClips...)
+}
+
+// localAnimState picks the clip for the locally simulated player.
+func (p *Player) localAnimState(moving bool, now time.Time) AnimState {
+	switch {
+	case p.Health <= 0:
+		return AnimDeath
+	case p.Reloading():
+		return AnimReload
+	case now.Sub(p.lastShot) < shootAnimTime:
+		return AnimShoot
+	case moving:
+		return AnimWalk
+	default:
+		return AnimIdle
+	}
+}
+
+// Animate advances the animation of the clip selected by Anim, which is either
+// computed in Update for the local player or synced for remote ones.
+func (p *Player) Animate(dt time.Duration) {
+	if p.Health <= 0 {
+		p.Anim = AnimDeath
+	} else if p.Anim == "" {
+		p.Anim = AnimIdle
+	}
+	p.animator.Play(string(p.Anim))
+	p.animator.Update(dt)
+	p.updateWounds(dt)
+}
@@ -2,7 +2,6 @@ package player
 
 import (
 	"encoding/json"
-	"fmt"
 	"image"
 	"image/color"
 	"math"
@@ -10,30 +9,102 @@ import (
 	"time"
 
 	"github.com/hajimehoshi/ebiten/v2"
-	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
 	"github.com/hajimehoshi/ebiten/v2/vector"
 
 	"shooter/game"
+	"shooter/input"
+	"shooter/render/anim"
+	"shooter/render/batch"
 	"shooter/utils"
+	"shooter/weapon"
 )
 
 const (
 	MaxHealth               = 100
 	PlayerSpeed             = 1.0
 	PlayerSprintSpeedFactor = 2.0
-	BulletSpeed             = 120.0
-	PlayerRadius            = 10.0
-	BulletRadius            = 3.0
-	ShootCooldown           = 50 * time.Millisecond
+	// Aiming down sights trades movement for accuracy, no sprinting while aiming
+	ADSSpeedFactor  = 0.5
+	ADSSpreadFactor = 0.4
+	PlayerRadius    = 10.0
+	BulletRadius    = 3.0
+	StepLength      = 40.0
+	SpriteScale     = 0.25
+
+	// Grenades and decoys carried after spawning
+	MaxGrenades = 2
+	MaxDecoys   = 1
+
+	BulletTrailLength = 220.0
+	BulletWidth       = 1.7
+
+	// Tick rate the per tick speeds are tuned for
+	BaseTPS = 60
 )
 
-var PlayerSprite = utils.MustLoadImage("assets/survivor-idle_rifle_0.png")
+// tickScale converts per tick speeds to the current tick rate, so the game speed doesn't depend on TPS.
+func tickScale() float64 {
+	return BaseTPS / float64(ebiten.TPS())
+}
+
+var PlayerSprite = utils.Image("assets/survivor-idle_rifle_0.png", 313, 207)
+
+// ProtocolVersion is checked in the handshake, server and client must have the same.
+// New event types and new fields don't need a bump, both sides skip what they don't know.
+// Removing or changing the meaning of a field or event does.
+const ProtocolVersion = 2
 
 type EventType string
 
 const (
-	EventTypePlayerUpdate EventType = "player_update"
-	EventTypePlayerHit    EventType = "player_hit"
+	EventTypePlayerUpdate   EventType = "player_update"
+	EventTypePlayerHit      EventType = "player_hit"
+	EventTypePlayerKilled   EventType = "player_killed"
+	EventTypeMatchEnd       EventType = "match_end"
+	EventTypeMatchStart     EventType = "match_start"
+	EventTypeMapVote        EventType = "map_vote"
+	EventTypeShoot          EventType = "shoot"
+	EventTypeBulletSpawn    EventType = "bullet_spawn"
+	EventTypeBulletDestroy  EventType = "bullet_destroy"
+	EventTypeHello          EventType = "hello"
+	EventTypeWelcome        EventType = "welcome"
+	EventTypeReject         EventType = "reject"
+	EventTypeServerShutdown EventType = "server_shutdown"
+	EventTypePing           EventType = "ping"
+	EventTypeGrenade        EventType = "grenade"
+	EventTypeDeploy         EventType = "deploy"
+	EventTypeEntities       EventType = "entities"
+	EventTypeEntityPlaced   EventType = "entity_placed"
+	EventTypeEntityRemoved  EventType = "entity_removed"
+	EventTypeNoise          EventType = "noise"
+	EventTypeZone           EventType = "zone"
+	EventTypeGunGame        EventType = "gun_game"
+	EventTypeRound          EventType = "round"
+	EventTypeCallVote       EventType = "call_vote"
+	EventTypeCastVote       EventType = "cast_vote"
+	EventTypePoll           EventType = "poll"
+	EventTypeChangeMap      EventType = "change_map"
+	EventTypeMapRequest     EventType = "map_request"
+	EventTypeMapData        EventType = "map_data"
+	EventTypePickup         EventType = "pickup"
+	EventTypeHidden         EventType = "hidden"
+	EventTypeWeaponData     EventType = "weapon_data"
+	EventTypeServerInfo     EventType = "server_info"
+	EventTypePartyMember    EventType = "party_member"
+	EventTypePartyState     EventType = "party_state"
+	EventTypePartyChat      EventType = "party_chat"
+	EventTypePartyJoin      EventType = "party_join"
+	EventTypeBuy            EventType = "buy"
+	EventTypeWallet         EventType = "wallet"
+	EventTypeAbility        EventType = "ability"
+	EventTypeAbilities      EventType = "abilities"
+	EventTypeScan           EventType = "scan"
+	EventTypeRide           EventType = "ride"
+	EventTypeDrive          EventType = "drive"
+	EventTypeMapEvent       EventType = "map_event"
+	EventTypeTick           EventType = "tick"
+	EventTypeMoveCorrection EventType = "move_correction"
+	EventTypeSeed           EventType = "seed"
 )
 
 type Event struct {
@@ -48,10 +119,53 @@ type Player struct {
 	Angle      float64   `json:"angle"`
 	Health     int       `json:"health"`
 	Bullets    []*Bullet `json:"bullets"`
+	Anim       AnimState `json:"anim"`
+	Weapon     weapon.ID `json:"weapon"`
+	Aiming     bool      `json:"aiming"`
+	Flashlight bool      `json:"flashlight"`
+	Team       string    `json:"team"`
+	// One of Skins, overridden by the team color in team modes
+	Skin string `json:"skin"`
+	// Grenades left until the next respawn
+	Grenades int `json:"-"`
+	Decoys   int `json:"-"`
+	// Movement is multiplied by it, set by the game from the ground under the player. 0 counts as 1.
+	SpeedFactor float64 `json:"-"`
+	// Set by modes handing out the weapon, see GiveWeapon
+	WeaponLocked bool `json:"-"`
+	// Set by modes with limited ammo, the magazine is all there is, see LimitAmmo
+	NoReload bool `json:"-"`
+	// Set by the game from the rules, shooting doesn't use up the magazine
+	InfiniteAmmo bool `json:"-"`
+	// Health the player respawns with, set by the game from the rules. 0 counts as MaxHealth.
+	StartHealth int `json:"-"`
+	// Out of sight as far as the server is concerned, where they are isn't known until it
+	// sends the next update
+	Hidden bool `json:"-"`
+	// Spread of every bullet follows from the seed and its number since the last spawn,
+	// see weapon.SpreadOffset. The server gives the seed when joining it.
+	Seed uint64 `json:"seed"`
+	Shot int    `json:"shot"`
+	// Bullets fired since the last TakeShots, to be sent to the server
+	shots []*Bullet
+	// Consecutive shots of the current spray, recovering between shots, see weapon.Recoil
+	spray      float64
 	lastShot   time.Time `json:"-"`
 	sprite     *ebiten.Image
+	animator   *anim.Animator
 	playerShot bool
-	capacity   int16
+	ammo       map[weapon.ID]int
+	// Weapons fitted with attachments, see SetAttachment
+	loadout map[weapon.ID]*weapon.Weapon
+
+	stepDistance   float64
+	reloadDone     time.Time
+	playerReloaded bool
+
+	// Walk cycle of the limp in [0, 1), see limpAngle
+	limpPhase float64
+	// When the player was seen dead, for the corpse to fade
+	diedAt time.Time
 }
 
 func (player Player) SpriteBounds() image.Rectangle {
@@ -60,8 +174,8 @@ func (player Player) SpriteBounds() image.Rectangle {
 
 func (p *Player) HitBox() game.Object {
 	// TODO: this is crap, create new object with centered x,y and use wh
-	dx := float64(p.SpriteBounds().Dx()) * 0.25
-	dy := float64(p.SpriteBounds().Dy()) * 0.25
+	dx := float64(p.SpriteBounds().Dx()) * SpriteScale
+	dy := float64(p.SpriteBounds().Dy()) * SpriteScale
 	return game.Object{Walls: game.Rect(
 		p.X-dx/2,
 		p.Y-dy/2,
@@ -71,6 +185,11 @@ func (p *Player) HitBox() game.Object {
 }
 
 func NewPlayer(id string, x, y float64) *Player {
+	ammo := make(map[weapon.ID]int, len(weapon.Loadout))
+	for _, id := range weapon.Loadout {
+		ammo[id] = weapon.Get(id).MagazineSize
+	}
+
 	return &Player{
 		ID:         id,
 		X:          x,
@@ -79,20 +198,38 @@ func NewPlayer(id string, x, y float64) *Player {
 		Health:     MaxHealth,
 		Bullets:    []*Bullet{},
 		lastShot:   time.Time{},
+		Anim:       AnimIdle,
+		Weapon:     weapon.Rifle,
+		Seed:       rand.Uint64(),
 		sprite:     PlayerSprite,
+		animator:   newAnimator(),
 		playerShot: false,
-		capacity:   30,
+		ammo:       ammo,
+		Grenades:   MaxGrenades,
+		Decoys:     MaxDecoys,
 	}
 }
 
 type Bullet struct {
+	// Assigned by the server, 0 until it confirmed the shot
+	ID        uint64  `json:"id"`
 	OwnerID   string  `json:"owner_id"`
 	X         float64 `json:"x"`
 	Y         float64 `json:"y"`
 	EndX      float64 `json:"end_x"`
 	EndY      float64 `json:"end_y"`
 	Direction float64 `json:"direction"`
-	Velocity  float64 `json:"velocity"`
+	// Where the owner aimed, Direction is off it by the recoil and the shot's spread
+	Aim      float64   `json:"aim"`
+	Velocity float64   `json:"velocity"`
+	Damage   int       `json:"damage"`
+	Weapon   weapon.ID `json:"weapon"`
+	// Number of the shot for the owner's seed
+	Shot int `json:"shot"`
+	// Fired through a suppressor, drawn without a tracer
+	Suppressed bool `json:"suppressed,omitempty"`
+	// Set when fired by one of the owner's deployed entities, see sim.Entity
+	EntityID uint64 `json:"entity_id,omitempty"`
 }
 
 func (p *Player) UpdateOnObstacle() {
@@ -158,55 +295,123 @@ func (p *Player) UpdateOnObstacle() {
 // 	}
 // }
 
-func (p *Player) Update(hitsObstacle bool) {
-	p.playerShot = false
-	if p.Health <= 0 {
-		return
-	}
+// HasShot reports whether the player fired during the last update.
+func (p *Player) HasShot() bool {
+	return p.playerShot
+}
 
-	moveX, moveY := 0.0, 0.0
-	movementSpeed := PlayerSpeed
+// HasReloaded reports whether the player started reloading during the last update.
+func (p *Player) HasReloaded() bool {
+	return p.playerReloaded
+}
 
-	if ebiten.IsKeyPressed(ebiten.KeyShiftLeft) {
-		movementSpeed *= PlayerSprintSpeedFactor
+// Stepped accumulates walked distance and reports when the next footstep should be heard.
+func (p *Player) Stepped(dist float64) bool {
+	p.stepDistance += dist
+	if p.stepDistance < StepLength {
+		return false
 	}
+	p.stepDistance = 0
+	return true
+}
+
+func (p *Player) Reloading() bool {
+	return !p.reloadDone.IsZero()
+}
 
-	if ebiten.IsKeyPressed(ebiten.KeyW) {
-		moveY -= movementSpeed
+// Reload starts reloading the held weapon at now, done ReloadTime later.
+func (p *Player) Reload(now time.Time) {
+	w := p.CurrentWeapon()
+	if p.NoReload || p.Reloading() || p.Ammo() >= w.MagazineSize {
+		return
 	}
-	if ebiten.IsKeyPressed(ebiten.KeyS) {
-		moveY += movementSpeed
+	p.reloadDone = now.Add(w.ReloadTime)
+	p.playerReloaded = true
+}
+
+// FullHealth is the health the player respawns with.
+func (p *Player) FullHealth() int {
+	if p.StartHealth > 0 {
+		return p.StartHealth
 	}
-	if ebiten.IsKeyPressed(ebiten.KeyA) {
-		moveX -= movementSpeed
+	return MaxHealth
+}
+
+// Respawn brings a dead player back at x, y with full health and ammo.
+func (p *Player) Respawn(x, y float64) {
+	p.X, p.Y = x, y
+	p.Health = p.FullHealth()
+	p.Bullets = p.Bullets[:0]
+	p.Shot = 0
+	p.spray = 0
+	p.reloadDone = time.Time{}
+	p.Grenades = MaxGrenades
+	p.Decoys = MaxDecoys
+	for id := range p.ammo {
+		p.ammo[id] = p.loadoutWeapon(id).MagazineSize
 	}
-	if ebiten.IsKeyPressed(ebiten.KeyD) {
-		moveX += movementSpeed
+}
+
+// Update moves and acts on the input of the local player at now, the time of the game's tick.
+func (p *Player) Update(in input.State, now time.Time) {
+	p.playerShot = false
+	p.playerReloaded = false
+	if p.Health <= 0 {
+		p.Anim = AnimDeath
+		return
 	}
 
-	p.X += moveX
-	if hitsObstacle {
-		p.X -= moveX // Revert horizontal movement if collides
+	movementSpeed := PlayerSpeed * tickScale()
+	if p.SpeedFactor > 0 {
+		movementSpeed *= p.SpeedFactor
+	}
+	switch {
+	case in.Aim:
+		movementSpeed *= ADSSpeedFactor
+	case in.Sprint:
+		movementSpeed *= PlayerSprintSpeedFactor
 	}
+	moveX, moveY := in.MoveX*movementSpeed, in.MoveY*movementSpeed
 
-	// // Move vertically and check collision
+	// Walls are up to the caller, see game.Move
+	p.X += moveX
 	p.Y += moveY
-	if hitsObstacle {
-		p.Y -= moveY // Revert vertical movement if collides
+
+	p.Angle = in.AimAngle
+
+	// Weapon switching
+	if in.WeaponSlot >= 0 && in.WeaponSlot < len(weapon.Loadout) && !p.WeaponLocked {
+		p.SwitchWeapon(weapon.Loadout[in.WeaponSlot])
+	}
+	if in.WeaponCycle != 0 && !p.WeaponLocked {
+		p.SwitchWeapon(weapon.Cycle(p.Weapon, in.WeaponCycle))
+	}
+	p.Aiming = in.Aim
+	if in.ToggleFlashlight {
+		p.Flashlight = !p.Flashlight
 	}
 
-	// Update aiming angle
-	mx, my := ebiten.CursorPosition()
-	dx, dy := float64(mx)-p.X, float64(my)-p.Y
-	p.Angle = math.Atan2(dy, dx)
+	// Reloading
+	if p.Reloading() && now.After(p.reloadDone) {
+		p.ammo[p.Weapon] = p.CurrentWeapon().MagazineSize
+		p.reloadDone = time.Time{}
+	}
+	if in.Reload || p.Ammo() <= 0 {
+		p.Reload(now)
+	}
 
 	// Shooting
-	if ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft) && time.Since(p.lastShot) > ShootCooldown {
+	p.spray = p.CurrentWeapon().Recoil.Recover(p.spray, 1/float64(ebiten.TPS()))
+	if in.Shoot && now.Sub(p.lastShot) > p.CurrentWeapon().Cooldown && !p.Reloading() {
 		p.Shoot()
-		p.lastShot = time.Now()
+		p.lastShot = now
 	}
+	p.Anim = p.localAnimState(moveX != 0 || moveY != 0, now)
+
+	p.UpdateBullets()
+}
 
-	// Update bullets
+func (p *Player) UpdateBullets() {
 	for i := len(p.Bullets) - 1; i >= 0; i-- {
 		p.Bullets[i].Update()
 		if p.Bullets[i].OutOfBounds(1600, 900) {
@@ -216,74 +421,126 @@ func (p *Player) Update(hitsObstacle bool) {
 }
 
 func (p *Player) Draw(screen *ebiten.Image) {
+	alpha := p.corpseAlpha()
+	if alpha == 0 {
+		// Dead long enough for the body to be gone
+		return
+	}
 	vector.DrawFilledCircle(screen, float32(p.X), float32(p.Y), PlayerRadius, color.RGBA{0, 255, 0, 255}, true)
-	ebitenutil.DebugPrintAt(screen, "WASD: move", 160, 0)
-	ebitenutil.DebugPrintAt(screen, fmt.Sprintf("TPS: %0.2f", ebiten.ActualTPS()), 51, 51)
-	ebitenutil.DebugPrintAt(screen, fmt.Sprintf("FPS: %0.2f", ebiten.ActualFPS()), 51, 61)
 
 	// TODO: separate player package for logic and ui
-	bounds := p.SpriteBounds()
+	frame := p.animator.Frame()
+	bounds := frame.Bounds()
 	opPlayer := &ebiten.DrawImageOptions{}
+	if tint, ok := p.Tint(); ok {
+		opPlayer.ColorScale.ScaleWithColor(tint)
+	}
+	p.woundTint(opPlayer)
+	if p.Health <= 0 {
+		opPlayer.ColorScale.Scale(0.4*alpha, 0.4*alpha, 0.4*alpha, alpha)
+	}
 
 	hw := float64(bounds.Dx() / 2)
 	hh := float64(bounds.Dy() / 2)
 
 	opPlayer.GeoM.Translate(-hw, -hh)
-	opPlayer.GeoM.Scale(0.25, 0.25)
-	opPlayer.GeoM.Rotate(p.Angle)
+	opPlayer.GeoM.Scale(SpriteScale, SpriteScale)
+	opPlayer.GeoM.Rotate(p.Angle + p.limpAngle())
 	// op.GeoM.Translate(hw, hh)
 	opPlayer.GeoM.Translate(p.X, p.Y)
 
-	screen.DrawImage(p.sprite, opPlayer)
+	screen.DrawImage(frame, opPlayer)
+	p.drawWounds(screen, alpha)
+	p.drawWeapon(screen)
 	vector.DrawFilledCircle(screen, float32(p.X), float32(p.Y), PlayerRadius, color.RGBA{0, 255, 0, 255}, false)
 	vector.StrokeLine(screen, float32(p.HitBox().Walls[0].X1), float32(p.HitBox().Walls[0].Y1), float32(p.HitBox().Walls[0].X2), float32(p.HitBox().Walls[0].Y2), 1.0, color.White, false)
 	vector.StrokeLine(screen, float32(p.HitBox().Walls[1].X1), float32(p.HitBox().Walls[1].Y1), float32(p.HitBox().Walls[1].X2), float32(p.HitBox().Walls[1].Y2), 1.0, color.White, false)
 	vector.StrokeLine(screen, float32(p.HitBox().Walls[2].X1), float32(p.HitBox().Walls[2].Y1), float32(p.HitBox().Walls[2].X2), float32(p.HitBox().Walls[2].Y2), 1.0, color.White, false)
 	vector.StrokeLine(screen, float32(p.HitBox().Walls[3].X1), float32(p.HitBox().Walls[3].Y1), float32(p.HitBox().Walls[3].X2), float32(p.HitBox().Walls[3].Y2), 1.0, color.White, false)
-	ebitenutil.DebugPrint(screen, fmt.Sprintf("Health: %d", p.Health))
-	ebitenutil.DebugPrintAt(screen, fmt.Sprintf("%d", p.capacity), 0, 20)
-
-	muzzleOffsetX := 136.0 / 4 // Adjust this value to match the actual muzzle position in the sprite
-	muzzleOffsetY := 49.0 / 4  // Adjust this value to match the actual muzzle position in the sprite
-
-	// Calculate the muzzle position
-	muzzleX := p.X + math.Cos(p.Angle)*muzzleOffsetX - math.Sin(p.Angle)*muzzleOffsetY
-	muzzleY := p.Y + math.Sin(p.Angle)*muzzleOffsetX + math.Cos(p.Angle)*muzzleOffsetY
+}
 
-	if p.playerShot {
-		DrawMuzzleFlash(screen, muzzleX, muzzleY)
+// Spread is the current weapon's spread, opened up by the recoil of the spray
+// and tightened while aiming down sights.
+func (p *Player) Spread() float64 {
+	w := p.CurrentWeapon()
+	spread := w.Spread + w.Recoil.ExtraSpread(p.spray)
+	if p.Aiming {
+		spread *= ADSSpreadFactor
 	}
+	return spread
 }
 
 func (p *Player) Shoot() {
+	w := p.CurrentWeapon()
 	p.playerShot = true
-	p.capacity--
-	angleRecoil := (rand.Float64() - 0.5) / 15
-
-	// based on player's sprite
-	muzzleOffsetX := 136.0 / 4
-	muzzleOffsetY := 49.0 / 4
-
-	// Calculate the muzzle position
-	muzzleX := p.X + math.Cos(p.Angle)*muzzleOffsetX - math.Sin(p.Angle)*muzzleOffsetY
-	muzzleY := p.Y + math.Sin(p.Angle)*muzzleOffsetX + math.Cos(p.Angle)*muzzleOffsetY
-
-	// Create the bullet starting from the muzzle position
-	bullet := &Bullet{
-		OwnerID:   p.ID,
-		X:         muzzleX,
-		Y:         muzzleY,
-		EndX:      muzzleX + math.Cos(p.Angle+angleRecoil)*BulletSpeed,
-		EndY:      muzzleY + math.Sin(p.Angle+angleRecoil)*BulletSpeed,
-		Direction: p.Angle + angleRecoil,
-		Velocity:  BulletSpeed,
+	if !p.InfiniteAmmo {
+		p.ammo[p.Weapon]--
+	}
+
+	muzzleX, muzzleY := p.MuzzlePosition()
+	kick := w.Recoil.Kick(p.spray)
+	spread := p.Spread()
+	p.spray++
+
+	for range w.Pellets {
+		angleRecoil := kick + weapon.SpreadOffset(p.Seed, p.Shot, spread)
+		p.Shot++
+
+		// Create the bullet starting from the muzzle position
+		bullet := &Bullet{
+			OwnerID:    p.ID,
+			X:          muzzleX,
+			Y:          muzzleY,
+			EndX:       muzzleX + math.Cos(p.Angle+angleRecoil)*w.BulletSpeed,
+			EndY:       muzzleY + math.Sin(p.Angle+angleRecoil)*w.BulletSpeed,
+			Direction:  p.Angle + angleRecoil,
+			Aim:        p.Angle,
+			Velocity:   w.BulletSpeed,
+			Damage:     w.Damage,
+			Weapon:     w.ID,
+			Shot:       p.Shot - 1,
+			Suppressed: w.Suppressed,
+		}
+		p.Bullets = append(p.Bullets, bullet)
+		p.shots = append(p.shots, bullet)
 	}
-	p.Bullets = append(p.Bullets, bullet)
+}
+
+// TakeShots returns copies of the bullets fired since the last call.
+func (p *Player) TakeShots() []*Bullet {
+	shots := make([]*Bullet, len(p.shots))
+	for i, b := range p.shots {
+		c := *b
+		shots[i] = &c
+	}
+	p.shots = p.shots[:0]
+	return shots
+}
+
+// ConfirmBullet sets the server's ID on the local bullet of the same shot.
+func (p *Player) ConfirmBullet(b *Bullet) {
+	for _, own := range p.Bullets {
+		if own.ID == 0 && own.Shot == b.Shot {
+			own.ID = b.ID
+			return
+		}
+	}
+}
+
+// RemoveBullet removes the bullet with the ID, false when there is none.
+func (p *Player) RemoveBullet(id uint64) (*Bullet, bool) {
+	for i, b := range p.Bullets {
+		if b.ID == id {
+			p.Bullets = append(p.Bullets[:i], p.Bullets[i+1:]...)
+			return b, true
+		}
+	}
+	return nil, false
 }
 
 func (b *Bullet) Update() {
-	dx := math.Cos(b.Direction) * b.Velocity
-	dy := math.Sin(b.Direction) * b.Velocity
+	dx := math.Cos(b.Direction) * b.Velocity * tickScale()
+	dy := math.Sin(b.Direction) * b.Velocity * tickScale()
 	b.EndX += dx
 	b.EndY += dy
 }
@@ -301,13 +558,25 @@ func (b *Bullet) Line() game.Line {
 	}
 }
 
-func DrawMuzzleFlash(screen *ebiten.Image, x, y float64) {
-	vector.DrawFilledCircle(screen, float32(x), float32(y), 5, color.White, false)
+// Trail returns the visible streak behind the head of the bullet.
+func (b *Bullet) Trail() (x1, y1, x2, y2 float64) {
+	return b.EndX - BulletTrailLength*math.Cos(b.Direction), b.EndY - BulletTrailLength*math.Sin(b.Direction), b.EndX, b.EndY
 }
 
+// Draw draws the bullet on its own, use DrawBatch when drawing many.
 func (b *Bullet) Draw(screen *ebiten.Image) {
+	if b.Suppressed {
+		return
+	}
 	// TODO: bulled line dissapears before hitbox
+	x1, y1, x2, y2 := b.Trail()
+	vector.StrokeLine(screen, float32(x1), float32(y1), float32(x2), float32(y2), BulletWidth, color.White, false)
+}
 
-	// vector.StrokeLine(screen, float32(b.X), float32(b.Y), float32(b.EndX+25*math.Cos(b.Direction)), float32(b.EndY+25*math.Sin(b.Direction)), 1.7, color.White, false)
-	vector.StrokeLine(screen, float32(b.EndX-220*math.Cos(b.Direction)), float32(b.EndY-220*math.Sin(b.Direction)), float32(b.EndX), float32(b.EndY), 1.7, color.White, false)
+func (b *Bullet) DrawBatch(bt *batch.Batch) {
+	if b.Suppressed {
+		return
+	}
+	x1, y1, x2, y2 := b.Trail()
+	bt.Line(x1, y1, x2, y2, BulletWidth, color.White)
 }
@@ -0,0 +1,30 @@
+package player
+
+import "image/color"
+
+// Skins are the sprite tints players can pick, the first one leaves the sprite as drawn.
+var Skins = []string{"default", "crimson", "forest", "ocean", "gold", "violet", "ghost"}
+
+var skinTints = map[string]color.RGBA{
+	"crimson": {255, 140, 140, 255},
+	"forest":  {150, 230, 150, 255},
+	"ocean":   {140, 190, 255, 255},
+	"gold":    {255, 220, 120, 255},
+	"violet":  {210, 150, 255, 255},
+	"ghost":   {200, 200, 200, 170},
+}
+
+// Team colors replace skins so teams stay recognizable, they match the health bar colors.
+var teamTints = map[string]color.RGBA{
+	"red":  {230, 50, 50, 255},
+	"blue": {60, 140, 255, 255},
+}
+
+// Tint returns the color the player's sprite is scaled by, ok is false when it is drawn as is.
+func (p *Player) Tint() (color.RGBA, bool) {
+	if c, ok := teamTints[p.Team]; ok {
+		return c, true
+	}
+	c, ok := skinTints[p.Skin]
+	return c, ok
+}
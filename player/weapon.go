@@ -0,0 +1,186 @@
+package player
+
+import (
+	"image/color"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+
+	"shooter/utils"
+	"shooter/weapon"
+)
+
+const (
+	LaserLength = 400.0
+	// Suppressors and scopes in body sprite pixels, drawn on top of the weapon
+	SuppressorLength = 40.0
+	SuppressorWidth  = 3.0
+	ScopeLength      = 36.0
+	ScopeWidth       = 4.0
+)
+
+var (
+	laserColor       = color.RGBA{255, 0, 0, 160}
+	placeholderColor = color.RGBA{40, 40, 40, 255}
+	attachmentColor  = color.RGBA{25, 25, 25, 255}
+)
+
+// Loaded lazily, weapons without an asset get a flat placeholder of their sprite size
+var weaponSprites = map[weapon.ID]*ebiten.Image{}
+
+func weaponSprite(w *weapon.Weapon) *ebiten.Image {
+	if w.Sprite == "" {
+		return nil
+	}
+	if img, ok := weaponSprites[w.ID]; ok {
+		return img
+	}
+
+	img, err := utils.LoadImage(w.Sprite)
+	if err != nil {
+		img = ebiten.NewImage(w.SpriteWidth, w.SpriteHeight)
+		img.Fill(placeholderColor)
+	}
+	weaponSprites[w.ID] = img
+	return img
+}
+
+// CurrentWeapon is the held weapon with its attachment.
+func (p *Player) CurrentWeapon() *weapon.Weapon {
+	return p.loadoutWeapon(p.Weapon)
+}
+
+func (p *Player) loadoutWeapon(id weapon.ID) *weapon.Weapon {
+	if w, ok := p.loadout[id]; ok {
+		return w
+	}
+	return weapon.Get(id)
+}
+
+// SetAttachment fits the weapon with the attachment, its magazine is refilled to the new size.
+func (p *Player) SetAttachment(id weapon.ID, a weapon.Attachment) {
+	w := weapon.Get(id).With(a)
+	if cur, ok := p.loadout[id]; ok && cur.Attachment == w.Attachment {
+		return
+	}
+	if p.loadout == nil {
+		p.loadout = map[weapon.ID]*weapon.Weapon{}
+	}
+	p.loadout[id] = w
+	if _, ok := p.ammo[id]; ok {
+		p.ammo[id] = w.MagazineSize
+	}
+}
+
+// MuzzlePosition returns where bullets leave the barrel of the held weapon, or its suppressor.
+func (p *Player) MuzzlePosition() (float64, float64) {
+	w := p.CurrentWeapon()
+	muzzle := w.Muzzle
+	if w.Suppressed {
+		muzzle.X += SuppressorLength
+	}
+	return muzzle.World(p.X, p.Y, p.Angle, SpriteScale)
+}
+
+// GiveWeapon switches to the weapon with a full magazine and keeps the player from switching away.
+func (p *Player) GiveWeapon(id weapon.ID) {
+	p.SwitchWeapon(id)
+	p.WeaponLocked = true
+	p.ammo[id] = p.loadoutWeapon(id).MagazineSize
+}
+
+// LimitAmmo leaves the held weapon n rounds, there is no reloading until the mode lifts the limit.
+func (p *Player) LimitAmmo(n int) {
+	p.NoReload = true
+	p.reloadDone = time.Time{}
+	p.ammo[p.Weapon] = n
+}
+
+// AddAmmo gives the held weapon n more rounds, even beyond its magazine size.
+func (p *Player) AddAmmo(n int) {
+	p.ammo[p.Weapon] += n
+}
+
+// PickUp switches to a weapon found on the ground and adds its rounds, a locked weapon only
+// takes the rounds when they are for it.
+func (p *Player) PickUp(id weapon.ID, ammo int) {
+	if p.WeaponLocked && id != p.Weapon {
+		return
+	}
+	p.SwitchWeapon(id)
+	p.ammo[id] += ammo
+}
+
+func (p *Player) SwitchWeapon(id weapon.ID) {
+	if id == p.Weapon {
+		return
+	}
+	p.Weapon = id
+	p.reloadDone = time.Time{}
+}
+
+func (p *Player) Ammo() int {
+	return p.ammo[p.Weapon]
+}
+
+// DrawDropped draws the weapon lying on the ground at x, y.
+func DrawDropped(screen *ebiten.Image, id weapon.ID, x, y, angle float64) {
+	img := weaponSprite(weapon.Get(id))
+	if img == nil {
+		return
+	}
+	b := img.Bounds()
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Translate(-float64(b.Dx())/2, -float64(b.Dy())/2)
+	op.GeoM.Scale(SpriteScale, SpriteScale)
+	op.GeoM.Rotate(angle)
+	op.GeoM.Translate(x, y)
+	screen.DrawImage(img, op)
+}
+
+func (p *Player) drawWeapon(screen *ebiten.Image) {
+	w := p.CurrentWeapon()
+
+	if img := weaponSprite(w); img != nil {
+		gx, gy := w.Grip.World(p.X, p.Y, p.Angle, SpriteScale)
+		b := img.Bounds()
+
+		op := &ebiten.DrawImageOptions{}
+		op.GeoM.Translate(-float64(b.Dx())/2, -float64(b.Dy())/2)
+		op.GeoM.Scale(SpriteScale, SpriteScale)
+		op.GeoM.Rotate(p.Angle)
+		op.GeoM.Translate(gx, gy)
+		screen.DrawImage(img, op)
+	}
+
+	switch {
+	case w.Suppressed:
+		p.drawAttachment(screen, w.Muzzle, SuppressorLength, SuppressorWidth)
+	case w.Zoom > 0:
+		scope := w.Muzzle
+		scope.X -= 2 * ScopeLength
+		p.drawAttachment(screen, scope, ScopeLength, ScopeWidth)
+	}
+}
+
+// drawAttachment draws a bar of length along the barrel from the offset.
+func (p *Player) drawAttachment(screen *ebiten.Image, from weapon.Offset, length, width float64) {
+	to := from
+	to.X += length
+	x1, y1 := from.World(p.X, p.Y, p.Angle, SpriteScale)
+	x2, y2 := to.World(p.X, p.Y, p.Angle, SpriteScale)
+	vector.StrokeLine(screen, float32(x1), float32(y1), float32(x2), float32(y2), float32(width), attachmentColor, true)
+}
+
+// HasLaser is true when the laser sight should be drawn for the player.
+func (p *Player) HasLaser() bool {
+	return p.CurrentWeapon().Laser && p.Aiming && p.Health > 0
+}
+
+// DrawLaser draws the laser sight from the muzzle to ex, ey, where it hits something.
+func (p *Player) DrawLaser(screen *ebiten.Image, ex, ey float64) {
+	mx, my := p.MuzzlePosition()
+	vector.StrokeLine(screen, float32(mx), float32(my), float32(ex), float32(ey), 1, laserColor, true)
+	vector.DrawFilledCircle(screen, float32(ex), float32(ey), 2, laserColor, true)
+}
@@ -0,0 +1,83 @@
+package player
+
+import (
+	"image/color"
+	"math"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+const (
+	// Below this players limp
+	LimpHealth = MaxHealth / 4
+	// Green and blue of the sprite are scaled down by up to this much at low health
+	maxWoundTint = 0.5
+	// The dead body stays for CorpseTime, then fades out over CorpseFade
+	CorpseTime = 3 * time.Second
+	CorpseFade = 2 * time.Second
+	// How far the sprite sways to the side while limping, in radians
+	limpSway = 0.15
+	// Limp cycles per second of walking
+	limpRate = 2.5
+)
+
+var woundColor = color.RGBA{120, 0, 0, 220}
+
+// Wounds on the sprite relative to the player center facing right, one more shows for every
+// quarter of health lost
+var woundSpots = [][3]float32{{-4, 6, 3}, {6, -7, 2.5}, {-8, -3, 3.5}}
+
+// woundTint scales the sprite redder the more health is lost.
+func (p *Player) woundTint(op *ebiten.DrawImageOptions) {
+	if p.Health <= 0 || p.Health >= MaxHealth {
+		return
+	}
+	lost := float32(MaxHealth-p.Health) / MaxHealth
+	gb := 1 - maxWoundTint*lost
+	op.ColorScale.Scale(1, gb, gb, 1)
+}
+
+// limpAngle sways the sprite while a badly hurt player walks.
+func (p *Player) limpAngle() float64 {
+	if p.Health <= 0 || p.Health >= LimpHealth {
+		return 0
+	}
+	return limpSway * math.Sin(2*math.Pi*p.limpPhase)
+}
+
+// corpseAlpha is how visible the dead body is, fading out after CorpseTime.
+func (p *Player) corpseAlpha() float32 {
+	if p.Health > 0 || p.diedAt.IsZero() {
+		return 1
+	}
+	fade := time.Since(p.diedAt) - CorpseTime
+	return float32(1 - min(max(fade.Seconds()/CorpseFade.Seconds(), 0), 1))
+}
+
+// updateWounds advances the limp while walking and notes the time of death for the corpse.
+func (p *Player) updateWounds(dt time.Duration) {
+	switch {
+	case p.Health <= 0 && p.diedAt.IsZero():
+		p.diedAt = time.Now()
+	case p.Health > 0:
+		p.diedAt = time.Time{}
+	}
+	if p.Anim == AnimWalk {
+		p.limpPhase = math.Mod(p.limpPhase+limpRate*dt.Seconds(), 1)
+	}
+}
+
+// drawWounds draws blood on the sprite, more of it the less health is left.
+func (p *Player) drawWounds(screen *ebiten.Image, alpha float32) {
+	n := min(len(woundSpots), (MaxHealth-max(p.Health, 0))*4/MaxHealth)
+	sin, cos := math.Sincos(p.Angle)
+	clr := woundColor
+	clr.A = uint8(float32(clr.A) * alpha)
+	for _, w := range woundSpots[:n] {
+		x := p.X + float64(w[0])*cos - float64(w[1])*sin
+		y := p.Y + float64(w[0])*sin + float64(w[1])*cos
+		vector.DrawFilledCircle(screen, float32(x), float32(y), w[2], clr, true)
+	}
+}
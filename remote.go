@@ -0,0 +1,49 @@
+package main
+
+import (
+	"math"
+	"time"
+
+	"shooter/grapple"
+	"shooter/interp"
+	"shooter/player"
+	"shooter/vehicle"
+)
+
+// MaxRemoteSpeed is the fastest anyone moves in pixels per second, riding or reeling in on the
+// fastest rules. Velocities past it in updates are bogus and would fling players across the screen.
+var MaxRemoteSpeed = 2 * math.Max(player.PlayerSpeed*player.PlayerSprintSpeedFactor*player.BaseTPS,
+	math.Max(grapple.ReelSpeed*grapple.TPS, vehicle.MaxSpeed*vehicle.TPS))
+
+// trackRemote feeds a remote player's update to their dead reckoning and aim smoothing, mu must be held.
+func (g *Game) trackRemote(update PlayerUpdate, now time.Time) {
+	t, ok := g.tracks[update.ID]
+	if !ok {
+		t = &interp.Track{}
+		g.tracks[update.ID] = t
+	}
+	vx, vy := update.VX, update.VY
+	if speed := math.Hypot(vx, vy); speed > MaxRemoteSpeed {
+		vx, vy = vx*MaxRemoteSpeed/speed, vy*MaxRemoteSpeed/speed
+	}
+	// The dead don't slide
+	if update.Health <= 0 {
+		vx, vy = 0, 0
+	}
+	t.Update(update.X, update.Y, vx, vy, now)
+	t.Aim(update.Angle, g.clock.Interval(), now)
+}
+
+// placeRemotes moves remote players to where dead reckoning has them by now, so they keep
+// moving through late updates instead of freezing, and turns their aim. mu must be held.
+func (g *Game) placeRemotes(now time.Time) {
+	for id, t := range g.tracks {
+		p, ok := g.players[id]
+		if !ok {
+			delete(g.tracks, id)
+			continue
+		}
+		p.X, p.Y = t.Position(now)
+		p.Angle = t.Angle(now)
+	}
+}
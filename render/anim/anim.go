@@ -0,0 +1,98 @@
+package anim
+
+import (
+	"image"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// Clip is a named sequence of frames played at a fixed rate.
+type Clip struct {
+	Name      string
+	Frames    []*ebiten.Image
+	FrameTime time.Duration
+	Loop      bool
+}
+
+// NewClip creates a clip from a horizontal sprite sheet of frames frameWidth pixels wide.
+func NewClip(name string, sheet *ebiten.Image, frameWidth int, frameTime time.Duration, loop bool) *Clip {
+	b := sheet.Bounds()
+	count := max(1, b.Dx()/frameWidth)
+	frameWidth = min(frameWidth, b.Dx())
+
+	frames := make([]*ebiten.Image, 0, count)
+	for i := range count {
+		r := image.Rect(b.Min.X+i*frameWidth, b.Min.Y, b.Min.X+(i+1)*frameWidth, b.Max.Y)
+		frames = append(frames, sheet.SubImage(r).(*ebiten.Image))
+	}
+	return &Clip{Name: name, Frames: frames, FrameTime: frameTime, Loop: loop}
+}
+
+// Animator plays one clip out of a set at a time.
+type Animator struct {
+	clips   map[string]*Clip
+	current *Clip
+	elapsed time.Duration
+}
+
+func NewAnimator(clips ...*Clip) *Animator {
+	a := &Animator{clips: make(map[string]*Clip, len(clips))}
+	for _, c := range clips {
+		a.clips[c.Name] = c
+	}
+	if len(clips) > 0 {
+		a.current = clips[0]
+	}
+	return a
+}
+
+// Play switches to the named clip, restarting it only when it's not already playing.
+func (a *Animator) Play(name string) {
+	if a.current != nil && a.current.Name == name {
+		return
+	}
+	if c, ok := a.clips[name]; ok {
+		a.current = c
+		a.elapsed = 0
+	}
+}
+
+func (a *Animator) Current() string {
+	if a.current == nil {
+		return ""
+	}
+	return a.current.Name
+}
+
+func (a *Animator) Update(dt time.Duration) {
+	a.elapsed += dt
+}
+
+// Frame returns the image to draw for the current clip.
+func (a *Animator) Frame() *ebiten.Image {
+	if a.current == nil || len(a.current.Frames) == 0 {
+		return nil
+	}
+
+	frames := a.current.Frames
+	if a.current.FrameTime <= 0 {
+		return frames[0]
+	}
+
+	i := int(a.elapsed / a.current.FrameTime)
+	if a.current.Loop {
+		i %= len(frames)
+	} else if i >= len(frames) {
+		i = len(frames) - 1
+	}
+	return frames[i]
+}
+
+// Finished reports whether a non looping clip reached its last frame.
+func (a *Animator) Finished() bool {
+	if a.current == nil || a.current.Loop {
+		return false
+	}
+	return a.elapsed >= a.current.FrameTime*time.Duration(len(a.current.Frames))
+}
@@ -0,0 +1,171 @@
+package batch
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// Atlas packs small images into one texture, so everything using it can be drawn in a single batch.
+type Atlas struct {
+	image   *ebiten.Image
+	regions map[string]image.Rectangle
+	// Shelf packing cursor
+	x, y, rowHeight int
+}
+
+// White is the name of the single white pixel region used for shapes.
+const White = "white"
+
+// Padding between regions keeps filtering from bleeding into neighbours
+const padding = 1
+
+func NewAtlas(size int) *Atlas {
+	a := &Atlas{
+		image:   ebiten.NewImage(size, size),
+		regions: map[string]image.Rectangle{},
+		x:       padding,
+		y:       padding,
+	}
+	white := image.NewRGBA(image.Rect(0, 0, 1, 1))
+	white.Set(0, 0, color.White)
+	a.Add(White, white)
+	return a
+}
+
+// Add copies img into the atlas, false when there is no room left.
+func (a *Atlas) Add(name string, img image.Image) (image.Rectangle, bool) {
+	if r, ok := a.regions[name]; ok {
+		return r, true
+	}
+
+	size := a.image.Bounds().Size()
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	if a.x+w+padding > size.X {
+		a.x = padding
+		a.y += a.rowHeight + padding
+		a.rowHeight = 0
+	}
+	if a.x+w+padding > size.X || a.y+h+padding > size.Y {
+		return image.Rectangle{}, false
+	}
+
+	r := image.Rect(a.x, a.y, a.x+w, a.y+h)
+	a.image.SubImage(r).(*ebiten.Image).WritePixels(toRGBA(img).Pix)
+	a.regions[name] = r
+	a.x += w + padding
+	a.rowHeight = max(a.rowHeight, h)
+	return r, true
+}
+
+func (a *Atlas) Region(name string) (image.Rectangle, bool) {
+	r, ok := a.regions[name]
+	return r, ok
+}
+
+func (a *Atlas) Image() *ebiten.Image {
+	return a.image
+}
+
+func toRGBA(img image.Image) *image.RGBA {
+	b := img.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	for y := range b.Dy() {
+		for x := range b.Dx() {
+			rgba.Set(x, y, img.At(b.Min.X+x, b.Min.Y+y))
+		}
+	}
+	return rgba
+}
+
+// Batch collects quads from one atlas and draws them with as few DrawTriangles calls as possible.
+type Batch struct {
+	atlas    *Atlas
+	white    image.Rectangle
+	dst      *ebiten.Image
+	vertices []ebiten.Vertex
+	indices  []uint16
+	// DrawTriangles calls since Begin, for profiling
+	Calls int
+}
+
+func New(atlas *Atlas) *Batch {
+	white, _ := atlas.Region(White)
+	return &Batch{atlas: atlas, white: white}
+}
+
+// Begin starts collecting quads to be drawn on dst.
+func (b *Batch) Begin(dst *ebiten.Image) {
+	b.dst = dst
+	b.vertices = b.vertices[:0]
+	b.indices = b.indices[:0]
+	b.Calls = 0
+}
+
+// End draws everything collected since Begin.
+func (b *Batch) End() {
+	b.flush()
+	b.dst = nil
+}
+
+func (b *Batch) flush() {
+	if len(b.indices) == 0 || b.dst == nil {
+		return
+	}
+	b.dst.DrawTriangles(b.vertices, b.indices, b.atlas.image, nil)
+	b.Calls++
+	b.vertices = b.vertices[:0]
+	b.indices = b.indices[:0]
+}
+
+// quad adds the four corners of a region, flushing first when indices would overflow.
+func (b *Batch) quad(corners [4][2]float64, src image.Rectangle, clr color.Color) {
+	if len(b.vertices)+4 > math.MaxUint16 {
+		b.flush()
+	}
+
+	cr, cg, cb, ca := clr.RGBA()
+	r, g, bl, a := float32(cr)/0xffff, float32(cg)/0xffff, float32(cb)/0xffff, float32(ca)/0xffff
+	srcs := [4][2]int{{src.Min.X, src.Min.Y}, {src.Max.X, src.Min.Y}, {src.Min.X, src.Max.Y}, {src.Max.X, src.Max.Y}}
+
+	i := uint16(len(b.vertices))
+	for k, c := range corners {
+		b.vertices = append(b.vertices, ebiten.Vertex{
+			DstX: float32(c[0]), DstY: float32(c[1]),
+			SrcX: float32(srcs[k][0]), SrcY: float32(srcs[k][1]),
+			ColorR: r, ColorG: g, ColorB: bl, ColorA: a,
+		})
+	}
+	b.indices = append(b.indices, i, i+1, i+2, i+1, i+3, i+2)
+}
+
+// Image draws the atlas region transformed by geo, like DrawImage with a sub image would.
+func (b *Batch) Image(region image.Rectangle, geo ebiten.GeoM, clr color.Color) {
+	w, h := float64(region.Dx()), float64(region.Dy())
+	var corners [4][2]float64
+	for k, p := range [4][2]float64{{0, 0}, {w, 0}, {0, h}, {w, h}} {
+		corners[k][0], corners[k][1] = geo.Apply(p[0], p[1])
+	}
+	b.quad(corners, region, clr)
+}
+
+// Rect fills an axis aligned rectangle.
+func (b *Batch) Rect(x, y, w, h float64, clr color.Color) {
+	b.quad([4][2]float64{{x, y}, {x + w, y}, {x, y + h}, {x + w, y + h}}, b.white, clr)
+}
+
+// Line draws a line as a quad of the given width.
+func (b *Batch) Line(x1, y1, x2, y2, width float64, clr color.Color) {
+	l := math.Hypot(x2-x1, y2-y1)
+	if l == 0 {
+		return
+	}
+	// Normal scaled to half of the width
+	nx, ny := -(y2-y1)/l*width/2, (x2-x1)/l*width/2
+	b.quad([4][2]float64{
+		{x1 + nx, y1 + ny}, {x2 + nx, y2 + ny},
+		{x1 - nx, y1 - ny}, {x2 - nx, y2 - ny},
+	}, b.white, clr)
+}
@@ -0,0 +1,129 @@
+package camera
+
+import (
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"shooter/config"
+)
+
+const (
+	// Trauma lost per second
+	TraumaDecay = 1.2
+	// Offset and rotation at full trauma
+	MaxShakeOffset   = 18.0
+	MaxShakeRotation = 0.04
+	// Distance at which trauma from world events (explosions) fades out completely
+	TraumaRadius = 600.0
+	// How quickly zoom and focus catch up with Focus, per second
+	ZoomSpeed = 10.0
+
+	noiseSpeed = 25.0
+)
+
+// Camera turns trauma, added by shots, hits and explosions, into decaying screen shake.
+// It also zooms in around a focus point, eased so aiming down sights doesn't snap.
+type Camera struct {
+	settings *config.Video
+
+	trauma float64
+	time   float64
+
+	offsetX  float64
+	offsetY  float64
+	rotation float64
+
+	zoom, targetZoom float64
+	focusX, focusY   float64
+	targetX, targetY float64
+}
+
+func New(settings *config.Video) *Camera {
+	return &Camera{settings: settings, zoom: 1, targetZoom: 1}
+}
+
+// Focus zooms towards x, y, a zoom of 1 shows the whole image again.
+func (c *Camera) Focus(zoom, x, y float64) {
+	c.targetZoom = zoom
+	// Zooming out keeps the last focus so the image doesn't slide away
+	if zoom != 1 {
+		c.targetX, c.targetY = x, y
+	}
+	if c.zoom == 1 {
+		c.focusX, c.focusY = c.targetX, c.targetY
+	}
+}
+
+func (c *Camera) AddTrauma(amount float64) {
+	c.trauma = math.Min(1, c.trauma+amount)
+}
+
+// AddTraumaAt adds trauma from an event dist pixels away from the viewer.
+func (c *Camera) AddTraumaAt(amount, dist float64) {
+	if dist >= TraumaRadius {
+		return
+	}
+	c.AddTrauma(amount * (1 - dist/TraumaRadius))
+}
+
+func (c *Camera) Trauma() float64 {
+	return c.trauma
+}
+
+// Update advances the shake by dt seconds.
+func (c *Camera) Update(dt float64) {
+	c.trauma = math.Max(0, c.trauma-TraumaDecay*dt)
+	c.time += dt
+
+	ease := 1 - math.Exp(-ZoomSpeed*dt)
+	c.zoom += (c.targetZoom - c.zoom) * ease
+	if math.Abs(c.zoom-c.targetZoom) < 0.001 {
+		c.zoom = c.targetZoom
+	}
+	c.focusX += (c.targetX - c.focusX) * ease
+	c.focusY += (c.targetY - c.focusY) * ease
+
+	// Squaring makes small trauma subtle and big trauma violent
+	shake := c.trauma * c.trauma * c.settings.ScreenShake
+	t := c.time * noiseSpeed
+	c.offsetX = MaxShakeOffset * shake * noise(t, 1)
+	c.offsetY = MaxShakeOffset * shake * noise(t, 2)
+	c.rotation = MaxShakeRotation * shake * noise(t, 3)
+}
+
+// Apply zooms and shakes an image of size w x h around its center.
+func (c *Camera) Apply(op *ebiten.DrawImageOptions, w, h float64) {
+	op.GeoM.Translate(-c.focusX, -c.focusY)
+	op.GeoM.Scale(c.zoom, c.zoom)
+	op.GeoM.Translate(c.focusX, c.focusY)
+	op.GeoM.Translate(-w/2, -h/2)
+	op.GeoM.Rotate(c.rotation)
+	op.GeoM.Translate(w/2+c.offsetX, h/2+c.offsetY)
+}
+
+// Parallax places a layer drawn into the image before Apply so it zooms in depth times as
+// much as the image, 0 stays put and 1 moves along with the image.
+func (c *Camera) Parallax(op *ebiten.DrawImageOptions, depth float64) {
+	s := (1 + (c.zoom-1)*depth) / c.zoom
+	op.GeoM.Translate(-c.focusX, -c.focusY)
+	op.GeoM.Scale(s, s)
+	op.GeoM.Translate(c.focusX, c.focusY)
+}
+
+// ToScreen applies the zoom to a point of the image, shake is left out.
+func (c *Camera) ToScreen(x, y float64) (float64, float64) {
+	return c.focusX + (x-c.focusX)*c.zoom, c.focusY + (y-c.focusY)*c.zoom
+}
+
+// ToWorld is the inverse of ToScreen.
+func (c *Camera) ToWorld(x, y float64) (float64, float64) {
+	return c.focusX + (x-c.focusX)/c.zoom, c.focusY + (y-c.focusY)/c.zoom
+}
+
+// noise is a cheap smooth noise in [-1, 1], seed selects an independent channel.
+func noise(t, seed float64) float64 {
+	return (math.Sin(t*1.0+seed*12.9898) +
+		math.Sin(t*2.3+seed*78.233)*0.5 +
+		math.Sin(t*4.7+seed*37.719)*0.25) / 1.75
+}
@@ -0,0 +1,147 @@
+package effects
+
+import (
+	"image/color"
+	"math"
+	"math/rand/v2"
+	"sync"
+
+	"shooter/game"
+	"shooter/render/batch"
+)
+
+// Keeps the whole system within a single DrawTriangles call (uint16 indices)
+const MaxParticles = math.MaxUint16 / 4
+
+// Emitter describes a burst of particles.
+type Emitter struct {
+	Count    int
+	Spread   float64 // radians around the emit angle
+	SpeedMin float64 // pixels per second
+	SpeedMax float64
+	LifeMin  float64 // seconds
+	LifeMax  float64
+	Size     float64
+	Color    color.RGBA
+	Drag     float64 // fraction of velocity lost per second
+	Fade     bool
+}
+
+var (
+	MuzzleFlash = Emitter{
+		Count: 6, Spread: 0.4, SpeedMin: 60, SpeedMax: 180, LifeMin: 0.03, LifeMax: 0.08,
+		Size: 4, Color: color.RGBA{255, 220, 120, 255}, Drag: 4, Fade: true,
+	}
+	Sparks = Emitter{
+		Count: 8, Spread: 1.2, SpeedMin: 80, SpeedMax: 260, LifeMin: 0.1, LifeMax: 0.3,
+		Size: 2, Color: color.RGBA{255, 200, 80, 255}, Drag: 5, Fade: true,
+	}
+	Dust = Emitter{
+		Count: 5, Spread: 1.0, SpeedMin: 10, SpeedMax: 40, LifeMin: 0.4, LifeMax: 0.8,
+		Size: 4, Color: color.RGBA{140, 130, 110, 180}, Drag: 2, Fade: true,
+	}
+	Blood = Emitter{
+		Count: 12, Spread: 0.8, SpeedMin: 40, SpeedMax: 160, LifeMin: 0.3, LifeMax: 0.6,
+		Size: 3, Color: color.RGBA{150, 0, 0, 255}, Drag: 6, Fade: true,
+	}
+	Explosion = Emitter{
+		Count: 60, Spread: math.Pi, SpeedMin: 80, SpeedMax: 420, LifeMin: 0.3, LifeMax: 0.9,
+		Size: 5, Color: color.RGBA{255, 150, 40, 255}, Drag: 3, Fade: true,
+	}
+	Casing = Emitter{
+		Count: 1, Spread: 0.5, SpeedMin: 60, SpeedMax: 100, LifeMin: 0.8, LifeMax: 1.2,
+		Size: 2, Color: color.RGBA{200, 170, 60, 255}, Drag: 5, Fade: false,
+	}
+)
+
+type particle struct {
+	x, y   float64
+	vx, vy float64
+	life   float64
+	maxAge float64
+	size   float64
+	color  color.RGBA
+	drag   float64
+	fade   bool
+}
+
+// System simulates particles and renders all of them in one batch.
+type System struct {
+	mu        sync.Mutex
+	particles []particle
+}
+
+func NewSystem() *System {
+	return &System{}
+}
+
+// Emit spawns a burst of particles at x, y travelling roughly along angle.
+func (s *System) Emit(e Emitter, x, y, angle float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for range e.Count {
+		if len(s.particles) >= MaxParticles {
+			return
+		}
+		a := angle + (rand.Float64()*2-1)*e.Spread
+		speed := e.SpeedMin + rand.Float64()*(e.SpeedMax-e.SpeedMin)
+		life := e.LifeMin + rand.Float64()*(e.LifeMax-e.LifeMin)
+		s.particles = append(s.particles, particle{
+			x: x, y: y,
+			vx: math.Cos(a) * speed, vy: math.Sin(a) * speed,
+			life: life, maxAge: life,
+			size: e.Size, color: e.Color, drag: e.Drag, fade: e.Fade,
+		})
+	}
+}
+
+// Update advances the simulation by dt seconds.
+func (s *System) Update(dt float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	alive := s.particles[:0]
+	for _, p := range s.particles {
+		p.life -= dt
+		if p.life <= 0 {
+			continue
+		}
+		damping := math.Max(0, 1-p.drag*dt)
+		p.vx *= damping
+		p.vy *= damping
+		p.x += p.vx * dt
+		p.y += p.vy * dt
+		alive = append(alive, p)
+	}
+	s.particles = alive
+}
+
+func (s *System) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.particles)
+}
+
+// Draw adds particles within view to the batch.
+func (s *System) Draw(b *batch.Batch, view game.Bounds) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, p := range s.particles {
+		if !view.Contains(p.x, p.y, p.size) {
+			continue
+		}
+		clr := p.color
+		if p.fade {
+			a := p.life / p.maxAge
+			clr = color.RGBA{
+				uint8(float64(clr.R) * a),
+				uint8(float64(clr.G) * a),
+				uint8(float64(clr.B) * a),
+				uint8(float64(clr.A) * a),
+			}
+		}
+		b.Rect(p.x-p.size/2, p.y-p.size/2, p.size, p.size, clr)
+	}
+}
@@ -0,0 +1,135 @@
+package lighting
+
+import (
+	"math"
+	"sync"
+
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"shooter/game"
+)
+
+// Light is a transient light source which cuts through the shadow mask.
+type Light struct {
+	X, Y      float64
+	Radius    float64
+	Intensity float64 // 0..1, how much of the shadow is removed at the center
+	// Fraction of intensity kept at and beyond Radius, lights with a floor aren't clipped to their radius
+	Floor float64
+	// Half angle of a cone light pointing towards Direction, 0 for omnidirectional lights
+	Cone      float64
+	Direction float64
+	Duration  float64 // seconds
+	age       float64
+}
+
+func MuzzleFlash(x, y float64) Light {
+	return Light{X: x, Y: y, Radius: 220, Intensity: 0.8, Duration: 0.06}
+}
+
+func Explosion(x, y float64) Light {
+	return Light{X: x, Y: y, Radius: 450, Intensity: 1, Duration: 0.5}
+}
+
+func Flare(x, y float64) Light {
+	return Light{X: x, Y: y, Radius: 300, Intensity: 0.7, Duration: 8}
+}
+
+// Flashlight is a persistent cone light, built every frame from the player's position.
+func Flashlight(x, y, angle float64) Light {
+	return Light{X: x, Y: y, Radius: 550, Intensity: 0.9, Cone: 0.35, Direction: angle}
+}
+
+// AimedFlashlight is the flashlight of a player aiming down sights, narrower and reaching further.
+func AimedFlashlight(x, y, angle float64) Light {
+	return Light{X: x, Y: y, Radius: 750, Intensity: 0.9, Cone: 0.2, Direction: angle}
+}
+
+// Current intensity, lights fade out linearly over their duration
+func (l Light) current() float64 {
+	return l.Intensity * math.Max(0, 1-l.age/l.Duration)
+}
+
+type Lights struct {
+	mu     sync.Mutex
+	lights []Light
+}
+
+func NewLights() *Lights {
+	return &Lights{}
+}
+
+func (l *Lights) Add(light Light) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lights = append(l.lights, light)
+}
+
+// Update ages the lights by dt seconds and drops expired ones.
+func (l *Lights) Update(dt float64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	alive := l.lights[:0]
+	for _, light := range l.lights {
+		light.age += dt
+		if light.age < light.Duration {
+			alive = append(alive, light)
+		}
+	}
+	l.lights = alive
+}
+
+// Active returns a snapshot of lights with their current intensity.
+func (l *Lights) Active() []Light {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	active := make([]Light, 0, len(l.lights))
+	for _, light := range l.lights {
+		light.Intensity = light.current()
+		active = append(active, light)
+	}
+	return active
+}
+
+// Vertices builds a triangle fan from the light's center to the ends of rays (sorted by angle),
+// clamped to the light radius unless it has a floor. Cone fans are not closed around. Alpha falls off from Intensity at the center
+// to Floor at the radius, so drawn with BlendDestinationOut it brightens only what the light can reach.
+func Vertices(light Light, rays []game.Line, vertices []ebiten.Vertex, indices []uint16) ([]ebiten.Vertex, []uint16) {
+	if len(rays) == 0 {
+		return vertices, indices
+	}
+
+	base := uint16(len(vertices))
+	a := float32(light.Intensity)
+	vertices = append(vertices, ebiten.Vertex{
+		DstX: float32(light.X), DstY: float32(light.Y),
+		ColorR: 1, ColorG: 1, ColorB: 1, ColorA: a,
+	})
+
+	for _, ray := range rays {
+		dist := math.Hypot(ray.X2-ray.X1, ray.Y2-ray.Y1)
+		t := 1.0
+		if light.Floor == 0 {
+			t = math.Min(1, light.Radius/math.Max(dist, 1))
+		}
+		x := ray.X1 + (ray.X2-ray.X1)*t
+		y := ray.Y1 + (ray.Y2-ray.Y1)*t
+		falloff := float32(light.Floor + (1-light.Floor)*(1-math.Min(dist, light.Radius)/light.Radius))
+		vertices = append(vertices, ebiten.Vertex{
+			DstX: float32(x), DstY: float32(y),
+			ColorR: 1, ColorG: 1, ColorB: 1, ColorA: a * falloff,
+		})
+	}
+
+	n := uint16(len(rays))
+	triangles := n
+	if light.Cone > 0 {
+		triangles = n - 1
+	}
+	for i := range triangles {
+		indices = append(indices, base, base+1+i, base+1+(i+1)%n)
+	}
+	return vertices, indices
+}
@@ -0,0 +1,63 @@
+package lighting
+
+import "math"
+
+// View describes how the player's field of view is cut out of the shadow mask.
+// The polygon is cast from several origins around the player and blended together,
+// which softens shadow edges into a penumbra instead of flickering hard lines.
+type View struct {
+	// Distance at which visibility fades down to MinVisibility
+	Radius        float64
+	MinVisibility float64
+	Samples       int
+	SampleOffset  float64
+}
+
+var DefaultView = View{
+	Radius:        900,
+	MinVisibility: 0.6,
+	Samples:       5,
+	SampleOffset:  6,
+}
+
+// View radius left in complete darkness
+const NightViewRadius = 220.0
+
+// Scaled shrinks the view for darker maps, at ambient 0 only NightViewRadius around the player stays lit.
+func (v View) Scaled(ambient float64) View {
+	ambient = math.Max(0, math.Min(1, ambient))
+	v.Radius = NightViewRadius + (v.Radius-NightViewRadius)*ambient
+	v.MinVisibility *= ambient
+	return v
+}
+
+// Remaining shadow where all samples overlap
+const fullyLitResidue = 0.01
+
+// Lights returns one light per sample, together they clear the shadow where all samples see.
+func (v View) Lights(x, y float64) []Light {
+	samples := max(1, v.Samples)
+	// Each pass removes intensity of what's left, so n passes leave (1-intensity)^n
+	intensity := 1 - math.Pow(fullyLitResidue, 1/float64(samples))
+	if samples == 1 {
+		intensity = 1
+	}
+
+	lights := make([]Light, 0, samples)
+	for i := range samples {
+		lx, ly := x, y
+		if i > 0 {
+			a := 2 * math.Pi * float64(i-1) / float64(samples-1)
+			lx += math.Cos(a) * v.SampleOffset
+			ly += math.Sin(a) * v.SampleOffset
+		}
+		lights = append(lights, Light{
+			X:         lx,
+			Y:         ly,
+			Radius:    v.Radius,
+			Intensity: intensity,
+			Floor:     v.MinVisibility,
+		})
+	}
+	return lights
+}
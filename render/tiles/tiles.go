@@ -0,0 +1,90 @@
+// Package tiles draws tiled map backgrounds.
+package tiles
+
+import (
+	"image"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"shooter/game"
+	"shooter/level"
+	"shooter/utils"
+)
+
+// Tiles are drawn into chunks of about this many pixels a side, so big maps don't need
+// one huge image
+const ChunkSize = 1024
+
+// Background draws a level's tiles from chunk images, each rendered the first time it comes
+// into view and kept after.
+type Background struct {
+	tiles   *level.Tiles
+	tileset *ebiten.Image
+	size    int
+	// Tiles per chunk side
+	perChunk int
+	// Nil for chunks outside the map
+	chunks map[image.Point]*ebiten.Image
+}
+
+func New(t *level.Tiles) *Background {
+	size := max(1, t.Size)
+	return &Background{
+		tiles:    t,
+		tileset:  utils.Image(t.Tileset, size, size),
+		size:     size,
+		perChunk: max(1, ChunkSize/size),
+		chunks:   map[image.Point]*ebiten.Image{},
+	}
+}
+
+// Draw draws the chunks overlapping view, in world coordinates.
+func (b *Background) Draw(screen *ebiten.Image, view game.Bounds) {
+	side := float64(b.perChunk * b.size)
+	for cy := int(math.Floor(view.MinY / side)); float64(cy)*side < view.MaxY; cy++ {
+		for cx := int(math.Floor(view.MinX / side)); float64(cx)*side < view.MaxX; cx++ {
+			chunk := b.chunk(cx, cy)
+			if chunk == nil {
+				continue
+			}
+			op := &ebiten.DrawImageOptions{}
+			op.GeoM.Translate(float64(cx)*side, float64(cy)*side)
+			screen.DrawImage(chunk, op)
+		}
+	}
+}
+
+// chunk returns the chunk in the column and row of chunks, rendering it the first time.
+func (b *Background) chunk(cx, cy int) *ebiten.Image {
+	p := image.Pt(cx, cy)
+	if c, ok := b.chunks[p]; ok {
+		return c
+	}
+	var c *ebiten.Image
+	if cx >= 0 && cy >= 0 && cx*b.perChunk < b.tiles.Columns && cy*b.perChunk < b.tiles.Rows() {
+		c = b.render(cx, cy)
+	}
+	b.chunks[p] = c
+	return c
+}
+
+func (b *Background) render(cx, cy int) *ebiten.Image {
+	size := b.size
+	c := ebiten.NewImage(b.perChunk*size, b.perChunk*size)
+	columns := max(1, b.tileset.Bounds().Dx()/size)
+	for row := range b.perChunk {
+		for col := range b.perChunk {
+			i := b.tiles.At(cx*b.perChunk+col, cy*b.perChunk+row)
+			if i < 0 {
+				continue
+			}
+			sx, sy := i%columns*size, i/columns*size
+			tile := b.tileset.SubImage(image.Rect(sx, sy, sx+size, sy+size)).(*ebiten.Image)
+			op := &ebiten.DrawImageOptions{}
+			op.GeoM.Translate(float64(col*size), float64(row*size))
+			c.DrawImage(tile, op)
+		}
+	}
+	return c
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+
+	"shooter/game"
+	"shooter/utils"
+)
+
+// Props are culled when their center is this far outside the view
+const propMargin = 100.0
+
+// drawBackground draws the level's layers, or the default image without any, then its tiles
+// and props.
+func (g *Game) drawBackground(screen *ebiten.Image, view game.Bounds) {
+	if len(g.level.Layers) == 0 {
+		screen.DrawImage(bgImage, nil)
+	}
+	for _, l := range g.level.Layers {
+		img := utils.Image(l.Image, ScreenWidth, ScreenHeight)
+		op := &ebiten.DrawImageOptions{}
+		// Stretched over the map like the default image
+		b := img.Bounds()
+		op.GeoM.Scale(g.level.Width/float64(b.Dx()), g.level.Height/float64(b.Dy()))
+		g.camera.Parallax(op, l.Depth)
+		op.Filter = ebiten.FilterLinear
+		screen.DrawImage(img, op)
+	}
+	if g.background != nil {
+		g.background.Draw(screen, view)
+	}
+	for _, p := range g.level.Props {
+		if !view.Contains(p.X, p.Y, propMargin) {
+			continue
+		}
+		img := utils.Image(p.Image, 32, 32)
+		b := img.Bounds()
+		scale := p.Scale
+		if scale == 0 {
+			scale = 1
+		}
+		op := &ebiten.DrawImageOptions{}
+		op.GeoM.Translate(-float64(b.Dx())/2, -float64(b.Dy())/2)
+		op.GeoM.Scale(scale, scale)
+		op.GeoM.Rotate(p.Angle)
+		op.GeoM.Translate(p.X, p.Y)
+		op.Filter = ebiten.FilterLinear
+		screen.DrawImage(img, op)
+	}
+}
+
+// drawFilledObstacles fills the obstacles which have a color or a texture, under the shadow
+// so only what's in sight shows. The texture is aligned to the world, so it lines up across
+// obstacles.
+func (g *Game) drawFilledObstacles(screen *ebiten.Image) {
+	for _, o := range g.Objects {
+		if !filled(o) || len(o.Walls) == 0 {
+			continue
+		}
+		var path vector.Path
+		path.MoveTo(float32(o.Walls[0].X1), float32(o.Walls[0].Y1))
+		for _, w := range o.Walls {
+			path.LineTo(float32(w.X2), float32(w.Y2))
+		}
+		path.Close()
+		vertices, indices := path.AppendVerticesAndIndicesForFilling(nil, nil)
+
+		src, tint := triangleImage, o.Fill
+		if o.Texture != "" {
+			src = utils.Image(o.Texture, 32, 32)
+		}
+		if tint.A == 0 {
+			tint = color.RGBA{255, 255, 255, 255}
+		}
+		for i := range vertices {
+			v := &vertices[i]
+			v.SrcX, v.SrcY = v.DstX, v.DstY
+			v.ColorR, v.ColorG, v.ColorB, v.ColorA = float32(tint.R)/255, float32(tint.G)/255, float32(tint.B)/255, float32(tint.A)/255
+		}
+		screen.DrawTriangles(vertices, indices, src, &ebiten.DrawTrianglesOptions{Address: ebiten.AddressRepeat, AntiAlias: true})
+
+		edge := color.RGBA{tint.R / 3, tint.G / 3, tint.B / 3, 255}
+		for _, w := range o.Walls {
+			vector.StrokeLine(screen, float32(w.X1), float32(w.Y1), float32(w.X2), float32(w.Y2), 2, edge, true)
+		}
+	}
+}
+
+func filled(o game.Object) bool {
+	return o.Texture != "" || o.Fill.A > 0
+}
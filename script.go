@@ -0,0 +1,22 @@
+package main
+
+import (
+	"fmt"
+
+	"shooter/level"
+	"shooter/script"
+)
+
+// mapEvent plays out an event of the map's script, doors and airdrops come as entities. mu must be held.
+func (g *Game) mapEvent(e script.Event) {
+	switch e.Kind {
+	case script.Lighting:
+		if l, ok := level.Lightings[e.Lighting]; ok {
+			g.lighting = l
+		}
+	case script.OpenDoor:
+		g.killfeed.AddMessage(fmt.Sprintf("The %s opened", e.Door))
+	case script.CloseDoor:
+		g.killfeed.AddMessage(fmt.Sprintf("The %s closed", e.Door))
+	}
+}
@@ -0,0 +1,27 @@
+package script
+
+import (
+	"math"
+	"time"
+
+	"shooter/weapon"
+)
+
+func init() {
+	// The gate between the refinery's pillars opens a little into the match, the sun goes
+	// down and comes up again
+	Register("refinery", Script{
+		Doors: []Door{{Name: "gate", X: 800, Y: 260, Angle: math.Pi / 2}},
+		Events: []Event{
+			{At: 30 * time.Second, Kind: OpenDoor, Door: "gate"},
+			{At: 2 * time.Minute, Every: 6 * time.Minute, Kind: Lighting, Lighting: "night"},
+			{At: 4 * time.Minute, Every: 6 * time.Minute, Kind: Lighting, Lighting: "day"},
+			{At: 6 * time.Minute, Every: 6 * time.Minute, Kind: Lighting, Lighting: "dusk"},
+		},
+	})
+	Register("warehouse", Script{
+		Events: []Event{
+			{At: 90 * time.Second, Every: 90 * time.Second, Kind: SupplyDrop, Weapon: weapon.Shotgun, Drops: 2},
+		},
+	})
+}
@@ -0,0 +1,97 @@
+// Package script has maps schedule events during matches, like doors opening, the light
+// changing or supply drops. The server runs them and tells the clients, see server_script.go.
+package script
+
+import (
+	"math"
+	"time"
+
+	"shooter/weapon"
+)
+
+// Kind is what a scripted event does.
+type Kind string
+
+const (
+	OpenDoor  Kind = "open_door"
+	CloseDoor Kind = "close_door"
+	// Switches the map to one of level.Lightings
+	Lighting Kind = "lighting"
+	// Drops weapons at random points of the map
+	SupplyDrop Kind = "supply_drop"
+)
+
+// Door is a wall of the map which scripts open and close, closed when the map loads.
+type Door struct {
+	Name string
+	// Center and the way it faces, it runs across the angle like a barricade
+	X, Y, Angle float64
+}
+
+// Event is something the script has happen At into the map and Every so often after that,
+// once when Every is 0.
+type Event struct {
+	At    time.Duration `json:"at"`
+	Every time.Duration `json:"every,omitempty"`
+	Kind  Kind          `json:"kind"`
+	// Of door events
+	Door string `json:"door,omitempty"`
+	// Of lighting events
+	Lighting string `json:"lighting,omitempty"`
+	// Of supply drops, Drops of the weapon
+	Weapon weapon.ID `json:"weapon,omitempty"`
+	Drops  int       `json:"drops,omitempty"`
+}
+
+// Script is what a map has happen during matches.
+type Script struct {
+	Doors  []Door
+	Events []Event
+}
+
+var scripts = map[string]Script{}
+
+// Register gives the map its script, maps without one have nothing happen.
+func Register(mapName string, s Script) {
+	scripts[mapName] = s
+}
+
+// Get returns the map's script.
+func Get(mapName string) Script {
+	return scripts[mapName]
+}
+
+// Schedule is where a script is at since the map loaded.
+type Schedule struct {
+	events []Event
+	// When each event is due next, infinite once it happened for good
+	next []time.Duration
+}
+
+func NewSchedule(s Script) *Schedule {
+	sched := &Schedule{events: s.Events}
+	for _, e := range s.Events {
+		sched.next = append(sched.next, e.At)
+	}
+	return sched
+}
+
+// Due returns the events which came due by elapsed since the map loaded, in the script's
+// order. Repeating events missed in between happen once.
+func (s *Schedule) Due(elapsed time.Duration) []Event {
+	var due []Event
+	for i, e := range s.events {
+		if s.next[i] > elapsed {
+			continue
+		}
+		due = append(due, e)
+		if e.Every <= 0 {
+			s.next[i] = math.MaxInt64
+			continue
+		}
+		for s.next[i] <= elapsed {
+			s.next[i] += e.Every
+		}
+	}
+	return due
+}
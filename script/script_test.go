@@ -0,0 +1,60 @@
+package script
+
+import (
+	"testing"
+	"time"
+
+	"shooter/level"
+)
+
+func TestSchedule(t *testing.T) {
+	s := NewSchedule(Script{Events: []Event{
+		{At: 10 * time.Second, Kind: OpenDoor, Door: "gate"},
+		{At: 5 * time.Second, Every: 20 * time.Second, Kind: Lighting, Lighting: "night"},
+	}})
+	kinds := func(events []Event) []Kind {
+		var k []Kind
+		for _, e := range events {
+			k = append(k, e.Kind)
+		}
+		return k
+	}
+	steps := []struct {
+		elapsed time.Duration
+		want    []Kind
+	}{
+		{4 * time.Second, nil},
+		{5 * time.Second, []Kind{Lighting}},
+		{6 * time.Second, nil},
+		{12 * time.Second, []Kind{OpenDoor}},
+		{25 * time.Second, []Kind{Lighting}},
+		// Missed ones happen once
+		{90 * time.Second, []Kind{Lighting}},
+		{100 * time.Second, nil},
+	}
+	for _, step := range steps {
+		if got := kinds(s.Due(step.elapsed)); len(got) != len(step.want) || len(got) > 0 && got[0] != step.want[0] {
+			t.Errorf("due at %v = %v, want %v", step.elapsed, got, step.want)
+		}
+	}
+}
+
+func TestBuiltInScripts(t *testing.T) {
+	for name, s := range scripts {
+		doors := map[string]bool{}
+		for _, d := range s.Doors {
+			doors[d.Name] = true
+		}
+		for _, e := range s.Events {
+			if (e.Kind == OpenDoor || e.Kind == CloseDoor) && !doors[e.Door] {
+				t.Errorf("%s opens unknown door %q", name, e.Door)
+			}
+			if _, ok := level.Lightings[e.Lighting]; e.Kind == Lighting && !ok {
+				t.Errorf("%s switches to unknown lighting %q", name, e.Lighting)
+			}
+			if _, ok := level.Get(name); !ok {
+				t.Errorf("script of unknown map %s", name)
+			}
+		}
+	}
+}
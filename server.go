@@ -0,0 +1,879 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"sync"
+	"syscall"
+	"time"
+
+	"shooter/ability"
+	"shooter/config"
+	"shooter/heatmap"
+	"shooter/level"
+	"shooter/match"
+	"shooter/matchlog"
+	"shooter/netsim"
+	"shooter/player"
+	"shooter/script"
+	"shooter/sim"
+	"shooter/weapon"
+	"shooter/webhook"
+	"shooter/wire"
+	"shooter/zone"
+)
+
+// Where the server keeps the match it was playing when shut down
+const MatchStateFile = "match.json"
+
+// Server relays events between clients and keeps score of the match.
+type Server struct {
+	mu      sync.Mutex
+	clients map[net.Conn]*client
+	match   *match.Tracker
+	// Set while the summary and map vote are shown between matches
+	summary *match.Summary
+	vote    match.Vote
+
+	// Latest player update of each client, relayed at the broadcast rate
+	pending map[net.Conn]string
+	cfg     config.Network
+	// Simulation steps done since tickStart, see steps
+	tickStart time.Time
+	ticks     uint64
+
+	listener net.Listener
+	// Shown by the server's own bots in their hello, see spawnedBot
+	botToken string
+
+	// Bullets and players as far as the server knows
+	world *sim.World
+	// Player ID of each client, from the handshake
+	ids map[net.Conn]string
+	// Whether the last entities update had any, see flushEntities
+	sentEntities bool
+	// Nil unless the zone modifier is on
+	zone *zone.Zone
+	// When the next elimination round may start
+	nextRound time.Time
+	// Running vote, nil when there is none
+	poll *match.Poll
+	// When each player last called a vote
+	lastCalled map[string]time.Time
+	// Names of players kicked by a vote and until when
+	kicked map[string]time.Time
+	// Entry of cfg.Rotation being played
+	rotation int
+	// Recent positions of each player, for checking shots against where they were
+	trails map[string][]trailPoint
+	// Shots of each player rejected as going through walls, see validShot
+	rejected map[string]int
+	// Spread seed of each player's life, given at the handshake and for each life after, see reseed
+	seeds map[string]uint64
+	// Bullets each player fired this life, counted for validShot
+	shots map[string]int
+	// When each player's next shot is due by their weapon's cooldown, see validTrigger
+	nextShot map[string]time.Time
+	// Teammates each player killed, see teamKill
+	teamKills map[string]int
+	// When each player last died, see allowedUpdate
+	died map[string]time.Time
+	// Of everyone who played on a ranked server, nil when it isn't one
+	ratings match.Ratings
+	// Whose key each rated name belongs to, nil with ratings
+	identities match.Identities
+	// Charges of everyone's abilities, see useAbility
+	abilities *ability.Tracker
+	// Recon pulses, see revealed
+	scans []scan
+	// Map's script since the world was made, IDs of its closed doors and the lighting it
+	// switched to, empty for the level's own
+	script      *script.Schedule
+	scriptStart time.Time
+	doors       map[string]uint64
+	lighting    string
+	// Supply crates dropped this match, see updateSupply
+	supplies int
+	// Where players can walk to from the spawns of the map, airdrops only land there
+	reach level.Reach
+	// Kills and deaths on each map since the server started, see recordHeat
+	heatmaps map[string]*heatmap.Heatmap
+	// Everything that happened in the match, see logEvent
+	matchLog *matchlog.Log
+	// Nil without webhooks in the config
+	webhooks *webhook.Notifier
+}
+
+// NewServer returns a server playing the map, or the first of the configured rotation.
+func NewServer(mapName string, cfg config.Network) *Server {
+	s := &Server{
+		clients: make(map[net.Conn]*client),
+		pending: make(map[net.Conn]string),
+		cfg:     cfg,
+		ids:     make(map[net.Conn]string),
+
+		lastCalled: make(map[string]time.Time),
+		kicked:     make(map[string]time.Time),
+		trails:     make(map[string][]trailPoint),
+		rejected:   make(map[string]int),
+		seeds:      make(map[string]uint64),
+		shots:      make(map[string]int),
+		nextShot:   make(map[string]time.Time),
+		teamKills:  make(map[string]int),
+		died:       make(map[string]time.Time),
+		abilities:  ability.NewTracker(),
+		heatmaps:   make(map[string]*heatmap.Heatmap),
+		webhooks:   newNotifier(cfg.Webhooks),
+	}
+	s.cfg.Rotation = validRotation(cfg.Rotation)
+	if token, err := config.NewKey(); err == nil {
+		s.botToken = token
+	} else {
+		log.Println("Error making the bot token, backfill bots play as people:", err)
+	}
+	if cfg.Ranked {
+		s.ratings = loadRatings()
+		s.identities = loadIdentities()
+	}
+	if e, ok := s.rotationEntry(); ok {
+		mapName = e.Map
+	}
+	s.match = match.NewTracker(mapName, time.Now())
+	s.match.SetRules(s.rules(s.mode()))
+	s.newMatchLog()
+	s.world = s.newWorld(mapName)
+	s.newZone()
+	s.loadScript(time.Now())
+	return s
+}
+
+// rules are the rules of the mode with the server's knobs and the limits of the rotation
+// entry being played.
+func (s *Server) rules(mode match.Mode) match.Rules {
+	weapons := make([]weapon.ID, 0, len(s.cfg.GunGameWeapons))
+	for _, id := range s.cfg.GunGameWeapons {
+		weapons = append(weapons, weapon.ID(id))
+	}
+	r := match.NewRules(mode, weapons, level.Arenas())
+	if ff := match.FriendlyFire(s.cfg.FriendlyFire); slices.Contains(match.FriendlyFireModes, ff) {
+		r.FriendlyFire = ff
+	}
+	r.RespawnTime = time.Duration(s.cfg.RespawnTime) * time.Second
+	r.Health = s.cfg.StartHealth
+	r.SpeedFactor = s.cfg.SpeedFactor
+	r.InfiniteAmmo = s.cfg.InfiniteAmmo
+	r.Abilities = s.cfg.Abilities
+	if r.InfiniteAmmo {
+		// Elimination rounds too
+		r.Ammo = 0
+	}
+	r.TimeLimit = time.Duration(s.cfg.TimeLimit) * time.Second
+	r.FragLimit = s.cfg.FragLimit
+	if e, ok := s.rotationEntry(); ok {
+		if e.TimeLimit > 0 {
+			r.TimeLimit = time.Duration(e.TimeLimit) * time.Second
+		}
+		if e.FragLimit > 0 {
+			r.FragLimit = e.FragLimit
+		}
+	}
+	return r
+}
+
+// startServer runs a dedicated server until SIGINT or SIGTERM, resuming the saved match with "resume".
+// Commands like changemap are read from stdin.
+func startServer(args []string) {
+	cfg, err := config.Load()
+	if err != nil {
+		log.Println("Error loading config, using defaults:", err)
+	}
+	listener, err := net.Listen("tcp", ServerPort)
+	if err != nil {
+		log.Fatal("Failed to start server:", err)
+	}
+	s := NewServer(level.Default, cfg.Network)
+	setupCrashReports(cfg, s.netState)
+	if len(args) > 0 && args[0] == "resume" {
+		s.resume()
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		s.Shutdown("Server shut down", true)
+	}()
+	go s.console(os.Stdin)
+	if cfg.Network.APIAddress != "" {
+		go s.serveAPI(cfg.Network.APIAddress, cfg.Network.APIToken)
+	}
+	if cfg.Network.PprofAddress != "" {
+		go servePprof(cfg.Network.PprofAddress, cfg.Network.APIToken)
+	}
+
+	s.Serve(listener)
+}
+
+func (s *Server) Serve(listener net.Listener) {
+	defer listener.Close()
+	log.Println("Server running on", listener.Addr())
+
+	s.mu.Lock()
+	s.listener = listener
+	s.mu.Unlock()
+	go s.run()
+	if s.cfg.Backfill > 0 {
+		stop := make(chan struct{})
+		defer close(stop)
+		// Bots dial in on loopback whatever address the server listens on
+		_, port, _ := net.SplitHostPort(listener.Addr().String())
+		go s.backfill(net.JoinHostPort("127.0.0.1", port), stop)
+	}
+
+	for {
+		conn, err := listener.Accept()
+		if errors.Is(err, net.ErrClosed) {
+			return
+		}
+		if err != nil {
+			log.Println("Connection error:", err)
+			continue
+		}
+		conn = netsim.Wrap(conn, fakeNet)
+
+		go s.handle(conn)
+	}
+}
+
+// handshake reads the client's hello and answers with a player ID no one else has.
+func (s *Server) handshake(c net.Conn, reader *wire.Reader) (string, error) {
+	c.SetReadDeadline(time.Now().Add(HandshakeTimeout))
+	defer c.SetReadDeadline(time.Time{})
+
+	msg, err := reader.ReadString()
+	if err != nil {
+		return "", err
+	}
+	var event player.Event
+	if err := json.Unmarshal([]byte(msg), &event); err != nil {
+		return "", err
+	}
+	var hello Hello
+	if event.Type != player.EventTypeHello {
+		return "", fmt.Errorf("expected hello, got %q", event.Type)
+	}
+	if err := json.Unmarshal(event.Data, &hello); err != nil {
+		return "", err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if hello.Info {
+		return "", s.sendInfo(c)
+	}
+	host := remoteHost(c)
+	if reason := s.rejection(hello, host); reason != "" {
+		if reject, err := encodeEvent(player.EventTypeReject, Reject{Reason: reason}); err == nil {
+			c.SetWriteDeadline(time.Now().Add(WriteTimeout))
+			c.Write(reject)
+		}
+		return "", fmt.Errorf("rejected %q: %s", hello.Name, reason)
+	}
+	id := s.uniqueID(hello.Name)
+	seed := rand.Uint64()
+	welcome, err := encodeEvent(player.EventTypeWelcome, Welcome{
+		ID:      id,
+		Rules:   s.match.Rules,
+		Map:     s.world.Level.Name,
+		MapHash: level.Hash(s.world.Level),
+		// Clients compare it with their own weapon data
+		WeaponsHash: weapon.Hash(),
+		Team:        hello.Party,
+		Seed:        seed,
+	})
+	if err != nil {
+		return "", err
+	}
+	c.SetWriteDeadline(time.Now().Add(WriteTimeout))
+	if _, err := c.Write(welcome); err != nil {
+		return "", err
+	}
+	var delay time.Duration
+	if hello.Observer {
+		delay = s.observerDelay()
+	}
+	cl := newClient(c, delay)
+	cl.observer = hello.Observer
+	cl.key, cl.host = hello.Key, host
+	cl.bot = s.spawnedBot(hello, host)
+	cl.name = hello.Name
+	cl.team = hello.Party
+	cl.class = hello.Class
+	s.clients[c] = cl
+	s.ids[c] = id
+	s.seeds[id] = seed
+	if !cl.observer && !cl.bot {
+		s.claim(hello)
+	}
+	s.progress(id)
+	s.joinRound(cl)
+	if !cl.observer {
+		s.logEvent(matchlog.Entry{Type: matchlog.Join, PlayerID: id})
+	}
+	return id, nil
+}
+
+// rejection returns why the client from the host can't join, empty when it can, mu must be held.
+func (s *Server) rejection(hello Hello, host string) string {
+	if hello.Version != player.ProtocolVersion {
+		return fmt.Sprintf("Wrong version: the server uses protocol %d, the game %d. Update to the same version.",
+			player.ProtocolVersion, hello.Version)
+	}
+	if slices.Contains(s.cfg.Banned, hello.Name) {
+		return "You are banned from this server"
+	}
+	if time.Now().Before(s.kicked[hello.Name]) {
+		return "You were kicked from this server, try again later"
+	}
+	if reason := s.watchingAndPlaying(hello, host); reason != "" {
+		return reason
+	}
+	if hello.Observer {
+		if s.cfg.ObserverToken == "" || subtle.ConstantTimeCompare([]byte(hello.Token), []byte(s.cfg.ObserverToken)) != 1 {
+			return "This server only lets its own observers watch"
+		}
+		return ""
+	}
+	if players := s.playerCount(); s.cfg.MaxPlayers > 0 && players >= s.cfg.MaxPlayers {
+		return fmt.Sprintf("Server full (%d/%d)", players, s.cfg.MaxPlayers)
+	}
+	if !s.spawnedBot(hello, host) {
+		if reason := s.impostor(hello); reason != "" {
+			return reason
+		}
+		return s.ratingGap(hello.Name)
+	}
+	return ""
+}
+
+// spawnedBot is true for the server's own bots, which dial in on loopback with its bot token.
+// Anyone else saying they are a bot joins as a person.
+func (s *Server) spawnedBot(hello Hello, host string) bool {
+	if !hello.Bot || s.botToken == "" {
+		return false
+	}
+	if ip := net.ParseIP(host); ip == nil || !ip.IsLoopback() {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(hello.Token), []byte(s.botToken)) == 1
+}
+
+// watchingAndPlaying returns why the hello can't join when its key or host is already in the
+// match the other way, observers see everyone and a player watching too would as well.
+// mu must be held.
+func (s *Server) watchingAndPlaying(hello Hello, host string) string {
+	for _, cl := range s.clients {
+		if cl.observer == hello.Observer || cl.bot {
+			continue
+		}
+		if hello.Key != "" && cl.key == hello.Key || host != "" && cl.host == host {
+			return "You can't play and watch the same match"
+		}
+	}
+	return ""
+}
+
+// observerDelay is how late observers get everything.
+func (s *Server) observerDelay() time.Duration {
+	if s.cfg.ObserverDelay > 0 {
+		return time.Duration(s.cfg.ObserverDelay) * time.Second
+	}
+	return ObserverDelay
+}
+
+// remoteHost is the host the client connects from, its whole address when it has no port.
+func remoteHost(c net.Conn) string {
+	address := c.RemoteAddr().String()
+	if host, _, err := net.SplitHostPort(address); err == nil {
+		return host
+	}
+	return address
+}
+
+// playerCount is the number of connected clients which aren't observers or bots, mu must be held.
+// Backfill bots leave to make room for people.
+func (s *Server) playerCount() int {
+	n := 0
+	for _, cl := range s.clients {
+		if !cl.observer && !cl.bot {
+			n++
+		}
+	}
+	return n
+}
+
+// uniqueID returns name, numbered when a connected player already has it, mu must be held.
+func (s *Server) uniqueID(name string) string {
+	if name == "" {
+		name = "player"
+	}
+	taken := make(map[string]bool, len(s.ids))
+	for _, id := range s.ids {
+		taken[id] = true
+	}
+	id := name
+	for n := 2; taken[id]; n++ {
+		id = fmt.Sprintf("%s#%d", name, n)
+	}
+	return id
+}
+
+func (s *Server) handle(c net.Conn) {
+	defer reporter.Recover("server connection", func(string) { c.Close() })
+	reader := wire.NewReader(c)
+	id, err := s.handshake(c, reader)
+	if errors.Is(err, errInfoOnly) {
+		c.Close()
+		return
+	}
+	if err != nil {
+		log.Println("Handshake failed:", err)
+		c.Close()
+		return
+	}
+	log.Println("Player joined:", id)
+
+	for {
+		msg, err := reader.ReadString()
+		if err != nil {
+			log.Println("Client disconnected:", err)
+			s.disconnect(c)
+			return
+		}
+		s.handleMessage(c, id, msg)
+	}
+}
+
+// disconnect forgets everything about the client once it's gone.
+func (s *Server) disconnect(c net.Conn) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if cl, ok := s.clients[c]; ok {
+		close(cl.out)
+		delete(s.clients, c)
+		if !cl.observer {
+			s.logEvent(matchlog.Entry{Type: matchlog.Leave, PlayerID: s.ids[c]})
+		}
+	}
+	delete(s.pending, c)
+	s.eliminate("", s.ids[c])
+	delete(s.world.Players, s.ids[c])
+	delete(s.trails, s.ids[c])
+	delete(s.died, s.ids[c])
+	delete(s.seeds, s.ids[c])
+	delete(s.shots, s.ids[c])
+	delete(s.nextShot, s.ids[c])
+	s.abilities.Forget(s.ids[c])
+	s.leaveVehicle(s.ids[c])
+	s.world.Ledger.Forget(s.ids[c])
+	s.world.RemoveOwned(s.ids[c])
+	delete(s.ids, c)
+}
+
+// handleMessage acts on a message of the client, a message making the server panic is dropped
+// and reported while the client stays connected.
+func (s *Server) handleMessage(c net.Conn, id, msg string) {
+	defer reporter.Recover("server message", nil)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch event := s.track(c, msg); event.Type {
+	case player.EventTypePlayerUpdate:
+		// Only the newest update matters, older ones are replaced until the next flush.
+		// It's sent on as the server has it, moves through walls corrected, see correctMove.
+		if msg, err := json.Marshal(event); err == nil {
+			s.pending[c] = string(msg)
+		}
+	case player.EventTypeShoot:
+		s.spawnBullets(id, event.Data)
+	case player.EventTypePing:
+		s.relayTeam(c, msg)
+	case player.EventTypeGrenade:
+		if s.throwGrenade(id, event.Data) {
+			s.relaySeen(c, msg)
+		}
+	case player.EventTypeDeploy:
+		s.deploy(id, event.Data)
+	case player.EventTypeCallVote:
+		s.callVote(event.Data, time.Now())
+	case player.EventTypeCastVote:
+		s.castVote(event.Data, time.Now())
+	case player.EventTypeMapRequest:
+		s.sendMap(c, event.Data)
+	case player.EventTypePickup:
+		s.pickUp(id, event.Data)
+	case player.EventTypeBuy:
+		s.buy(id, event.Data)
+	case player.EventTypeAbility:
+		s.useAbility(id, event.Data)
+	case player.EventTypeRide:
+		s.ride(id, event.Data)
+	case player.EventTypeDrive:
+		s.drive(id, event.Data)
+	case player.EventTypeMapVote:
+		s.relay(c, msg)
+	default:
+		// Only the events above come from clients. Hits, kills, entities, match state and the rest are
+		// the server's to send, clients sending them or types it doesn't know are broken or cheating.
+		// Empty are invalid, spoofed or from observers.
+	}
+}
+
+// relay sends a client's message to all the other clients, mu must be held.
+func (s *Server) relay(from net.Conn, msg string) {
+	for c, cl := range s.clients {
+		if c != from {
+			cl.send(wire.Frame([]byte(msg)))
+		}
+	}
+}
+
+// relayTeam sends a client's message to its teammates and to observers, mu must be held.
+// Players without a team have no teammates.
+func (s *Server) relayTeam(from net.Conn, msg string) {
+	sender, ok := s.clients[from]
+	if !ok {
+		return
+	}
+	for c, cl := range s.clients {
+		if c != from && (cl.observer || sender.team != "" && cl.team == sender.team) {
+			cl.send(wire.Frame([]byte(msg)))
+		}
+	}
+}
+
+// flush relays the pending player updates to those who can see the player, mu must be held.
+func (s *Server) flush() {
+	for c, msg := range s.pending {
+		s.relayVisible(c, msg)
+		delete(s.pending, c)
+	}
+}
+
+// track updates match stats from a client event and returns it, mu must be held.
+// Events claiming to be from another player than the client's, and all but map requests from observers,
+// are dropped and returned empty.
+func (s *Server) track(c net.Conn, msg string) player.Event {
+	var event player.Event
+	if err := json.Unmarshal([]byte(msg), &event); err != nil {
+		log.Println("Error unmarshaling event:", err)
+		return event
+	}
+	// Observers only get to ask for the map they watch
+	if cl, ok := s.clients[c]; ok && cl.observer && event.Type != player.EventTypeMapRequest {
+		return player.Event{}
+	}
+
+	switch event.Type {
+	case player.EventTypePlayerUpdate:
+		var update PlayerUpdate
+		if err := json.Unmarshal(event.Data, &update); err != nil || update.ID != s.ids[c] {
+			return player.Event{}
+		}
+		now := time.Now()
+		if !s.allowedUpdate(update, now) {
+			return player.Event{}
+		}
+		// The team is the one given at the handshake, a client claiming another one could see
+		// the other team through walls or dodge its bullets
+		claimed := update.Team
+		update.Team = ""
+		if cl, ok := s.clients[c]; ok {
+			update.Team = cl.team
+		}
+		// So is the health, the server deals all damage, see health
+		claimedHealth := update.Health
+		update.Health = s.health(update)
+		if s.correctSpawn(c, &update) || s.correctReach(c, &update, now) || s.correctMove(c, &update, now) ||
+			update.Team != claimed || update.Health != claimedHealth {
+			data, err := json.Marshal(update)
+			if err != nil {
+				log.Println("Error marshaling PlayerUpdate:", err)
+				return player.Event{}
+			}
+			event.Data = data
+		}
+		s.match.Join(update.ID)
+		if cl, ok := s.clients[c]; ok && cl.bot {
+			s.match.JoinBot(update.ID)
+		}
+		s.recordTrail(update.ID, update.X, update.Y, update.Angle, now)
+		s.world.Players[update.ID] = &sim.Player{X: update.X, Y: update.Y, Health: update.Health, Team: update.Team, Weapon: update.Weapon}
+	case player.EventTypeGrenade:
+		var throw GrenadeThrow
+		if err := json.Unmarshal(event.Data, &throw); err != nil || throw.PlayerID != s.ids[c] {
+			return player.Event{}
+		}
+	case player.EventTypeDeploy:
+		var d Deploy
+		if err := json.Unmarshal(event.Data, &d); err != nil || d.PlayerID != s.ids[c] {
+			return player.Event{}
+		}
+	case player.EventTypePing:
+		var p Ping
+		if err := json.Unmarshal(event.Data, &p); err != nil || p.PlayerID != s.ids[c] {
+			return player.Event{}
+		}
+	case player.EventTypeMapVote:
+		var vote MapVote
+		if err := json.Unmarshal(event.Data, &vote); err != nil || vote.PlayerID != s.ids[c] {
+			return player.Event{}
+		}
+		if s.summary != nil {
+			s.vote.Cast(vote.PlayerID, vote.Map)
+		}
+	case player.EventTypeCallVote:
+		var call CallVote
+		if err := json.Unmarshal(event.Data, &call); err != nil || call.PlayerID != s.ids[c] {
+			return player.Event{}
+		}
+	case player.EventTypeCastVote:
+		var vote CastVote
+		if err := json.Unmarshal(event.Data, &vote); err != nil || vote.PlayerID != s.ids[c] {
+			return player.Event{}
+		}
+	case player.EventTypePickup:
+		var req Pickup
+		if err := json.Unmarshal(event.Data, &req); err != nil || req.PlayerID != s.ids[c] {
+			return player.Event{}
+		}
+	case player.EventTypeBuy:
+		var req Buy
+		if err := json.Unmarshal(event.Data, &req); err != nil || req.PlayerID != s.ids[c] {
+			return player.Event{}
+		}
+	case player.EventTypeAbility:
+		var use UseAbility
+		if err := json.Unmarshal(event.Data, &use); err != nil || use.PlayerID != s.ids[c] {
+			return player.Event{}
+		}
+	case player.EventTypeRide:
+		var ride Ride
+		if err := json.Unmarshal(event.Data, &ride); err != nil || ride.PlayerID != s.ids[c] {
+			return player.Event{}
+		}
+	case player.EventTypeDrive:
+		var drive Drive
+		if err := json.Unmarshal(event.Data, &drive); err != nil || drive.PlayerID != s.ids[c] {
+			return player.Event{}
+		}
+	case player.EventTypeMapRequest:
+		var req MapRequest
+		if err := json.Unmarshal(event.Data, &req); err != nil || req.PlayerID != s.ids[c] {
+			return player.Event{}
+		}
+	}
+	return event
+}
+
+// run relays player updates at the broadcast rate, ends matches and starts new ones after the map vote.
+func (s *Server) run() {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	updates := time.NewTicker(time.Second / time.Duration(max(1, s.cfg.BroadcastRate)))
+	defer updates.Stop()
+	physics := time.NewTicker(time.Second / time.Duration(s.tickRate()))
+	defer physics.Stop()
+	s.mu.Lock()
+	s.tickStart, s.ticks = time.Now(), 0
+	s.mu.Unlock()
+
+	for {
+		var now time.Time
+		select {
+		case tick := <-physics.C:
+			s.tick("server physics", func() {
+				for range s.steps(tick) {
+					s.updateZone(tick)
+					s.updateEntities()
+					s.updateBullets()
+					s.updateGrenades()
+					s.updateHazards()
+				}
+			})
+			continue
+		case tick := <-updates.C:
+			s.tick("server broadcast", func() {
+				s.sendTick(tick)
+				s.flush()
+				s.flushEntities()
+			})
+			continue
+		case now = <-ticker.C:
+		}
+		s.tick("server match", func() { s.second(now) })
+	}
+}
+
+// second ends and starts matches and rounds and runs everything else which is checked once a second, mu must be held.
+func (s *Server) second(now time.Time) {
+	switch {
+	case s.summary == nil && s.match.Over(now):
+		summary := s.match.Summary(now, level.Maps())
+		if len(s.cfg.Rotation) > 0 {
+			s.rotation = (s.rotation + 1) % len(s.cfg.Rotation)
+			summary.Maps, summary.Next = nil, s.cfg.Rotation[s.rotation].Map
+		}
+		s.rate(&summary)
+		s.summary = &summary
+		s.vote = match.Vote{}
+		s.broadcast(player.EventTypeMatchEnd, summary)
+		s.recordResult(summary, now)
+		s.notifyMatchEnd(summary)
+		if err := s.endMatchLog(summary); err != nil {
+			log.Println("Error writing match log:", err)
+		}
+	case s.summary != nil && now.After(s.summary.NextMap):
+		next := s.summary.Next
+		if next == "" {
+			next = s.vote.Winner(s.summary.Maps, s.match.Map)
+		}
+		s.startMatch(next, s.mode(), now)
+	case s.summary == nil:
+		s.updateRound(now)
+	}
+	s.sendZone(now)
+	s.updateScript(now)
+	s.updateSupply(now)
+	s.updatePoll(now)
+}
+
+// tick runs a part of the game loop holding mu. A panic in it is reported and the loop goes on
+// with the next tick, rather than taking the whole server down with it.
+func (s *Server) tick(subsystem string, update func()) {
+	defer reporter.Recover(subsystem, nil)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	update()
+}
+
+// startMatch starts a new match of the mode on the map, everyone starts over. mu must be held.
+func (s *Server) startMatch(mapName string, mode match.Mode, now time.Time) {
+	s.match = match.NewTracker(mapName, now)
+	s.match.SetRules(s.rules(mode))
+	s.newMatchLog()
+	s.summary = nil
+	s.world = s.newWorld(mapName)
+	s.abilities, s.scans, s.supplies = ability.NewTracker(), nil, 0
+	s.newZone()
+	s.loadScript(now)
+	s.notifyMatchStart()
+	for _, id := range s.ids {
+		s.reseed(id)
+	}
+	s.broadcast(player.EventTypeMatchStart, MatchStart{Map: mapName, MapHash: mapHash(mapName), Rules: s.match.Rules})
+}
+
+// Shutdown tells clients the server is going away, optionally saves the match and closes
+// everything. Holding mu makes sure no message is cut off.
+func (s *Server) Shutdown(reason string, save bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	log.Println("Shutting down:", reason)
+	s.broadcast(player.EventTypeServerShutdown, ServerShutdown{Reason: reason})
+	if save && s.summary == nil {
+		if err := s.saveMatch(); err != nil {
+			log.Println("Error saving match:", err)
+		}
+	}
+	// Let the writers send what's queued, all together waiting at most WriteTimeout. Once it's up
+	// the rest are closed without waiting.
+	for _, cl := range s.clients {
+		close(cl.out)
+	}
+	deadline := time.NewTimer(WriteTimeout)
+	defer deadline.Stop()
+	expired := false
+	for c, cl := range s.clients {
+		if !expired {
+			select {
+			case <-cl.done:
+			case <-deadline.C:
+				expired = true
+			}
+		}
+		delete(s.clients, c)
+		c.Close()
+	}
+	if s.listener != nil {
+		s.listener.Close()
+	}
+	// Whatever was notified goes out before the process does
+	s.webhooks.Close()
+	s.webhooks = nil
+}
+
+func (s *Server) saveMatch() error {
+	path, err := config.DataPath(MatchStateFile)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(s.match.State(time.Now()))
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// resume continues the match saved at the last shutdown, which is used up by that.
+func (s *Server) resume() {
+	path, err := config.DataPath(MatchStateFile)
+	if err != nil {
+		log.Println("Error finding saved match:", err)
+		return
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		log.Println("No saved match to resume:", err)
+		return
+	}
+	var state match.State
+	if err := json.Unmarshal(data, &state); err != nil {
+		log.Println("Error loading saved match:", err)
+		return
+	}
+	s.match = match.Restore(state, time.Now())
+	s.newMatchLog()
+	s.rotation = slices.IndexFunc(s.cfg.Rotation, func(e config.RotationEntry) bool { return e.Map == state.Map })
+	s.rotation = max(s.rotation, 0)
+	s.world = s.newWorld(state.Map)
+	s.newZone()
+	s.loadScript(time.Now())
+	os.Remove(path)
+	log.Printf("Resumed match on %s at %v", state.Map, state.Elapsed.Round(time.Second))
+}
+
+// broadcast sends a server event to all clients, mu must be held.
+func (s *Server) broadcast(eventType player.EventType, data interface{}) {
+	message, err := encodeEvent(eventType, data)
+	if err != nil {
+		log.Println("Error marshaling event:", err)
+		return
+	}
+	for _, cl := range s.clients {
+		cl.send(message)
+	}
+}
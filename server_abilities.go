@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"math"
+	"time"
+
+	"shooter/ability"
+	"shooter/grapple"
+	"shooter/player"
+	"shooter/sim"
+)
+
+// UseAbility asks to use the ability or ultimate of the player's class, the server sends it
+// to everyone once it went off.
+type UseAbility struct {
+	PlayerID string     `json:"player_id"`
+	Ability  ability.ID `json:"ability"`
+	// Where the player faces, abilities place things in front of them
+	Angle float64 `json:"angle"`
+	// Where a grapple hooked, filled in by the server
+	X float64 `json:"x,omitempty"`
+	Y float64 `json:"y,omitempty"`
+}
+
+// AbilityStatus is where a player's abilities are at, sent to everyone when they change.
+type AbilityStatus struct {
+	PlayerID  string           `json:"player_id"`
+	Abilities []ability.Status `json:"abilities"`
+}
+
+// abilityHandlers are what abilities do on the server, false when they couldn't go off. They fill
+// in what the clients need of the use. Abilities without one only do something on the clients,
+// see abilityEffects. mu must be held.
+var abilityHandlers = map[ability.ID]func(s *Server, use *UseAbility) bool{
+	ability.Wall: func(s *Server, use *UseAbility) bool {
+		return s.placeInFront(sim.Barricade, use)
+	},
+	ability.Turret: func(s *Server, use *UseAbility) bool {
+		return s.placeInFront(sim.Turret, use)
+	},
+	ability.Scan: func(s *Server, use *UseAbility) bool {
+		s.scan(use.PlayerID, time.Now())
+		return true
+	},
+	ability.Jammer: func(s *Server, use *UseAbility) bool {
+		return s.placeInFront(sim.Jammer, use)
+	},
+	ability.Grapple: func(s *Server, use *UseAbility) bool {
+		p := s.world.Players[use.PlayerID]
+		hook, ok := grapple.Fire(p.X, p.Y, use.Angle, s.world.Objects())
+		use.X, use.Y = hook.X, hook.Y
+		return ok
+	},
+}
+
+// useAbility spends a charge of the ability when the player is alive, has it in the kit of their
+// class and it went off, and tells everyone. mu must be held.
+func (s *Server) useAbility(id string, data json.RawMessage) {
+	var use UseAbility
+	if err := json.Unmarshal(data, &use); err != nil {
+		log.Println("Error unmarshaling UseAbility:", err)
+		return
+	}
+	p, ok := s.world.Players[id]
+	if !s.match.Rules.Abilities || !ok || p.Health <= 0 || !s.kit(id).Has(use.Ability) {
+		return
+	}
+	now := time.Now()
+	if s.abilities.Status(id, use.Ability, now).Charges == 0 {
+		return
+	}
+	if handler, ok := abilityHandlers[use.Ability]; ok && !handler(s, &use) {
+		return
+	}
+	s.abilities.Use(id, use.Ability, now)
+	s.broadcast(player.EventTypeAbility, use)
+	s.sendAbilities(id, now)
+}
+
+// chargeUltimate counts a kill of an enemy towards the killer's ultimate. mu must be held.
+func (s *Server) chargeUltimate(killerID, victimID string) {
+	if !s.match.Rules.Abilities || killerID == "" || killerID == victimID || s.world.Teammates(killerID, victimID) {
+		return
+	}
+	now := time.Now()
+	s.abilities.Kill(killerID, s.kit(killerID).Ultimate, now)
+	s.sendAbilities(killerID, now)
+}
+
+// sendAbilities tells everyone where the player's abilities are at, mu must be held.
+func (s *Server) sendAbilities(id string, now time.Time) {
+	status := AbilityStatus{PlayerID: id}
+	for _, a := range s.kit(id).IDs() {
+		status.Abilities = append(status.Abilities, s.abilities.Status(id, a, now))
+	}
+	s.broadcast(player.EventTypeAbilities, status)
+}
+
+// kit is the abilities of the player's class, mu must be held.
+func (s *Server) kit(id string) ability.Kit {
+	for c, playerID := range s.ids {
+		if playerID == id {
+			if cl, ok := s.clients[c]; ok {
+				return ability.KitOf(cl.class)
+			}
+		}
+	}
+	return ability.KitOf("")
+}
+
+// placeInFront places an entity of the kind in front of the player where there is room,
+// replacing their oldest as deploying does. mu must be held.
+func (s *Server) placeInFront(kind sim.EntityKind, use *UseAbility) bool {
+	p := s.world.Players[use.PlayerID]
+	x, y := p.X+math.Cos(use.Angle)*ability.Reach, p.Y+math.Sin(use.Angle)*ability.Reach
+	if !s.world.CanPlace(kind, x, y, use.Angle) {
+		return false
+	}
+	s.place(&sim.Entity{Kind: kind, OwnerID: use.PlayerID, X: x, Y: y, Angle: use.Angle})
+	return true
+}
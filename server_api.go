@@ -0,0 +1,135 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"shooter/config"
+	"shooter/match"
+)
+
+// Match results the stats store keeps for the HTTP API, the oldest go first
+const APIHistory = 20
+
+// APIStatus is what the HTTP API says about the match being played.
+type APIStatus struct {
+	ServerInfo
+	// Seconds into the match
+	Elapsed float64 `json:"elapsed"`
+	// Scores of everyone who played in the match so far, best first
+	Scores []match.PlayerStats `json:"scores"`
+	// Connected players
+	Online []APIPlayer `json:"online"`
+}
+
+// APIPlayer is a connected client.
+type APIPlayer struct {
+	ID       string `json:"id"`
+	Team     string `json:"team,omitempty"`
+	Bot      bool   `json:"bot,omitempty"`
+	Observer bool   `json:"observer,omitempty"`
+}
+
+// serveAPI serves the HTTP API for dashboards and bots on the address, it needs the token as
+// a bearer token. Servers are a single room, /api/rooms lists just this one.
+func (s *Server) serveAPI(address, token string) {
+	if token == "" {
+		log.Println("Not serving the HTTP API without an api_token")
+		return
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/status", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		status := s.apiStatus(time.Now())
+		s.mu.Unlock()
+		writeJSON(w, status)
+	})
+	mux.HandleFunc("GET /api/matches", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		results, err := loadResults()
+		s.mu.Unlock()
+		if err != nil {
+			log.Println("Error loading match results:", err)
+			http.Error(w, "match results unavailable", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, results)
+	})
+	mux.HandleFunc("GET /api/rooms", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		info := s.info()
+		s.mu.Unlock()
+		writeJSON(w, []ServerInfo{info})
+	})
+	log.Println("HTTP API running on", address)
+	if err := http.ListenAndServe(address, authorized(token, mux)); err != nil {
+		log.Println("HTTP API stopped:", err)
+	}
+}
+
+// authorized lets through requests with the bearer token.
+func authorized(token string, next http.Handler) http.Handler {
+	want := []byte("Bearer " + token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), want) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Println("Error writing API response:", err)
+	}
+}
+
+// apiStatus is the match being played and who's connected, mu must be held.
+func (s *Server) apiStatus(now time.Time) APIStatus {
+	status := APIStatus{
+		ServerInfo: s.info(),
+		Elapsed:    now.Sub(s.match.Started).Seconds(),
+		Scores:     s.match.Summary(now, nil).Players,
+		Online:     []APIPlayer{},
+	}
+	for c, cl := range s.clients {
+		status.Online = append(status.Online, APIPlayer{ID: s.ids[c], Team: cl.team, Bot: cl.bot, Observer: cl.observer})
+	}
+	slices.SortFunc(status.Online, func(a, b APIPlayer) int { return strings.Compare(a.ID, b.ID) })
+	return status
+}
+
+// recordResult adds the finished match to the results in the stats store, mu must be held.
+func (s *Server) recordResult(summary match.Summary, now time.Time) {
+	path, err := config.DataPath(match.ResultsFile)
+	if err != nil {
+		log.Println("Error finding match results:", err)
+		return
+	}
+	results, err := match.LoadResults(path)
+	if err != nil {
+		log.Println("Error loading match results, starting over:", err)
+		results = match.Results{}
+	}
+	results = results.Add(match.Result{Map: s.match.Map, Mode: s.match.Rules.Mode, Ended: now, Summary: summary}, APIHistory)
+	if err := results.Save(path); err != nil {
+		log.Println("Error saving match results:", err)
+	}
+}
+
+// loadResults reads the latest finished matches from the stats store in the data directory.
+// The server writes them with mu held, readers hold it too.
+func loadResults() (match.Results, error) {
+	path, err := config.DataPath(match.ResultsFile)
+	if err != nil {
+		return nil, err
+	}
+	return match.LoadResults(path)
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"slices"
+	"time"
+
+	"shooter/level"
+	"shooter/matchlog"
+	"shooter/player"
+	"shooter/sim"
+	"shooter/weapon"
+)
+
+// Shoot is sent by a client for the bullets of one shot, and back to everyone by the
+// server as a bullet spawn once the bullets got their IDs.
+type Shoot struct {
+	Bullets []*player.Bullet `json:"bullets"`
+}
+
+// BulletDestroy tells clients a bullet hit something or expired.
+type BulletDestroy struct {
+	ID      uint64  `json:"id"`
+	OwnerID string  `json:"owner_id"`
+	X       float64 `json:"x"`
+	Y       float64 `json:"y"`
+	// Set when the bullet hit a wall or a player, for the impact effects
+	Wall     bool   `json:"wall,omitempty"`
+	VictimID string `json:"victim_id,omitempty"`
+}
+
+// newWorld returns a simulation of the map by the match's rules with nothing but the server's
+// buggies in it, unknown maps fall back to the default. Where players can walk on it is set too.
+func (s *Server) newWorld(mapName string) *sim.World {
+	lvl, ok := level.Get(mapName)
+	if !ok {
+		lvl, _ = level.Get(level.Default)
+	}
+	s.reach = level.NewNavGrid(lvl).Reach(lvl.SpawnPoints()...)
+	w := sim.NewWorld(lvl)
+	w.FriendlyFire = s.match.Rules.FriendlyFire
+	w.Absorb = s.absorb
+	w.ParkVehicles(s.cfg.Vehicles)
+	return w
+}
+
+// spawnBullets gives the client's bullets IDs and sends them to those who see the shooter, others
+// may hear it, see sendShot. mu must be held.
+func (s *Server) spawnBullets(ownerID string, data json.RawMessage) {
+	var shot Shoot
+	if err := json.Unmarshal(data, &shot); err != nil {
+		log.Println("Error unmarshaling Shoot:", err)
+		return
+	}
+	now := time.Now()
+	// The client counts every bullet it fires, those dropped here too
+	n := s.shots[ownerID]
+	s.shots[ownerID] += len(shot.Bullets)
+	if !s.validTrigger(ownerID, shot.Bullets, now) {
+		return
+	}
+	shot.Bullets = slices.DeleteFunc(shot.Bullets, func(b *player.Bullet) bool {
+		n++
+		return !s.allowedWeapon(ownerID, b.Weapon) || !s.validShot(ownerID, b, n-1, now)
+	})
+	if len(shot.Bullets) == 0 || s.match.Series != nil && !s.match.Series.Shoot(ownerID) {
+		return
+	}
+	for _, b := range shot.Bullets {
+		// Damage is the server's, not what the client's weapon data says
+		b.Damage = weapon.Get(b.Weapon).Damage
+		if s.match.Rules.OneShot {
+			b.Damage = player.MaxHealth
+		}
+		// The head of the bullet is where it moves from
+		sb := &sim.Bullet{
+			OwnerID:   ownerID,
+			X:         b.EndX,
+			Y:         b.EndY,
+			Direction: b.Direction,
+			Velocity:  b.Velocity,
+			Damage:    b.Damage,
+			Weapon:    b.Weapon,
+		}
+		s.world.Spawn(sb)
+		s.match.Fired(ownerID, b.Weapon, 1)
+		b.ID, b.OwnerID = sb.ID, ownerID
+	}
+	x, y := shot.Bullets[0].X, shot.Bullets[0].Y
+	if p, ok := s.world.Players[ownerID]; ok {
+		x, y = p.X, p.Y
+	}
+	s.sendShot(shot, x, y, shot.Bullets[0].Suppressed, func(cl *client, viewerID string) bool {
+		return s.sees(cl, viewerID, ownerID)
+	})
+}
+
+// updateBullets moves the bullets one tick and tells clients which see them what they hit,
+// mu must be held.
+func (s *Server) updateBullets() {
+	for _, impact := range s.world.Step() {
+		b := impact.Bullet
+		switch {
+		case impact.Reflected:
+			s.hit(PlayerHit{AttackerID: b.OwnerID, VictimID: b.OwnerID, Damage: impact.Damage, Weapon: b.Weapon})
+		case impact.VictimID != "":
+			s.match.Hit(b.OwnerID, b.Weapon, impact.Damage, impact.Headshot)
+			s.hit(PlayerHit{AttackerID: b.OwnerID, VictimID: impact.VictimID, Damage: impact.Damage, Weapon: b.Weapon, Headshot: impact.Headshot})
+		}
+		if impact.Destroyed {
+			s.broadcast(player.EventTypeEntityRemoved, EntityRemoved{ID: impact.EntityID, Destroyed: true})
+		}
+		destroy, err := encodeEvent(player.EventTypeBulletDestroy, BulletDestroy{
+			ID:       b.ID,
+			OwnerID:  b.OwnerID,
+			X:        impact.X,
+			Y:        impact.Y,
+			Wall:     impact.Wall,
+			VictimID: impact.VictimID,
+		})
+		if err != nil {
+			log.Println("Error marshaling event:", err)
+			continue
+		}
+		// Those who didn't get the bullet only learn where it ended when they see that
+		for c, cl := range s.clients {
+			if s.sees(cl, s.ids[c], b.OwnerID) || s.seesPoint(cl, s.ids[c], impact.X, impact.Y) {
+				cl.send(destroy)
+			}
+		}
+	}
+}
+
+// hit tells everyone of damage the world dealt, and kills the victim when it took their last
+// health. The server's health is what counts, clients only follow it. mu must be held.
+func (s *Server) hit(hit PlayerHit) {
+	s.broadcast(player.EventTypePlayerHit, hit)
+	if p, ok := s.world.Players[hit.VictimID]; ok && hit.Damage > 0 && p.Health <= 0 {
+		s.kill(PlayerKilled{KillerID: hit.AttackerID, VictimID: hit.VictimID, Weapon: hit.Weapon})
+	}
+}
+
+// kill settles who gets the credit for the death and tells everyone, mu must be held.
+func (s *Server) kill(kill PlayerKilled) {
+	s.died[kill.VictimID] = time.Now()
+	s.reseed(kill.VictimID)
+	kill.KillerID, kill.Assists = s.world.Credit(kill.VictimID, kill.KillerID)
+	s.teamKill(kill.KillerID, kill.VictimID, time.Now())
+	s.match.Kill(kill.KillerID, kill.VictimID)
+	for _, id := range kill.Assists {
+		s.match.Assist(id)
+	}
+	s.broadcast(player.EventTypePlayerKilled, kill)
+	if kill.KillerID != kill.VictimID {
+		s.progress(kill.KillerID)
+	}
+	s.recordHeat(kill.KillerID, kill.VictimID)
+	s.logEvent(matchlog.Entry{
+		Type:      matchlog.Kill,
+		PlayerID:  kill.KillerID,
+		OtherID:   kill.VictimID,
+		Weapon:    kill.Weapon,
+		Positions: s.positions(kill.KillerID, kill.VictimID),
+		Data:      kill.Assists,
+	})
+	s.payKill(kill.KillerID, kill.VictimID)
+	s.chargeUltimate(kill.KillerID, kill.VictimID)
+	s.eliminate(kill.KillerID, kill.VictimID)
+	s.dropOnDeath(kill.VictimID)
+	s.leaveVehicle(kill.VictimID)
+}
+
+// throwGrenade starts the server's copy of a thrown grenade, false when the player can't
+// throw it. Grenades of economy rounds have to be bought. mu must be held.
+func (s *Server) throwGrenade(ownerID string, data json.RawMessage) bool {
+	var throw GrenadeThrow
+	if err := json.Unmarshal(data, &throw); err != nil {
+		log.Println("Error unmarshaling GrenadeThrow:", err)
+		return false
+	}
+	if throw.Kind != "" && throw.Kind != sim.Decoy {
+		return false
+	}
+	if bank := s.match.Bank; bank != nil && throw.Kind == "" {
+		if !bank.Throw(ownerID) {
+			return false
+		}
+		s.sendWallet(ownerID, "")
+	}
+	s.world.Throw(&sim.Grenade{OwnerID: ownerID, Payload: throw.Kind}, throw.State())
+	return true
+}
+
+// updateHazards damages players standing in the map's hazards, mu must be held.
+func (s *Server) updateHazards() {
+	for _, hit := range s.world.StepHazards() {
+		s.hit(PlayerHit{VictimID: hit.VictimID, Damage: hit.Damage, Weapon: hit.Weapon})
+	}
+}
+
+// updateGrenades moves grenades one tick and tells clients whom the explosions hit, decoys
+// become entities where they land. mu must be held.
+func (s *Server) updateGrenades() {
+	explosions, landed := s.world.StepGrenades()
+	for _, g := range landed {
+		end := g.Path.End()
+		s.place(&sim.Entity{Kind: g.Payload, OwnerID: g.OwnerID, X: end.X, Y: end.Y})
+	}
+	for _, e := range explosions {
+		for _, hit := range e.Hits {
+			if !hit.Reflected {
+				s.match.Hit(e.Grenade.OwnerID, weapon.Grenade, hit.Damage, false)
+			}
+			s.hit(PlayerHit{AttackerID: e.Grenade.OwnerID, VictimID: hit.VictimID, Damage: hit.Damage, Weapon: weapon.Grenade})
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"log"
+	"net"
+	"time"
+
+	"shooter/ability"
+)
+
+const (
+	// Messages waiting for a client, it is disconnected when more pile up
+	ClientQueueSize = 256
+	// A single write taking longer than this disconnects the client
+	WriteTimeout = 2 * time.Second
+	// How late observers get everything unless the config says otherwise, so players
+	// can't watch themselves from above while playing
+	ObserverDelay = 30 * time.Second
+)
+
+// client writes to its connection from its own goroutine, so a slow client can't hold up the others.
+type client struct {
+	conn net.Conn
+	out  chan []byte
+	// Closed when the writer is done
+	done chan struct{}
+	// Observers only watch, they don't take a player slot and their events are dropped
+	observer bool
+	// Messages are written this late, see ObserverDelay
+	delay time.Duration
+	// From the hello and the connection, observers can't be players at the same time
+	key, host string
+	// Joined as a bot, see backfill
+	bot bool
+	// From the hello, kicked players are kept out by it
+	name string
+	// From the hello's party, given at the handshake and kept. Pings only go to the same team.
+	team string
+	// From the hello, whose abilities the player uses
+	class ability.Class
+	// Players whose updates the client gets, see relayVisible
+	seen map[string]bool
+}
+
+// newClient starts writing to the connection, delay late.
+func newClient(conn net.Conn, delay time.Duration) *client {
+	c := &client{conn: conn, out: make(chan []byte, ClientQueueSize), done: make(chan struct{}), delay: delay, seen: map[string]bool{}}
+	go c.write()
+	return c
+}
+
+func (c *client) write() {
+	defer close(c.done)
+	if c.delay > 0 {
+		c.writeLate()
+		return
+	}
+	for msg := range c.out {
+		if !c.writeNow(msg) {
+			return
+		}
+	}
+}
+
+// writeLate holds messages back for the delay. The queue takes them as they come so it doesn't
+// fill up, what's held back when the client goes is dropped.
+func (c *client) writeLate() {
+	type held struct {
+		msg []byte
+		at  time.Time
+	}
+	var queue []held
+	timer := time.NewTimer(c.delay)
+	defer timer.Stop()
+	for {
+		select {
+		case msg, ok := <-c.out:
+			if !ok {
+				return
+			}
+			if len(queue) == 0 {
+				timer.Reset(c.delay)
+			}
+			queue = append(queue, held{msg: msg, at: time.Now()})
+		case <-timer.C:
+			for len(queue) > 0 && time.Since(queue[0].at) >= c.delay {
+				if !c.writeNow(queue[0].msg) {
+					return
+				}
+				queue = queue[1:]
+			}
+			if len(queue) > 0 {
+				timer.Reset(c.delay - time.Since(queue[0].at))
+			}
+		}
+	}
+}
+
+// writeNow writes the message, false when the client is gone.
+func (c *client) writeNow(msg []byte) bool {
+	c.conn.SetWriteDeadline(time.Now().Add(WriteTimeout))
+	if _, err := c.conn.Write(msg); err != nil {
+		log.Println("Error sending to client:", err)
+		// The reader notices and removes the client
+		c.conn.Close()
+		return false
+	}
+	return true
+}
+
+// send queues the message without waiting, a client whose queue is full is disconnected.
+func (c *client) send(msg []byte) {
+	select {
+	case c.out <- msg:
+	default:
+		log.Println("Client can't keep up, disconnecting:", c.conn.RemoteAddr())
+		c.conn.Close()
+	}
+}
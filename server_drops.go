@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"slices"
+
+	"shooter/match"
+	"shooter/matchlog"
+	"shooter/player"
+	"shooter/sim"
+	"shooter/weapon"
+)
+
+// Pickup asks the server for a dropped weapon. Once granted it is sent to everyone with the
+// weapon and its rounds filled in.
+type Pickup struct {
+	PlayerID string    `json:"player_id"`
+	EntityID uint64    `json:"entity_id"`
+	Weapon   weapon.ID `json:"weapon,omitempty"`
+	Ammo     int       `json:"ammo,omitempty"`
+}
+
+// dropOnDeath leaves a corpse where the player died along with their weapon for others to
+// grab, mu must be held. Modes which hand out the weapons drop none.
+func (s *Server) dropOnDeath(id string) {
+	p, ok := s.world.Players[id]
+	if !ok {
+		return
+	}
+	s.place(&sim.Entity{Kind: sim.Corpse, OwnerID: id, X: p.X, Y: p.Y})
+	if !slices.Contains(weapon.Loadout, p.Weapon) || s.match.Rules.Mode == match.GunGame || s.match.Rules.Rounds() {
+		return
+	}
+	s.place(&sim.Entity{Kind: sim.WeaponDrop, OwnerID: id, X: p.X, Y: p.Y, Weapon: p.Weapon, Ammo: weapon.Get(p.Weapon).MagazineSize})
+}
+
+// pickUp hands the dropped weapon to the player when they are alive, next to it and the mode
+// lets them hold it, mu must be held.
+func (s *Server) pickUp(playerID string, data json.RawMessage) {
+	var req Pickup
+	if err := json.Unmarshal(data, &req); err != nil {
+		log.Println("Error unmarshaling Pickup:", err)
+		return
+	}
+	// Crates of economy rounds go to the wallet
+	bank := s.match.Bank
+	if e, ok := s.world.Entities[req.EntityID]; !ok || !s.allowedWeapon(playerID, e.Weapon) && (e.Kind != sim.Crate || bank == nil) {
+		return
+	}
+	e, ok := s.world.PickUp(playerID, req.EntityID)
+	if !ok {
+		return
+	}
+	if e.Kind == sim.Crate && bank != nil {
+		bank.Grant(playerID, e.Weapon)
+		s.sendWallet(playerID, "")
+	}
+	s.logEvent(matchlog.Entry{Type: matchlog.Pickup, PlayerID: playerID, Weapon: e.Weapon, Positions: s.positions(playerID)})
+	s.broadcast(player.EventTypeEntityRemoved, EntityRemoved{ID: e.ID})
+	s.broadcast(player.EventTypePickup, Pickup{PlayerID: playerID, EntityID: e.ID, Weapon: e.Weapon, Ammo: e.Ammo})
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"slices"
+	"time"
+
+	"shooter/match"
+	"shooter/player"
+	"shooter/weapon"
+)
+
+// Buy asks to buy an item of the match.Shop during the buy phase of an economy round.
+type Buy struct {
+	PlayerID string    `json:"player_id"`
+	Item     weapon.ID `json:"item"`
+}
+
+// WalletUpdate is a player's wallet after it changed. Error says why their purchase failed.
+type WalletUpdate struct {
+	PlayerID string       `json:"player_id"`
+	Wallet   match.Wallet `json:"wallet"`
+	Error    string       `json:"error,omitempty"`
+}
+
+// buy pays for what the player asked for when they are alive in the buy phase, mu must be held.
+func (s *Server) buy(id string, data json.RawMessage) {
+	var req Buy
+	if err := json.Unmarshal(data, &req); err != nil {
+		log.Println("Error unmarshaling Buy:", err)
+		return
+	}
+	bank, series := s.match.Bank, s.match.Series
+	if bank == nil || series == nil {
+		return
+	}
+	if !series.Buying(time.Now()) || !slices.Contains(series.Alive(), id) {
+		s.sendWallet(id, "The buy phase is over")
+		return
+	}
+	if err := bank.Buy(id, req.Item); err != nil {
+		s.sendWallet(id, weapon.Get(req.Item).Name+": "+err.Error())
+		return
+	}
+	s.sendWallet(id, "")
+}
+
+// sendWallet tells everyone the player's wallet, mu must be held.
+func (s *Server) sendWallet(id, reason string) {
+	if s.match.Bank == nil {
+		return
+	}
+	s.broadcast(player.EventTypeWallet, WalletUpdate{PlayerID: id, Wallet: *s.match.Bank.Wallet(id), Error: reason})
+}
+
+// payKill rewards the killer of an enemy, the victim loses what they bought. mu must be held.
+func (s *Server) payKill(killerID, victimID string) {
+	bank := s.match.Bank
+	if bank == nil {
+		return
+	}
+	bank.Die(victimID)
+	s.sendWallet(victimID, "")
+	if killerID != "" && killerID != victimID && !s.world.Teammates(killerID, victimID) {
+		bank.Earn(killerID, match.KillReward)
+		s.sendWallet(killerID, "")
+	}
+}
+
+// payRound pays the players of the round which ended, the winner more. mu must be held.
+func (s *Server) payRound(winner string) {
+	bank := s.match.Bank
+	if bank == nil {
+		return
+	}
+	for _, id := range s.match.Series.Players {
+		if id == winner {
+			bank.Earn(id, match.RoundWinReward)
+		} else {
+			bank.Earn(id, match.RoundLossReward)
+		}
+		s.sendWallet(id, "")
+	}
+}
+
+// absorb is the damage to the victim after their armor, mu must be held.
+func (s *Server) absorb(victimID string, damage int) int {
+	bank := s.match.Bank
+	if bank == nil || bank.Wallet(victimID).Armor == 0 {
+		return damage
+	}
+	damage = bank.Absorb(victimID, damage)
+	s.sendWallet(victimID, "")
+	return damage
+}
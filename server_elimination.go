@@ -0,0 +1,122 @@
+package main
+
+import (
+	"slices"
+	"time"
+
+	"shooter/match"
+	"shooter/matchlog"
+	"shooter/player"
+)
+
+// Round is sent when an elimination round or duel starts or ends, and to players joining during one.
+type Round struct {
+	Number int            `json:"number"`
+	Alive  []string       `json:"alive"`
+	Wins   map[string]int `json:"wins"`
+	// Arena of the duel, empty to stay on the match's map
+	Map     string `json:"map,omitempty"`
+	MapHash string `json:"map_hash,omitempty"`
+	// Players waiting for a duel, in order
+	Queue []string `json:"queue,omitempty"`
+	// Set once the round is over, Winner is empty for a draw
+	Ended  bool   `json:"ended,omitempty"`
+	Winner string `json:"winner,omitempty"`
+}
+
+// round is where the elimination series is at, mu must be held.
+func (s *Server) round() Round {
+	series := s.match.Series
+	arena := s.match.Rules.Arena(series.Round)
+	r := Round{Number: series.Round, Alive: series.Alive(), Wins: series.Wins, Map: arena, MapHash: mapHash(arena)}
+	if s.match.Queue != nil {
+		r.Queue = s.match.Queue.Waiting()
+	}
+	return r
+}
+
+// updateRound starts the next elimination round or duel after the pause, or ends the one
+// being played when it ran out of time. mu must be held.
+func (s *Server) updateRound(now time.Time) {
+	series := s.match.Series
+	switch {
+	case series == nil:
+	case series.InRound && series.Expired(now):
+		s.endRound("", now)
+	case !series.InRound && now.After(s.nextRound) && series.Start(s.contenders(), now):
+		if arena := s.match.Rules.Arena(series.Round); arena != "" && arena != s.world.Level.Name {
+			s.world = s.newWorld(arena)
+			s.loadScript(now)
+		}
+		// Everyone starts the round at a spawn point and with full health, see allowedUpdate
+		clear(s.trails)
+		for _, id := range series.Players {
+			if p, ok := s.world.Players[id]; ok {
+				p.Health = s.fullHealth()
+			}
+			s.reseed(id)
+		}
+		s.broadcast(player.EventTypeRound, s.round())
+		for _, id := range series.Players {
+			s.sendWallet(id, "")
+		}
+	}
+}
+
+// contenders are the players of the next round, the first two of the queue in duels. mu must be held.
+func (s *Server) contenders() []string {
+	players := s.playerIDs()
+	if q := s.match.Queue; q != nil {
+		q.Sync(players)
+		players = q.Next()
+	}
+	return players
+}
+
+// eliminate takes the victim out of the elimination round, ending it when one player is left. mu must be held.
+func (s *Server) eliminate(killerID, victimID string) {
+	if s.match.Series == nil {
+		return
+	}
+	if winner, ended := s.match.Series.Kill(killerID, victimID); ended {
+		s.endRound(winner, time.Now())
+	}
+}
+
+// endRound announces the winner and sends the loser of a duel to the back of the queue, mu must be held.
+func (s *Server) endRound(winner string, now time.Time) {
+	s.nextRound = now.Add(match.RoundDelay)
+	if s.match.Queue != nil {
+		s.match.Queue.Result(s.match.Series.Players, winner)
+	}
+	r := s.round()
+	r.Ended, r.Winner = true, winner
+	if ace := s.match.Series.Ace(); ace != "" && ace == winner {
+		s.notifyAce(ace, r.Number)
+	}
+	s.logEvent(matchlog.Entry{Type: matchlog.Objective, PlayerID: winner, Data: r})
+	s.broadcast(player.EventTypeRound, r)
+	s.payRound(winner)
+}
+
+// joinRound tells a player joining during a round that they wait for the next, mu must be held.
+func (s *Server) joinRound(cl *client) {
+	if s.match.Series == nil || !s.match.Series.InRound {
+		return
+	}
+	if msg, err := encodeEvent(player.EventTypeRound, s.round()); err == nil {
+		cl.send(msg)
+	}
+}
+
+// playerIDs are the IDs of the connected players who aren't observers, sorted. mu must be held.
+func (s *Server) playerIDs() []string {
+	var ids []string
+	for c, cl := range s.clients {
+		if !cl.observer {
+			ids = append(ids, s.ids[c])
+		}
+	}
+	slices.Sort(ids)
+	return ids
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"math"
+	"slices"
+
+	"shooter/matchlog"
+	"shooter/player"
+	"shooter/sim"
+)
+
+// Deploy asks the server to place an entity, it is sent to everyone with the next entities update.
+type Deploy struct {
+	PlayerID string         `json:"player_id"`
+	Kind     sim.EntityKind `json:"kind"`
+	X        float64        `json:"x"`
+	Y        float64        `json:"y"`
+	Angle    float64        `json:"angle"`
+}
+
+// EntityRemoved tells clients an entity is gone, destroyed by gunfire or despawned.
+type EntityRemoved struct {
+	ID        uint64 `json:"id"`
+	Destroyed bool   `json:"destroyed,omitempty"`
+}
+
+// Noise is a gunshot heard out of sight, or a decoy's, about where it came from, see sendNoise.
+type Noise struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+// deploy places the entity when the player is alive, within reach and there is room, mu must be held.
+func (s *Server) deploy(ownerID string, data json.RawMessage) {
+	var d Deploy
+	if err := json.Unmarshal(data, &d); err != nil {
+		log.Println("Error unmarshaling Deploy:", err)
+		return
+	}
+	p, ok := s.world.Players[ownerID]
+	if !ok || p.Health <= 0 || math.Hypot(d.X-p.X, d.Y-p.Y) > sim.DeployReach || !s.world.CanPlace(d.Kind, d.X, d.Y, d.Angle) {
+		return
+	}
+	s.place(&sim.Entity{Kind: d.Kind, OwnerID: ownerID, X: d.X, Y: d.Y, Angle: d.Angle})
+}
+
+// place adds the entity and tells those who see it, and everyone of the owner's entities it
+// replaced. mu must be held.
+func (s *Server) place(e *sim.Entity) {
+	for _, id := range s.world.Place(e) {
+		s.broadcast(player.EventTypeEntityRemoved, EntityRemoved{ID: id})
+	}
+	s.sendEntity(e)
+}
+
+// sendEntity tells the clients which see it about a new entity, mu must be held.
+func (s *Server) sendEntity(e *sim.Entity) {
+	msg, err := encodeEvent(player.EventTypeEntityPlaced, e)
+	if err != nil {
+		log.Println("Error marshaling event:", err)
+		return
+	}
+	for c, cl := range s.clients {
+		if s.seesEntity(cl, s.ids[c], e) {
+			cl.send(msg)
+		}
+	}
+}
+
+// updateEntities runs the entities one tick and sends the bullets turrets fired to those who see
+// the turret, the noise decoys made and which entities despawned, mu must be held.
+func (s *Server) updateEntities() {
+	step := s.world.StepEntities()
+	for _, e := range step.Expired {
+		s.broadcast(player.EventTypeEntityRemoved, EntityRemoved{ID: e.ID})
+	}
+	for _, e := range step.Landed {
+		s.logEvent(matchlog.Entry{Type: matchlog.Objective, Weapon: e.Weapon, Positions: map[string][2]float64{"": {e.X, e.Y}}, Data: e.Kind})
+		s.sendEntity(e)
+	}
+	for _, e := range step.Noises {
+		// Heard like a real shot by those who don't see the decoy
+		var hearing []*client
+		for c, cl := range s.clients {
+			if !s.seesEntity(cl, s.ids[c], e) {
+				hearing = append(hearing, cl)
+			}
+		}
+		sendNoise(e.X, e.Y, hearing)
+	}
+	if len(step.Fired) == 0 {
+		return
+	}
+	for _, b := range step.Fired {
+		shot := Shoot{Bullets: []*player.Bullet{{
+			ID:        b.ID,
+			OwnerID:   b.OwnerID,
+			X:         b.X,
+			Y:         b.Y,
+			EndX:      b.X,
+			EndY:      b.Y,
+			Direction: b.Direction,
+			Velocity:  b.Velocity,
+			Damage:    b.Damage,
+			Weapon:    b.Weapon,
+			EntityID:  b.EntityID,
+		}}}
+		turret, ok := s.world.Entities[b.EntityID]
+		if !ok {
+			continue
+		}
+		s.sendShot(shot, turret.X, turret.Y, false, func(cl *client, viewerID string) bool {
+			return s.seesEntity(cl, viewerID, turret)
+		})
+	}
+}
+
+// flushEntities sends everyone the entities they see at the broadcast rate, once more after the
+// last one is gone. mu must be held.
+func (s *Server) flushEntities() {
+	if len(s.world.Entities) == 0 && !s.sentEntities {
+		return
+	}
+	entities := s.world.EntityList()
+	for c, cl := range s.clients {
+		seen := slices.DeleteFunc(slices.Clone(entities), func(e *sim.Entity) bool { return !s.seesEntity(cl, s.ids[c], e) })
+		msg, err := encodeEvent(player.EventTypeEntities, seen)
+		if err != nil {
+			log.Println("Error marshaling event:", err)
+			return
+		}
+		cl.send(msg)
+	}
+	s.sentEntities = len(s.world.Entities) > 0
+}
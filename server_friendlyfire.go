@@ -0,0 +1,21 @@
+package main
+
+import (
+	"log"
+	"time"
+)
+
+// teamKill counts the kill when the victim was the killer's teammate, kicking the killer once
+// they reach the configured limit. mu must be held.
+func (s *Server) teamKill(killerID, victimID string, now time.Time) {
+	if killerID == "" || killerID == victimID || !s.world.Teammates(killerID, victimID) {
+		return
+	}
+	s.teamKills[killerID]++
+	n := s.teamKills[killerID]
+	log.Println("Team kill by", killerID+":", n, "so far")
+	if limit := s.cfg.TeamKillKick; limit > 0 && n >= limit {
+		delete(s.teamKills, killerID)
+		s.kick(killerID, "Kicked for team killing", now)
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"shooter/match"
+	"shooter/player"
+	"shooter/weapon"
+)
+
+// GunGameProgress is sent to everyone when a gun game player moves on to the next weapon,
+// and to a player joining the match in progress.
+type GunGameProgress struct {
+	PlayerID string `json:"player_id"`
+	Kills    int    `json:"kills"`
+}
+
+// progress tells everyone the killer moved on in the gun game, mu must be held.
+func (s *Server) progress(killerID string) {
+	if s.match.Rules.Mode == match.GunGame && killerID != "" {
+		s.broadcast(player.EventTypeGunGame, GunGameProgress{PlayerID: killerID, Kills: s.match.Kills(killerID)})
+	}
+}
+
+// allowedWeapon is false for shots of any other weapon than the gun game one the player is at,
+// or the one weapon of elimination. mu must be held.
+func (s *Server) allowedWeapon(playerID string, id weapon.ID) bool {
+	switch s.match.Rules.Mode {
+	case match.GunGame:
+		w, ok := s.match.Rules.GunGameWeapon(s.match.Kills(playerID))
+		return ok && w == id
+	case match.Elimination:
+		return id == s.match.Rules.Weapons[0]
+	case match.Economy:
+		return s.match.Bank.Wallet(playerID).Owns(id)
+	}
+	return true
+}
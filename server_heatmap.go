@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"image/png"
+	"os"
+	"path/filepath"
+
+	"shooter/heatmap"
+	"shooter/level"
+)
+
+// Map pixels per pixel of exported heatmap images
+const heatmapScale = 2.0
+
+// recordHeat adds where the victim died and their killer stood to the heatmap of the map being
+// played, mu must be held. Suicides and deaths to the map only count as deaths.
+func (s *Server) recordHeat(killerID, victimID string) {
+	victim, ok := s.world.Players[victimID]
+	if !ok {
+		return
+	}
+	lvl := s.world.Level
+	h, ok := s.heatmaps[lvl.Name]
+	if !ok {
+		h = heatmap.New(lvl.Name, lvl.Width, lvl.Height)
+		s.heatmaps[lvl.Name] = h
+	}
+	h.Death(victim.X, victim.Y)
+	if killer, ok := s.world.Players[killerID]; ok && killerID != victimID {
+		h.Kill(killer.X, killer.Y)
+	}
+}
+
+// exportHeatmap writes the kills and deaths on the map since the server started to path, as
+// JSON for .json files and a PNG image otherwise. mu must be held.
+func (s *Server) exportHeatmap(mapName, path string) error {
+	lvl, ok := level.Get(mapName)
+	if !ok {
+		return fmt.Errorf("unknown map %q", mapName)
+	}
+	h, ok := s.heatmaps[mapName]
+	if !ok {
+		h = heatmap.New(mapName, lvl.Width, lvl.Height)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if filepath.Ext(path) == ".json" {
+		err = json.NewEncoder(f).Encode(h)
+	} else {
+		err = png.Encode(f, h.Image(lvl.Objects, heatmapScale))
+	}
+	if err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"shooter/match"
+	"shooter/player"
+	"shooter/wire"
+)
+
+// ServerInfo answers a Hello asking only for it, what a server listing shows before joining.
+type ServerInfo struct {
+	Map        string      `json:"map"`
+	Players    int         `json:"players"`
+	MaxPlayers int         `json:"max_players"`
+	Rules      match.Rules `json:"rules"`
+	// Average rating of the players of a ranked server, 0 for unranked ones
+	Rating int `json:"rating,omitempty"`
+}
+
+// errInfoOnly ends the handshake of a client which only asked for the ServerInfo.
+var errInfoOnly = errors.New("only asked for the server info")
+
+// Listing is the server's line in a server listing, with the rules it plays by.
+func (i ServerInfo) Listing() string {
+	players := fmt.Sprint(i.Players)
+	if i.MaxPlayers > 0 {
+		players += fmt.Sprintf("/%d", i.MaxPlayers)
+	}
+	line := fmt.Sprintf("%s on %s, %s players", i.Rules.Mode, i.Map, players)
+	if i.Rating > 0 {
+		line += fmt.Sprintf(", ranked %s %d", match.Rank(i.Rating), i.Rating)
+	}
+	if knobs := i.Rules.Knobs(); len(knobs) > 0 {
+		line += " - " + strings.Join(knobs, ", ")
+	}
+	return line
+}
+
+// info is the server's ServerInfo, mu must be held.
+func (s *Server) info() ServerInfo {
+	info := ServerInfo{
+		Map:        s.world.Level.Name,
+		Players:    s.playerCount(),
+		MaxPlayers: s.cfg.MaxPlayers,
+		Rules:      s.match.Rules,
+	}
+	if s.ratings != nil {
+		info.Rating = s.ratings.Average(s.ratedPlayers())
+	}
+	return info
+}
+
+// netState is the network part of crash bundles, it's called with mu not held.
+func (s *Server) netState() any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return NetState{Clients: len(s.clients), FakeNet: fakeNet}
+}
+
+// sendInfo answers a client asking for the ServerInfo, mu must be held.
+func (s *Server) sendInfo(c net.Conn) error {
+	msg, err := encodeEvent(player.EventTypeServerInfo, s.info())
+	if err != nil {
+		return err
+	}
+	c.SetWriteDeadline(time.Now().Add(WriteTimeout))
+	if _, err := c.Write(msg); err != nil {
+		return err
+	}
+	return errInfoOnly
+}
+
+// queryServer asks the server at the address for its ServerInfo without joining.
+func queryServer(address string) (ServerInfo, error) {
+	conn, err := net.DialTimeout("tcp", address, HandshakeTimeout)
+	if err != nil {
+		return ServerInfo{}, err
+	}
+	defer conn.Close()
+	message, err := encodeEvent(player.EventTypeHello, Hello{Version: player.ProtocolVersion, Info: true})
+	if err != nil {
+		return ServerInfo{}, err
+	}
+	if _, err := conn.Write(message); err != nil {
+		return ServerInfo{}, err
+	}
+	conn.SetReadDeadline(time.Now().Add(HandshakeTimeout))
+	msg, err := wire.NewReader(conn).ReadString()
+	if err != nil {
+		return ServerInfo{}, err
+	}
+	var event player.Event
+	if err := json.Unmarshal([]byte(msg), &event); err != nil {
+		return ServerInfo{}, err
+	}
+	if event.Type != player.EventTypeServerInfo {
+		return ServerInfo{}, fmt.Errorf("unexpected %q from server", event.Type)
+	}
+	var info ServerInfo
+	err = json.Unmarshal(event.Data, &info)
+	return info, err
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"log"
+	"net"
+	"strings"
+	"time"
+
+	"shooter/level"
+	"shooter/player"
+	"shooter/weapon"
+)
+
+// ChangeMap moves everyone to another map without ending the match, each player to a spawn
+// point of their own.
+type ChangeMap struct {
+	Map     string `json:"map"`
+	MapHash string `json:"map_hash"`
+	// Index of each player's spawn point, see level.Spawn
+	Spawns map[string]int `json:"spawns"`
+}
+
+// changeMap switches to the map in the middle of the match. Bullets, grenades and entities
+// are gone, scores are kept. mu must be held.
+func (s *Server) changeMap(mapName string) bool {
+	if _, ok := level.Get(mapName); !ok {
+		return false
+	}
+	s.match.Map = mapName
+	s.world = s.newWorld(mapName)
+	s.newZone()
+	s.loadScript(time.Now())
+	spawns := map[string]int{}
+	for i, id := range s.playerIDs() {
+		spawns[id] = i
+	}
+	s.broadcast(player.EventTypeChangeMap, ChangeMap{Map: mapName, MapHash: mapHash(mapName), Spawns: spawns})
+	return true
+}
+
+// MapRequest asks the server for a map the client doesn't have or has a different version of.
+type MapRequest struct {
+	PlayerID string `json:"player_id"`
+	Map      string `json:"map"`
+}
+
+// MapData is the server's map sent to a client which asked for it.
+type MapData struct {
+	Level *level.Level `json:"level"`
+}
+
+// mapHash is the level.Hash of the server's map with the name, empty for unknown maps.
+func mapHash(name string) string {
+	lvl, ok := level.Get(name)
+	if !ok {
+		return ""
+	}
+	return level.Hash(lvl)
+}
+
+// sendMap answers a client's MapRequest with the map, mu must be held.
+func (s *Server) sendMap(c net.Conn, data json.RawMessage) {
+	var req MapRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		log.Println("Error unmarshaling MapRequest:", err)
+		return
+	}
+	lvl, ok := level.Get(req.Map)
+	cl, connected := s.clients[c]
+	if !ok || !connected {
+		return
+	}
+	msg, err := encodeEvent(player.EventTypeMapData, MapData{Level: lvl})
+	if err != nil {
+		log.Println("Error marshaling event:", err)
+		return
+	}
+	cl.send(msg)
+}
+
+// console runs the dedicated server's commands read from r, one per line.
+func (s *Server) console(r io.Reader) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "changemap":
+			if len(fields) < 2 {
+				log.Println("Usage: changemap <map>, maps:", strings.Join(level.Names(), ", "))
+				continue
+			}
+			s.mu.Lock()
+			ok := s.changeMap(fields[1])
+			s.mu.Unlock()
+			if !ok {
+				log.Println("Unknown map:", fields[1])
+				continue
+			}
+			log.Println("Changed map to", fields[1])
+		case "reloadweapons":
+			s.mu.Lock()
+			err := s.reloadWeapons()
+			s.mu.Unlock()
+			if err != nil {
+				log.Println("Error reloading weapon data:", err)
+				continue
+			}
+			log.Println("Reloaded weapon data, hash", weapon.Hash())
+		case "heatmap":
+			if len(fields) < 2 {
+				log.Println("Usage: heatmap <map> [out.png|out.json]")
+				continue
+			}
+			path := fields[1] + "-heatmap.png"
+			if len(fields) > 2 {
+				path = fields[2]
+			}
+			s.mu.Lock()
+			err := s.exportHeatmap(fields[1], path)
+			s.mu.Unlock()
+			if err != nil {
+				log.Println("Error exporting heatmap:", err)
+				continue
+			}
+			log.Println("Exported heatmap to", path)
+		default:
+			log.Println("Unknown command:", fields[0])
+		}
+	}
+}
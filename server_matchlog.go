@@ -0,0 +1,60 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"shooter/config"
+	"shooter/match"
+	"shooter/matchlog"
+)
+
+// Directory in the data directory match logs are written to
+const MatchLogDir = "matches"
+
+// newMatchLog starts logging the match being played, mu must be held.
+func (s *Server) newMatchLog() {
+	s.matchLog = matchlog.New(s.match.Map, string(s.match.Rules.Mode), s.match.Started)
+}
+
+// logEvent adds the entry to the match's log as of now, mu must be held.
+func (s *Server) logEvent(e matchlog.Entry) {
+	s.matchLog.Add(time.Now(), e)
+}
+
+// positions are where the players are, for log entries. mu must be held.
+func (s *Server) positions(ids ...string) map[string][2]float64 {
+	at := map[string][2]float64{}
+	for _, id := range ids {
+		if p, ok := s.world.Players[id]; ok {
+			at[id] = [2]float64{p.X, p.Y}
+		}
+	}
+	return at
+}
+
+// endMatchLog logs the summary and writes the log to MatchLogDir on servers keeping match
+// logs, mu must be held.
+func (s *Server) endMatchLog(summary match.Summary) error {
+	s.logEvent(matchlog.Entry{Type: matchlog.MatchEnd, Data: summary})
+	if !s.cfg.MatchLog {
+		return nil
+	}
+	path, err := config.DataPath(filepath.Join(MatchLogDir, s.matchLog.FileName()))
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := s.matchLog.Write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"log"
+	"math"
+	"net"
+	"time"
+
+	"shooter/ability"
+	"shooter/game"
+	"shooter/player"
+)
+
+// CorrectionTolerance is how far a player may end up from where the server's collision has them
+// before they're put back there, for doors and entities the client saw a little later.
+const CorrectionTolerance = 8.0
+
+// MoveCorrection puts a client's player back where the server has them, after it moved
+// through a wall or spawned somewhere unsafe.
+type MoveCorrection struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+// correctMove replays the move of an update from the player's last position against the walls,
+// an update ending up too far from where the walls let the player get is moved there and its
+// client corrected. Reports whether it did. Respawns, riders and grapples aren't walking, they
+// aren't checked. mu must be held.
+func (s *Server) correctMove(c net.Conn, u *PlayerUpdate, now time.Time) bool {
+	p, ok := s.world.Players[u.ID]
+	if !ok || p.Health <= 0 || u.Health <= 0 || s.abilities.Active(u.ID, ability.Grapple, now) {
+		return false
+	}
+	if _, riding := s.world.Riding(u.ID); riding {
+		return false
+	}
+	x, y := game.Move(p.X, p.Y, u.X-p.X, u.Y-p.Y, player.PlayerRadius, s.world.Objects())
+	if math.Hypot(u.X-x, u.Y-y) <= CorrectionTolerance {
+		return false
+	}
+	u.X, u.Y = x, y
+	s.sendCorrection(c, x, y)
+	return true
+}
+
+// correctSpawn moves a player coming back to life or into the map to a safe spawn, when the one
+// its client picked isn't the level's, is in a wall, taken or watched by enemies, see
+// sim.World.SafeSpawn. Rounds
+// spawn everyone at once on spawns of their own and aren't checked. mu must be held.
+func (s *Server) correctSpawn(c net.Conn, u *PlayerUpdate) bool {
+	if u.Health <= 0 || s.match.Rules.Rounds() {
+		return false
+	}
+	if p, ok := s.world.Players[u.ID]; ok && p.Health > 0 {
+		return false
+	}
+	x, y, moved := s.world.SafeSpawn(u.ID, u.X, u.Y)
+	if !moved {
+		return false
+	}
+	u.X, u.Y = x, y
+	s.sendCorrection(c, x, y)
+	return true
+}
+
+// sendCorrection puts the client's player at x, y, mu must be held.
+func (s *Server) sendCorrection(c net.Conn, x, y float64) {
+	msg, err := encodeEvent(player.EventTypeMoveCorrection, MoveCorrection{X: x, Y: y})
+	if err != nil {
+		log.Println("Error marshaling MoveCorrection:", err)
+		return
+	}
+	if cl, ok := s.clients[c]; ok {
+		cl.send(msg)
+	}
+}
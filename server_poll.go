@@ -0,0 +1,146 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+
+	"shooter/level"
+	"shooter/match"
+	"shooter/player"
+)
+
+// Players kicked by a vote can't join again for this long
+const KickBanTime = 5 * time.Minute
+
+// CallVote asks everyone to vote on changing the map or the mode, or on kicking a player.
+type CallVote struct {
+	PlayerID string         `json:"player_id"`
+	Kind     match.PollKind `json:"kind"`
+	Target   string         `json:"target"`
+}
+
+// CastVote is a player's answer to the running vote.
+type CastVote struct {
+	PlayerID string `json:"player_id"`
+	Yes      bool   `json:"yes"`
+}
+
+// PollStatus is sent when a vote is called, every second while it runs and once it's decided.
+type PollStatus struct {
+	Kind      match.PollKind `json:"kind"`
+	Target    string         `json:"target"`
+	Caller    string         `json:"caller"`
+	Yes       int            `json:"yes"`
+	No        int            `json:"no"`
+	Voters    int            `json:"voters"`
+	Remaining time.Duration  `json:"remaining"`
+	// Set once decided
+	Done   bool `json:"done,omitempty"`
+	Passed bool `json:"passed,omitempty"`
+}
+
+// callVote starts the player's vote when none is running, the player hasn't called one
+// recently and what it is about exists. mu must be held.
+func (s *Server) callVote(data json.RawMessage, now time.Time) {
+	var call CallVote
+	if err := json.Unmarshal(data, &call); err != nil {
+		log.Println("Error unmarshaling CallVote:", err)
+		return
+	}
+	if s.poll != nil || s.summary != nil || now.Sub(s.lastCalled[call.PlayerID]) < match.PollCooldown {
+		return
+	}
+	if call.Kind == match.PollKick && call.Target == call.PlayerID ||
+		!match.ValidPoll(call.Kind, call.Target, level.Maps(), s.playerIDs()) {
+		return
+	}
+	s.poll = match.NewPoll(call.Kind, call.Target, call.PlayerID, now)
+	s.lastCalled[call.PlayerID] = now
+	s.updatePoll(now)
+}
+
+// castVote records a player's vote on the running vote, mu must be held.
+func (s *Server) castVote(data json.RawMessage, now time.Time) {
+	var vote CastVote
+	if err := json.Unmarshal(data, &vote); err != nil {
+		log.Println("Error unmarshaling CastVote:", err)
+		return
+	}
+	if s.poll == nil {
+		return
+	}
+	s.poll.Cast(vote.PlayerID, vote.Yes)
+	s.updatePoll(now)
+}
+
+// updatePoll tells everyone where the running vote is at and carries it out once passed,
+// mu must be held.
+func (s *Server) updatePoll(now time.Time) {
+	if s.poll == nil {
+		return
+	}
+	voters := s.voters()
+	yes, no, total := s.poll.Tally(voters)
+	passed, done := s.poll.Result(voters, now)
+	s.broadcast(player.EventTypePoll, PollStatus{
+		Kind:      s.poll.Kind,
+		Target:    s.poll.Target,
+		Caller:    s.poll.Caller,
+		Yes:       yes,
+		No:        no,
+		Voters:    total,
+		Remaining: max(s.poll.Ends.Sub(now), 0),
+		Done:      done,
+		Passed:    passed,
+	})
+	if !done {
+		return
+	}
+	poll := s.poll
+	s.poll = nil
+	if !passed {
+		return
+	}
+	log.Printf("Vote passed: %s %s", poll.Kind, poll.Target)
+	switch poll.Kind {
+	case match.PollMap:
+		s.startMatch(poll.Target, s.match.Rules.Mode, now)
+	case match.PollMode:
+		s.cfg.Mode = poll.Target
+		s.startMatch(s.match.Map, match.Mode(poll.Target), now)
+	case match.PollKick:
+		s.kick(poll.Target, "Kicked by vote", now)
+	}
+}
+
+// voters are the players who can vote, bots and observers don't. mu must be held.
+func (s *Server) voters() []string {
+	var ids []string
+	for c, cl := range s.clients {
+		if !cl.observer && !cl.bot {
+			ids = append(ids, s.ids[c])
+		}
+	}
+	return ids
+}
+
+// kick disconnects the player with the reason, keeping them out for KickBanTime. mu must be held.
+func (s *Server) kick(id, reason string, now time.Time) {
+	for c, cl := range s.clients {
+		if s.ids[c] != id {
+			continue
+		}
+		s.kicked[cl.name] = now.Add(KickBanTime)
+		if msg, err := encodeEvent(player.EventTypeServerShutdown, ServerShutdown{Reason: reason}); err == nil {
+			cl.send(msg)
+		}
+		// The reader cleans up after the player once the writer sent the reason and the connection is closed
+		close(cl.out)
+		delete(s.clients, c)
+		go func() {
+			<-cl.done
+			c.Close()
+		}()
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"log"
+	"net"
+	"net/http"
+	"net/http/pprof"
+)
+
+// servePprof serves the runtime profiles of the server. With an api_token they need it as a bearer
+// token like the HTTP API, fetched with curl -H "Authorization: Bearer <token>". Without one only
+// loopback addresses are served, like "go tool pprof http://localhost:6060/debug/pprof/profile".
+func servePprof(address, token string) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+	var handler http.Handler = mux
+	switch {
+	case token != "":
+		handler = authorized(token, mux)
+	case !loopback(address):
+		log.Println("Not serving pprof on", address, "without an api_token, use a localhost address")
+		return
+	}
+	log.Println("pprof running on", address)
+	if err := http.ListenAndServe(address, handler); err != nil {
+		log.Println("pprof stopped:", err)
+	}
+}
+
+// loopback is true for addresses only reachable from this machine, those without a host listen
+// on every interface.
+func loopback(address string) bool {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
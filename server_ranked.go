@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"shooter/config"
+	"shooter/match"
+)
+
+// RankedSpread is how far from the average rating of a ranked server's players a player's may
+// be to join, so they play others of their level.
+const RankedSpread = 300
+
+// loadRatings reads the ratings of a ranked server, starting everyone over when they can't be read.
+func loadRatings() match.Ratings {
+	path, err := config.DataPath(match.RatingsFile)
+	if err != nil {
+		log.Println("Error finding ratings:", err)
+		return match.Ratings{}
+	}
+	ratings, err := match.LoadRatings(path)
+	if err != nil {
+		log.Println("Error loading ratings, starting over:", err)
+		return match.Ratings{}
+	}
+	return ratings
+}
+
+// loadIdentities reads whose key each name of a ranked server belongs to. They can't be started
+// over like ratings, anyone could take over a name then, so a broken file is a fatal error.
+func loadIdentities() match.Identities {
+	path, err := config.DataPath(match.IdentitiesFile)
+	if err != nil {
+		log.Fatal("Error finding identities:", err)
+	}
+	identities, err := match.LoadIdentities(path)
+	if err != nil {
+		log.Fatal("Error loading identities:", err)
+	}
+	return identities
+}
+
+// impostor returns why the hello can't play under its name on a ranked server, empty when it can.
+// Ratings are kept by name, so a name belongs to the key of the client which first played under it
+// and is played by one client at a time. mu must be held.
+func (s *Server) impostor(hello Hello) string {
+	if s.identities == nil {
+		return ""
+	}
+	if hello.Key == "" {
+		return "Ranked: your game sent no player key, update it to play ranked"
+	}
+	if !s.identities.Owns(hello.Name, hello.Key) {
+		return fmt.Sprintf("Ranked: the name %s belongs to another player, pick another one", hello.Name)
+	}
+	for _, id := range s.ratedPlayers() {
+		if id == hello.Name {
+			return fmt.Sprintf("Ranked: %s is already playing", hello.Name)
+		}
+	}
+	return ""
+}
+
+// claim ties the hello's name to its key when it's the first to play under it on a ranked server,
+// and saves who owns what. mu must be held.
+func (s *Server) claim(hello Hello) {
+	if s.identities == nil || !s.identities.Claim(hello.Name, hello.Key) {
+		return
+	}
+	path, err := config.DataPath(match.IdentitiesFile)
+	if err != nil {
+		log.Println("Error finding identities:", err)
+		return
+	}
+	if err := s.identities.Save(path); err != nil {
+		log.Println("Error saving identities:", err)
+	}
+}
+
+// rate updates the ratings of the people in the summary by their standings and saves them,
+// bots aren't rated. mu must be held.
+func (s *Server) rate(summary *match.Summary) {
+	if s.ratings == nil || s.match.Rules.Mode == match.Practice {
+		return
+	}
+	var standings []string
+	for _, p := range summary.Players {
+		if !p.Bot {
+			standings = append(standings, p.ID)
+		}
+	}
+	changes := s.ratings.Update(standings)
+	for i, p := range summary.Players {
+		if change, ok := changes[p.ID]; ok {
+			summary.Players[i].Rating, summary.Players[i].RatingChange = s.ratings.Get(p.ID), change
+		}
+	}
+	path, err := config.DataPath(match.RatingsFile)
+	if err != nil {
+		log.Println("Error finding ratings:", err)
+		return
+	}
+	if err := s.ratings.Save(path); err != nil {
+		log.Println("Error saving ratings:", err)
+	}
+}
+
+// ratingGap returns why the player's rating keeps them from a ranked server, empty when it
+// doesn't. mu must be held.
+func (s *Server) ratingGap(name string) string {
+	players := s.ratedPlayers()
+	if s.ratings == nil || len(players) == 0 {
+		return ""
+	}
+	rating, average := s.ratings.Get(name), s.ratings.Average(players)
+	if rating < average-RankedSpread || rating > average+RankedSpread {
+		return fmt.Sprintf("Ranked: your rating %d is too far from this server's %d, find players of your level",
+			rating, average)
+	}
+	return ""
+}
+
+// ratedPlayers are the people playing, not watching, mu must be held.
+func (s *Server) ratedPlayers() []string {
+	var ids []string
+	for c, cl := range s.clients {
+		if !cl.observer && !cl.bot {
+			ids = append(ids, s.ids[c])
+		}
+	}
+	return ids
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"log"
+
+	"shooter/config"
+	"shooter/level"
+	"shooter/match"
+)
+
+// validRotation drops entries of maps the server doesn't have.
+func validRotation(rotation []config.RotationEntry) []config.RotationEntry {
+	var valid []config.RotationEntry
+	for _, e := range rotation {
+		if _, ok := level.Get(e.Map); !ok {
+			log.Println("Unknown map in rotation, skipping:", e.Map)
+			continue
+		}
+		valid = append(valid, e)
+	}
+	return valid
+}
+
+// rotationEntry is the entry of the rotation being played, false without a rotation.
+func (s *Server) rotationEntry() (config.RotationEntry, bool) {
+	if len(s.cfg.Rotation) == 0 {
+		return config.RotationEntry{}, false
+	}
+	return s.cfg.Rotation[s.rotation], true
+}
+
+// mode is what the next match is played as, the rotation entry's mode or the configured one.
+func (s *Server) mode() match.Mode {
+	if e, ok := s.rotationEntry(); ok && e.Mode != "" {
+		return match.Mode(e.Mode)
+	}
+	return match.Mode(s.cfg.Mode)
+}
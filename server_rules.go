@@ -0,0 +1,93 @@
+package main
+
+import (
+	"math"
+	"net"
+	"time"
+
+	"shooter/ability"
+	"shooter/grapple"
+	"shooter/player"
+	"shooter/vehicle"
+)
+
+const (
+	// Updates may move a player this much faster than they can run, for updates bunching up
+	MoveTolerance = 1.5
+	// And this much farther, in pixels
+	MoveSlack = 30.0
+)
+
+// allowedUpdate is false for an update breaking the rules: a player back from the dead before
+// the respawn time or with more than the rules' health. Moving faster than they can is corrected
+// instead, see correctReach. mu must be held.
+func (s *Server) allowedUpdate(u PlayerUpdate, now time.Time) bool {
+	rules := s.match.Rules
+	if rules.Health > 0 && u.Health > rules.Health || rules.Health == 0 && u.Health > player.MaxHealth {
+		return false
+	}
+	p, ok := s.world.Players[u.ID]
+	if !ok || p.Health > 0 {
+		return true
+	}
+	// Rounds bring everyone back at once. The client counts from before the server
+	// heard of the death, a late update doesn't make up for that.
+	died, dead := s.died[u.ID]
+	return u.Health <= 0 || rules.Rounds() || !dead || now.Sub(died) >= rules.Respawn()-RewindTime
+}
+
+// fullHealth is what players start and respawn with by the match's rules.
+func (s *Server) fullHealth() int {
+	if s.match.Rules.Health > 0 {
+		return s.match.Rules.Health
+	}
+	return player.MaxHealth
+}
+
+// health is the player's health as the server has it, whatever their client says. Players the
+// world doesn't have yet start with full health, dead ones get it back when they respawn as
+// allowedUpdate lets them. Rounds bring everyone back at once, see updateRound. mu must be held.
+func (s *Server) health(u PlayerUpdate) int {
+	p, ok := s.world.Players[u.ID]
+	switch {
+	case !ok:
+		return s.fullHealth()
+	case p.Health <= 0 && u.Health > 0 && !s.match.Rules.Rounds():
+		return s.fullHealth()
+	default:
+		return p.Health
+	}
+}
+
+// correctReach puts a player who got farther since their last update than they can move, speed
+// bursts and grapples included, back where that was and corrects their client. Clients update at
+// least every KeepaliveInterval, waiting longer doesn't let them get any farther. Players the world
+// doesn't have yet, like after a map change, may be anywhere. Reports whether it moved them.
+// mu must be held.
+func (s *Server) correctReach(c net.Conn, u *PlayerUpdate, now time.Time) bool {
+	p, ok := s.world.Players[u.ID]
+	trail := s.trails[u.ID]
+	if !ok || p.Health <= 0 || len(trail) == 0 {
+		return false
+	}
+	last := trail[len(trail)-1]
+	rules := s.match.Rules
+	reach := player.PlayerSpeed * player.PlayerSprintSpeedFactor * player.BaseTPS * rules.Speed() * MoveTolerance
+	if s.abilities.Active(u.ID, ability.SpeedBurst, last.at) || s.abilities.Active(u.ID, ability.SpeedBurst, now) {
+		reach *= ability.SpeedBurstFactor
+	}
+	if s.abilities.Active(u.ID, ability.Grapple, last.at) || s.abilities.Active(u.ID, ability.Grapple, now) {
+		reach = math.Max(reach, grapple.ReelSpeed*grapple.TPS*MoveTolerance)
+	}
+	// Riders go along with the vehicle, the server moves them with it anyway
+	if _, riding := s.world.Riding(u.ID); riding {
+		reach = math.Max(reach, vehicle.MaxSpeed*vehicle.TPS*MoveTolerance)
+	}
+	elapsed := min(now.Sub(last.at), KeepaliveInterval)
+	if math.Hypot(u.X-last.x, u.Y-last.y) <= reach*elapsed.Seconds()+MoveSlack {
+		return false
+	}
+	u.X, u.Y = last.x, last.y
+	s.sendCorrection(c, u.X, u.Y)
+	return true
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"time"
+
+	"shooter/level"
+	"shooter/matchlog"
+	"shooter/player"
+	"shooter/script"
+	"shooter/sim"
+)
+
+// scriptActions are what scripted events do on the server, false when they didn't happen and
+// nobody is told. mu must be held.
+var scriptActions = map[script.Kind]func(s *Server, e script.Event) bool{
+	script.OpenDoor: func(s *Server, e script.Event) bool {
+		id, closed := s.doors[e.Door]
+		if !closed {
+			return false
+		}
+		delete(s.world.Entities, id)
+		delete(s.doors, e.Door)
+		s.broadcast(player.EventTypeEntityRemoved, EntityRemoved{ID: id})
+		return true
+	},
+	script.CloseDoor: func(s *Server, e script.Event) bool {
+		if _, closed := s.doors[e.Door]; closed {
+			return false
+		}
+		for _, d := range script.Get(s.world.Level.Name).Doors {
+			if d.Name == e.Door {
+				door := &sim.Entity{Kind: sim.Door, X: d.X, Y: d.Y, Angle: d.Angle}
+				s.place(door)
+				s.doors[d.Name] = door.ID
+				return true
+			}
+		}
+		return false
+	},
+	script.Lighting: func(s *Server, e script.Event) bool {
+		if _, ok := level.Lightings[e.Lighting]; !ok {
+			return false
+		}
+		s.lighting = e.Lighting
+		return true
+	},
+	script.SupplyDrop: func(s *Server, e script.Event) bool {
+		dropped := false
+		for range e.Drops {
+			dropped = s.airdrop(e.Weapon) || dropped
+		}
+		return dropped
+	},
+}
+
+// loadScript starts the script of the world's map from the beginning with its doors closed,
+// after every new world. mu must be held.
+func (s *Server) loadScript(now time.Time) {
+	sc := script.Get(s.world.Level.Name)
+	s.script, s.scriptStart, s.lighting = script.NewSchedule(sc), now, ""
+	s.doors = map[string]uint64{}
+	for _, d := range sc.Doors {
+		door := &sim.Entity{Kind: sim.Door, X: d.X, Y: d.Y, Angle: d.Angle}
+		s.world.Place(door)
+		s.doors[d.Name] = door.ID
+	}
+}
+
+// updateScript runs the map's events which came due and tells everyone, it stops between
+// matches. The lighting is sent over and over for those who joined since. mu must be held.
+func (s *Server) updateScript(now time.Time) {
+	if s.summary != nil {
+		return
+	}
+	for _, e := range s.script.Due(now.Sub(s.scriptStart)) {
+		if action, ok := scriptActions[e.Kind]; ok && action(s, e) {
+			s.logEvent(matchlog.Entry{Type: matchlog.Objective, Data: e})
+			s.broadcast(player.EventTypeMapEvent, e)
+		}
+	}
+	if s.lighting != "" {
+		s.broadcast(player.EventTypeMapEvent, script.Event{Kind: script.Lighting, Lighting: s.lighting})
+	}
+}
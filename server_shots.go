@@ -0,0 +1,135 @@
+package main
+
+import (
+	"log"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"time"
+
+	"shooter/player"
+	"shooter/weapon"
+)
+
+const (
+	// How far back the shooter's positions are checked, shots can arrive before the update
+	// of where they were fired from
+	RewindTime = 250 * time.Millisecond
+	// Farthest the muzzle can be from the shooter, the weapon's reach plus some movement
+	MuzzleReach = 60.0
+	// Rejected shots of a player are logged again after this many more
+	RejectedShotsLogEvery = 10
+	// Radians a bullet may be off its spread, for the rounding of the client's math
+	SpreadTolerance = 1e-6
+	// Radians a shot's aim may be off where the shooter was aiming within RewindTime, they turn
+	// between updates
+	AimTolerance = 0.5
+	// How far ahead of the weapon's cooldown shots may arrive, they bunch up on the way
+	CooldownSlack = 100 * time.Millisecond
+)
+
+// trailPoint is where a player was and aimed at some point.
+type trailPoint struct {
+	x, y  float64
+	angle float64
+	at    time.Time
+}
+
+// recordTrail adds the player's position and aim, dropping those older than RewindTime. mu must be held.
+func (s *Server) recordTrail(id string, x, y, angle float64, now time.Time) {
+	trail := s.trails[id]
+	for len(trail) > 0 && now.Sub(trail[0].at) > RewindTime {
+		trail = trail[1:]
+	}
+	s.trails[id] = append(trail, trailPoint{x: x, y: y, angle: angle, at: now})
+}
+
+// reseed gives the player a new spread seed for their next life and tells their client, their
+// shots are counted from 0 again. mu must be held.
+func (s *Server) reseed(id string) {
+	s.seeds[id] = rand.Uint64()
+	delete(s.shots, id)
+	msg, err := encodeEvent(player.EventTypeSeed, Seed{Seed: s.seeds[id]})
+	if err != nil {
+		log.Println("Error marshaling event:", err)
+		return
+	}
+	for c, playerID := range s.ids {
+		if cl, ok := s.clients[c]; ok && playerID == id {
+			cl.send(msg)
+		}
+	}
+}
+
+// validShot is false for a bullet the shooter couldn't have fired: its muzzle out of their reach
+// or behind a wall from everywhere they were within RewindTime, it aimed away from where they
+// aimed then, it going faster than the weapon fires, or off its aim by more than recoil and the
+// spread allow. The spread is that of the shot-th bullet of the shooter's life, counted by the
+// server. Those are logged as suspicious. Bullets which cross a wall on their first tick hit it
+// before the server saw them, they are dropped quietly. mu must be held.
+func (s *Server) validShot(ownerID string, b *player.Bullet, shot int, now time.Time) bool {
+	reachable, aimed := false, false
+	for _, p := range s.trails[ownerID] {
+		if now.Sub(p.at) > RewindTime {
+			continue
+		}
+		aimed = aimed || math.Abs(math.Remainder(b.Aim-p.angle, 2*math.Pi)) <= AimTolerance
+		if !reachable && math.Hypot(b.X-p.x, b.Y-p.y) <= MuzzleReach && s.world.LineOfSight(p.x, p.y, b.X, b.Y) {
+			reachable = true
+		}
+	}
+	if !reachable {
+		s.reject(ownerID, "fired from behind a wall or out of reach")
+		return false
+	}
+	if !aimed {
+		s.reject(ownerID, "aimed away from where the player was aiming")
+		return false
+	}
+	if b.Velocity > weapon.Get(b.Weapon).BulletSpeed || math.Hypot(b.EndX-b.X, b.EndY-b.Y) > b.Velocity+1 {
+		s.reject(ownerID, "bullet skipped ahead")
+		return false
+	}
+	// The spread follows from the seed the server gave, bullets without it or steered
+	// elsewhere don't match
+	lo, hi := weapon.Get(b.Weapon).Deflection(s.seeds[ownerID], shot, player.ADSSpreadFactor)
+	if off := math.Remainder(b.Direction-b.Aim, 2*math.Pi); off < lo-SpreadTolerance || off > hi+SpreadTolerance {
+		s.reject(ownerID, "bullet off its spread")
+		return false
+	}
+	return s.world.LineOfSight(b.X, b.Y, b.EndX, b.EndY)
+}
+
+// validTrigger is false for a shot with more bullets than its weapon fires at once, bullets of
+// different weapons, or one coming faster than the weapon's cooldown allows. Shots are paced by
+// when they were due rather than when they came, so those arriving late don't let later ones
+// come early. mu must be held.
+func (s *Server) validTrigger(ownerID string, bullets []*player.Bullet, now time.Time) bool {
+	if len(bullets) == 0 {
+		return false
+	}
+	w := weapon.Get(bullets[0].Weapon)
+	if len(bullets) > w.Pellets || slices.ContainsFunc(bullets, func(b *player.Bullet) bool { return b.Weapon != w.ID }) {
+		s.reject(ownerID, "more bullets than the weapon fires")
+		return false
+	}
+	due := s.nextShot[ownerID]
+	if due.Before(now) {
+		due = now
+	}
+	if due.Sub(now) > CooldownSlack {
+		s.reject(ownerID, "fired faster than the weapon")
+		return false
+	}
+	s.nextShot[ownerID] = due.Add(w.Cooldown)
+	return true
+}
+
+// reject counts a suspicious shot of the player, logging the first and every RejectedShotsLogEvery
+// after it. mu must be held.
+func (s *Server) reject(id, reason string) {
+	s.rejected[id]++
+	if n := s.rejected[id]; n%RejectedShotsLogEvery == 1 {
+		log.Println("Rejected shot of", id+":", reason+",", n, "so far")
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"math/rand/v2"
+	"time"
+
+	"shooter/match"
+	"shooter/sim"
+	"shooter/weapon"
+)
+
+const (
+	// Tries to find room for an airdrop
+	dropTries = 20
+	// Magazines in a crate's weapon
+	crateMagazines = 3
+)
+
+// updateSupply drops a crate of the mode's loot every so often into the match on servers with
+// supply drops, mu must be held.
+func (s *Server) updateSupply(now time.Time) {
+	supply, ok := match.Supplies[s.match.Rules.Mode]
+	if !s.cfg.SupplyDrops || !ok || s.summary != nil {
+		return
+	}
+	elapsed := now.Sub(s.match.Started)
+	if elapsed < time.Duration(s.supplies+1)*supply.Every {
+		return
+	}
+	// A resumed match doesn't make up for the crates it missed
+	s.supplies = int(elapsed / supply.Every)
+	s.airdrop(supply.Pick(rand.Float64()))
+}
+
+// airdrop marks a random open spot of the map where a crate with the item lands a little later,
+// false when there was no room. mu must be held.
+func (s *Server) airdrop(item weapon.ID) bool {
+	ammo := 0
+	if item != weapon.Armor {
+		ammo = crateMagazines * weapon.Get(item).MagazineSize
+	}
+	for range dropTries {
+		x, y := rand.Float64()*s.world.Level.Width, rand.Float64()*s.world.Level.Height
+		// Pickups players can't walk to are no use
+		if s.world.CanPlace(sim.Crate, x, y, 0) && s.reach.Contains(x, y) {
+			s.place(&sim.Entity{Kind: sim.Airdrop, X: x, Y: y, Weapon: item, Ammo: ammo})
+			return true
+		}
+	}
+	return false
+}
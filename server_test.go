@@ -0,0 +1,698 @@
+package main
+
+import (
+	"encoding/json"
+	"math"
+	"net"
+	"testing"
+	"time"
+
+	"shooter/config"
+	"shooter/level"
+	"shooter/match"
+	"shooter/player"
+	"shooter/sim"
+	"shooter/weapon"
+	"shooter/wire"
+)
+
+// testServer is a server on the default map with its defaults.
+func testServer(t *testing.T) *Server {
+	t.Helper()
+	return NewServer("", config.Network{})
+}
+
+// testClient adds a client with the player ID to the server, as if it did the handshake.
+// It returns the server's end of the connection and a reader of what the server sends it.
+func testClient(t *testing.T, s *Server, id string) (net.Conn, *testReader) {
+	t.Helper()
+	conn, peer := net.Pipe()
+	t.Cleanup(func() {
+		conn.Close()
+		peer.Close()
+	})
+	s.mu.Lock()
+	s.clients[conn] = newClient(conn, 0)
+	s.ids[conn] = id
+	s.mu.Unlock()
+	return conn, &testReader{conn: peer, reader: wire.NewReader(peer)}
+}
+
+type testReader struct {
+	conn   net.Conn
+	reader *wire.Reader
+}
+
+// events returns the events sent to the client until nothing more comes for a while.
+func (r *testReader) events(t *testing.T) []player.Event {
+	t.Helper()
+	var events []player.Event
+	for {
+		r.conn.SetReadDeadline(time.Now().Add(50 * time.Millisecond))
+		msg, err := r.reader.Read()
+		if err != nil {
+			return events
+		}
+		var event player.Event
+		if err := json.Unmarshal(msg, &event); err != nil {
+			t.Fatalf("client got %q: %v", msg, err)
+		}
+		events = append(events, event)
+	}
+}
+
+// types are the types of the events sent to the client, see events.
+func (r *testReader) types(t *testing.T) []player.EventType {
+	t.Helper()
+	var types []player.EventType
+	for _, e := range r.events(t) {
+		types = append(types, e.Type)
+	}
+	return types
+}
+
+// message encodes an event as a client sends it, without the frame.
+func message(t *testing.T, eventType player.EventType, data any) string {
+	t.Helper()
+	frame, err := encodeEvent(eventType, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(frame[wire.HeaderSize:])
+}
+
+func TestServerOnlyEventsAreNotRelayed(t *testing.T) {
+	s := testServer(t)
+	alice, _ := testClient(t, s, "alice")
+	_, bob := testClient(t, s, "bob")
+
+	for _, eventType := range []player.EventType{
+		player.EventTypeServerShutdown, player.EventTypeMatchEnd, player.EventTypeMatchStart,
+		player.EventTypeBulletSpawn, player.EventTypeBulletDestroy, player.EventTypeWelcome,
+		player.EventTypeReject, player.EventTypePlayerHit, player.EventTypePlayerKilled, "made_up",
+	} {
+		s.handleMessage(alice, "alice", message(t, eventType, struct{}{}))
+	}
+	if got := bob.types(t); len(got) > 0 {
+		t.Errorf("forged server events reached the other client: %v", got)
+	}
+
+	s.handleMessage(alice, "alice", message(t, player.EventTypeMapVote, MapVote{PlayerID: "alice", Map: "warehouse"}))
+	if got := bob.types(t); len(got) != 1 || got[0] != player.EventTypeMapVote {
+		t.Errorf("other client got %v, want the map vote", got)
+	}
+}
+
+func TestClientCannotChangeTeam(t *testing.T) {
+	s := testServer(t)
+	alice, _ := testClient(t, s, "alice")
+	s.clients[alice].team = "red"
+
+	spawn := s.world.Level.Spawns[0]
+	event := s.track(alice, message(t, player.EventTypePlayerUpdate, PlayerUpdate{ID: "alice", X: spawn[0], Y: spawn[1], Health: player.MaxHealth, Team: "blue"}))
+	var update PlayerUpdate
+	if err := json.Unmarshal(event.Data, &update); err != nil {
+		t.Fatal(err)
+	}
+	if update.Team != "red" {
+		t.Errorf("relayed update has team %q, want red", update.Team)
+	}
+	if got := s.world.Players["alice"].Team; got != "red" {
+		t.Errorf("server has alice on %q, want red", got)
+	}
+	if got := s.clients[alice].team; got != "red" {
+		t.Errorf("client moved to %q, want red", got)
+	}
+}
+
+func TestValidShotFollowsTheSeed(t *testing.T) {
+	s := testServer(t)
+	spawn := s.world.Level.Spawns[0]
+	now := time.Now()
+	s.seeds["alice"] = 7
+	s.recordTrail("alice", spawn[0], spawn[1], 0, now)
+
+	w := weapon.Get(weapon.Pistol)
+	bullet := func(aim, direction float64) *player.Bullet {
+		return &player.Bullet{
+			X: spawn[0], Y: spawn[1], EndX: spawn[0] + math.Cos(direction), EndY: spawn[1] + math.Sin(direction),
+			Direction: direction, Aim: aim, Velocity: 1, Weapon: w.ID,
+		}
+	}
+	straight := 0
+	for shot := range 20 {
+		offset := weapon.SpreadOffset(7, shot, w.Spread)
+		if !s.validShot("alice", bullet(0, offset), shot, now) {
+			t.Errorf("shot %d along its spread was rejected", shot)
+		}
+		if lo, hi := w.Deflection(7, shot, player.ADSSpreadFactor); lo > 0 || hi < 0 {
+			straight++
+			if s.validShot("alice", bullet(0, 0), shot, now) {
+				t.Errorf("shot %d without spread was accepted", shot)
+			}
+		}
+	}
+	if straight == 0 {
+		t.Fatal("no shot to check without spread")
+	}
+	if s.validShot("alice", bullet(0, weapon.SpreadOffset(8, 0, w.Spread)+1), 0, now) {
+		t.Error("shot steered away from its aim was accepted")
+	}
+	// Moving the aim along with the bullet doesn't help, the server knows where the player aimed
+	if s.validShot("alice", bullet(2, 2+weapon.SpreadOffset(7, 0, w.Spread)), 0, now) {
+		t.Error("shot aimed away from where the player aimed was accepted")
+	}
+}
+
+func TestServerCountsShotsPerLife(t *testing.T) {
+	s := testServer(t)
+	_, reader := testClient(t, s, "alice")
+	spawn := s.world.Level.Spawns[0]
+	s.seeds["alice"] = 7
+	s.recordTrail("alice", spawn[0], spawn[1], 0, time.Now())
+	w := weapon.Get(weapon.Pistol)
+	fire := func(n int) {
+		t.Helper()
+		direction := weapon.SpreadOffset(s.seeds["alice"], n, w.Spread)
+		// Claiming every shot is the first doesn't get the first's spread
+		b := &player.Bullet{X: spawn[0], Y: spawn[1], EndX: spawn[0] + math.Cos(direction), EndY: spawn[1] + math.Sin(direction),
+			Direction: direction, Velocity: 1, Weapon: w.ID, Shot: 0}
+		data, err := json.Marshal(Shoot{Bullets: []*player.Bullet{b}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.nextShot["alice"] = time.Time{}
+		s.spawnBullets("alice", data)
+	}
+
+	fire(0)
+	fire(1)
+	if len(s.world.Bullets) != 2 || s.shots["alice"] != 2 {
+		t.Errorf("%d bullets in the world after %d shots, want 2 of 2", len(s.world.Bullets), s.shots["alice"])
+	}
+
+	reader.events(t)
+	s.world.Players["alice"] = &sim.Player{X: spawn[0], Y: spawn[1]}
+	s.kill(PlayerKilled{VictimID: "alice"})
+	if s.seeds["alice"] == 7 || s.shots["alice"] != 0 {
+		t.Errorf("after dying alice has seed %d and %d shots, want a new one from 0", s.seeds["alice"], s.shots["alice"])
+	}
+	var sent *Seed
+	for _, e := range reader.events(t) {
+		if e.Type == player.EventTypeSeed {
+			sent = &Seed{}
+			if err := json.Unmarshal(e.Data, sent); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if sent == nil || sent.Seed != s.seeds["alice"] {
+		t.Errorf("alice's client got seed %v, want %d", sent, s.seeds["alice"])
+	}
+}
+
+func TestUpdatesKeepTheServersHealth(t *testing.T) {
+	s := testServer(t)
+	alice, _ := testClient(t, s, "alice")
+	spawn := s.world.Level.Spawns[0]
+	update := func(health int) PlayerUpdate {
+		t.Helper()
+		event := s.track(alice, message(t, player.EventTypePlayerUpdate, PlayerUpdate{ID: "alice", X: spawn[0], Y: spawn[1], Health: health}))
+		var u PlayerUpdate
+		if err := json.Unmarshal(event.Data, &u); err != nil {
+			t.Fatal(err)
+		}
+		return u
+	}
+
+	if u := update(1); u.Health != player.MaxHealth {
+		t.Errorf("joining with %d health, want full", u.Health)
+	}
+	s.world.Players["alice"].Health = 30
+	if u := update(player.MaxHealth); u.Health != 30 || s.world.Players["alice"].Health != 30 {
+		t.Errorf("claiming full health relayed %d and left the server at %d, want 30", u.Health, s.world.Players["alice"].Health)
+	}
+
+	s.world.Players["alice"].Health = 0
+	s.died["alice"] = time.Now()
+	if event := s.track(alice, message(t, player.EventTypePlayerUpdate, PlayerUpdate{ID: "alice", X: spawn[0], Y: spawn[1], Health: player.MaxHealth})); event.Type != "" {
+		t.Error("respawn before the respawn time was kept")
+	}
+	s.died["alice"] = time.Now().Add(-s.match.Rules.Respawn())
+	if u := update(player.MaxHealth); u.Health != player.MaxHealth || s.world.Players["alice"].Health != player.MaxHealth {
+		t.Errorf("respawned with %d health, want full", u.Health)
+	}
+}
+
+func TestServerKillsAtNoHealth(t *testing.T) {
+	s := testServer(t)
+	_, reader := testClient(t, s, "bob")
+	s.world.Players["alice"] = &sim.Player{X: 100, Y: 100, Health: player.MaxHealth}
+	s.world.Players["bob"] = &sim.Player{X: 200, Y: 100, Health: 10}
+
+	s.world.Spawn(&sim.Bullet{OwnerID: "alice", X: 170, Y: 100, Velocity: 20, Damage: 20, Weapon: weapon.Rifle})
+	s.updateBullets()
+	var kill PlayerKilled
+	for _, e := range reader.events(t) {
+		if e.Type == player.EventTypePlayerKilled {
+			if err := json.Unmarshal(e.Data, &kill); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if kill.VictimID != "bob" || kill.KillerID != "alice" {
+		t.Errorf("server sent kill %+v, want alice killing bob", kill)
+	}
+	if _, dead := s.died["bob"]; !dead || s.world.Players["bob"].Health != 0 {
+		t.Error("bob isn't dead on the server")
+	}
+
+	// Dead players aren't hit and killed again
+	s.world.Spawn(&sim.Bullet{OwnerID: "alice", X: 170, Y: 100, Velocity: 20, Damage: 20, Weapon: weapon.Rifle})
+	s.updateBullets()
+	for _, e := range reader.events(t) {
+		if e.Type == player.EventTypePlayerKilled {
+			t.Error("a dead player was killed again")
+		}
+	}
+}
+
+func TestTrackDropsSpoofedEvents(t *testing.T) {
+	s := testServer(t)
+	alice, _ := testClient(t, s, "alice")
+	watcher, _ := testClient(t, s, "watcher")
+	s.clients[watcher].observer = true
+
+	for name, tt := range map[string]struct {
+		conn net.Conn
+		msg  string
+	}{
+		"update of another player": {alice, message(t, player.EventTypePlayerUpdate, PlayerUpdate{ID: "bob", Health: player.MaxHealth})},
+		"observer playing":         {watcher, message(t, player.EventTypePlayerUpdate, PlayerUpdate{ID: "watcher", Health: player.MaxHealth})},
+		"not json":                 {alice, "{"},
+	} {
+		if event := s.track(tt.conn, tt.msg); event.Type != "" {
+			t.Errorf("%s: track kept a %s event", name, event.Type)
+		}
+	}
+	if _, ok := s.world.Players["bob"]; ok {
+		t.Error("a spoofed update put bob in the world")
+	}
+	if event := s.track(watcher, message(t, player.EventTypeMapRequest, MapRequest{PlayerID: "watcher", Map: level.Default})); event.Type != player.EventTypeMapRequest {
+		t.Errorf("observer's map request became %q", event.Type)
+	}
+}
+
+func TestRejection(t *testing.T) {
+	s := NewServer("", config.Network{MaxPlayers: 1, Banned: []string{"mallory"}, ObserverToken: "secret"})
+	s.kicked["trudy"] = time.Now().Add(time.Minute)
+	alice, _ := testClient(t, s, "alice")
+	s.clients[alice].key, s.clients[alice].host = "alice-key", "10.0.0.1"
+
+	for _, tt := range []struct {
+		name   string
+		hello  Hello
+		host   string
+		reject bool
+	}{
+		{"old version", Hello{Name: "bob", Version: player.ProtocolVersion - 1, Observer: true, Token: "secret"}, "10.0.0.2", true},
+		{"banned", Hello{Name: "mallory", Version: player.ProtocolVersion, Observer: true, Token: "secret"}, "10.0.0.2", true},
+		{"kicked", Hello{Name: "trudy", Version: player.ProtocolVersion, Observer: true, Token: "secret"}, "10.0.0.2", true},
+		{"full", Hello{Name: "bob", Version: player.ProtocolVersion}, "10.0.0.2", true},
+		{"observer of a full server", Hello{Name: "bob", Version: player.ProtocolVersion, Observer: true, Token: "secret"}, "10.0.0.2", false},
+		{"observer without the token", Hello{Name: "bob", Version: player.ProtocolVersion, Observer: true, Token: "guess"}, "10.0.0.2", true},
+		{"player watching by key", Hello{Name: "bob", Version: player.ProtocolVersion, Observer: true, Token: "secret", Key: "alice-key"}, "10.0.0.2", true},
+		{"player watching by host", Hello{Name: "bob", Version: player.ProtocolVersion, Observer: true, Token: "secret"}, "10.0.0.1", true},
+	} {
+		if got := s.rejection(tt.hello, tt.host); (got != "") != tt.reject {
+			t.Errorf("%s: rejection() = %q, want rejected %v", tt.name, got, tt.reject)
+		}
+	}
+}
+
+func TestRankedNamesBelongToTheirKey(t *testing.T) {
+	s := testServer(t)
+	s.ratings, s.identities = match.Ratings{}, match.Identities{}
+	s.identities.Claim("alice", "alice-key")
+	testClient(t, s, "carol")
+
+	for _, tt := range []struct {
+		name   string
+		hello  Hello
+		reject bool
+	}{
+		{"owner", Hello{Name: "alice", Version: player.ProtocolVersion, Key: "alice-key"}, false},
+		{"unclaimed name", Hello{Name: "bob", Version: player.ProtocolVersion, Key: "bob-key"}, false},
+		{"someone else's name", Hello{Name: "alice", Version: player.ProtocolVersion, Key: "mallory-key"}, true},
+		{"no key", Hello{Name: "bob", Version: player.ProtocolVersion}, true},
+		{"already playing", Hello{Name: "carol", Version: player.ProtocolVersion, Key: "carol-key"}, true},
+		{"bot by its own say", Hello{Name: "alice", Version: player.ProtocolVersion, Bot: true}, true},
+	} {
+		if got := s.rejection(tt.hello, ""); (got != "") != tt.reject {
+			t.Errorf("%s: rejection() = %q, want rejected %v", tt.name, got, tt.reject)
+		}
+	}
+}
+
+func TestOnlyTheServersBotsAreBots(t *testing.T) {
+	s := testServer(t)
+	s.ratings, s.identities = match.Ratings{}, match.Identities{}
+	s.identities.Claim("alice", "alice-key")
+
+	for _, tt := range []struct {
+		name  string
+		hello Hello
+		host  string
+		want  bool
+	}{
+		{"backfill", Hello{Name: "alice", Bot: true, Token: s.botToken}, "127.0.0.1", true},
+		{"no token", Hello{Name: "alice", Bot: true}, "127.0.0.1", false},
+		{"wrong token", Hello{Name: "alice", Bot: true, Token: "guess"}, "127.0.0.1", false},
+		{"from elsewhere", Hello{Name: "alice", Bot: true, Token: s.botToken}, "10.0.0.2", false},
+		{"not a bot", Hello{Name: "alice", Token: s.botToken}, "127.0.0.1", false},
+	} {
+		tt.hello.Version = player.ProtocolVersion
+		if got := s.spawnedBot(tt.hello, tt.host); got != tt.want {
+			t.Errorf("%s: spawnedBot() = %v, want %v", tt.name, got, tt.want)
+		}
+		// Bots may take a ranked name, people need its key
+		if got := s.rejection(tt.hello, tt.host); (got == "") != tt.want {
+			t.Errorf("%s: rejection() = %q, want rejected %v", tt.name, got, !tt.want)
+		}
+	}
+}
+
+func TestObserversWithoutConfigAreRejected(t *testing.T) {
+	s := testServer(t)
+	if got := s.rejection(Hello{Name: "bob", Version: player.ProtocolVersion, Observer: true}, "10.0.0.2"); got == "" {
+		t.Error("observer joined a server without an observer token")
+	}
+}
+
+func TestObserversGetEverythingLate(t *testing.T) {
+	conn, peer := net.Pipe()
+	t.Cleanup(func() {
+		conn.Close()
+		peer.Close()
+	})
+	delay := 100 * time.Millisecond
+	cl := newClient(conn, delay)
+	sent := time.Now()
+	cl.send(wire.Frame([]byte("hi")))
+
+	reader := wire.NewReader(peer)
+	peer.SetReadDeadline(time.Now().Add(time.Second))
+	msg, err := reader.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(msg) != "hi" || time.Since(sent) < delay {
+		t.Errorf("got %q after %v, want hi after %v", msg, time.Since(sent), delay)
+	}
+	close(cl.out)
+}
+
+func TestUniqueID(t *testing.T) {
+	s := testServer(t)
+	testClient(t, s, "alice")
+	testClient(t, s, "alice#2")
+	for name, want := range map[string]string{"bob": "bob", "alice": "alice#3", "": "player"} {
+		if got := s.uniqueID(name); got != want {
+			t.Errorf("uniqueID(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+// Warehouse has a box in the middle, from 750, 500 to 850, 600.
+
+func TestSeesOnlyThroughOpenSpace(t *testing.T) {
+	s := testServer(t)
+	s.world.Players["alice"] = &sim.Player{X: 200, Y: 550, Health: player.MaxHealth, Team: "red"}
+	s.world.Players["bob"] = &sim.Player{X: 1400, Y: 550, Health: player.MaxHealth, Team: "blue"}
+	s.world.Players["carol"] = &sim.Player{X: 1400, Y: 200, Health: player.MaxHealth, Team: "blue"}
+
+	red := &client{team: "red"}
+	for _, tt := range []struct {
+		name   string
+		viewer *client
+		id     string
+		want   bool
+	}{
+		{"behind the box", red, "bob", false},
+		{"in the open", red, "carol", true},
+		{"teammate", &client{team: "blue"}, "bob", true},
+		{"observer", &client{observer: true}, "bob", true},
+	} {
+		if got := s.sees(tt.viewer, "alice", tt.id); got != tt.want {
+			t.Errorf("%s: sees(%s) = %v, want %v", tt.name, tt.id, got, tt.want)
+		}
+	}
+	s.world.Players["bob"].Health = 0
+	if !s.sees(red, "alice", "bob") {
+		t.Error("dead players are hidden")
+	}
+}
+
+func TestRelayVisibleHidesPlayersOutOfSight(t *testing.T) {
+	s := testServer(t)
+	alice, _ := testClient(t, s, "alice")
+	_, bob := testClient(t, s, "bob")
+	s.world.Players["bob"] = &sim.Player{X: 1400, Y: 550, Health: player.MaxHealth}
+
+	s.world.Players["alice"] = &sim.Player{X: 1400, Y: 200, Health: player.MaxHealth}
+	s.relayVisible(alice, message(t, player.EventTypePlayerUpdate, PlayerUpdate{ID: "alice"}))
+	if got := bob.types(t); len(got) != 1 || got[0] != player.EventTypePlayerUpdate {
+		t.Fatalf("bob got %v in sight of alice, want her update", got)
+	}
+
+	s.world.Players["alice"] = &sim.Player{X: 200, Y: 550, Health: player.MaxHealth}
+	s.relayVisible(alice, message(t, player.EventTypePlayerUpdate, PlayerUpdate{ID: "alice"}))
+	s.relayVisible(alice, message(t, player.EventTypePlayerUpdate, PlayerUpdate{ID: "alice"}))
+	if got := bob.types(t); len(got) != 1 || got[0] != player.EventTypeHidden {
+		t.Errorf("bob got %v after alice went behind the box, want her hidden once", got)
+	}
+}
+
+func TestShotsOutOfSightAreOnlyHeard(t *testing.T) {
+	s := testServer(t)
+	_, bob := testClient(t, s, "bob")
+	_, carol := testClient(t, s, "carol")
+	s.world.Players["alice"] = &sim.Player{X: 230, Y: 560, Health: player.MaxHealth}
+	s.world.Players["bob"] = &sim.Player{X: 1400, Y: 550, Health: player.MaxHealth}
+	s.world.Players["carol"] = &sim.Player{X: 230, Y: 700, Health: player.MaxHealth}
+	seen := func(cl *client, viewerID string) bool { return s.sees(cl, viewerID, "alice") }
+	shot := Shoot{Bullets: []*player.Bullet{{ID: 1, OwnerID: "alice", X: 230, Y: 560}}}
+
+	s.sendShot(shot, 230, 560, false, seen)
+	if got := carol.types(t); len(got) != 1 || got[0] != player.EventTypeBulletSpawn {
+		t.Errorf("carol got %v in sight of alice, want the bullets", got)
+	}
+	events := bob.events(t)
+	if len(events) != 1 || events[0].Type != player.EventTypeNoise {
+		t.Fatalf("bob got %v behind the box, want a noise", events)
+	}
+	var noise Noise
+	if err := json.Unmarshal(events[0].Data, &noise); err != nil {
+		t.Fatal(err)
+	}
+	if noise.X != 300 || noise.Y != 500 {
+		t.Errorf("bob heard the shot at %v, %v, want the center of its cell 300, 500", noise.X, noise.Y)
+	}
+
+	s.sendShot(shot, 230, 560, true, seen)
+	if got := bob.types(t); len(got) != 0 {
+		t.Errorf("bob got %v of a suppressed shot out of sight", got)
+	}
+}
+
+func TestDecoysSoundLikeShots(t *testing.T) {
+	s := testServer(t)
+	_, bob := testClient(t, s, "bob")
+	_, carol := testClient(t, s, "carol")
+	s.world.Players["alice"] = &sim.Player{X: 200, Y: 550, Health: player.MaxHealth}
+	s.world.Players["bob"] = &sim.Player{X: 1400, Y: 550, Health: player.MaxHealth}
+	s.world.Players["carol"] = &sim.Player{X: 230, Y: 700, Health: player.MaxHealth}
+	s.world.Place(&sim.Entity{Kind: sim.Decoy, OwnerID: "alice", X: 230, Y: 560})
+	carol.events(t)
+	bob.events(t)
+
+	for range sim.DecoyBurstInterval {
+		s.updateEntities()
+	}
+	decoy := bob.events(t)
+	if len(decoy) == 0 {
+		t.Fatal("bob heard no decoy behind the box")
+	}
+	if got := carol.types(t); len(got) != 0 {
+		t.Errorf("carol got %v in sight of the decoy", got)
+	}
+
+	s.sendShot(Shoot{Bullets: []*player.Bullet{{ID: 1, OwnerID: "alice", X: 230, Y: 560}}}, 230, 560, false,
+		func(cl *client, viewerID string) bool { return s.sees(cl, viewerID, "alice") })
+	shot := bob.events(t)
+	if len(shot) != 1 || decoy[0].Type != shot[0].Type || string(decoy[0].Data) != string(shot[0].Data) {
+		t.Errorf("bob heard the decoy as %v and a shot from it as %v, want the same", decoy[0], shot)
+	}
+}
+
+func TestEntitiesOutOfSightAreHidden(t *testing.T) {
+	s := testServer(t)
+	_, bob := testClient(t, s, "bob")
+	s.world.Players["alice"] = &sim.Player{X: 230, Y: 560, Health: player.MaxHealth}
+	s.world.Players["bob"] = &sim.Player{X: 1400, Y: 550, Health: player.MaxHealth}
+	s.world.Place(&sim.Entity{Kind: sim.Turret, OwnerID: "alice", X: 250, Y: 560})
+	s.world.Place(&sim.Entity{Kind: sim.Barricade, OwnerID: "alice", X: 250, Y: 450})
+	s.world.Place(&sim.Entity{Kind: sim.Vehicle, X: 230, Y: 560, Riders: []string{"alice"}})
+	s.world.Place(&sim.Entity{Kind: sim.Vehicle, X: 230, Y: 700})
+
+	s.flushEntities()
+	events := bob.events(t)
+	if len(events) != 1 {
+		t.Fatalf("bob got %v, want the entities", events)
+	}
+	var entities []*sim.Entity
+	if err := json.Unmarshal(events[0].Data, &entities); err != nil {
+		t.Fatal(err)
+	}
+	var kinds []sim.EntityKind
+	for _, e := range entities {
+		kinds = append(kinds, e.Kind)
+		if len(e.Riders) > 0 {
+			t.Errorf("bob sees the riders of a vehicle out of sight")
+		}
+	}
+	if len(kinds) != 2 || kinds[0] != sim.Barricade || kinds[1] != sim.Vehicle {
+		t.Errorf("bob got %v, want the barricade and the parked vehicle", kinds)
+	}
+}
+
+func TestCorrectMoveThroughWall(t *testing.T) {
+	s := testServer(t)
+	alice, reader := testClient(t, s, "alice")
+	s.world.Players["alice"] = &sim.Player{X: 700, Y: 550, Health: player.MaxHealth}
+	now := time.Now()
+
+	along := PlayerUpdate{ID: "alice", X: 700, Y: 450, Health: player.MaxHealth}
+	if s.correctMove(alice, &along, now) {
+		t.Errorf("move in the open corrected to %v, %v", along.X, along.Y)
+	}
+
+	through := PlayerUpdate{ID: "alice", X: 900, Y: 550, Health: player.MaxHealth}
+	if !s.correctMove(alice, &through, now) {
+		t.Fatal("move through the box wasn't corrected")
+	}
+	if through.X > 750-player.PlayerRadius+1 {
+		t.Errorf("corrected to %v, %v, inside or past the box", through.X, through.Y)
+	}
+	events := reader.events(t)
+	if len(events) != 1 || events[0].Type != player.EventTypeMoveCorrection {
+		t.Fatalf("client got %v, want a correction", events)
+	}
+	var correction MoveCorrection
+	if err := json.Unmarshal(events[0].Data, &correction); err != nil {
+		t.Fatal(err)
+	}
+	if correction.X != through.X || correction.Y != through.Y {
+		t.Errorf("client corrected to %v, server has %v, %v", correction, through.X, through.Y)
+	}
+}
+
+func TestValidShotRejectsUnreachableMuzzles(t *testing.T) {
+	s := testServer(t)
+	now := time.Now()
+	s.recordTrail("alice", 700, 550, 0, now)
+	w := weapon.Get(weapon.Pistol)
+	shot := func(x, y, velocity float64) *player.Bullet {
+		direction := weapon.SpreadOffset(s.seeds["alice"], 0, w.Spread)
+		return &player.Bullet{X: x, Y: y, EndX: x + math.Cos(direction)*velocity, EndY: y + math.Sin(direction)*velocity,
+			Direction: direction, Velocity: velocity, Weapon: w.ID}
+	}
+
+	if !s.validShot("alice", shot(710, 550, 1), 0, now) {
+		t.Error("shot from the muzzle was rejected")
+	}
+	for name, b := range map[string]*player.Bullet{
+		"far away":         shot(300, 300, 1),
+		"behind the box":   shot(760, 550, 1),
+		"skipping ahead":   shot(710, 550, w.BulletSpeed*2),
+		"fired long after": shot(710, 550, 1),
+	} {
+		at := now
+		if name == "fired long after" {
+			at = now.Add(2 * RewindTime)
+		}
+		if s.validShot("alice", b, 0, at) {
+			t.Errorf("shot %s was accepted", name)
+		}
+	}
+}
+
+func TestValidTriggerLimitsBulletsAndRate(t *testing.T) {
+	s := testServer(t)
+	now := time.Now()
+	pellets := func(id weapon.ID, n int) []*player.Bullet {
+		var bullets []*player.Bullet
+		for range n {
+			bullets = append(bullets, &player.Bullet{Weapon: id})
+		}
+		return bullets
+	}
+	shotgun, pistol := weapon.Get(weapon.Shotgun), weapon.Get(weapon.Pistol)
+
+	if s.validTrigger("alice", pellets(weapon.Pistol, pistol.Pellets+1), now) {
+		t.Error("more bullets than the pistol fires were accepted")
+	}
+	if s.validTrigger("alice", append(pellets(weapon.Pistol, 1), pellets(weapon.Shotgun, 1)...), now) {
+		t.Error("bullets of two weapons were accepted")
+	}
+	if !s.validTrigger("bob", pellets(weapon.Shotgun, shotgun.Pellets), now) {
+		t.Fatal("a shotgun's pellets were rejected")
+	}
+	if s.validTrigger("bob", pellets(weapon.Shotgun, shotgun.Pellets), now.Add(shotgun.Cooldown/2-CooldownSlack)) {
+		t.Error("shot within the cooldown was accepted")
+	}
+	// Shots coming in bunched up are fine as long as they keep the weapon's pace
+	next := now.Add(shotgun.Cooldown - CooldownSlack/2)
+	if !s.validTrigger("bob", pellets(weapon.Shotgun, shotgun.Pellets), next) {
+		t.Error("shot arriving a little early was rejected")
+	}
+}
+
+func TestCorrectReachDoesNotSaveUpDistance(t *testing.T) {
+	s := testServer(t)
+	alice, reader := testClient(t, s, "alice")
+	now := time.Now()
+	s.world.Players["alice"] = &sim.Player{X: 200, Y: 200, Health: player.MaxHealth}
+	s.recordTrail("alice", 200, 200, 0, now.Add(-10*time.Second))
+
+	// Ten seconds of running would get there, the update before a long silence says where they were
+	u := PlayerUpdate{ID: "alice", X: 1400, Y: 200, Health: player.MaxHealth}
+	if !s.correctReach(alice, &u, now) || u.X != 200 || u.Y != 200 {
+		t.Errorf("correctReach() left alice at %v, %v, want her back at 200, 200", u.X, u.Y)
+	}
+	if got := reader.types(t); len(got) != 1 || got[0] != player.EventTypeMoveCorrection {
+		t.Errorf("client got %v, want a correction", got)
+	}
+
+	step := PlayerUpdate{ID: "alice", X: 210, Y: 200, Health: player.MaxHealth}
+	if s.correctReach(alice, &step, now) {
+		t.Error("a step was corrected")
+	}
+}
+
+func TestLoopback(t *testing.T) {
+	for address, want := range map[string]bool{
+		"localhost:6060":  true,
+		"127.0.0.1:6060":  true,
+		"[::1]:6060":      true,
+		":6060":           false,
+		"0.0.0.0:6060":    false,
+		"example.com:80":  false,
+		"192.168.1.2:606": false,
+		"no port":         false,
+	} {
+		if got := loopback(address); got != want {
+			t.Errorf("loopback(%q) = %v, want %v", address, got, want)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"time"
+
+	"shooter/player"
+	"shooter/sim"
+)
+
+// MaxSteps caps the simulation steps of a tick, a server which fell far behind skips ahead instead.
+const MaxSteps = 8
+
+// Tick heads each broadcast of player updates and entities, which are as of it.
+type Tick struct {
+	// Simulation steps since the server started, sim.TPS a second whatever the tick rate
+	Tick uint64    `json:"tick"`
+	Time time.Time `json:"time"`
+	// Server ticks per second
+	Rate int `json:"rate"`
+}
+
+// tickRate is how many times a second the server steps the simulation and takes in updates.
+func (s *Server) tickRate() int {
+	if s.cfg.TickRate > 0 {
+		return s.cfg.TickRate
+	}
+	return sim.TPS
+}
+
+// steps returns how many simulation steps are due by now, there are more than one a tick
+// when the server ticks slower than sim.TPS. mu must be held.
+func (s *Server) steps(now time.Time) int {
+	due := uint64(now.Sub(s.tickStart) * sim.TPS / time.Second)
+	n := due - s.ticks
+	s.ticks = due
+	return min(int(n), MaxSteps)
+}
+
+// sendTick starts a broadcast, mu must be held.
+func (s *Server) sendTick(now time.Time) {
+	s.broadcast(player.EventTypeTick, Tick{Tick: s.ticks, Time: now, Rate: s.tickRate()})
+}
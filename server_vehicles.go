@@ -0,0 +1,54 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+
+	"shooter/player"
+	"shooter/vehicle"
+)
+
+// Ride asks to get in a vehicle, or out of the one the player is in when VehicleID is 0.
+type Ride struct {
+	PlayerID  string `json:"player_id"`
+	VehicleID uint64 `json:"vehicle_id,omitempty"`
+}
+
+// Drive is the driver's controls, the server drives the vehicle by them until the next.
+type Drive struct {
+	PlayerID string           `json:"player_id"`
+	Controls vehicle.Controls `json:"controls"`
+}
+
+// ride gets the player in or out of a vehicle and sends everyone who's in it now, mu must be held.
+func (s *Server) ride(id string, data json.RawMessage) {
+	var ride Ride
+	if err := json.Unmarshal(data, &ride); err != nil {
+		log.Println("Error unmarshaling Ride:", err)
+		return
+	}
+	if ride.VehicleID == 0 {
+		s.leaveVehicle(id)
+		return
+	}
+	if s.world.Ride(id, ride.VehicleID) {
+		s.broadcast(player.EventTypeEntityPlaced, s.world.Entities[ride.VehicleID])
+	}
+}
+
+// leaveVehicle takes the player out of the vehicle they're in, mu must be held.
+func (s *Server) leaveVehicle(id string) {
+	if e, ok := s.world.Leave(id); ok {
+		s.broadcast(player.EventTypeEntityPlaced, e)
+	}
+}
+
+// drive steers the vehicle the player drives, mu must be held.
+func (s *Server) drive(id string, data json.RawMessage) {
+	var drive Drive
+	if err := json.Unmarshal(data, &drive); err != nil {
+		log.Println("Error unmarshaling Drive:", err)
+		return
+	}
+	s.world.Drive(id, drive.Controls)
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"log"
+	"math"
+	"net"
+	"slices"
+	"time"
+
+	"shooter/ability"
+	"shooter/player"
+	"shooter/sim"
+	"shooter/wire"
+)
+
+const (
+	// HearingDistance is how close enemies are heard moving behind walls, their updates are sent
+	// this close even out of sight.
+	HearingDistance = 400.0
+	// Gunshots out of sight are heard at the center of the square this big they came from
+	NoiseCell = 200.0
+)
+
+// Scan tells the scanner's side who their recon pulse found, they show on the minimap until it fades.
+type Scan struct {
+	PlayerID string   `json:"player_id"`
+	X        float64  `json:"x"`
+	Y        float64  `json:"y"`
+	Revealed []string `json:"revealed"`
+}
+
+// scan is a recon pulse, the scanner's side sees who it found until it fades.
+type scan struct {
+	ownerID  string
+	team     string
+	revealed []string
+	until    time.Time
+}
+
+// Hidden tells a client it won't get updates of the player until they come into sight again,
+// their last position is stale.
+type Hidden struct {
+	ID string `json:"id"`
+}
+
+// sees is true when the viewer could know where the player is: observers and teammates always,
+// others when the player is dead, found by the viewer's side's recon pulse, within hearing
+// distance or in line of sight past the level's walls and barricades. mu must be held.
+func (s *Server) sees(viewer *client, viewerID, id string) bool {
+	if viewer.observer {
+		return true
+	}
+	p, ok := s.world.Players[id]
+	if !ok || p.Health <= 0 || viewer.team != "" && viewer.team == p.Team || s.revealed(viewer, viewerID, id) {
+		return true
+	}
+	return s.seesPoint(viewer, viewerID, p.X, p.Y)
+}
+
+// seesPoint is true when the viewer could know what's at x, y: observers always, others within
+// hearing distance or in line of sight. mu must be held.
+func (s *Server) seesPoint(viewer *client, viewerID string, x, y float64) bool {
+	if viewer.observer {
+		return true
+	}
+	v, ok := s.world.Players[viewerID]
+	if !ok {
+		return false
+	}
+	return math.Hypot(x-v.X, y-v.Y) <= HearingDistance || s.world.LineOfSight(v.X, v.Y, x, y)
+}
+
+// seesEntity is true when the viewer could know the entity is there: their side's entities and
+// the server's always, unless a vehicle's riders are out of sight, others like a point. Walls
+// are always known, clients collide with them. mu must be held.
+func (s *Server) seesEntity(viewer *client, viewerID string, e *sim.Entity) bool {
+	if e.Def().Length > 0 || viewer.observer || e.OwnerID == viewerID {
+		return true
+	}
+	for _, id := range e.Riders {
+		if !s.sees(viewer, viewerID, id) {
+			return false
+		}
+	}
+	if e.OwnerID == "" {
+		return true
+	}
+	if owner, ok := s.world.Players[e.OwnerID]; ok && viewer.team != "" && owner.Team == viewer.team {
+		return true
+	}
+	return s.seesPoint(viewer, viewerID, e.X, e.Y)
+}
+
+// relaySeen sends a client's message to the other clients which see its player, mu must be held.
+func (s *Server) relaySeen(from net.Conn, msg string) {
+	id := s.ids[from]
+	frame := wire.Frame([]byte(msg))
+	for c, cl := range s.clients {
+		if c != from && s.sees(cl, s.ids[c], id) {
+			cl.send(frame)
+		}
+	}
+}
+
+// sendShot sends the bullets to the clients which see where they were fired from at x, y. The rest
+// only hear shots which aren't suppressed, as a Noise about where they came from. mu must be held.
+func (s *Server) sendShot(shot Shoot, x, y float64, suppressed bool, seen func(cl *client, viewerID string) bool) {
+	spawn, err := encodeEvent(player.EventTypeBulletSpawn, shot)
+	if err != nil {
+		log.Println("Error marshaling event:", err)
+		return
+	}
+	var hearing []*client
+	for c, cl := range s.clients {
+		switch {
+		case seen(cl, s.ids[c]):
+			cl.send(spawn)
+		case !suppressed:
+			hearing = append(hearing, cl)
+		}
+	}
+	sendNoise(x, y, hearing)
+}
+
+// sendNoise sends the clients the Noise of a gunshot at x, y they don't see, decoys fake theirs
+// the same way so they can't be told apart.
+func sendNoise(x, y float64, hearing []*client) {
+	if len(hearing) == 0 {
+		return
+	}
+	noise, err := encodeEvent(player.EventTypeNoise, heard(x, y))
+	if err != nil {
+		log.Println("Error marshaling event:", err)
+		return
+	}
+	for _, cl := range hearing {
+		cl.send(noise)
+	}
+}
+
+// heard is the Noise of a gunshot at x, y out of sight, at the center of its NoiseCell.
+func heard(x, y float64) Noise {
+	return Noise{X: (math.Floor(x/NoiseCell) + 0.5) * NoiseCell, Y: (math.Floor(y/NoiseCell) + 0.5) * NoiseCell}
+}
+
+// relayVisible sends a player update to the clients which see the player, those losing sight
+// of them are told they are hidden. mu must be held.
+func (s *Server) relayVisible(from net.Conn, msg string) {
+	id := s.ids[from]
+	frame := wire.Frame([]byte(msg))
+	var hidden []byte
+	for c, cl := range s.clients {
+		switch {
+		case c == from:
+		case s.sees(cl, s.ids[c], id):
+			cl.seen[id] = true
+			cl.send(frame)
+		case cl.seen[id]:
+			delete(cl.seen, id)
+			if hidden == nil {
+				var err error
+				if hidden, err = encodeEvent(player.EventTypeHidden, Hidden{ID: id}); err != nil {
+					log.Println("Error marshaling event:", err)
+					continue
+				}
+			}
+			cl.send(hidden)
+		}
+	}
+}
+
+// scan finds the enemies around the player for their side, updates of those not hidden by a
+// jammer go to them until the pulse fades. mu must be held.
+func (s *Server) scan(id string, now time.Time) {
+	p, ok := s.world.Players[id]
+	if !ok {
+		return
+	}
+	s.scans = slices.DeleteFunc(s.scans, func(sc scan) bool { return !now.Before(sc.until) })
+	sc := scan{ownerID: id, team: p.Team, revealed: s.world.Scan(id, p.X, p.Y, ability.ScanRadius), until: now.Add(ability.Defs[ability.Scan].Duration)}
+	s.scans = append(s.scans, sc)
+	msg, err := encodeEvent(player.EventTypeScan, Scan{PlayerID: id, X: p.X, Y: p.Y, Revealed: sc.revealed})
+	if err != nil {
+		log.Println("Error marshaling event:", err)
+		return
+	}
+	for c, cl := range s.clients {
+		if cl.observer || s.ids[c] == id || sc.team != "" && cl.team == sc.team {
+			cl.send(msg)
+		}
+	}
+}
+
+// revealed is true when a recon pulse of the viewer's side found the player and hasn't faded,
+// mu must be held.
+func (s *Server) revealed(viewer *client, viewerID, id string) bool {
+	now := time.Now()
+	for _, sc := range s.scans {
+		if now.Before(sc.until) && slices.Contains(sc.revealed, id) && (sc.ownerID == viewerID || sc.team != "" && sc.team == viewer.team) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"shooter/config"
+	"shooter/match"
+	"shooter/webhook"
+)
+
+// Players listed with their scores in match end notifications
+const webhookScores = 5
+
+func newNotifier(hooks []config.Webhook) *webhook.Notifier {
+	var wh []webhook.Hook
+	for _, h := range hooks {
+		wh = append(wh, webhook.Hook{URL: h.URL, Format: webhook.Format(h.Format)})
+	}
+	return webhook.New(wh)
+}
+
+// notifyMatchStart tells the webhooks a match started, mu must be held.
+func (s *Server) notifyMatchStart() {
+	s.webhooks.Notify(fmt.Sprintf("Match started: %s on %s", s.match.Rules.Mode, s.match.Map))
+}
+
+// notifyMatchEnd tells the webhooks who won the match and the best scores, mu must be held.
+func (s *Server) notifyMatchEnd(summary match.Summary) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Match over: %s on %s", s.match.Rules.Mode, s.match.Map)
+	if summary.Winner != "" {
+		fmt.Fprintf(&b, ", %s won", summary.Winner)
+	}
+	for i, p := range summary.Players[:min(len(summary.Players), webhookScores)] {
+		fmt.Fprintf(&b, "\n%d. %s - %d kills, %d deaths", i+1, p.ID, p.Kills, p.Deaths)
+	}
+	s.webhooks.Notify(b.String())
+}
+
+// notifyAce tells the webhooks about a player who took out everyone else in a round, mu must be held.
+func (s *Server) notifyAce(id string, round int) {
+	s.webhooks.Notify(fmt.Sprintf("%s aced round %d on %s", id, round, s.world.Level.Name))
+}
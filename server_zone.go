@@ -0,0 +1,40 @@
+package main
+
+import (
+	"time"
+
+	"shooter/player"
+	"shooter/zone"
+)
+
+// ZoneUpdate is sent every second while the zone modifier is on. Clients plan the same
+// circles from the seed and follow along from the elapsed time.
+type ZoneUpdate struct {
+	Seed    int64         `json:"seed"`
+	Elapsed time.Duration `json:"elapsed"`
+}
+
+// newZone plans a zone for the world's level when the server has the modifier on, mu must be held.
+func (s *Server) newZone() {
+	s.zone = nil
+	if s.cfg.Zone {
+		s.zone = zone.New(s.world.Level.Width, s.world.Level.Height, time.Now().UnixNano())
+	}
+}
+
+// updateZone moves the zone to where it is now in the match, it deals no damage between
+// matches. mu must be held.
+func (s *Server) updateZone(now time.Time) {
+	s.world.Zone = nil
+	if s.zone != nil && s.summary == nil {
+		state := s.zone.At(now.Sub(s.match.Started))
+		s.world.Zone = &state
+	}
+}
+
+// sendZone tells clients where the zone is at, mu must be held.
+func (s *Server) sendZone(now time.Time) {
+	if s.zone != nil && s.summary == nil {
+		s.broadcast(player.EventTypeZone, ZoneUpdate{Seed: s.zone.Seed, Elapsed: now.Sub(s.match.Started)})
+	}
+}
@@ -0,0 +1,402 @@
+package sim
+
+import (
+	"math"
+	"slices"
+
+	"shooter/game"
+	"shooter/vehicle"
+	"shooter/weapon"
+)
+
+// EntityKind is what an entity is, its behavior each step and its EntityDef.
+type EntityKind string
+
+const (
+	// Shoots at the nearest enemy it can see until out of ammo
+	Turret EntityKind = "turret"
+	// Wall across the direction it was deployed in, blocking bullets and sight
+	Barricade EntityKind = "barricade"
+	// Thrown, fakes bursts of gunfire where it lands
+	Decoy EntityKind = "decoy"
+	// Left where a player died
+	Corpse EntityKind = "corpse"
+	// Weapon dropped by a dying player with its Ammo, anyone can pick it up
+	WeaponDrop EntityKind = "weapon_drop"
+	// Hides its owner's side from enemy scans within JammerRadius
+	Jammer EntityKind = "jammer"
+	// Buggy players drive around, parked by the server, see vehicles.go
+	Vehicle EntityKind = "vehicle"
+	// Wall of the map which its script opens and closes
+	Door EntityKind = "door"
+	// Marks where a supply crate lands once it runs out
+	Airdrop EntityKind = "airdrop"
+	// Supply crate with a Weapon and its Ammo, or armor, picked up like a weapon drop
+	Crate EntityKind = "crate"
+)
+
+// EntityDef is what every entity of a kind starts with.
+type EntityDef struct {
+	Health int
+	Ammo   int
+	// Bullets hit the entity within this distance of its center, or of its wall
+	Radius float64
+	// Wall entities block like a wall this long across their Angle, 0 for round ones
+	Length float64
+	// Ticks until the entity despawns, 0 for never
+	Lifetime int
+	// Deploying more of a kind than this removes the owner's oldest
+	MaxPerPlayer int
+	// Bullets go through without hurting it
+	Intangible bool
+	// Bullets stop at it without hurting it
+	Indestructible bool
+}
+
+var EntityDefs = map[EntityKind]EntityDef{
+	Turret:     {Health: 150, Ammo: 60, Radius: 16, MaxPerPlayer: 1},
+	Barricade:  {Health: 300, Radius: 6, Length: 120, Lifetime: 30 * TPS, MaxPerPlayer: 2},
+	Decoy:      {Health: 40, Radius: 8, Lifetime: 15 * TPS, MaxPerPlayer: 1},
+	Corpse:     {Radius: 14, Lifetime: 20 * TPS, MaxPerPlayer: 1, Intangible: true},
+	WeaponDrop: {Radius: 12, Lifetime: 30 * TPS, MaxPerPlayer: 3, Intangible: true},
+	Jammer:     {Health: 60, Radius: 10, Lifetime: 40 * TPS, MaxPerPlayer: 1},
+	Vehicle:    {Health: 500, Radius: vehicle.Radius, MaxPerPlayer: MaxVehicles},
+	Door:       {Radius: 6, Length: 160, MaxPerPlayer: 16, Indestructible: true},
+	Airdrop:    {Radius: 60, Lifetime: 10 * TPS, MaxPerPlayer: 4, Intangible: true},
+	Crate:      {Radius: 16, Lifetime: 60 * TPS, MaxPerPlayer: 4, Intangible: true},
+}
+
+const (
+	TurretRange = 500.0
+	// Radians turned per tick
+	TurretTurnRate = 0.06
+	// Fires once aimed this close to the target
+	TurretAimTolerance = 0.05
+	// Ticks between shots
+	TurretCooldown = 12
+	// Farthest from the player an entity can be deployed
+	DeployReach = 80.0
+	// Farthest from the player a weapon can be picked up
+	PickupReach = 50.0
+
+	// Decoys fake a burst of 3 to 5 shots this often, in ticks
+	DecoyBurstInterval = 150
+	DecoyShotInterval  = 6
+
+	// Players this close to an enemy jammer don't show up on scans
+	JammerRadius = 250.0
+)
+
+// Entity is something deployed into the world and simulated by the server, like a turret.
+// Damage it deals is attributed to its owner.
+type Entity struct {
+	ID      uint64     `json:"id"`
+	Kind    EntityKind `json:"kind"`
+	OwnerID string     `json:"owner_id"`
+	X       float64    `json:"x"`
+	Y       float64    `json:"y"`
+	Angle   float64    `json:"angle"`
+	Health  int        `json:"health"`
+	Ammo    int        `json:"ammo"`
+	// Player the entity is after, empty when it has none
+	TargetID string `json:"target_id,omitempty"`
+	// Of a weapon drop
+	Weapon weapon.ID `json:"weapon,omitempty"`
+	// Of a vehicle, the driver first
+	Riders []string `json:"riders,omitempty"`
+	Speed  float64  `json:"speed,omitempty"`
+	// Driver's input, held until they send the next
+	controls vehicle.Controls
+	cooldown int
+	ticks    int
+}
+
+func (e *Entity) Def() EntityDef {
+	return EntityDefs[e.Kind]
+}
+
+// Object is the footprint of a wall entity, a rectangle Length long and twice Radius thick
+// across its Angle. Round entities have no walls.
+func (e *Entity) Object() game.Object {
+	def := e.Def()
+	if def.Length == 0 {
+		return game.Object{}
+	}
+	// Half of the wall along it and across it
+	ax, ay := -math.Sin(e.Angle)*def.Length/2, math.Cos(e.Angle)*def.Length/2
+	cx, cy := math.Cos(e.Angle)*def.Radius, math.Sin(e.Angle)*def.Radius
+	corners := [4][2]float64{
+		{e.X - ax - cx, e.Y - ay - cy},
+		{e.X + ax - cx, e.Y + ay - cy},
+		{e.X + ax + cx, e.Y + ay + cy},
+		{e.X - ax + cx, e.Y - ay + cy},
+	}
+	var o game.Object
+	for i, c := range corners {
+		next := corners[(i+1)%len(corners)]
+		o.Walls = append(o.Walls, game.Line{X1: c[0], Y1: c[1], X2: next[0], Y2: next[1]})
+	}
+	return o
+}
+
+// Objects is the level with the walls of wall entities, what blocks bullets, sight and grenades.
+func (w *World) Objects() []game.Object {
+	return EntityObjects(w.Level.Objects, w.EntityList())
+}
+
+// EntityObjects appends the footprints of the wall entities to the objects.
+func EntityObjects(objects []game.Object, entities []*Entity) []game.Object {
+	objects = slices.Clip(objects)
+	for _, e := range entities {
+		if o := e.Object(); len(o.Walls) > 0 {
+			objects = append(objects, o)
+		}
+	}
+	return objects
+}
+
+// CanPlace is true when an entity of the kind fits at x, y facing angle, inside the level and clear of walls.
+func (w *World) CanPlace(kind EntityKind, x, y, angle float64) bool {
+	def, ok := EntityDefs[kind]
+	if !ok {
+		return false
+	}
+	r := def.Radius
+	if x < r || y < r || x > w.Level.Width-r || y > w.Level.Height-r {
+		return false
+	}
+	footprint := (&Entity{Kind: kind, X: x, Y: y, Angle: angle}).Object()
+	for _, o := range w.Objects() {
+		for _, wall := range o.Walls {
+			if _, d := wall.Closest(x, y); d < r {
+				return false
+			}
+			for _, side := range footprint.Walls {
+				if _, _, ok := game.Intersection(side, wall); ok {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+// Place adds the entity with the next ID and its kind's health and ammo, unless it brings its
+// own ammo. Once the owner has more than MaxPerPlayer of the kind the oldest ones are removed,
+// their IDs are returned.
+func (w *World) Place(e *Entity) []uint64 {
+	def := e.Def()
+	w.nextID++
+	e.ID = w.nextID
+	e.Health = def.Health
+	if e.Ammo == 0 {
+		e.Ammo = def.Ammo
+	}
+	w.Entities[e.ID] = e
+
+	var owned []uint64
+	for _, id := range w.entityIDs() {
+		if o := w.Entities[id]; o.OwnerID == e.OwnerID && o.Kind == e.Kind {
+			owned = append(owned, id)
+		}
+	}
+	var removed []uint64
+	for _, id := range owned[:max(0, len(owned)-def.MaxPerPlayer)] {
+		delete(w.Entities, id)
+		removed = append(removed, id)
+	}
+	return removed
+}
+
+// PickUp removes the weapon drop or crate for a living player within PickupReach of it, false
+// when it isn't there or out of reach.
+func (w *World) PickUp(playerID string, id uint64) (*Entity, bool) {
+	e, ok := w.Entities[id]
+	p, alive := w.Players[playerID]
+	if !ok || !alive || e.Kind != WeaponDrop && e.Kind != Crate || p.Health <= 0 || math.Hypot(e.X-p.X, e.Y-p.Y) > PickupReach {
+		return nil, false
+	}
+	delete(w.Entities, id)
+	return e, true
+}
+
+// RemoveOwned removes all entities of the owner, for when they leave.
+func (w *World) RemoveOwned(ownerID string) {
+	for id, e := range w.Entities {
+		// Vehicles belong to nobody
+		if ownerID != "" && e.OwnerID == ownerID {
+			delete(w.Entities, id)
+		}
+	}
+}
+
+// EntityList returns the entities ordered by ID.
+func (w *World) EntityList() []*Entity {
+	entities := make([]*Entity, 0, len(w.Entities))
+	for _, id := range w.entityIDs() {
+		entities = append(entities, w.Entities[id])
+	}
+	return entities
+}
+
+// EntityStep is what the entities did in one step.
+type EntityStep struct {
+	// Bullets turrets fired, already spawned
+	Fired []*Bullet
+	// Entities which despawned
+	Expired []*Entity
+	// Decoys faking a shot
+	Noises []*Entity
+	// Crates of airdrops which ran out, already placed
+	Landed []*Entity
+}
+
+// StepEntities runs the entities for one tick.
+func (w *World) StepEntities() EntityStep {
+	var step EntityStep
+	for _, id := range w.entityIDs() {
+		e := w.Entities[id]
+		e.ticks++
+		if lifetime := e.Def().Lifetime; lifetime > 0 && e.ticks >= lifetime {
+			delete(w.Entities, id)
+			step.Expired = append(step.Expired, e)
+			if e.Kind == Airdrop {
+				crate := &Entity{Kind: Crate, X: e.X, Y: e.Y, Weapon: e.Weapon, Ammo: e.Ammo}
+				w.Place(crate)
+				step.Landed = append(step.Landed, crate)
+			}
+			continue
+		}
+		switch e.Kind {
+		case Turret:
+			if b := w.stepTurret(e); b != nil {
+				step.Fired = append(step.Fired, b)
+			}
+		case Decoy:
+			if e.fakesShot() {
+				step.Noises = append(step.Noises, e)
+			}
+		case Vehicle:
+			w.stepVehicle(e)
+		}
+	}
+	return step
+}
+
+// fakesShot is true on the ticks the decoy fakes a shot. Bursts differ in length between decoys
+// but are the same every run.
+func (e *Entity) fakesShot() bool {
+	phase := e.ticks % DecoyBurstInterval
+	return phase%DecoyShotInterval == 0 && phase/DecoyShotInterval < 3+int(e.ID%3)
+}
+
+func (w *World) stepTurret(e *Entity) *Bullet {
+	e.cooldown = max(0, e.cooldown-1)
+	e.TargetID = w.turretTarget(e)
+	if e.TargetID == "" {
+		return nil
+	}
+	target := w.Players[e.TargetID]
+	diff := math.Remainder(math.Atan2(target.Y-e.Y, target.X-e.X)-e.Angle, 2*math.Pi)
+	e.Angle += math.Max(-TurretTurnRate, math.Min(TurretTurnRate, diff))
+	if math.Abs(diff) > TurretAimTolerance || e.cooldown > 0 || e.Ammo <= 0 {
+		return nil
+	}
+
+	e.cooldown = TurretCooldown
+	e.Ammo--
+	gun := weapon.Get(weapon.Turret)
+	b := &Bullet{
+		OwnerID:   e.OwnerID,
+		EntityID:  e.ID,
+		X:         e.X + math.Cos(e.Angle)*e.Def().Radius,
+		Y:         e.Y + math.Sin(e.Angle)*e.Def().Radius,
+		Direction: e.Angle,
+		Velocity:  gun.BulletSpeed,
+		Damage:    gun.Damage,
+		Weapon:    gun.ID,
+	}
+	w.Spawn(b)
+	return b
+}
+
+// turretTarget is the nearest living player the turret can see in range, other than its owner
+// and the owner's teammates. Ties go to the smaller ID.
+func (w *World) turretTarget(e *Entity) string {
+	var team string
+	if owner, ok := w.Players[e.OwnerID]; ok {
+		team = owner.Team
+	}
+	target, closest := "", math.Inf(1)
+	for _, id := range w.playerIDs() {
+		p := w.Players[id]
+		if id == e.OwnerID || p.Health <= 0 || team != "" && p.Team == team {
+			continue
+		}
+		d := math.Hypot(p.X-e.X, p.Y-e.Y)
+		if d <= TurretRange && d < closest && w.LineOfSight(e.X, e.Y, p.X, p.Y) {
+			target, closest = id, d
+		}
+	}
+	return target
+}
+
+// LineOfSight is true when no wall of the level or of an entity crosses the line between the points.
+// It decides what the server lets players see and what blasts reach.
+func (w *World) LineOfSight(x1, y1, x2, y2 float64) bool {
+	return lineOfSight(w.Objects(), x1, y1, x2, y2)
+}
+
+func lineOfSight(objects []game.Object, x1, y1, x2, y2 float64) bool {
+	line := game.Line{X1: x1, Y1: y1, X2: x2, Y2: y2}
+	for _, o := range objects {
+		for _, wall := range o.Walls {
+			if _, _, ok := game.Intersection(line, wall); ok {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (w *World) entityIDs() []uint64 {
+	ids := make([]uint64, 0, len(w.Entities))
+	for id := range w.Entities {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+func (w *World) playerIDs() []string {
+	ids := make([]string, 0, len(w.Players))
+	for id := range w.Players {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+// Scan is the living enemies of the scanner within radius of x, y, leaving out those an
+// enemy jammer hides.
+func (w *World) Scan(scannerID string, x, y, radius float64) []string {
+	var found []string
+	for _, id := range w.playerIDs() {
+		p := w.Players[id]
+		if id == scannerID || p.Health <= 0 || w.Teammates(scannerID, id) || math.Hypot(p.X-x, p.Y-y) > radius || w.jammed(scannerID, p.X, p.Y) {
+			continue
+		}
+		found = append(found, id)
+	}
+	return found
+}
+
+// jammed is true when a jammer of someone who isn't the scanner or their teammate covers x, y.
+func (w *World) jammed(scannerID string, x, y float64) bool {
+	for _, e := range w.Entities {
+		if e.Kind == Jammer && e.OwnerID != scannerID && !w.Teammates(scannerID, e.OwnerID) && math.Hypot(e.X-x, e.Y-y) <= JammerRadius {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,283 @@
+package sim
+
+import (
+	"math"
+	"slices"
+	"testing"
+
+	"shooter/game"
+	"shooter/level"
+	"shooter/vehicle"
+)
+
+func TestTurretShootsNearestEnemy(t *testing.T) {
+	w := NewWorld(&level.Level{Width: 2000, Height: 2000})
+	w.Players["owner"] = &Player{X: 100, Y: 100, Health: 100}
+	w.Players["far"] = &Player{X: 100, Y: 400, Health: 100}
+	w.Players["near"] = &Player{X: 100, Y: 300, Health: 100}
+	w.Place(&Entity{Kind: Turret, OwnerID: "owner", X: 100, Y: 100})
+
+	var fired []*Bullet
+	for range 60 {
+		fired = append(fired, w.StepEntities().Fired...)
+	}
+	if len(fired) == 0 {
+		t.Fatal("turret never fired")
+	}
+	b := fired[0]
+	if b.OwnerID != "owner" || b.EntityID == 0 {
+		t.Errorf("bullet owner %q entity %d, want the turret's owner and ID", b.OwnerID, b.EntityID)
+	}
+	for _, e := range w.Entities {
+		if e.TargetID != "near" {
+			t.Errorf("TargetID = %q, want near", e.TargetID)
+		}
+	}
+}
+
+func TestTurretIgnoresTeammatesAndHiddenPlayers(t *testing.T) {
+	wall := game.Object{Walls: game.Rect(50, 190, 300, 20)}
+	w := NewWorld(&level.Level{Width: 2000, Height: 2000, Objects: []game.Object{wall}})
+	w.Players["owner"] = &Player{X: 100, Y: 100, Health: 100, Team: "red"}
+	w.Players["mate"] = &Player{X: 200, Y: 100, Health: 100, Team: "red"}
+	w.Players["hidden"] = &Player{X: 100, Y: 300, Health: 100, Team: "blue"}
+	w.Place(&Entity{Kind: Turret, OwnerID: "owner", X: 100, Y: 100})
+
+	for range 60 {
+		if len(w.StepEntities().Fired) > 0 {
+			t.Fatalf("turret fired at %q", w.EntityList()[0].TargetID)
+		}
+	}
+}
+
+func TestPlaceRemovesOldest(t *testing.T) {
+	w := NewWorld(&level.Level{Width: 2000, Height: 2000})
+	first := &Entity{Kind: Turret, OwnerID: "a", X: 100, Y: 100}
+	w.Place(first)
+	removed := w.Place(&Entity{Kind: Turret, OwnerID: "a", X: 200, Y: 100})
+	if len(removed) != 1 || removed[0] != first.ID {
+		t.Errorf("removed %v, want [%d]", removed, first.ID)
+	}
+	if len(w.Entities) != 1 {
+		t.Errorf("%d entities, want 1", len(w.Entities))
+	}
+}
+
+func TestBulletsDestroyEntities(t *testing.T) {
+	w := NewWorld(&level.Level{Width: 2000, Height: 2000})
+	w.Place(&Entity{Kind: Turret, OwnerID: "a", X: 300, Y: 100})
+	w.Spawn(&Bullet{OwnerID: "b", X: 100, Y: 100, Velocity: 50, Damage: EntityDefs[Turret].Health})
+
+	var impacts []Impact
+	for range 10 {
+		impacts = append(impacts, w.Step()...)
+	}
+	if len(impacts) != 1 || impacts[0].EntityID == 0 {
+		t.Fatalf("impacts = %+v, want one on the turret", impacts)
+	}
+	if len(w.Entities) != 0 {
+		t.Error("turret left after being destroyed")
+	}
+}
+
+func TestBarricadeBlocksBulletsAndSight(t *testing.T) {
+	w := NewWorld(&level.Level{Width: 2000, Height: 2000})
+	// Facing right, so the wall runs up and down across the bullet
+	w.Place(&Entity{Kind: Barricade, OwnerID: "a", X: 300, Y: 100})
+	if w.LineOfSight(100, 100, 500, 100) {
+		t.Error("line of sight through the barricade")
+	}
+	w.Spawn(&Bullet{OwnerID: "a", X: 100, Y: 100, Velocity: 50, Damage: 10})
+
+	var impacts []Impact
+	for range 10 {
+		impacts = append(impacts, w.Step()...)
+	}
+	if len(impacts) != 1 || impacts[0].EntityID == 0 || impacts[0].Destroyed {
+		t.Fatalf("impacts = %+v, want one on the barricade", impacts)
+	}
+	if got := w.EntityList()[0].Health; got != EntityDefs[Barricade].Health-10 {
+		t.Errorf("Health = %d, want %d", got, EntityDefs[Barricade].Health-10)
+	}
+}
+
+func TestBarricadeDespawns(t *testing.T) {
+	w := NewWorld(&level.Level{Width: 2000, Height: 2000})
+	w.Place(&Entity{Kind: Barricade, OwnerID: "a", X: 300, Y: 100})
+	for range EntityDefs[Barricade].Lifetime - 1 {
+		if len(w.StepEntities().Expired) > 0 {
+			t.Fatal("despawned early")
+		}
+	}
+	if len(w.StepEntities().Expired) != 1 || len(w.Entities) != 0 {
+		t.Error("barricade still there after its lifetime")
+	}
+}
+
+func TestDoorsHoldUp(t *testing.T) {
+	w := NewWorld(&level.Level{Width: 2000, Height: 2000})
+	w.Place(&Entity{Kind: Door, X: 300, Y: 100})
+	w.Spawn(&Bullet{OwnerID: "a", X: 100, Y: 100, Velocity: 50, Damage: 1000})
+	var impacts []Impact
+	for range 10 {
+		impacts = append(impacts, w.Step()...)
+	}
+	if len(impacts) != 1 || impacts[0].EntityID == 0 || impacts[0].Destroyed || len(w.Entities) != 1 {
+		t.Errorf("impacts = %+v, want one on the door leaving it standing", impacts)
+	}
+}
+
+func TestCanPlace(t *testing.T) {
+	wall := game.Object{Walls: game.Rect(300, 0, 20, 2000)}
+	w := NewWorld(&level.Level{Width: 2000, Height: 2000, Objects: []game.Object{wall}})
+	if !w.CanPlace(Barricade, 200, 100, 0) {
+		t.Error("can't place in the open")
+	}
+	// Turned along the wall, the ends stick into it
+	if w.CanPlace(Barricade, 280, 100, math.Pi/2) {
+		t.Error("placed through a wall")
+	}
+	if w.CanPlace("ladder", 200, 100, 0) {
+		t.Error("placed an unknown kind")
+	}
+}
+
+func TestDecoyFakesBursts(t *testing.T) {
+	w := NewWorld(&level.Level{Width: 2000, Height: 2000})
+	w.Place(&Entity{Kind: Decoy, OwnerID: "a", X: 300, Y: 100})
+
+	var ticks []int
+	for tick := 1; tick <= 2*DecoyBurstInterval; tick++ {
+		if len(w.StepEntities().Noises) > 0 {
+			ticks = append(ticks, tick)
+		}
+	}
+	// The first decoy's bursts are 4 shots long
+	want := []int{6, 12, 18, 150, 156, 162, 168, 300}
+	if !slices.Equal(ticks, want) {
+		t.Errorf("noises on ticks %v, want %v", ticks, want)
+	}
+}
+
+func TestBulletsPassThroughDrops(t *testing.T) {
+	w := NewWorld(&level.Level{Width: 2000, Height: 2000})
+	w.Place(&Entity{Kind: Corpse, OwnerID: "a", X: 300, Y: 100})
+	w.Place(&Entity{Kind: WeaponDrop, OwnerID: "a", X: 400, Y: 100, Weapon: "shotgun", Ammo: 6})
+	w.Spawn(&Bullet{OwnerID: "b", X: 100, Y: 100, Velocity: 50, Damage: 10})
+
+	for range 10 {
+		for _, impact := range w.Step() {
+			if impact.EntityID != 0 {
+				t.Fatalf("bullet hit entity %d", impact.EntityID)
+			}
+		}
+	}
+	if len(w.Entities) != 2 {
+		t.Errorf("%d entities left, want 2", len(w.Entities))
+	}
+}
+
+func TestPickUp(t *testing.T) {
+	w := NewWorld(&level.Level{Width: 2000, Height: 2000})
+	drop := &Entity{Kind: WeaponDrop, OwnerID: "dead", X: 100, Y: 100, Weapon: "shotgun", Ammo: 6}
+	w.Place(drop)
+	w.Players["far"] = &Player{X: 100, Y: 100 + PickupReach + 1, Health: 100}
+	w.Players["ghost"] = &Player{X: 100, Y: 100}
+	w.Players["near"] = &Player{X: 110, Y: 100, Health: 100}
+
+	for _, id := range []string{"far", "ghost"} {
+		if _, ok := w.PickUp(id, drop.ID); ok {
+			t.Errorf("%s picked up the drop", id)
+		}
+	}
+	e, ok := w.PickUp("near", drop.ID)
+	if !ok || e.Ammo != 6 {
+		t.Fatalf("PickUp = %+v, %v, want the drop with its 6 rounds", e, ok)
+	}
+	if _, ok := w.PickUp("near", drop.ID); ok {
+		t.Error("drop picked up twice")
+	}
+}
+
+func TestAirdropLands(t *testing.T) {
+	w := NewWorld(&level.Level{Width: 2000, Height: 2000})
+	w.Place(&Entity{Kind: Airdrop, X: 100, Y: 100, Weapon: "rifle", Ammo: 90})
+	for range EntityDefs[Airdrop].Lifetime - 1 {
+		if len(w.StepEntities().Landed) > 0 {
+			t.Fatal("landed early")
+		}
+	}
+	landed := w.StepEntities().Landed
+	if len(landed) != 1 || len(w.Entities) != 1 || landed[0].Kind != Crate || landed[0].Weapon != "rifle" || landed[0].Ammo != 90 {
+		t.Fatalf("landed %+v, want a crate with the rifle in place of the marker", landed)
+	}
+	w.Players["a"] = &Player{X: 100, Y: 100, Health: 100}
+	if _, ok := w.PickUp("a", landed[0].ID); !ok {
+		t.Error("crate can't be picked up")
+	}
+}
+
+func TestScanSkipsJammed(t *testing.T) {
+	w := NewWorld(&level.Level{Width: 2000, Height: 2000})
+	w.Players["a"] = &Player{X: 100, Y: 100, Health: 100, Team: "red"}
+	w.Players["mate"] = &Player{X: 150, Y: 100, Health: 100, Team: "red"}
+	w.Players["b"] = &Player{X: 300, Y: 100, Health: 100, Team: "blue"}
+	w.Players["c"] = &Player{X: 100, Y: 600, Health: 100, Team: "blue"}
+	w.Players["dead"] = &Player{X: 200, Y: 200, Health: 0, Team: "blue"}
+	w.Players["far"] = &Player{X: 1900, Y: 1900, Health: 100, Team: "blue"}
+
+	if got := w.Scan("a", 100, 100, 800); !slices.Equal(got, []string{"b", "c"}) {
+		t.Errorf("scan found %v, want b and c", got)
+	}
+	w.Place(&Entity{Kind: Jammer, OwnerID: "c", X: 100, Y: 550})
+	if got := w.Scan("a", 100, 100, 800); !slices.Equal(got, []string{"b"}) {
+		t.Errorf("scan found %v with c jammed, want b", got)
+	}
+	// Jammers don't hide anyone from their own side
+	if got := w.Scan("b", 300, 100, 800); !slices.Equal(got, []string{"a", "mate"}) {
+		t.Errorf("blue scan found %v, want a and mate", got)
+	}
+}
+
+func TestVehicles(t *testing.T) {
+	w := NewWorld(&level.Level{Width: 2000, Height: 2000, Spawns: [][2]float64{{300, 300}}})
+	parked := w.ParkVehicles(1)
+	if len(parked) != 1 {
+		t.Fatalf("parked %d buggies, want 1", len(parked))
+	}
+	car := parked[0]
+	w.Players["a"] = &Player{X: 320, Y: 300, Health: 100}
+	w.Players["b"] = &Player{X: 280, Y: 300, Health: 100}
+	w.Players["c"] = &Player{X: 300, Y: 340, Health: 100}
+	w.Players["far"] = &Player{X: 900, Y: 300, Health: 100}
+
+	if w.Ride("far", car.ID) {
+		t.Error("got in from across the map")
+	}
+	if !w.Ride("a", car.ID) || !w.Ride("b", car.ID) || w.Ride("c", car.ID) || w.Ride("a", car.ID) {
+		t.Fatalf("riders %v, want a driving and b along", car.Riders)
+	}
+	if w.Drive("b", vehicle.Controls{Throttle: 1}) || !w.Drive("a", vehicle.Controls{Throttle: 1}) {
+		t.Error("only the driver drives")
+	}
+	for range TPS {
+		w.StepEntities()
+	}
+	if car.X <= 300 || w.Players["a"].X != car.X || w.Players["b"].Y != car.Y {
+		t.Errorf("buggy at %v, %v with riders at %+v and %+v", car.X, car.Y, w.Players["a"], w.Players["b"])
+	}
+
+	// The riders' own bullets go through their buggy, others' hit it
+	w.Spawn(&Bullet{OwnerID: "b", X: car.X - 5, Y: car.Y, Velocity: 50, Damage: 10})
+	w.Spawn(&Bullet{OwnerID: "far", X: car.X, Y: car.Y + 200, Direction: -math.Pi / 2, Velocity: 50, Damage: 10})
+	for range 5 {
+		w.Step()
+	}
+	if car.Health != EntityDefs[Vehicle].Health-10 || w.Players["a"].Health != 100 {
+		t.Errorf("buggy health %d and driver's %d, want the buggy hit once", car.Health, w.Players["a"].Health)
+	}
+
+	if _, ok := w.Leave("a"); !ok || !slices.Equal(car.Riders, []string{"b"}) || !w.Drive("b", vehicle.Controls{}) {
+		t.Errorf("riders %v after the driver left, want b driving", car.Riders)
+	}
+}
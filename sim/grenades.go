@@ -0,0 +1,124 @@
+package sim
+
+import (
+	"math"
+	"slices"
+
+	"shooter/grenade"
+	"shooter/match"
+	"shooter/weapon"
+)
+
+// BlastRadius is how far explosions reach, damage falls off linearly to 0 at the edge.
+const BlastRadius = 150.0
+
+// Rays from a blast to points across a player's body, walls blocking some of them take
+// their share of the damage
+const exposureRays = 3
+
+// Grenade follows its precomputed path, one point per tick.
+type Grenade struct {
+	ID      uint64       `json:"id,omitempty"`
+	OwnerID string       `json:"owner_id"`
+	Path    grenade.Path `json:"-"`
+	// Entity placed where it lands instead of exploding, like a decoy
+	Payload EntityKind `json:"payload,omitempty"`
+	ticks   int
+}
+
+// BlastHit is damage dealt to a player by an explosion.
+type BlastHit struct {
+	VictimID string
+	Damage   int
+	// Meant for a teammate of the thrower, who took it instead, see match.FriendlyFireReflect
+	Reflected bool
+}
+
+// Explosion is a grenade whose fuse ran out in a step, with the players it hit sorted by ID.
+type Explosion struct {
+	Grenade *Grenade
+	X, Y    float64
+	Hits    []BlastHit
+}
+
+// Throw simulates the grenade's flight from s and adds it.
+func (w *World) Throw(g *Grenade, s grenade.State) {
+	w.nextID++
+	g.ID = w.nextID
+	g.Path = grenade.Simulate(s, w.Objects())
+	w.Grenades[g.ID] = g
+}
+
+// StepGrenades moves grenades one tick, damages the players near those exploding and
+// returns the explosions ordered by grenade ID. Grenades with a payload don't explode,
+// they are returned as landed for their entity to be placed.
+func (w *World) StepGrenades() (explosions []Explosion, landed []*Grenade) {
+	ids := make([]uint64, 0, len(w.Grenades))
+	for id := range w.Grenades {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+
+	for _, id := range ids {
+		g := w.Grenades[id]
+		g.ticks++
+		if g.ticks < len(g.Path.Points)-1 {
+			continue
+		}
+		if g.Payload != "" {
+			delete(w.Grenades, id)
+			landed = append(landed, g)
+			continue
+		}
+		end := g.Path.End()
+		e := Explosion{Grenade: g, X: end.X, Y: end.Y}
+		pids := make([]string, 0, len(w.Players))
+		for pid := range w.Players {
+			pids = append(pids, pid)
+		}
+		slices.Sort(pids)
+		for _, pid := range pids {
+			p := w.Players[pid]
+			d := math.Hypot(p.X-end.X, p.Y-end.Y)
+			if p.Health <= 0 || d >= BlastRadius || w.FriendlyFire == match.FriendlyFireOff && w.Teammates(g.OwnerID, pid) {
+				continue
+			}
+			exposure := w.Exposure(end.X, end.Y, p.X, p.Y)
+			if exposure == 0 {
+				continue
+			}
+			damage := int(math.Ceil(float64(weapon.Get(weapon.Grenade).Damage) * (1 - d/BlastRadius) * exposure))
+			if w.reflects(g.OwnerID, pid) {
+				e.Hits = append(e.Hits, BlastHit{VictimID: g.OwnerID, Damage: w.hurt(g.OwnerID, damage), Reflected: true})
+				continue
+			}
+			damage = w.hurt(pid, damage)
+			w.Ledger.Record(pid, g.OwnerID, damage, w.ticks)
+			e.Hits = append(e.Hits, BlastHit{VictimID: pid, Damage: damage})
+		}
+		delete(w.Grenades, id)
+		explosions = append(explosions, e)
+	}
+	return explosions, landed
+}
+
+// Exposure is the fraction of a player at px, py in line of sight from x, y, 0 behind full cover.
+// The rays go to the player's center and to both sides of it across the line, so an edge of
+// cover takes part of a blast. Walls of entities count as cover.
+func (w *World) Exposure(x, y, px, py float64) float64 {
+	d := math.Hypot(px-x, py-y)
+	if d == 0 {
+		return 1
+	}
+	// Across the line from the blast, half the hit radius to each side
+	ax, ay := -(py-y)/d*HitRadius/2, (px-x)/d*HitRadius/2
+	objects := w.Objects()
+	seen := 0
+	for i := range exposureRays {
+		k := float64(i - exposureRays/2)
+		if lineOfSight(objects, x, y, px+ax*k, py+ay*k) {
+			seen++
+		}
+	}
+	return float64(seen) / exposureRays
+}
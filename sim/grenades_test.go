@@ -0,0 +1,72 @@
+package sim
+
+import (
+	"testing"
+
+	"shooter/game"
+	"shooter/grenade"
+	"shooter/level"
+)
+
+func TestGrenadeExplodes(t *testing.T) {
+	w := NewWorld(&level.Level{Width: 2000, Height: 2000})
+	s := grenade.Throw(100, 100, 0, 0)
+	w.Players["near"] = &Player{X: 100, Y: 100, Health: 100}
+	w.Players["edge"] = &Player{X: 100 + BlastRadius/2, Y: 100, Health: 100}
+	w.Players["far"] = &Player{X: 100 + BlastRadius, Y: 100, Health: 100}
+	w.Throw(&Grenade{OwnerID: "near"}, s)
+
+	var explosions []Explosion
+	for range grenade.Fuse {
+		if len(explosions) > 0 {
+			t.Fatal("exploded before the fuse ran out")
+		}
+		explosions, _ = w.StepGrenades()
+	}
+	if len(explosions) != 1 {
+		t.Fatalf("%d explosions, want 1", len(explosions))
+	}
+	want := []BlastHit{{VictimID: "edge", Damage: 50}, {VictimID: "near", Damage: 100}}
+	if got := explosions[0].Hits; len(got) != 2 || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("Hits = %+v, want %+v", got, want)
+	}
+	if len(w.Grenades) != 0 {
+		t.Error("grenade left after exploding")
+	}
+}
+
+func TestPayloadLandsInsteadOfExploding(t *testing.T) {
+	w := NewWorld(&level.Level{Width: 2000, Height: 2000})
+	w.Players["a"] = &Player{X: 100, Y: 100, Health: 100}
+	w.Throw(&Grenade{OwnerID: "a", Payload: Decoy}, grenade.Throw(100, 100, 0, 0))
+
+	var landed []*Grenade
+	for range grenade.Fuse {
+		explosions, l := w.StepGrenades()
+		if len(explosions) > 0 {
+			t.Fatal("decoy exploded")
+		}
+		landed = append(landed, l...)
+	}
+	if len(landed) != 1 || w.Players["a"].Health != 100 {
+		t.Errorf("landed %d, health %d, want 1 landed without damage", len(landed), w.Players["a"].Health)
+	}
+}
+
+func TestExposure(t *testing.T) {
+	// Wall between x 190 and 210 from y 0 down to y 100, covering the upper half of a player at y 100
+	wall := game.Object{Walls: game.Rect(190, 0, 20, 100)}
+	w := NewWorld(&level.Level{Width: 2000, Height: 2000, Objects: []game.Object{wall}})
+	for _, tc := range []struct {
+		y    float64
+		want float64
+	}{
+		{50, 0},
+		{100, 1.0 / 3},
+		{300, 1},
+	} {
+		if got := w.Exposure(100, tc.y, 300, tc.y); got != tc.want {
+			t.Errorf("Exposure at y %v = %v, want %v", tc.y, got, tc.want)
+		}
+	}
+}
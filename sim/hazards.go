@@ -0,0 +1,44 @@
+package sim
+
+import "shooter/weapon"
+
+// HazardInterval is how often hazards and the zone deal their damage, in ticks.
+const HazardInterval = TPS / 2
+
+// HazardHit is damage dealt to a player standing in a hazard or outside the zone.
+type HazardHit struct {
+	VictimID string
+	Damage   int
+	// weapon.Zone or the ID named after the level.HazardKind
+	Weapon weapon.ID
+}
+
+// StepHazards advances the hazards one tick and damages the living players standing in them,
+// or outside the zone, every HazardInterval. Hits are ordered by player ID.
+func (w *World) StepHazards() []HazardHit {
+	w.ticks++
+	if w.ticks%HazardInterval != 0 {
+		return nil
+	}
+	var hits []HazardHit
+	hit := func(id string, p *Player, dps int, gun weapon.ID) {
+		damage := dps * HazardInterval / TPS
+		if p.Health <= 0 || damage <= 0 {
+			return
+		}
+		p.Health = max(0, p.Health-damage)
+		hits = append(hits, HazardHit{VictimID: id, Damage: damage, Weapon: gun})
+	}
+	for _, id := range w.playerIDs() {
+		p := w.Players[id]
+		for _, h := range w.Level.Hazards {
+			if h.Contains(p.X, p.Y) {
+				hit(id, p, h.Effect().DPS, weapon.ID(h.Kind))
+			}
+		}
+		if w.Zone != nil && !w.Zone.Current.Contains(p.X, p.Y) {
+			hit(id, p, w.Zone.DPS(), weapon.Zone)
+		}
+	}
+	return hits
+}
@@ -0,0 +1,49 @@
+package sim
+
+import (
+	"testing"
+
+	"shooter/level"
+	"shooter/weapon"
+	"shooter/zone"
+)
+
+func TestHazardsDamageOverTime(t *testing.T) {
+	w := NewWorld(&level.Level{Width: 2000, Height: 2000, Hazards: []level.Hazard{
+		{Kind: level.Fire, X: 0, Y: 0, Width: 200, Height: 200},
+		{Kind: level.Mud, X: 200, Y: 0, Width: 200, Height: 200},
+	}})
+	w.Players["burning"] = &Player{X: 100, Y: 100, Health: 100}
+	w.Players["muddy"] = &Player{X: 300, Y: 100, Health: 100}
+	w.Players["safe"] = &Player{X: 1000, Y: 1000, Health: 100}
+
+	var hits []HazardHit
+	for range TPS {
+		hits = append(hits, w.StepHazards()...)
+	}
+	dps := level.HazardEffects[level.Fire].DPS
+	if len(hits) != 2 || hits[0].VictimID != "burning" || hits[0].Weapon != weapon.Fire || hits[0].Damage+hits[1].Damage != dps {
+		t.Errorf("hits = %+v, want burning twice for %d", hits, dps)
+	}
+	if w.Players["burning"].Health != 100-dps || w.Players["muddy"].Health != 100 || w.Players["safe"].Health != 100 {
+		t.Error("wrong players damaged")
+	}
+}
+
+func TestZoneDamage(t *testing.T) {
+	w := NewWorld(&level.Level{Width: 2000, Height: 2000})
+	w.Zone = &zone.State{Current: zone.Circle{X: 500, Y: 500, Radius: 200}, Phase: 2}
+	w.Players["inside"] = &Player{X: 550, Y: 450, Health: 100}
+	w.Players["outside"] = &Player{X: 1000, Y: 1000, Health: 100}
+
+	var hits []HazardHit
+	for range TPS {
+		hits = append(hits, w.StepHazards()...)
+	}
+	if len(hits) != 2 || hits[0].VictimID != "outside" || hits[0].Weapon != weapon.Zone {
+		t.Errorf("hits = %+v, want outside twice", hits)
+	}
+	if w.Players["outside"].Health != 100-w.Zone.DPS() || w.Players["inside"].Health != 100 {
+		t.Error("wrong players damaged")
+	}
+}
@@ -0,0 +1,88 @@
+package sim
+
+import (
+	"cmp"
+	"slices"
+)
+
+const (
+	// Damage older than this when the victim dies earns no credit, in ticks
+	AssistWindow = 10 * TPS
+	// Least damage to the victim within AssistWindow earning an assist
+	AssistMinDamage = 20
+)
+
+// Contribution is damage dealt to a player.
+type Contribution struct {
+	AttackerID string
+	Damage     int
+	Tick       int
+}
+
+// Ledger keeps who recently damaged each player, to credit kills and assists when they die.
+type Ledger struct {
+	damage map[string][]Contribution
+}
+
+// Record adds damage dealt by the attacker, forgetting the victim's contributions older than AssistWindow.
+func (l *Ledger) Record(victimID, attackerID string, damage, tick int) {
+	if attackerID == "" || attackerID == victimID || damage <= 0 {
+		return
+	}
+	if l.damage == nil {
+		l.damage = map[string][]Contribution{}
+	}
+	recent := slices.DeleteFunc(l.damage[victimID], func(c Contribution) bool { return tick-c.Tick > AssistWindow })
+	l.damage[victimID] = append(recent, Contribution{AttackerID: attackerID, Damage: damage, Tick: tick})
+}
+
+// Credit settles the victim's death and clears their ledger. The killer the victim names keeps the
+// kill when the ledger has their damage within AssistWindow, anyone else the victim names, the map
+// or themselves, is replaced by whoever dealt the most. Suicides without damage by others stay
+// theirs, other deaths without any go to no one. Others who dealt at least AssistMinDamage get
+// assists, most damage first.
+func (l *Ledger) Credit(victimID, killerID string, tick int) (string, []string) {
+	totals := map[string]int{}
+	var attackers []string
+	for _, c := range l.damage[victimID] {
+		if tick-c.Tick > AssistWindow {
+			continue
+		}
+		if _, ok := totals[c.AttackerID]; !ok {
+			attackers = append(attackers, c.AttackerID)
+		}
+		totals[c.AttackerID] += c.Damage
+	}
+	delete(l.damage, victimID)
+
+	slices.SortStableFunc(attackers, func(a, b string) int {
+		return cmp.Or(totals[b]-totals[a], cmp.Compare(a, b))
+	})
+	// Victims report their own deaths, naming a killer who never hurt them doesn't credit them
+	if _, dealt := totals[killerID]; !dealt && (killerID != victimID || len(attackers) > 0) {
+		killerID = ""
+		if len(attackers) > 0 {
+			killerID = attackers[0]
+		}
+	}
+	var assists []string
+	for _, id := range attackers {
+		if id != killerID && totals[id] >= AssistMinDamage {
+			assists = append(assists, id)
+		}
+	}
+	return killerID, assists
+}
+
+// Credit settles the player's death by the ledger as of now, see Ledger.Credit.
+func (w *World) Credit(victimID, killerID string) (string, []string) {
+	return w.Ledger.Credit(victimID, killerID, w.ticks)
+}
+
+// Forget drops the player's ledger and their damage to others, for when they leave.
+func (l *Ledger) Forget(id string) {
+	delete(l.damage, id)
+	for victim, contributions := range l.damage {
+		l.damage[victim] = slices.DeleteFunc(contributions, func(c Contribution) bool { return c.AttackerID == id })
+	}
+}
@@ -0,0 +1,55 @@
+package sim
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestLedgerCredit(t *testing.T) {
+	var l Ledger
+	l.Record("carol", "alice", 30, 0)
+	l.Record("carol", "bob", 50, 10)
+	l.Record("carol", "dave", 10, 20)
+	l.Record("carol", "alice", 40, 30)
+
+	killer, assists := l.Credit("carol", "bob", 40)
+	if killer != "bob" || !slices.Equal(assists, []string{"alice"}) {
+		t.Errorf("Credit = %q, %v, want bob assisted by alice", killer, assists)
+	}
+	if killer, assists := l.Credit("carol", "bob", 40); killer != "" || len(assists) != 0 {
+		t.Errorf("second Credit = %q, %v, want the ledger cleared", killer, assists)
+	}
+}
+
+func TestLedgerCreditsMapDeaths(t *testing.T) {
+	var l Ledger
+	l.Record("carol", "alice", 30, 0)
+	l.Record("carol", "bob", 50, 0)
+	if killer, assists := l.Credit("carol", "", 10); killer != "bob" || !slices.Equal(assists, []string{"alice"}) {
+		t.Errorf("Credit = %q, %v, want bob assisted by alice", killer, assists)
+	}
+}
+
+func TestLedgerExpires(t *testing.T) {
+	var l Ledger
+	l.Record("carol", "alice", 90, 0)
+	l.Record("carol", "bob", 10, AssistWindow)
+	if killer, assists := l.Credit("carol", "", AssistWindow+1); killer != "bob" || len(assists) != 0 {
+		t.Errorf("Credit = %q, %v, want bob alone", killer, assists)
+	}
+}
+
+func TestLedgerIgnoresClaimedKillers(t *testing.T) {
+	var l Ledger
+	l.Record("carol", "alice", 30, 0)
+	l.Record("carol", "bob", 50, 0)
+	if killer, _ := l.Credit("carol", "mallory", 10); killer != "bob" {
+		t.Errorf("Credit = %q, want bob for a killer who dealt no damage", killer)
+	}
+	if killer, _ := l.Credit("dave", "mallory", 10); killer != "" {
+		t.Errorf("Credit = %q without damage, want no killer", killer)
+	}
+	if killer, _ := l.Credit("dave", "dave", 10); killer != "dave" {
+		t.Errorf("Credit = %q, want the suicide kept", killer)
+	}
+}
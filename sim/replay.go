@@ -0,0 +1,98 @@
+package sim
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"shooter/level"
+)
+
+// Replay is a recorded sequence of player movement and shots, with the outcome it should have.
+type Replay struct {
+	Level string `json:"level"`
+	Ticks int    `json:"ticks"`
+	// Players at the start
+	Players map[string]Player `json:"players"`
+	Inputs  []Input           `json:"inputs"`
+	Expect  Result            `json:"expect"`
+}
+
+// Input happens at the start of Tick, before the bullets move.
+type Input struct {
+	Tick  int                 `json:"tick"`
+	Moves map[string]Position `json:"moves,omitempty"`
+	Shots []Bullet            `json:"shots,omitempty"`
+}
+
+type Position struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+type Hit struct {
+	Tick       int    `json:"tick"`
+	AttackerID string `json:"attacker_id"`
+	VictimID   string `json:"victim_id"`
+	Damage     int    `json:"damage"`
+}
+
+type Result struct {
+	Hits []Hit `json:"hits"`
+	// Players after the last tick
+	Players map[string]Player `json:"players"`
+}
+
+func LoadReplay(path string) (*Replay, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var r Replay
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &r, nil
+}
+
+// Run plays the replay from the start and returns what happened.
+func (r *Replay) Run() (Result, error) {
+	lvl, ok := level.Get(r.Level)
+	if !ok {
+		return Result{}, fmt.Errorf("unknown level %q", r.Level)
+	}
+	w := NewWorld(lvl)
+	for id, p := range r.Players {
+		w.Players[id] = &p
+	}
+
+	result := Result{Hits: []Hit{}, Players: map[string]Player{}}
+	inputs := r.Inputs
+	for tick := range r.Ticks {
+		for len(inputs) > 0 && inputs[0].Tick == tick {
+			for id, pos := range inputs[0].Moves {
+				if p, ok := w.Players[id]; ok {
+					p.X, p.Y = pos.X, pos.Y
+				}
+			}
+			for _, b := range inputs[0].Shots {
+				w.Spawn(&b)
+			}
+			inputs = inputs[1:]
+		}
+		for _, impact := range w.Step() {
+			if impact.VictimID != "" {
+				result.Hits = append(result.Hits, Hit{
+					Tick:       tick,
+					AttackerID: impact.Bullet.OwnerID,
+					VictimID:   impact.VictimID,
+					Damage:     impact.Bullet.Damage,
+				})
+			}
+		}
+	}
+	for id, p := range w.Players {
+		result.Players[id] = *p
+	}
+	return result, nil
+}
@@ -0,0 +1,50 @@
+package sim
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the expected results of the replays in testdata")
+
+// TestReplays plays every replay in testdata and compares the outcome with the recorded one.
+func TestReplays(t *testing.T) {
+	paths, err := filepath.Glob("testdata/*.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) == 0 {
+		t.Fatal("no replays in testdata")
+	}
+	for _, path := range paths {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			r, err := LoadReplay(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := r.Run()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if *update {
+				r.Expect = got
+				data, err := json.MarshalIndent(r, "", "  ")
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if !reflect.DeepEqual(got, r.Expect) {
+				t.Errorf("Run() = %+v, want %+v", got, r.Expect)
+			}
+		})
+	}
+}
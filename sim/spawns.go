@@ -0,0 +1,84 @@
+package sim
+
+import (
+	"math"
+	"slices"
+
+	"shooter/game"
+	"shooter/level"
+)
+
+// SpawnSpacing is how close another living player may be to a spawn before they stand on it.
+const SpawnSpacing = 2 * HitRadius
+
+// spawnRisk ranks spawn points, lower is better in the order of the fields.
+type spawnRisk struct {
+	// Inside an obstacle or too close to a wall
+	obstructed bool
+	occupied   bool
+	// Living enemies who can see it
+	seen int
+	// Of the closest living enemy, negated
+	distance float64
+}
+
+func (r spawnRisk) safe() bool {
+	return !r.obstructed && !r.occupied && r.seen == 0
+}
+
+func (r spawnRisk) less(o spawnRisk) bool {
+	switch {
+	case r.obstructed != o.obstructed:
+		return !r.obstructed
+	case r.occupied != o.occupied:
+		return !r.occupied
+	case r.seen != o.seen:
+		return r.seen < o.seen
+	}
+	return r.distance < o.distance
+}
+
+// risk is what playerID would face spawning at x, y.
+func (w *World) risk(playerID string, x, y float64, objects []game.Object) spawnRisk {
+	r := spawnRisk{
+		obstructed: w.Level.InsideObstacle(x, y) || game.Clearance(x, y, objects) < level.SpawnClearance,
+		distance:   math.Inf(-1),
+	}
+	for _, id := range w.playerIDs() {
+		p := w.Players[id]
+		if id == playerID || p.Health <= 0 {
+			continue
+		}
+		d := math.Hypot(p.X-x, p.Y-y)
+		r.occupied = r.occupied || d < SpawnSpacing
+		if w.Teammates(playerID, id) {
+			continue
+		}
+		r.distance = math.Max(r.distance, -d)
+		if lineOfSight(objects, p.X, p.Y, x, y) {
+			r.seen++
+		}
+	}
+	return r
+}
+
+// SafeSpawn checks where playerID spawned, x, y: one of the level's spawn points, in the clear, with
+// nobody standing there and out of sight of living enemies. A spawn which isn't is moved to the best
+// of the level's: the safe one farthest from enemies, failing that the one seen by the fewest. It
+// reports whether it moved it.
+func (w *World) SafeSpawn(playerID string, x, y float64) (float64, float64, bool) {
+	objects := w.Objects()
+	spawns := w.Level.SpawnPoints()
+	// Anywhere else could be a spot of the client's choosing, however safe
+	if slices.Contains(spawns, [2]float64{x, y}) && w.risk(playerID, x, y, objects).safe() {
+		return x, y, false
+	}
+	var best spawnRisk
+	bestX, bestY := x, y
+	for i, s := range spawns {
+		if r := w.risk(playerID, s[0], s[1], objects); i == 0 || r.less(best) {
+			best, bestX, bestY = r, s[0], s[1]
+		}
+	}
+	return bestX, bestY, bestX != x || bestY != y
+}
@@ -0,0 +1,93 @@
+package sim
+
+import (
+	"testing"
+
+	"shooter/game"
+	"shooter/level"
+)
+
+func spawnLevel() *level.Level {
+	return &level.Level{
+		Width:  1000,
+		Height: 1000,
+		Objects: []game.Object{
+			{Walls: game.Rect(0, 0, 1000, 1000)},
+			// Splits the level into a west and an east half with a gap at the bottom
+			{Walls: game.Rect(490, 0, 20, 800)},
+		},
+		Spawns: [][2]float64{{100, 100}, {900, 100}, {100, 700}, {900, 700}},
+	}
+}
+
+func TestSafeSpawnKeepsSafePoint(t *testing.T) {
+	w := NewWorld(spawnLevel())
+	w.Players["enemy"] = &Player{X: 800, Y: 300, Health: 100}
+	if x, y, moved := w.SafeSpawn("me", 100, 100); moved || x != 100 || y != 100 {
+		t.Errorf("SafeSpawn() = %v, %v, %v, want the safe spawn kept", x, y, moved)
+	}
+	// The dead see nothing
+	w.Players["corpse"] = &Player{X: 150, Y: 150}
+	if _, _, moved := w.SafeSpawn("me", 100, 100); moved {
+		t.Error("SafeSpawn() moved away from a dead player")
+	}
+}
+
+func TestSafeSpawnFallsBack(t *testing.T) {
+	tests := []struct {
+		name    string
+		at      [2]float64
+		players map[string]*Player
+		want    [2]float64
+	}{
+		{
+			name:    "seen by an enemy",
+			at:      [2]float64{100, 100},
+			players: map[string]*Player{"enemy": {X: 300, Y: 300, Health: 100}},
+			// The east spawns are out of sight, the farther one wins
+			want: [2]float64{900, 700},
+		},
+		{
+			name:    "not a spawn",
+			at:      [2]float64{300, 100},
+			players: map[string]*Player{},
+			want:    [2]float64{100, 100},
+		},
+		{
+			name:    "inside an obstacle",
+			at:      [2]float64{500, 400},
+			players: map[string]*Player{},
+			want:    [2]float64{100, 100},
+		},
+		{
+			name: "occupied by a teammate",
+			at:   [2]float64{100, 100},
+			players: map[string]*Player{
+				"me":   {Team: "red"},
+				"mate": {X: 110, Y: 100, Health: 100, Team: "red"},
+			},
+			want: [2]float64{900, 100},
+		},
+		{
+			name: "seen everywhere",
+			at:   [2]float64{100, 100},
+			players: map[string]*Player{
+				"west": {X: 300, Y: 400, Health: 100},
+				"east": {X: 800, Y: 500, Health: 100},
+				"gap":  {X: 500, Y: 900, Health: 100},
+			},
+			// Every spawn is seen by two of them, 900, 100 is farthest from the closest
+			want: [2]float64{900, 100},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := NewWorld(spawnLevel())
+			w.Players = tt.players
+			x, y, moved := w.SafeSpawn("me", tt.at[0], tt.at[1])
+			if !moved || x != tt.want[0] || y != tt.want[1] {
+				t.Errorf("SafeSpawn() = %v, %v, %v, want %v", x, y, moved, tt.want)
+			}
+		})
+	}
+}
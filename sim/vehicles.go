@@ -0,0 +1,105 @@
+package sim
+
+import (
+	"math"
+	"slices"
+
+	"shooter/vehicle"
+)
+
+// Buggies a server parks at most
+const MaxVehicles = 8
+
+// ParkVehicles parks up to n buggies at the level's spawns, those without room are left out.
+func (w *World) ParkVehicles(n int) []*Entity {
+	var parked []*Entity
+	for i := range min(n, MaxVehicles) {
+		x, y := w.Level.Spawn(i)
+		if !w.CanPlace(Vehicle, x, y, 0) {
+			continue
+		}
+		e := &Entity{Kind: Vehicle, X: x, Y: y}
+		w.Place(e)
+		parked = append(parked, e)
+	}
+	return parked
+}
+
+// Ride puts a living player in a free seat of the vehicle within vehicle.EnterReach, the first
+// one in drives. False when they can't get in.
+func (w *World) Ride(playerID string, id uint64) bool {
+	e, ok := w.Entities[id]
+	p, alive := w.Players[playerID]
+	if !ok || !alive || e.Kind != Vehicle || p.Health <= 0 || len(e.Riders) >= vehicle.Seats ||
+		math.Hypot(e.X-p.X, e.Y-p.Y) > vehicle.EnterReach {
+		return false
+	}
+	if _, riding := w.Riding(playerID); riding {
+		return false
+	}
+	e.Riders = append(e.Riders, playerID)
+	return true
+}
+
+// Leave takes the player out of the vehicle they ride, a passenger takes the wheel when the
+// driver leaves. It returns the vehicle, false when they weren't in one.
+func (w *World) Leave(playerID string) (*Entity, bool) {
+	e, ok := w.Riding(playerID)
+	if !ok {
+		return nil, false
+	}
+	if e.Riders[0] == playerID {
+		e.controls = vehicle.Controls{}
+	}
+	e.Riders = slices.DeleteFunc(e.Riders, func(id string) bool { return id == playerID })
+	return e, true
+}
+
+// Riding returns the vehicle the player is in.
+func (w *World) Riding(playerID string) (*Entity, bool) {
+	for _, id := range w.entityIDs() {
+		if e := w.Entities[id]; e.Kind == Vehicle && slices.Contains(e.Riders, playerID) {
+			return e, true
+		}
+	}
+	return nil, false
+}
+
+// Drive sets the controls of the vehicle the player drives until they send the next, false
+// when they aren't driving.
+func (w *World) Drive(playerID string, c vehicle.Controls) bool {
+	e, ok := w.Riding(playerID)
+	if !ok || e.Riders[0] != playerID {
+		return false
+	}
+	e.controls = c
+	return true
+}
+
+// State is where the vehicle is for driving it.
+func (e *Entity) State() vehicle.State {
+	return vehicle.State{X: e.X, Y: e.Y, Angle: e.Angle, Speed: e.Speed}
+}
+
+// seats is the vehicle each rider is in, riders don't shoot those in the same one.
+func (w *World) seats() map[string]uint64 {
+	seats := map[string]uint64{}
+	for id, e := range w.Entities {
+		for _, rider := range e.Riders {
+			seats[rider] = id
+		}
+	}
+	return seats
+}
+
+// stepVehicle drives the vehicle one tick, its riders go along.
+func (w *World) stepVehicle(e *Entity) {
+	s := e.State()
+	s.Step(e.controls, w.Objects())
+	e.X, e.Y, e.Angle, e.Speed = s.X, s.Y, s.Angle, s.Speed
+	for _, id := range e.Riders {
+		if p, ok := w.Players[id]; ok {
+			p.X, p.Y = e.X, e.Y
+		}
+	}
+}
@@ -0,0 +1,227 @@
+// Package sim is the server's headless simulation of bullets, grenades and what they hit.
+package sim
+
+import (
+	"math"
+	"slices"
+
+	"shooter/game"
+	"shooter/level"
+	"shooter/match"
+	"shooter/weapon"
+	"shooter/zone"
+)
+
+const (
+	TPS = 60
+	// Players are hit by bullets passing within this distance of their center
+	HitRadius = 25.0
+	// Bullets passing this close to a player's center are headshots
+	HeadRadius = 8.0
+	// Bullets which haven't hit anything by then are removed, in ticks
+	BulletLifetime = 3 * TPS
+)
+
+// Player is what the simulation knows of a player, from its client's updates.
+type Player struct {
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Health int     `json:"health"`
+	Team   string  `json:"team,omitempty"`
+	// Held weapon, dropped on death
+	Weapon weapon.ID `json:"weapon,omitempty"`
+}
+
+// Bullet moves Velocity along Direction every tick from X, Y.
+type Bullet struct {
+	ID        uint64    `json:"id,omitempty"`
+	OwnerID   string    `json:"owner_id"`
+	X         float64   `json:"x"`
+	Y         float64   `json:"y"`
+	Direction float64   `json:"direction"`
+	Velocity  float64   `json:"velocity"`
+	Damage    int       `json:"damage"`
+	Weapon    weapon.ID `json:"weapon"`
+	// Set when fired by an entity rather than by its owner
+	EntityID uint64 `json:"entity_id,omitempty"`
+	ticks    int
+}
+
+// Impact is a bullet removed in a step, none of Wall, VictimID and EntityID are set for expired ones.
+type Impact struct {
+	Bullet   *Bullet
+	X, Y     float64
+	Wall     bool
+	VictimID string
+	Headshot bool
+	// The victim is a teammate and the damage went to the bullet's owner, see match.FriendlyFireReflect
+	Reflected bool
+	// Damage dealt to the victim, or the owner when Reflected, after armor
+	Damage int
+	// Entity hit and whether this destroyed it, removing it from the world
+	EntityID  uint64
+	Destroyed bool
+}
+
+type World struct {
+	Level    *level.Level
+	Players  map[string]*Player
+	Bullets  map[uint64]*Bullet
+	Grenades map[uint64]*Grenade
+	Entities map[uint64]*Entity
+	// Players outside its current circle take damage, nil without a zone
+	Zone *zone.State
+	// Who damaged whom recently, for kill credit and assists
+	Ledger Ledger
+	// What bullets and blasts do to teammates
+	FriendlyFire match.FriendlyFire
+	// Returns the part of the damage to the player their armor lets through, nil without armor
+	Absorb func(id string, damage int) int
+	nextID uint64
+	ticks  int
+}
+
+func NewWorld(lvl *level.Level) *World {
+	return &World{
+		Level:    lvl,
+		Players:  map[string]*Player{},
+		Bullets:  map[uint64]*Bullet{},
+		Grenades: map[uint64]*Grenade{},
+		Entities: map[uint64]*Entity{},
+	}
+}
+
+// Spawn gives the bullet the next ID and adds it.
+func (w *World) Spawn(b *Bullet) {
+	w.nextID++
+	b.ID = w.nextID
+	w.Bullets[b.ID] = b
+}
+
+// Step moves the bullets one tick, damages the players they hit and returns the removed bullets
+// ordered by ID.
+func (w *World) Step() []Impact {
+	var impacts []Impact
+	seats := w.seats()
+	for _, id := range w.bulletIDs() {
+		b := w.Bullets[id]
+		x0, y0 := b.X, b.Y
+		b.X += math.Cos(b.Direction) * b.Velocity
+		b.Y += math.Sin(b.Direction) * b.Velocity
+		b.ticks++
+		path := game.Line{X1: x0, Y1: y0, X2: b.X, Y2: b.Y}
+
+		impact := Impact{Bullet: b}
+		closest := math.Inf(1)
+		for _, o := range w.Level.Objects {
+			for _, wall := range o.Walls {
+				if x, y, ok := game.Intersection(path, wall); ok && math.Hypot(x-x0, y-y0) < closest {
+					closest = math.Hypot(x-x0, y-y0)
+					impact.X, impact.Y, impact.Wall = x, y, true
+				}
+			}
+		}
+		for _, eid := range w.entityIDs() {
+			e := w.Entities[eid]
+			if e.Def().Intangible {
+				continue
+			}
+			// Walls stop everyone's bullets, other entities only those of others than their owner and riders
+			if walls := e.Object().Walls; len(walls) > 0 {
+				for _, wall := range walls {
+					if x, y, ok := game.Intersection(path, wall); ok && math.Hypot(x-x0, y-y0) < closest {
+						closest = math.Hypot(x-x0, y-y0)
+						impact.X, impact.Y = x, y
+						impact.Wall, impact.EntityID = false, eid
+					}
+				}
+				continue
+			}
+			if e.OwnerID == b.OwnerID || slices.Contains(e.Riders, b.OwnerID) {
+				continue
+			}
+			t, d := path.Closest(e.X, e.Y)
+			if along := t * math.Hypot(b.X-x0, b.Y-y0); d <= e.Def().Radius && along < closest {
+				closest = along
+				impact.X, impact.Y = x0+t*(b.X-x0), y0+t*(b.Y-y0)
+				impact.Wall, impact.EntityID = false, eid
+			}
+		}
+		for pid, p := range w.Players {
+			if pid == b.OwnerID || p.Health <= 0 || w.FriendlyFire == match.FriendlyFireOff && w.Teammates(b.OwnerID, pid) ||
+				seats[pid] != 0 && seats[pid] == seats[b.OwnerID] {
+				continue
+			}
+			t, d := path.Closest(p.X, p.Y)
+			// Ties go to the smaller ID so steps are the same every run
+			along := t * math.Hypot(b.X-x0, b.Y-y0)
+			if d <= HitRadius && (along < closest || along == closest && impact.VictimID != "" && pid < impact.VictimID) {
+				closest = along
+				impact.X, impact.Y = x0+t*(b.X-x0), y0+t*(b.Y-y0)
+				impact.Wall, impact.VictimID, impact.EntityID = false, pid, 0
+				impact.Headshot = d <= HeadRadius
+			}
+		}
+
+		switch {
+		case impact.VictimID != "" && w.reflects(b.OwnerID, impact.VictimID):
+			impact.Reflected = true
+			impact.Damage = w.hurt(b.OwnerID, b.Damage)
+		case impact.VictimID != "":
+			impact.Damage = w.hurt(impact.VictimID, b.Damage)
+			w.Ledger.Record(impact.VictimID, b.OwnerID, impact.Damage, w.ticks)
+		case impact.EntityID != 0:
+			if e := w.Entities[impact.EntityID]; e.Def().Indestructible {
+				// Hits it like a wall
+			} else if e.Health <= b.Damage {
+				delete(w.Entities, impact.EntityID)
+				impact.Destroyed = true
+			} else {
+				e.Health -= b.Damage
+			}
+		case impact.Wall:
+		case b.ticks >= BulletLifetime || b.X < 0 || b.Y < 0 || b.X > w.Level.Width || b.Y > w.Level.Height:
+			impact.X, impact.Y = b.X, b.Y
+		default:
+			continue
+		}
+		delete(w.Bullets, id)
+		impacts = append(impacts, impact)
+	}
+	return impacts
+}
+
+// hurt deals the damage getting through the player's armor and returns it, dead and unknown
+// players take none.
+func (w *World) hurt(id string, damage int) int {
+	p, ok := w.Players[id]
+	if !ok || p.Health <= 0 {
+		return 0
+	}
+	if w.Absorb != nil {
+		damage = w.Absorb(id, damage)
+	}
+	p.Health = max(0, p.Health-damage)
+	return damage
+}
+
+// Teammates is true for two players on the same team, players without one have no teammates.
+func (w *World) Teammates(a, b string) bool {
+	pa, ok := w.Players[a]
+	pb, ok2 := w.Players[b]
+	return ok && ok2 && pa.Team != "" && pa.Team == pb.Team
+}
+
+// reflects is true when the attacker's damage to the victim goes back to the attacker.
+func (w *World) reflects(attackerID, victimID string) bool {
+	return w.FriendlyFire == match.FriendlyFireReflect && attackerID != victimID && w.Teammates(attackerID, victimID)
+}
+
+func (w *World) bulletIDs() []uint64 {
+	ids := make([]uint64, 0, len(w.Bullets))
+	for id := range w.Bullets {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return ids
+}
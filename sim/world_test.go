@@ -0,0 +1,69 @@
+package sim
+
+import (
+	"testing"
+
+	"shooter/level"
+	"shooter/match"
+)
+
+func TestHeadshots(t *testing.T) {
+	w := NewWorld(&level.Level{Width: 2000, Height: 2000})
+	w.Players["head"] = &Player{X: 300, Y: 100, Health: 100}
+	w.Players["arm"] = &Player{X: 300, Y: 300 + HitRadius - 1, Health: 100}
+	w.Spawn(&Bullet{OwnerID: "a", X: 100, Y: 100, Velocity: 50, Damage: 10})
+	w.Spawn(&Bullet{OwnerID: "a", X: 100, Y: 300, Velocity: 50, Damage: 10})
+
+	headshots := map[string]bool{}
+	for range 10 {
+		for _, impact := range w.Step() {
+			headshots[impact.VictimID] = impact.Headshot
+		}
+	}
+	if len(headshots) != 2 || !headshots["head"] || headshots["arm"] {
+		t.Errorf("headshots = %v, want only head", headshots)
+	}
+}
+
+func TestFriendlyFire(t *testing.T) {
+	for _, tc := range []struct {
+		ff                    match.FriendlyFire
+		mateHealth, ownHealth int
+	}{
+		{match.FriendlyFireOn, 90, 100},
+		{match.FriendlyFireOff, 100, 100},
+		{match.FriendlyFireReflect, 100, 90},
+	} {
+		w := NewWorld(&level.Level{Width: 2000, Height: 2000})
+		w.FriendlyFire = tc.ff
+		w.Players["a"] = &Player{X: 100, Y: 100, Health: 100, Team: "red"}
+		w.Players["mate"] = &Player{X: 300, Y: 100, Health: 100, Team: "red"}
+		w.Spawn(&Bullet{OwnerID: "a", X: 150, Y: 100, Velocity: 50, Damage: 10})
+		for range 10 {
+			w.Step()
+		}
+		if got := w.Players["mate"].Health; got != tc.mateHealth {
+			t.Errorf("%s: teammate health = %d, want %d", tc.ff, got, tc.mateHealth)
+		}
+		if got := w.Players["a"].Health; got != tc.ownHealth {
+			t.Errorf("%s: shooter health = %d, want %d", tc.ff, got, tc.ownHealth)
+		}
+	}
+}
+
+func TestArmorAbsorbs(t *testing.T) {
+	w := NewWorld(&level.Level{Width: 2000, Height: 2000})
+	w.Absorb = func(id string, damage int) int { return damage / 2 }
+	w.Players["b"] = &Player{X: 300, Y: 100, Health: 100}
+	w.Spawn(&Bullet{OwnerID: "a", X: 100, Y: 100, Velocity: 50, Damage: 10})
+
+	var dealt int
+	for range 10 {
+		for _, impact := range w.Step() {
+			dealt = impact.Damage
+		}
+	}
+	if dealt != 5 || w.Players["b"].Health != 95 {
+		t.Errorf("dealt %d leaving %d health, want 5 leaving 95", dealt, w.Players["b"].Health)
+	}
+}
@@ -0,0 +1,470 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"math/rand/v2"
+	"net"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"shooter/config"
+	"shooter/game"
+	"shooter/level"
+	"shooter/match"
+	"shooter/player"
+	"shooter/weapon"
+	"shooter/wire"
+)
+
+const (
+	simDefaultBots  = 8
+	simDefaultTicks = 60 * 60
+	simTPS          = 60
+
+	botSpeed = 3.0
+	botRange = 600.0
+	// Bots aim up to this far off, in radians, on top of the weapon spread
+	botAimError = 0.05
+	// Players the bots haven't had an update of for this long are gone
+	botForgetTime = 2 * time.Second
+)
+
+// simBot is a headless client with the same connection and events as a real one.
+type simBot struct {
+	id     string
+	conn   net.Conn
+	x, y   float64
+	goalX  float64
+	goalY  float64
+	health int
+	// Health of the server's rules
+	fullHealth int
+	weapon     weapon.ID
+	team       string
+	level      *level.Level
+	cooldown   int
+	shots      int
+	// From the server, the spread of the bot's shots follows from it
+	seed uint64
+
+	sent     int64
+	received atomic.Int64
+
+	// Hits on the bot and the seed of its next life read from the server, handled on the next tick
+	hitsMu   sync.Mutex
+	hits     []PlayerHit
+	nextSeed *uint64
+}
+
+// simulation runs bots against a local server.
+type simulation struct {
+	bots  []*simBot
+	ticks []time.Duration
+
+	mu sync.Mutex
+	// Switched by the server between matches and for duels
+	level     *level.Level
+	killsBy   map[weapon.ID]int
+	matchEnds []match.Summary
+	// Players other than the bots, as relayed by the server
+	others map[string]seenPlayer
+	// Bot token of the server, which joins the bots as people without it
+	token string
+}
+
+type seenPlayer struct {
+	update PlayerUpdate
+	seen   time.Time
+}
+
+func newSimulation(lvl *level.Level) *simulation {
+	return &simulation{level: lvl, killsBy: map[weapon.ID]int{}, others: map[string]seenPlayer{}}
+}
+
+// join connects a bot to the server at addr, it plays from the next tick.
+func (sim *simulation) join(addr, name string, results bool) (*simBot, error) {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	reader := wire.NewReader(conn)
+	// Teams come from parties, the server keeps the one the bot joined with
+	welcome, err := sayHello(conn, reader, Hello{Name: name, Bot: true, Token: sim.token, Party: sim.smallerTeam()})
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	full := player.MaxHealth
+	if welcome.Rules.Health > 0 {
+		full = welcome.Rules.Health
+	}
+	bot := &simBot{id: welcome.ID, conn: conn, health: full, fullHealth: full}
+	bot.weapon = weapon.Loadout[len(sim.bots)%len(weapon.Loadout)]
+	bot.team = welcome.Team
+	bot.seed = welcome.Seed
+	bot.level = sim.currentLevel()
+	bot.x, bot.y = bot.level.SpawnPoint()
+	bot.goalX, bot.goalY = bot.x, bot.y
+	sim.bots = append(sim.bots, bot)
+	go sim.read(bot, reader, results)
+	return bot, nil
+}
+
+// leave disconnects the last n bots.
+func (sim *simulation) leave(n int) {
+	for _, bot := range sim.bots[len(sim.bots)-n:] {
+		bot.conn.Close()
+	}
+	sim.bots = sim.bots[:len(sim.bots)-n]
+}
+
+func (sim *simulation) currentLevel() *level.Level {
+	sim.mu.Lock()
+	defer sim.mu.Unlock()
+	return sim.level
+}
+
+// smallerTeam is the team with the fewest players for a bot to join, keeping teams even.
+// Empty when nobody plays in a team.
+func (sim *simulation) smallerTeam() string {
+	counts := map[string]int{}
+	for _, bot := range sim.bots {
+		if bot.team != "" {
+			counts[bot.team]++
+		}
+	}
+	sim.mu.Lock()
+	for id, p := range sim.others {
+		if p.update.Team != "" && sim.bot(id) == nil {
+			counts[p.update.Team]++
+		}
+	}
+	sim.mu.Unlock()
+	team := ""
+	for t, n := range counts {
+		if team == "" || n < counts[team] || n == counts[team] && t < team {
+			team = t
+		}
+	}
+	return team
+}
+
+// bot returns the simulation's bot with the ID, nil for other players.
+func (sim *simulation) bot(id string) *simBot {
+	for _, bot := range sim.bots {
+		if bot.id == id {
+			return bot
+		}
+	}
+	return nil
+}
+
+// runSimulate plays bots against the server headless and prints tick times, bandwidth and outcomes.
+func runSimulate(args []string) {
+	bots, ticks := simDefaultBots, simDefaultTicks
+	for i, dst := range []*int{&bots, &ticks} {
+		if len(args) > i {
+			n, err := strconv.Atoi(args[i])
+			if err != nil || n <= 0 {
+				log.Fatal("Invalid number: ", args[i])
+			}
+			*dst = n
+		}
+	}
+
+	lvl, _ := level.Get(level.Default)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		log.Fatal("Failed to start server:", err)
+	}
+	server := NewServer(lvl.Name, config.Default().Network)
+	go server.Serve(listener)
+
+	sim := newSimulation(lvl)
+	sim.token = server.botToken
+	for i := range bots {
+		if _, err := sim.join(listener.Addr().String(), fmt.Sprintf("bot-%d", i+1), i == 0); err != nil {
+			log.Fatal("Bot failed to join:", err)
+		}
+	}
+
+	// Real time pace, the server moves bullets on its own clock
+	ticker := time.NewTicker(time.Second / simTPS)
+	defer ticker.Stop()
+	for range ticks {
+		<-ticker.C
+		start := time.Now()
+		sim.tick()
+		sim.ticks = append(sim.ticks, time.Since(start))
+	}
+	// Let the server relay the last messages
+	time.Sleep(200 * time.Millisecond)
+	sim.report(ticks)
+}
+
+// read counts bytes relayed to the bot and keeps track of the other players and the map,
+// the first bot also collects match results.
+func (sim *simulation) read(bot *simBot, reader *wire.Reader, results bool) {
+	for {
+		msg, err := reader.Read()
+		if err != nil {
+			return
+		}
+		bot.received.Add(int64(wire.HeaderSize + len(msg)))
+		var event player.Event
+		if err := json.Unmarshal(msg, &event); err != nil {
+			continue
+		}
+		switch event.Type {
+		case player.EventTypePlayerHit:
+			var hit PlayerHit
+			if err := json.Unmarshal(event.Data, &hit); err == nil && hit.VictimID == bot.id {
+				bot.hitsMu.Lock()
+				bot.hits = append(bot.hits, hit)
+				bot.hitsMu.Unlock()
+			}
+		case player.EventTypeSeed:
+			var seed Seed
+			if err := json.Unmarshal(event.Data, &seed); err == nil {
+				bot.hitsMu.Lock()
+				bot.nextSeed = &seed.Seed
+				bot.hitsMu.Unlock()
+			}
+		case player.EventTypePlayerUpdate:
+			var update PlayerUpdate
+			if err := json.Unmarshal(event.Data, &update); err == nil {
+				sim.mu.Lock()
+				sim.others[update.ID] = seenPlayer{update: update, seen: time.Now()}
+				sim.mu.Unlock()
+			}
+		case player.EventTypeMatchStart:
+			var start MatchStart
+			if err := json.Unmarshal(event.Data, &start); err == nil {
+				sim.setLevel(start.Map)
+			}
+		case player.EventTypeChangeMap:
+			var change ChangeMap
+			if err := json.Unmarshal(event.Data, &change); err == nil {
+				sim.setLevel(change.Map)
+			}
+		case player.EventTypeRound:
+			var round Round
+			if err := json.Unmarshal(event.Data, &round); err == nil && round.Map != "" {
+				sim.setLevel(round.Map)
+			}
+		case player.EventTypeMatchEnd:
+			var summary match.Summary
+			if err := json.Unmarshal(event.Data, &summary); err == nil && results {
+				sim.mu.Lock()
+				sim.matchEnds = append(sim.matchEnds, summary)
+				sim.mu.Unlock()
+			}
+		}
+	}
+}
+
+// setLevel moves the bots to the map the server switched to, respawning them there.
+func (sim *simulation) setLevel(name string) {
+	lvl, ok := level.Get(name)
+	if !ok {
+		return
+	}
+	sim.mu.Lock()
+	defer sim.mu.Unlock()
+	if sim.level != lvl {
+		sim.level = lvl
+		// The tick picks up the new map and respawns the bots on it
+		clear(sim.others)
+	}
+}
+
+func (sim *simulation) tick() {
+	lvl := sim.currentLevel()
+	others := sim.enemies()
+	for _, bot := range sim.bots {
+		sim.takeHits(bot, lvl)
+		x, y := bot.x, bot.y
+		sim.move(bot, lvl)
+		if x, y, ok := sim.target(bot, others, lvl); ok {
+			sim.shoot(bot, x, y)
+		}
+		bot.send(player.EventTypePlayerUpdate, PlayerUpdate{
+			ID:     bot.id,
+			X:      bot.x,
+			Y:      bot.y,
+			Health: bot.health,
+			Weapon: bot.weapon,
+			Team:   bot.team,
+			Bot:    true,
+			VX:     (bot.x - x) * simTPS,
+			VY:     (bot.y - y) * simTPS,
+		})
+	}
+}
+
+// enemies are the living players other than the simulation's bots, forgetting those gone quiet.
+func (sim *simulation) enemies() []PlayerUpdate {
+	sim.mu.Lock()
+	defer sim.mu.Unlock()
+	var enemies []PlayerUpdate
+	for id, p := range sim.others {
+		switch {
+		case time.Since(p.seen) > botForgetTime:
+			delete(sim.others, id)
+		case p.update.Health > 0 && sim.bot(id) == nil:
+			enemies = append(enemies, p.update)
+		}
+	}
+	return enemies
+}
+
+// move walks towards a random goal, picking a new one when reached or when a wall is in the way.
+func (sim *simulation) move(bot *simBot, lvl *level.Level) {
+	dx, dy := bot.goalX-bot.x, bot.goalY-bot.y
+	d := math.Hypot(dx, dy)
+	if d < botSpeed || !lineOfSight(bot.x, bot.y, bot.goalX, bot.goalY, lvl.Objects) {
+		bot.goalX = level.SpawnClearance + rand.Float64()*(lvl.Width-2*level.SpawnClearance)
+		bot.goalY = level.SpawnClearance + rand.Float64()*(lvl.Height-2*level.SpawnClearance)
+		return
+	}
+	// Grazing a wall on the way would only get them corrected by the server
+	bot.x, bot.y = game.Move(bot.x, bot.y, dx/d*botSpeed, dy/d*botSpeed, player.PlayerRadius, lvl.Objects)
+}
+
+// target returns where the closest enemy in range and sight is, other bots or players.
+// Teammates are left alone.
+func (sim *simulation) target(bot *simBot, others []PlayerUpdate, lvl *level.Level) (float64, float64, bool) {
+	var tx, ty float64
+	found, bestDist := false, botRange
+	consider := func(x, y float64, team string) {
+		if team != "" && team == bot.team {
+			return
+		}
+		d := math.Hypot(x-bot.x, y-bot.y)
+		if d < bestDist && lineOfSight(bot.x, bot.y, x, y, lvl.Objects) {
+			tx, ty, found, bestDist = x, y, true, d
+		}
+	}
+	for _, other := range sim.bots {
+		if other != bot {
+			consider(other.x, other.y, other.team)
+		}
+	}
+	for _, p := range others {
+		consider(p.X, p.Y, p.Team)
+	}
+	return tx, ty, found
+}
+
+// shoot sends the bullets at x, y to the server, which decides what they hit.
+func (sim *simulation) shoot(bot *simBot, x, y float64) {
+	if bot.cooldown > 0 {
+		bot.cooldown--
+		return
+	}
+	w := weapon.Get(bot.weapon)
+	bot.cooldown = int(w.Cooldown * simTPS / time.Second)
+
+	aim := math.Atan2(y-bot.y, x-bot.x) + (rand.Float64()*2-1)*botAimError
+	var shot Shoot
+	for range w.Pellets {
+		angle := aim + weapon.SpreadOffset(bot.seed, bot.shots, w.Spread)
+		bot.shots++
+		shot.Bullets = append(shot.Bullets, &player.Bullet{
+			OwnerID:   bot.id,
+			X:         bot.x,
+			Y:         bot.y,
+			EndX:      bot.x,
+			EndY:      bot.y,
+			Direction: angle,
+			Aim:       aim,
+			Velocity:  w.BulletSpeed,
+			Damage:    w.Damage,
+			Weapon:    w.ID,
+			Shot:      bot.shots - 1,
+		})
+	}
+	bot.send(player.EventTypeShoot, shot)
+}
+
+// takeHits applies the server's hits, like real clients the victim reports its own death.
+func (sim *simulation) takeHits(bot *simBot, lvl *level.Level) {
+	bot.hitsMu.Lock()
+	hits := bot.hits
+	bot.hits = nil
+	if bot.nextSeed != nil {
+		bot.seed, bot.shots, bot.nextSeed = *bot.nextSeed, 0, nil
+	}
+	bot.hitsMu.Unlock()
+
+	for _, hit := range hits {
+		if bot.health <= 0 {
+			break
+		}
+		bot.health -= hit.Damage
+		// The server sends the kill itself
+		if bot.health <= 0 {
+			sim.mu.Lock()
+			sim.killsBy[hit.Weapon]++
+			sim.mu.Unlock()
+		}
+	}
+	if bot.health <= 0 || bot.level != lvl {
+		bot.health, bot.level = bot.fullHealth, lvl
+		bot.x, bot.y = lvl.SpawnPoint()
+		bot.goalX, bot.goalY = bot.x, bot.y
+	}
+}
+
+func (bot *simBot) send(eventType player.EventType, data interface{}) {
+	message, err := encodeEvent(eventType, data)
+	if err != nil {
+		log.Println("Error marshaling event:", err)
+		return
+	}
+	n, err := bot.conn.Write(message)
+	if err != nil {
+		log.Println("Error sending event:", err)
+	}
+	bot.sent += int64(n)
+}
+
+func (sim *simulation) report(ticks int) {
+	seconds := float64(ticks) / simTPS
+	fmt.Printf("Simulated %d bots for %d ticks (%.0fs of play) on %s\n", len(sim.bots), ticks, seconds, sim.level.Name)
+
+	slices.Sort(sim.ticks)
+	fmt.Printf("Tick duration: p50 %v  p95 %v  p99 %v  max %v\n",
+		percentile(sim.ticks, 0.5), percentile(sim.ticks, 0.95), percentile(sim.ticks, 0.99), sim.ticks[len(sim.ticks)-1])
+
+	fmt.Println("Bandwidth per client:")
+	for _, bot := range sim.bots {
+		fmt.Printf("  %-8s up %6.1f KB/s  down %6.1f KB/s\n", bot.id,
+			float64(bot.sent)/1024/seconds, float64(bot.received.Load())/1024/seconds)
+	}
+
+	sim.mu.Lock()
+	defer sim.mu.Unlock()
+	fmt.Println("Kills by weapon:")
+	for _, id := range weapon.Loadout {
+		fmt.Printf("  %-8s %d\n", id, sim.killsBy[id])
+	}
+	fmt.Printf("Matches finished: %d\n", len(sim.matchEnds))
+	for i, s := range sim.matchEnds {
+		fmt.Printf("  match %d: winner %s, MVP %s\n", i+1, s.Winner, s.MVP)
+	}
+}
+
+// percentile returns the p-th (0 to 1) value of sorted durations.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	return sorted[min(len(sorted)-1, int(p*float64(len(sorted))))]
+}
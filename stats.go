@@ -0,0 +1,30 @@
+package main
+
+import (
+	"log"
+
+	"shooter/config"
+	"shooter/match"
+)
+
+// recordCareer adds the player's stats of the finished match to the career saved in the data directory.
+func recordCareer(id string, summary match.Summary) {
+	path, err := config.DataPath(match.CareerFile)
+	if err != nil {
+		log.Println("Error finding stats file:", err)
+		return
+	}
+	career, err := match.LoadCareer(path)
+	if err != nil {
+		log.Println("Error loading stats:", err)
+		return
+	}
+	for _, p := range summary.Players {
+		if p.ID == id {
+			career.Add(p, summary.Winner == id)
+		}
+	}
+	if err := career.Save(path); err != nil {
+		log.Println("Error saving stats:", err)
+	}
+}
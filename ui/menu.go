@@ -0,0 +1,315 @@
+package ui
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+const (
+	titleScale = 4.0
+	itemScale  = 2.0
+	itemHeight = 36.0
+	sliderBars = 10
+)
+
+var (
+	overlayColor  = color.RGBA{0, 0, 0, 180}
+	titleColor    = color.White
+	itemColor     = color.RGBA{190, 190, 190, 255}
+	focusColor    = color.RGBA{255, 210, 80, 255}
+	disabledColor = color.RGBA{110, 110, 110, 255}
+	statusColor   = color.RGBA{230, 80, 80, 255}
+)
+
+// Item is a single menu row. Items without Select or Adjust are plain labels.
+type Item struct {
+	Label  func() string
+	Select func()
+	// Adjust is called with -1 or 1 on left / right
+	Adjust func(dir int)
+	// Text is edited with the keyboard while the item is focused
+	Text *string
+	// Capture is called every tick after the item is selected, until it returns true.
+	// The menu ignores other input meanwhile, Escape cancels.
+	Capture func() bool
+}
+
+func (i *Item) interactive() bool {
+	return i.Select != nil || i.Adjust != nil || i.Text != nil || i.Capture != nil
+}
+
+func Label(text string) *Item {
+	return &Item{Label: func() string { return text }}
+}
+
+func Button(text string, fn func()) *Item {
+	return &Item{Label: func() string { return text }, Select: fn}
+}
+
+// Slider edits value in [0, 1] by step.
+func Slider(text string, value *float64, step float64, onChange func()) *Item {
+	adjust := func(dir int) {
+		*value = math.Max(0, math.Min(1, *value+float64(dir)*step))
+		if onChange != nil {
+			onChange()
+		}
+	}
+	return &Item{
+		Label: func() string {
+			n := int(math.Round(*value * sliderBars))
+			return fmt.Sprintf("%s  [%s%s] %3.0f%%", text, strings.Repeat("#", n), strings.Repeat("-", sliderBars-n), *value*100)
+		},
+		Adjust: adjust,
+	}
+}
+
+func Toggle(text string, value *bool, onChange func()) *Item {
+	toggle := func() {
+		*value = !*value
+		if onChange != nil {
+			onChange()
+		}
+	}
+	return &Item{
+		Label: func() string {
+			if *value {
+				return text + ": on"
+			}
+			return text + ": off"
+		},
+		Select: toggle,
+		Adjust: func(int) { toggle() },
+	}
+}
+
+// Choice cycles value through options.
+func Choice[T comparable](text string, options []T, value *T, onChange func()) *Item {
+	adjust := func(dir int) {
+		i := 0
+		for j, o := range options {
+			if o == *value {
+				i = j
+			}
+		}
+		*value = options[(i+dir+len(options))%len(options)]
+		if onChange != nil {
+			onChange()
+		}
+	}
+	return &Item{
+		Label:  func() string { return fmt.Sprintf("%s: < %v >", text, *value) },
+		Select: func() { adjust(1) },
+		Adjust: adjust,
+	}
+}
+
+// Rebind shows value and calls capture for the next key press once selected.
+func Rebind(text string, value func() string, capture func() bool) *Item {
+	return &Item{
+		Label:   func() string { return fmt.Sprintf("%s: %s", text, value()) },
+		Capture: capture,
+	}
+}
+
+func TextField(text string, value *string) *Item {
+	return &Item{
+		Label: func() string { return fmt.Sprintf("%s: %s", text, *value) },
+		Text:  value,
+	}
+}
+
+type Menu struct {
+	Title  string
+	Items  []*Item
+	Status string
+	// Called on Escape
+	Back func()
+
+	focus     int
+	capturing bool
+	chars     []rune
+	touches   []ebiten.TouchID
+	// Item positions from the last Draw, used for mouse input
+	itemScale  float64
+	itemLayout []float64
+}
+
+func NewMenu(title string, items ...*Item) *Menu {
+	m := &Menu{Title: title, Items: items}
+	m.focus = m.next(-1, 1)
+	return m
+}
+
+// next returns the first interactive item from i in direction dir.
+func (m *Menu) next(i, dir int) int {
+	for range m.Items {
+		i = (i + dir + len(m.Items)) % len(m.Items)
+		if m.Items[i].interactive() {
+			return i
+		}
+	}
+	return max(0, i)
+}
+
+func (m *Menu) Update() {
+	if len(m.Items) == 0 {
+		return
+	}
+	item := m.Items[m.focus]
+
+	if m.capturing {
+		if inpututil.IsKeyJustPressed(ebiten.KeyEscape) || item.Capture() {
+			m.capturing = false
+		}
+		return
+	}
+
+	if item.Text != nil {
+		m.chars = ebiten.AppendInputChars(m.chars[:0])
+		*item.Text += string(m.chars)
+		if repeatPressed(ebiten.KeyBackspace) && len(*item.Text) > 0 {
+			r := []rune(*item.Text)
+			*item.Text = string(r[:len(r)-1])
+		}
+	}
+
+	switch {
+	case repeatPressed(ebiten.KeyArrowDown) || (item.Text == nil && repeatPressed(ebiten.KeyS)) || padPressed(ebiten.StandardGamepadButtonLeftBottom):
+		m.focus = m.next(m.focus, 1)
+	case repeatPressed(ebiten.KeyArrowUp) || (item.Text == nil && repeatPressed(ebiten.KeyW)) || padPressed(ebiten.StandardGamepadButtonLeftTop):
+		m.focus = m.next(m.focus, -1)
+	case (repeatPressed(ebiten.KeyArrowLeft) || padPressed(ebiten.StandardGamepadButtonLeftLeft)) && item.Adjust != nil:
+		item.Adjust(-1)
+	case (repeatPressed(ebiten.KeyArrowRight) || padPressed(ebiten.StandardGamepadButtonLeftRight)) && item.Adjust != nil:
+		item.Adjust(1)
+	case (inpututil.IsKeyJustPressed(ebiten.KeyEnter) || padPressed(ebiten.StandardGamepadButtonRightBottom)) && item.Capture != nil:
+		m.capturing = true
+		return
+	case (inpututil.IsKeyJustPressed(ebiten.KeyEnter) || padPressed(ebiten.StandardGamepadButtonRightBottom)) && item.Select != nil:
+		item.Select()
+	case (inpututil.IsKeyJustPressed(ebiten.KeyEscape) || padPressed(ebiten.StandardGamepadButtonRightRight)) && m.Back != nil:
+		m.Back()
+	}
+
+	m.updateMouse()
+}
+
+func (m *Menu) updateMouse() {
+	if len(m.itemLayout) != len(m.Items) {
+		return
+	}
+	// A tap selects like a click
+	m.touches = inpututil.AppendJustPressedTouchIDs(m.touches[:0])
+	for _, id := range m.touches {
+		_, ty := ebiten.TouchPosition(id)
+		if i, ok := m.itemAt(float64(ty)); ok {
+			m.focus = i
+			m.activate(m.Items[i])
+			return
+		}
+	}
+
+	_, cy := ebiten.CursorPosition()
+	i, ok := m.itemAt(float64(cy))
+	if !ok {
+		return
+	}
+	if _, dy := ebiten.Wheel(); dy != 0 && m.Items[i].Adjust != nil {
+		m.Items[i].Adjust(int(math.Copysign(1, dy)))
+	}
+	m.focus = i
+	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+		m.activate(m.Items[i])
+	}
+}
+
+// itemAt returns the interactive item at screen height y.
+func (m *Menu) itemAt(y float64) (int, bool) {
+	for i, top := range m.itemLayout {
+		if y >= top && y < top+itemHeight*m.itemScale && m.Items[i].interactive() {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// activate selects the item or starts capturing a key for it.
+func (m *Menu) activate(item *Item) {
+	if item.Capture != nil {
+		m.capturing = true
+	} else if item.Select != nil {
+		item.Select()
+	}
+}
+
+// Draw draws the menu centered over a dimmed screen.
+func (m *Menu) Draw(screen *ebiten.Image) {
+	b := screen.Bounds()
+	sw, sh := float64(b.Dx()), float64(b.Dy())
+	scale := sh / 900
+
+	vector.DrawFilledRect(screen, 0, 0, float32(sw), float32(sh), overlayColor, false)
+
+	tw, _ := TextSize(m.Title)
+	y := sh * 0.2
+	DrawText(screen, m.Title, (sw-tw*titleScale*scale)/2, y, titleScale*scale, titleColor)
+	y += 100 * scale
+
+	m.itemScale = scale
+	m.itemLayout = m.itemLayout[:0]
+	for i, item := range m.Items {
+		label := item.Label()
+		clr := itemColor
+		switch {
+		case !item.interactive():
+			clr = disabledColor
+		case i == m.focus:
+			clr = focusColor
+			if item.Text != nil {
+				label += "_"
+			}
+			if m.capturing {
+				label = "> press a key, Escape to cancel <"
+			}
+		}
+
+		lw, _ := TextSize(label)
+		DrawText(screen, label, (sw-lw*itemScale*scale)/2, y, itemScale*scale, clr)
+		m.itemLayout = append(m.itemLayout, y)
+		y += itemHeight * scale
+	}
+
+	if m.Status != "" {
+		w, _ := TextSize(m.Status)
+		DrawText(screen, m.Status, (sw-w*itemScale*scale)/2, y+itemHeight*scale, itemScale*scale, statusColor)
+	}
+}
+
+// repeatPressed is true on press and then periodically while the key is held.
+func repeatPressed(key ebiten.Key) bool {
+	const (
+		delay    = 30
+		interval = 4
+	)
+	d := inpututil.KeyPressDuration(key)
+	return d == 1 || (d >= delay && (d-delay)%interval == 0)
+}
+
+var gamepads []ebiten.GamepadID
+
+// padPressed is true when the button was just pressed on any gamepad.
+func padPressed(button ebiten.StandardGamepadButton) bool {
+	gamepads = ebiten.AppendGamepadIDs(gamepads[:0])
+	for _, id := range gamepads {
+		if inpututil.IsStandardGamepadButtonJustPressed(id, button) {
+			return true
+		}
+	}
+	return false
+}
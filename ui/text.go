@@ -0,0 +1,28 @@
+package ui
+
+import (
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text/v2"
+	"golang.org/x/image/font/basicfont"
+)
+
+var face = text.NewGoXFace(basicfont.Face7x13)
+
+const LineHeight = 13.0
+
+// TextSize returns the unscaled size of s in the UI font.
+func TextSize(s string) (float64, float64) {
+	return text.Measure(s, face, LineHeight)
+}
+
+// DrawText draws s with its top left corner at x, y scaled by scale.
+func DrawText(screen *ebiten.Image, s string, x, y, scale float64, clr color.Color) {
+	op := &text.DrawOptions{}
+	op.LineSpacing = LineHeight
+	op.GeoM.Scale(scale, scale)
+	op.GeoM.Translate(x, y)
+	op.ColorScale.ScaleWithColor(clr)
+	text.Draw(screen, s, face, op)
+}
@@ -1,9 +1,17 @@
 package utils
 
 import (
+	"bytes"
 	"embed"
+	"fmt"
 	"image"
+	"image/color"
 	_ "image/png"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 
 	"github.com/hajimehoshi/ebiten/v2"
 	"golang.org/x/image/font"
@@ -13,23 +21,89 @@ import (
 //go:embed assets/*
 var assets embed.FS
 
-func MustLoadImage(name string) *ebiten.Image {
-	file, err := assets.Open(name)
-	if err != nil {
-		panic(err)
+// Files in this directory are used instead of the embedded ones, so assets can be
+// modded without rebuilding. Read from the environment because assets load during package init.
+var assetDir = os.Getenv("SHOOTER_ASSETS")
+
+var (
+	imagesMu sync.Mutex
+	images   = map[string]*ebiten.Image{}
+	missing  = map[string]bool{}
+)
+
+// LoadImage returns the image with the given asset name, decoded images are cached.
+func LoadImage(name string) (*ebiten.Image, error) {
+	imagesMu.Lock()
+	defer imagesMu.Unlock()
+
+	if img, ok := images[name]; ok {
+		return img, nil
 	}
-	defer file.Close()
 
-	img, _, err := image.Decode(file)
+	data, err := ReadAsset(name)
 	if err != nil {
-		panic(err)
+		return nil, err
+	}
+	decoded, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
 	}
 
+	img := ebiten.NewImageFromImage(decoded)
+	images[name] = img
+	return img, nil
+}
+
+// Image is like LoadImage but never fails, missing assets are replaced by a w x h placeholder.
+// Placeholders are cached with the images, by the name and size they stand in for.
+func Image(name string, w, h int) *ebiten.Image {
+	key := fmt.Sprintf("%s (placeholder %dx%d)", name, w, h)
+	imagesMu.Lock()
+	img, ok := images[key]
+	imagesMu.Unlock()
+	if ok {
+		return img
+	}
+
+	img, err := LoadImage(name)
+	if err == nil {
+		return img
+	}
+
+	imagesMu.Lock()
+	defer imagesMu.Unlock()
+	if !missing[name] {
+		log.Println("Using placeholder for asset:", err)
+		missing[name] = true
+	}
+	img = Placeholder(w, h)
+	images[key] = img
+	return img
+}
+
+var (
+	placeholderDark  = color.RGBA{30, 30, 30, 255}
+	placeholderLight = color.RGBA{255, 0, 255, 255}
+)
+
+// Placeholder returns a checkerboard which is easy to spot in game.
+func Placeholder(w, h int) *ebiten.Image {
+	const cell = 8
+	img := image.NewRGBA(image.Rect(0, 0, max(1, w), max(1, h)))
+	for y := range img.Bounds().Dy() {
+		for x := range img.Bounds().Dx() {
+			if (x/cell+y/cell)%2 == 0 {
+				img.SetRGBA(x, y, placeholderDark)
+			} else {
+				img.SetRGBA(x, y, placeholderLight)
+			}
+		}
+	}
 	return ebiten.NewImageFromImage(img)
 }
 
 func MustLoadFont(name string) font.Face {
-	f, err := assets.ReadFile(name)
+	f, err := ReadAsset(name)
 	if err != nil {
 		panic(err)
 	}
@@ -51,3 +125,14 @@ func MustLoadFont(name string) font.Face {
 	return face
 }
 
+// ReadAsset reads the asset from the override directory if it's there, otherwise from the embedded ones.
+// Names are paths like assets/sounds/hit.wav.
+func ReadAsset(name string) ([]byte, error) {
+	if assetDir != "" {
+		path := filepath.Join(assetDir, filepath.FromSlash(strings.TrimPrefix(name, "assets/")))
+		if data, err := os.ReadFile(path); err == nil {
+			return data, nil
+		}
+	}
+	return assets.ReadFile(name)
+}
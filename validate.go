@@ -0,0 +1,50 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"shooter/level"
+)
+
+// runValidate checks the named levels or map files, or all built-in levels, and exits with 1
+// when any has problems.
+func runValidate(names []string) {
+	if len(names) == 0 {
+		names = level.Names()
+	}
+
+	failed := false
+	for _, name := range names {
+		lvl, err := loadLevel(name)
+		if err != nil {
+			fmt.Printf("%s: %v\n", name, err)
+			failed = true
+			continue
+		}
+		problems := lvl.Validate()
+		if len(problems) == 0 {
+			fmt.Printf("%s: ok\n", name)
+			continue
+		}
+		failed = true
+		for _, p := range problems {
+			fmt.Printf("%s: %v\n", name, p)
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// loadLevel returns the built-in level with the name, or else the one in the file at that path.
+func loadLevel(name string) (*level.Level, error) {
+	if lvl, ok := level.Get(name); ok {
+		return lvl, nil
+	}
+	if _, err := os.Stat(name); err != nil {
+		return nil, errors.New("unknown map")
+	}
+	return level.Load(name)
+}
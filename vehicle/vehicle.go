@@ -0,0 +1,81 @@
+// Package vehicle is the buggy's driving. It runs in fixed ticks and only depends on the
+// controls and the walls, so the driver's prediction and the server's simulation agree.
+package vehicle
+
+import (
+	"math"
+
+	"shooter/game"
+)
+
+const (
+	// Physics ticks per second, whatever the game's tick rate
+	TPS = 60
+	// Speeds are per tick
+	MaxSpeed   = 9.0
+	MaxReverse = 3.0
+	// Speed gained per tick at full throttle, and lost braking against the way it rolls
+	Acceleration = 0.2
+	Braking      = 0.5
+	// Speed kept per tick without throttle
+	Drag = 0.97
+	// Radians turned per tick at full steer and speed, slower buggies turn slower
+	TurnRate = 0.06
+	// Hitbox, walls stop it this far from its center
+	Radius = 24.0
+	// Driver included
+	Seats = 2
+	// Farthest from the buggy players get in
+	EnterReach = 60.0
+)
+
+// Controls are the driver's input, Throttle and Steer are from -1 to 1.
+type Controls struct {
+	Throttle float64 `json:"throttle"`
+	Steer    float64 `json:"steer"`
+}
+
+// State is where the buggy is, the way it faces and its speed along it, negative in reverse.
+type State struct {
+	X, Y, Angle, Speed float64
+}
+
+// Step drives the buggy one tick. It stops dead Radius short of a wall in its way, it returns
+// whether it crashed.
+func (s *State) Step(c Controls, objects []game.Object) bool {
+	throttle, steer := math.Max(-1, math.Min(1, c.Throttle)), math.Max(-1, math.Min(1, c.Steer))
+	switch {
+	case throttle != 0 && s.Speed*throttle < 0:
+		s.Speed += Braking * throttle
+	case throttle != 0:
+		s.Speed += Acceleration * throttle
+	default:
+		s.Speed *= Drag
+	}
+	s.Speed = math.Max(-MaxReverse, math.Min(MaxSpeed, s.Speed))
+	s.Angle += steer * TurnRate * s.Speed / MaxSpeed
+	if s.Speed == 0 {
+		return false
+	}
+
+	dx, dy := math.Cos(s.Angle), math.Sin(s.Angle)
+	if s.Speed < 0 {
+		dx, dy = -dx, -dy
+	}
+	step := math.Abs(s.Speed)
+	ahead := game.Line{X1: s.X, Y1: s.Y, X2: s.X + dx*(step+Radius), Y2: s.Y + dy*(step+Radius)}
+	crashed := false
+	for _, o := range objects {
+		for _, wall := range o.Walls {
+			if hx, hy, ok := game.Intersection(ahead, wall); ok {
+				step = math.Min(step, math.Max(0, math.Hypot(hx-s.X, hy-s.Y)-Radius))
+				crashed = true
+			}
+		}
+	}
+	s.X, s.Y = s.X+dx*step, s.Y+dy*step
+	if crashed {
+		s.Speed = 0
+	}
+	return crashed
+}
@@ -0,0 +1,65 @@
+package vehicle
+
+import (
+	"math"
+	"testing"
+
+	"shooter/game"
+)
+
+func TestAccelerates(t *testing.T) {
+	var s State
+	for range 2 * TPS {
+		s.Step(Controls{Throttle: 1}, nil)
+	}
+	if s.Speed != MaxSpeed || s.Y != 0 || s.X <= 0 {
+		t.Errorf("after two seconds of throttle = %+v, want at full speed ahead", s)
+	}
+	for range 3 {
+		s.Step(Controls{Throttle: -1}, nil)
+	}
+	if want := MaxSpeed - 3*Braking; math.Abs(s.Speed-want) > 1e-9 {
+		t.Errorf("speed after braking %v, want %v", s.Speed, want)
+	}
+	for range 10 * TPS {
+		s.Step(Controls{}, nil)
+	}
+	if s.Speed > 0.01 {
+		t.Errorf("still rolling at %v", s.Speed)
+	}
+}
+
+func TestSteers(t *testing.T) {
+	parked := State{}
+	parked.Step(Controls{Steer: 1}, nil)
+	if parked.Angle != 0 {
+		t.Error("turned standing still")
+	}
+	s := State{Speed: MaxSpeed}
+	s.Step(Controls{Throttle: 1, Steer: 1}, nil)
+	if math.Abs(s.Angle-TurnRate) > 1e-9 {
+		t.Errorf("angle %v after a tick of full steer, want %v", s.Angle, TurnRate)
+	}
+}
+
+func TestCrashes(t *testing.T) {
+	wall := []game.Object{{Walls: []game.Line{{X1: 200, Y1: -500, X2: 200, Y2: 500}}}}
+	var s State
+	crashed := false
+	for range 2 * TPS {
+		if s.Step(Controls{Throttle: 1}, wall) {
+			crashed = true
+		}
+		if s.X > 200-Radius+1e-9 {
+			t.Fatalf("drove into the wall: %+v", s)
+		}
+	}
+	if !crashed || s.Speed != 0 {
+		t.Errorf("didn't crash: %+v", s)
+	}
+	// Backing away is still possible
+	s.Step(Controls{Throttle: -1}, wall)
+	if s.Speed >= 0 {
+		t.Errorf("speed %v reversing from the wall", s.Speed)
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"math"
+	"slices"
+	"time"
+
+	"shooter/player"
+	"shooter/sim"
+	"shooter/vehicle"
+)
+
+// driving is the local player's prediction of the buggy they drive, stepped in its fixed ticks
+// from when they took the wheel like the server does.
+type driving struct {
+	id       uint64
+	state    vehicle.State
+	controls vehicle.Controls
+	start    time.Time
+	ticks    int
+}
+
+// riding returns the vehicle the local player is in, as of the server's last word.
+func (g *Game) riding() (*sim.Entity, bool) {
+	for _, e := range g.entities {
+		if e.Kind == sim.Vehicle && slices.Contains(e.Riders, g.player.ID) {
+			return e, true
+		}
+	}
+	return nil, false
+}
+
+// updateRide asks the server to get out of the vehicle the player is in, or into the nearest
+// one with a free seat. False when there's none in reach, interacting picks up weapons then.
+func (g *Game) updateRide() bool {
+	if !g.input.Interact || g.player.Health <= 0 {
+		return false
+	}
+	if _, ok := g.riding(); ok {
+		g.sendEvent(player.EventTypeRide, Ride{PlayerID: g.player.ID})
+		return true
+	}
+	var nearest *sim.Entity
+	closest := vehicle.EnterReach
+	for _, e := range g.entities {
+		if d := math.Hypot(e.X-g.player.X, e.Y-g.player.Y); e.Kind == sim.Vehicle && len(e.Riders) < vehicle.Seats && d <= closest {
+			nearest, closest = e, d
+		}
+	}
+	if nearest == nil {
+		return false
+	}
+	g.sendEvent(player.EventTypeRide, Ride{PlayerID: g.player.ID, VehicleID: nearest.ID})
+	return true
+}
+
+// driveVehicle drives the predicted buggy by the movement keys and tells the server when the
+// controls change. Drivers have their hands full, only passengers shoot.
+func (g *Game) driveVehicle() {
+	if g.drive == nil {
+		return
+	}
+	g.input.Shoot = false
+	controls := vehicle.Controls{Throttle: -g.input.MoveY, Steer: g.input.MoveX}
+	if g.player.Health <= 0 {
+		controls = vehicle.Controls{}
+	}
+	ticks := int(g.now().Sub(g.drive.start) * vehicle.TPS / time.Second)
+	for g.drive.ticks < ticks {
+		g.drive.state.Step(g.drive.controls, g.Objects)
+		g.drive.ticks++
+	}
+	if controls != g.drive.controls {
+		g.drive.controls = controls
+		g.sendEvent(player.EventTypeDrive, Drive{PlayerID: g.player.ID, Controls: controls})
+	}
+}
+
+// pinRider keeps the local player in the seat of their vehicle whatever they pressed.
+func (g *Game) pinRider() {
+	if g.drive != nil {
+		g.player.X, g.player.Y = g.drive.state.X, g.drive.state.Y
+		return
+	}
+	if e, ok := g.riding(); ok {
+		g.player.X, g.player.Y = e.X, e.Y
+	}
+}
+
+// syncVehicle starts or stops predicting the buggy once the server says the local player took
+// or left the wheel. A prediction gone too far off the server's, like after a crash the client
+// didn't see, snaps back to it.
+func (g *Game) syncVehicle() {
+	e, ok := g.riding()
+	if !ok || e.Riders[0] != g.player.ID {
+		g.drive = nil
+		return
+	}
+	if g.drive != nil && g.drive.id == e.ID {
+		if math.Hypot(e.X-g.drive.state.X, e.Y-g.drive.state.Y) > vehicle.Radius {
+			g.drive.state = e.State()
+		}
+		return
+	}
+	g.drive = &driving{id: e.ID, state: e.State(), start: g.now()}
+}
+
+// vehicleState is where a vehicle is drawn, the local driver's where they predict it.
+func (g *Game) vehicleState(e *sim.Entity) vehicle.State {
+	if g.drive != nil && g.drive.id == e.ID {
+		return g.drive.state
+	}
+	return e.State()
+}
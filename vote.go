@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+
+	"shooter/input"
+	"shooter/level"
+	"shooter/match"
+	"shooter/player"
+	"shooter/ui"
+)
+
+// voteMenu lets the player answer the running vote, or call one on the map, the mode or
+// kicking a player when none is running.
+func (g *Game) voteMenu() {
+	var items []*ui.Item
+	if p := g.poll; p != nil {
+		items = append(items,
+			ui.Label(fmt.Sprintf("%s wants to %s", p.Caller, pollText(*p))),
+			ui.Button("Vote yes", func() { g.castVote(true) }),
+			ui.Button("Vote no", func() { g.castVote(false) }),
+		)
+	} else {
+		var maps, modes, players []string
+		for _, m := range level.Maps() {
+			if m != g.level.Name {
+				maps = append(maps, m)
+			}
+		}
+		for _, m := range match.Modes {
+			if m != g.rules.Mode {
+				modes = append(modes, string(m))
+			}
+		}
+		for id := range g.players {
+			players = append(players, id)
+		}
+		slices.Sort(players)
+		items = append(items,
+			ui.Button("Change map", func() { g.voteOn(match.PollMap, "CHANGE MAP", maps) }),
+			ui.Button("Change mode", func() { g.voteOn(match.PollMode, "CHANGE MODE", modes) }),
+			ui.Button("Kick player", func() { g.voteOn(match.PollKick, "KICK PLAYER", players) }),
+		)
+	}
+	items = append(items, ui.Button("Back", g.resume))
+	menu := ui.NewMenu("VOTE", items...)
+	menu.Back = g.resume
+	g.overlay = menu
+}
+
+// voteOn lists what a vote can be called on, picking one calls it.
+func (g *Game) voteOn(kind match.PollKind, title string, targets []string) {
+	items := make([]*ui.Item, 0, len(targets)+1)
+	for _, target := range targets {
+		items = append(items, ui.Button(target, func() { g.callVote(kind, target) }))
+	}
+	items = append(items, ui.Button("Back", g.voteMenu))
+	menu := ui.NewMenu(title, items...)
+	menu.Back = g.voteMenu
+	g.overlay = menu
+}
+
+// callVote asks the server to start a vote, it ignores calls while another vote runs or
+// the player called one recently.
+func (g *Game) callVote(kind match.PollKind, target string) {
+	g.sendEvent(player.EventTypeCallVote, CallVote{PlayerID: g.player.ID, Kind: kind, Target: target})
+	g.resume()
+}
+
+func (g *Game) castVote(yes bool) {
+	g.sendEvent(player.EventTypeCastVote, CastVote{PlayerID: g.player.ID, Yes: yes})
+	g.resume()
+}
+
+// setPoll follows the running vote, telling the player in the killfeed when one is called
+// and how it went.
+func (g *Game) setPoll(p PollStatus) {
+	switch {
+	case p.Done && p.Passed:
+		g.killfeed.AddMessage(fmt.Sprintf("Vote passed: %s", pollText(p)))
+	case p.Done:
+		g.killfeed.AddMessage(fmt.Sprintf("Vote failed: %s", pollText(p)))
+	case g.poll == nil:
+		g.killfeed.AddMessage(fmt.Sprintf("%s called a vote to %s", p.Caller, pollText(p)))
+	}
+	if p.Done {
+		g.poll = nil
+		return
+	}
+	g.poll, g.pollSynced = &p, g.now()
+}
+
+// pollObjective shows the running vote with the key to answer it, empty when there is none.
+func (g *Game) pollObjective() string {
+	if g.poll == nil {
+		return ""
+	}
+	left := max(g.poll.Remaining-g.now().Sub(g.pollSynced), 0)
+	return fmt.Sprintf("Vote to %s: %d/%d yes, %ds (%s)",
+		pollText(*g.poll), g.poll.Yes, g.poll.Voters, int(left.Seconds()), g.app.input.Key(input.Vote))
+}
+
+// pollText says what the vote is about.
+func pollText(p PollStatus) string {
+	switch p.Kind {
+	case match.PollMap:
+		return "change the map to " + p.Target
+	case match.PollMode:
+		return "switch to " + p.Target
+	case match.PollKick:
+		return "kick " + p.Target
+	}
+	return string(p.Kind) + " " + p.Target
+}
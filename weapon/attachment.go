@@ -0,0 +1,49 @@
+package weapon
+
+import (
+	"math"
+	"time"
+)
+
+// Attachment modifies a weapon of the loadout, each weapon takes one.
+type Attachment string
+
+const (
+	NoAttachment Attachment = "none"
+	// Quieter shots without a tracer
+	Suppressor Attachment = "suppressor"
+	// More rounds per magazine, slower to reload
+	ExtendedMag Attachment = "extended_mag"
+	// Zooms further while aiming down sights
+	Scope Attachment = "scope"
+)
+
+var Attachments = []Attachment{NoAttachment, Suppressor, ExtendedMag, Scope}
+
+const (
+	ExtendedMagFactor       = 1.5
+	ExtendedMagReloadFactor = 1.25
+	// Camera zoom while aiming down sights with a scope
+	ScopeZoom = 1.8
+	// Volume of suppressed shots compared to normal ones
+	SuppressedVolume = 0.3
+)
+
+// With returns a copy of the weapon with the attachment applied, the definitions themselves never change.
+// Unknown attachments give the weapon without any.
+func (w *Weapon) With(a Attachment) *Weapon {
+	m := *w
+	m.Attachment = a
+	switch a {
+	case Suppressor:
+		m.Suppressed = true
+	case ExtendedMag:
+		m.MagazineSize = int(math.Ceil(float64(w.MagazineSize) * ExtendedMagFactor))
+		m.ReloadTime = time.Duration(float64(w.ReloadTime) * ExtendedMagReloadFactor)
+	case Scope:
+		m.Zoom = ScopeZoom
+	default:
+		m.Attachment = NoAttachment
+	}
+	return &m
+}
@@ -0,0 +1,32 @@
+package weapon
+
+import "testing"
+
+func TestWithExtendedMag(t *testing.T) {
+	w := Get(Rifle).With(ExtendedMag)
+	if w.MagazineSize != 45 {
+		t.Errorf("MagazineSize = %d, want 45", w.MagazineSize)
+	}
+	if w.ReloadTime <= Get(Rifle).ReloadTime {
+		t.Errorf("ReloadTime = %v, want slower than %v", w.ReloadTime, Get(Rifle).ReloadTime)
+	}
+	if Get(Rifle).MagazineSize != 30 {
+		t.Error("With() changed the weapon definition")
+	}
+}
+
+func TestWithUnknownAttachment(t *testing.T) {
+	w := Get(Pistol).With("bayonet")
+	if w.Attachment != NoAttachment || w.Suppressed || w.Zoom != 0 {
+		t.Errorf("With(bayonet) = %+v, want no attachment", w)
+	}
+}
+
+func TestWithSuppressorAndScope(t *testing.T) {
+	if !Get(Shotgun).With(Suppressor).Suppressed {
+		t.Error("suppressor should suppress the weapon")
+	}
+	if got := Get(Rifle).With(Scope).Zoom; got != ScopeZoom {
+		t.Errorf("Zoom = %v, want %v", got, ScopeZoom)
+	}
+}
@@ -0,0 +1,48 @@
+package weapon
+
+import "math"
+
+// Recoil of sustained fire. Consecutive shots walk the aim along Pattern and open up the
+// spread by Bloom per shot, both recover at RecoveryRate shots per second. Together with
+// SpreadOffset every bullet of a spray still follows from the seed and the shot number.
+type Recoil struct {
+	// Aim offset in radians at each shot of a spray, the last step holds after the end
+	Pattern []float64
+	// Spread added per shot of the spray, up to MaxBloom
+	Bloom    float64
+	MaxBloom float64
+	// Spray shots recovered per second
+	RecoveryRate float64
+}
+
+// Kick returns the aim offset after spray consecutive shots, between pattern steps when
+// the spray partly recovered.
+func (r Recoil) Kick(spray float64) float64 {
+	if len(r.Pattern) == 0 || spray <= 0 {
+		return 0
+	}
+	i := int(spray)
+	if i >= len(r.Pattern)-1 {
+		return r.Pattern[len(r.Pattern)-1]
+	}
+	frac := spray - float64(i)
+	return r.Pattern[i] + (r.Pattern[i+1]-r.Pattern[i])*frac
+}
+
+// KickRange returns the smallest and largest kick of a spray, 0 for its first shot included.
+func (r Recoil) KickRange() (lo, hi float64) {
+	for _, k := range r.Pattern {
+		lo, hi = math.Min(lo, k), math.Max(hi, k)
+	}
+	return lo, hi
+}
+
+// ExtraSpread is the spread the spray adds to the weapon's own.
+func (r Recoil) ExtraSpread(spray float64) float64 {
+	return math.Min(r.MaxBloom, r.Bloom*math.Max(0, spray))
+}
+
+// Recover returns the spray left after dt seconds without shooting.
+func (r Recoil) Recover(spray, dt float64) float64 {
+	return math.Max(0, spray-r.RecoveryRate*dt)
+}
@@ -0,0 +1,38 @@
+package weapon
+
+import (
+	"math"
+	"testing"
+)
+
+func TestRecoilKick(t *testing.T) {
+	r := Recoil{Pattern: []float64{0, 0.1, 0.3}}
+	tests := []struct {
+		spray, want float64
+	}{
+		{0, 0},
+		{1, 0.1},
+		{1.5, 0.2},
+		{2, 0.3},
+		{10, 0.3},
+	}
+	for _, tt := range tests {
+		if got := r.Kick(tt.spray); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("Kick(%v) = %v, want %v", tt.spray, got, tt.want)
+		}
+	}
+}
+
+func TestRecoilRecovers(t *testing.T) {
+	r := Recoil{Bloom: 0.01, MaxBloom: 0.05, RecoveryRate: 4}
+	if got := r.ExtraSpread(10); got != 0.05 {
+		t.Errorf("ExtraSpread(10) = %v, want 0.05", got)
+	}
+	spray := r.Recover(3, 0.5)
+	if spray != 1 {
+		t.Errorf("Recover(3, 0.5) = %v, want 1", spray)
+	}
+	if got := r.Recover(spray, 1); got != 0 {
+		t.Errorf("Recover(1, 1) = %v, want 0", got)
+	}
+}
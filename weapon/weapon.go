@@ -0,0 +1,149 @@
+package weapon
+
+import (
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+type ID string
+
+const (
+	Rifle   ID = "rifle"
+	Pistol  ID = "pistol"
+	Shotgun ID = "shotgun"
+	// Thrown, not in the loadout. Only its name and damage are used.
+	Grenade ID = "grenade"
+	// Fired by deployed turrets, not in the loadout either
+	Turret ID = "turret"
+	// Map hazards, named after the level.HazardKind doing the damage
+	Fire ID = "fire"
+	Acid ID = "acid"
+	// Outside the shrinking zone
+	Zone ID = "zone"
+	// Bought in economy rounds, not a weapon but priced with them
+	Armor ID = "armor"
+)
+
+// Offset is a point in body sprite pixels relative to the sprite's center,
+// X points along the barrel and Y to the right of it.
+type Offset struct {
+	X, Y float64
+}
+
+// World returns the offset placed at x, y, scaled and rotated by angle.
+func (o Offset) World(x, y, angle, scale float64) (float64, float64) {
+	ox, oy := o.X*scale, o.Y*scale
+	return x + math.Cos(angle)*ox - math.Sin(angle)*oy,
+		y + math.Sin(angle)*ox + math.Cos(angle)*oy
+}
+
+type Weapon struct {
+	ID           ID
+	Name         string
+	Damage       int
+	Cooldown     time.Duration
+	MagazineSize int
+	ReloadTime   time.Duration
+	Pellets      int     // bullets per shot
+	Spread       float64 // max random angle offset in radians
+	BulletSpeed  float64
+	Recoil       Recoil
+	// Cost in economy rounds, 0 when it can't be bought
+	Price int
+
+	// Held weapon sprite, empty when the weapon is part of the body sprite
+	Sprite string
+	// Size of the placeholder drawn when the sprite asset is missing
+	SpriteWidth, SpriteHeight int
+	// Where the center of the weapon sprite is held
+	Grip Offset
+	// Where bullets and muzzle flash leave the barrel
+	Muzzle Offset
+
+	// Laser sight attachment, shown while aiming
+	Laser bool
+
+	// Set by With, see Attachment
+	Attachment Attachment
+	Suppressed bool
+	// Camera zoom while aiming down sights, 0 for the default
+	Zoom float64
+}
+
+// defs are what the weapons look like, their stats come from the weapon data, see Load
+var defs = map[ID]*Weapon{
+	Rifle: {
+		ID:     Rifle,
+		Name:   "Rifle",
+		Muzzle: Offset{136, 49},
+		Laser:  true,
+	},
+	Pistol: {
+		ID:           Pistol,
+		Name:         "Pistol",
+		Sprite:       "assets/weapons/pistol.png",
+		SpriteWidth:  40,
+		SpriteHeight: 14,
+		Grip:         Offset{90, 49},
+		Muzzle:       Offset{110, 49},
+	},
+	Shotgun: {
+		ID:           Shotgun,
+		Name:         "Shotgun",
+		Sprite:       "assets/weapons/shotgun.png",
+		SpriteWidth:  100,
+		SpriteHeight: 18,
+		Grip:         Offset{100, 49},
+		Muzzle:       Offset{150, 49},
+	},
+	Grenade: {ID: Grenade, Name: "Grenade"},
+	Turret:  {ID: Turret, Name: "Turret"},
+	Fire:    {ID: Fire, Name: "Fire"},
+	Acid:    {ID: Acid, Name: "Acid"},
+	Zone:    {ID: Zone, Name: "Zone"},
+	Armor:   {ID: Armor, Name: "Armor"},
+}
+
+// Order in which weapons are bound to number keys
+var Loadout = []ID{Rifle, Pistol, Shotgun}
+
+// Get returns the weapon definition with the loaded stats, unknown ids fall back to the rifle.
+// Definitions are replaced rather than changed when the data is reloaded.
+func Get(id ID) *Weapon {
+	weapons := loaded.Load().weapons
+	if w, ok := weapons[id]; ok {
+		return w
+	}
+	return weapons[Rifle]
+}
+
+// Cycle returns the weapon dir steps from id in the loadout, wrapping around.
+func Cycle(id ID, dir int) ID {
+	i := 0
+	for j, l := range Loadout {
+		if l == id {
+			i = j
+		}
+	}
+	n := len(Loadout)
+	return Loadout[((i+dir)%n+n)%n]
+}
+
+// SpreadOffset returns the random angle offset of a shot within spread. It only depends on
+// the shooter's seed and the shot number, so the server and replays can recompute every shot.
+func SpreadOffset(seed uint64, shot int, spread float64) float64 {
+	rng := rand.New(rand.NewPCG(seed, uint64(shot)))
+	return (rng.Float64()*2 - 1) * spread
+}
+
+// Deflection returns how far off its aim a shot can leave the weapon: the recoil kick anywhere
+// in a spray plus the shot's SpreadOffset at any spread from aiming down sights, tightened by
+// the factor ads, to full bloom.
+func (w *Weapon) Deflection(seed uint64, shot int, ads float64) (lo, hi float64) {
+	kickLo, kickHi := w.Recoil.KickRange()
+	// The offset grows with the spread, the tightest and widest spreads bound it
+	offset := SpreadOffset(seed, shot, 1)
+	a, b := offset*w.Spread*ads, offset*(w.Spread+w.Recoil.MaxBloom)
+	return kickLo + math.Min(a, b), kickHi + math.Max(a, b)
+}
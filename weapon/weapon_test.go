@@ -0,0 +1,123 @@
+package weapon
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+func TestOffsetWorld(t *testing.T) {
+	tests := []struct {
+		name   string
+		offset Offset
+		angle  float64
+		wantX  float64
+		wantY  float64
+	}{
+		{"forward", Offset{100, 0}, 0, 25, 0},
+		{"rotated down", Offset{100, 0}, math.Pi / 2, 0, 25},
+		{"right of barrel", Offset{0, 40}, 0, 0, 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			x, y := tt.offset.World(0, 0, tt.angle, 0.25)
+			if math.Abs(x-tt.wantX) > 1e-9 || math.Abs(y-tt.wantY) > 1e-9 {
+				t.Errorf("World() = (%v, %v), want (%v, %v)", x, y, tt.wantX, tt.wantY)
+			}
+		})
+	}
+}
+
+func TestGetUnknownFallsBackToRifle(t *testing.T) {
+	if got := Get("bazooka"); got.ID != Rifle {
+		t.Errorf("Get() = %v, want %v", got.ID, Rifle)
+	}
+}
+
+func TestCycle(t *testing.T) {
+	if got := Cycle(Rifle, 1); got != Pistol {
+		t.Errorf("Cycle(Rifle, 1) = %v, want %v", got, Pistol)
+	}
+	if got := Cycle(Rifle, -1); got != Shotgun {
+		t.Errorf("Cycle(Rifle, -1) = %v, want %v", got, Shotgun)
+	}
+}
+
+func TestSpreadOffsetIsDeterministic(t *testing.T) {
+	for shot := range 100 {
+		a, b := SpreadOffset(42, shot, 0.1), SpreadOffset(42, shot, 0.1)
+		if a != b {
+			t.Fatalf("shot %d: %v != %v", shot, a, b)
+		}
+		if math.Abs(a) > 0.1 {
+			t.Fatalf("shot %d: %v outside spread", shot, a)
+		}
+	}
+	if SpreadOffset(1, 0, 0.1) == SpreadOffset(2, 0, 0.1) {
+		t.Error("different seeds gave the same offset")
+	}
+}
+
+func TestDeflectionHoldsEveryShot(t *testing.T) {
+	w := &Weapon{Spread: 0.1, Recoil: Recoil{Pattern: []float64{0, 0.05, -0.02}, Bloom: 0.02, MaxBloom: 0.06}}
+	for shot := range 50 {
+		lo, hi := w.Deflection(7, shot, 0.4)
+		for _, spray := range []float64{0, 0.5, 1, 2, 5} {
+			for _, ads := range []float64{0.4, 1} {
+				off := w.Recoil.Kick(spray) + SpreadOffset(7, shot, (w.Spread+w.Recoil.ExtraSpread(spray))*ads)
+				if off < lo-1e-9 || off > hi+1e-9 {
+					t.Fatalf("shot %d, spray %v, ads %v: %v outside [%v, %v]", shot, spray, ads, off, lo, hi)
+				}
+			}
+		}
+	}
+	// Straight down the aim only fits shots whose offset can be cancelled by the kick
+	missed := 0
+	for shot := range 50 {
+		if lo, hi := w.Deflection(7, shot, 1); lo > 0 || hi < 0 {
+			missed++
+		}
+	}
+	if missed == 0 {
+		t.Error("a shot without spread fit every deflection")
+	}
+}
+
+func TestLoad(t *testing.T) {
+	defer LoadDefault()
+	hash := Hash()
+
+	for name, raw := range map[string]string{
+		"not json":       "{",
+		"missing weapon": `{"rifle": {}}`,
+	} {
+		if err := Load([]byte(raw)); err == nil {
+			t.Errorf("Load(%s) succeeded", name)
+		}
+	}
+	if Hash() != hash {
+		t.Fatal("failed Load changed the weapon data")
+	}
+
+	var stats map[ID]map[string]any
+	if err := json.Unmarshal(defaultData, &stats); err != nil {
+		t.Fatal(err)
+	}
+	stats[Pistol]["damage"] = 40
+	raw, _ := json.Marshal(stats)
+	if err := Load(raw); err != nil {
+		t.Fatal(err)
+	}
+	if got := Get(Pistol).Damage; got != 40 {
+		t.Errorf("pistol damage = %d, want 40", got)
+	}
+	if Hash() == hash {
+		t.Error("hash didn't change with the weapon data")
+	}
+
+	stats["laser"] = map[string]any{}
+	raw, _ = json.Marshal(stats)
+	if err := Load(raw); err == nil {
+		t.Error("Load with an unknown weapon succeeded")
+	}
+}
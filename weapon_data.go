@@ -0,0 +1,57 @@
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"log"
+
+	"shooter/config"
+	"shooter/player"
+	"shooter/weapon"
+)
+
+// WeaponData tells clients the server reloaded its weapon data, with its weapon.Hash.
+type WeaponData struct {
+	Hash string `json:"hash"`
+}
+
+// loadWeaponData loads the weapon data from the data directory when there is one there,
+// otherwise the built-in stats are kept.
+func loadWeaponData() {
+	path, err := config.DataPath(weapon.DataFile)
+	if err != nil {
+		log.Println("Error finding weapon data:", err)
+		return
+	}
+	if err := weapon.LoadFile(path); err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			log.Println("Error loading weapon data, using the built-in one:", err)
+		}
+		return
+	}
+	log.Println("Loaded weapon data from", path)
+}
+
+// reloadWeapons loads the weapon data file again for live balance testing and tells the clients,
+// mu must be held.
+func (s *Server) reloadWeapons() error {
+	path, err := config.DataPath(weapon.DataFile)
+	if err != nil {
+		return err
+	}
+	if err := weapon.LoadFile(path); err != nil {
+		return err
+	}
+	s.broadcast(player.EventTypeWeaponData, WeaponData{Hash: weapon.Hash()})
+	return nil
+}
+
+// verifyWeapons warns when the server plays with other weapon stats than the client, what the
+// client predicts won't match what happens.
+func (g *Game) verifyWeapons(hash string) {
+	if hash == "" || hash == weapon.Hash() {
+		return
+	}
+	log.Println("Weapon data differs from the server's")
+	g.killfeed.AddMessage("Your weapon data differs from the server's")
+}
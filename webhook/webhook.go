@@ -0,0 +1,115 @@
+// Package webhook posts messages about matches to chat webhooks, like Discord's or Slack's.
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// Format is the body a webhook expects.
+type Format string
+
+const (
+	Discord Format = "discord"
+	Slack   Format = "slack"
+)
+
+const (
+	// Posts taking longer are given up on
+	Timeout = 10 * time.Second
+	// Messages waiting to be posted, more are dropped rather than hold up the server
+	QueueSize = 32
+)
+
+// Hook is where to post and in which format.
+type Hook struct {
+	URL    string
+	Format Format
+}
+
+// Payload is the JSON body of a message for the format, Discord's for unknown ones. Player names
+// are in the text, Discord is told not to ping anyone for an @everyone among them.
+func Payload(f Format, text string) ([]byte, error) {
+	if f == Slack {
+		return json.Marshal(map[string]string{"text": text})
+	}
+	return json.Marshal(map[string]any{
+		"content":          text,
+		"allowed_mentions": map[string][]string{"parse": {}},
+	})
+}
+
+// Notifier posts messages to its hooks in the background, in the order they came.
+type Notifier struct {
+	hooks  []Hook
+	client *http.Client
+	queue  chan string
+	// Closed once everything queued was posted
+	done chan struct{}
+}
+
+// New starts posting to the hooks, nil without any.
+func New(hooks []Hook) *Notifier {
+	if len(hooks) == 0 {
+		return nil
+	}
+	n := &Notifier{hooks: hooks, client: &http.Client{Timeout: Timeout}, queue: make(chan string, QueueSize), done: make(chan struct{})}
+	go n.run()
+	return n
+}
+
+// Notify queues the message for every hook, it never blocks. Nil notifiers do nothing.
+func (n *Notifier) Notify(text string) {
+	if n == nil {
+		return
+	}
+	select {
+	case n.queue <- text:
+	default:
+		log.Println("Webhook queue full, dropped:", text)
+	}
+}
+
+// Close posts what's queued and stops, waiting for it at most Timeout. Nothing may be notified after.
+func (n *Notifier) Close() {
+	if n == nil {
+		return
+	}
+	close(n.queue)
+	select {
+	case <-n.done:
+	case <-time.After(Timeout):
+		log.Println("Gave up on posting the queued webhook messages")
+	}
+}
+
+func (n *Notifier) run() {
+	defer close(n.done)
+	for text := range n.queue {
+		for _, h := range n.hooks {
+			if err := n.post(h, text); err != nil {
+				log.Println("Error posting to webhook:", err)
+			}
+		}
+	}
+}
+
+func (n *Notifier) post(h Hook, text string) error {
+	body, err := Payload(h.Format, text)
+	if err != nil {
+		return err
+	}
+	resp, err := n.client.Post(h.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s answered %s", h.URL, resp.Status)
+	}
+	return nil
+}
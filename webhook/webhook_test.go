@@ -0,0 +1,84 @@
+package webhook
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNotify(t *testing.T) {
+	bodies := make(chan map[string]any, 4)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		var body map[string]any
+		if err := json.Unmarshal(data, &body); err != nil {
+			t.Errorf("body %q isn't JSON", data)
+		}
+		bodies <- body
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	n := New([]Hook{{URL: srv.URL, Format: Discord}, {URL: srv.URL, Format: Slack}})
+	n.Notify("match over")
+	for _, key := range []string{"content", "text"} {
+		select {
+		case body := <-bodies:
+			if body[key] != "match over" {
+				t.Errorf("body = %v, want %q set", body, key)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("webhook never called")
+		}
+	}
+	n.Close()
+}
+
+func TestDiscordMentionsNobody(t *testing.T) {
+	data, err := Payload(Discord, "@everyone left the match")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var body struct {
+		AllowedMentions *struct {
+			Parse []string `json:"parse"`
+		} `json:"allowed_mentions"`
+	}
+	if err := json.Unmarshal(data, &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.AllowedMentions == nil || body.AllowedMentions.Parse == nil || len(body.AllowedMentions.Parse) != 0 {
+		t.Errorf("payload %s doesn't turn off mentions", data)
+	}
+}
+
+func TestClosePostsQueued(t *testing.T) {
+	var posted atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		posted.Add(1)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	n := New([]Hook{{URL: srv.URL, Format: Discord}})
+	for range 3 {
+		n.Notify("match over")
+	}
+	n.Close()
+	if got := posted.Load(); got != 3 {
+		t.Errorf("%d messages posted by Close, want 3", got)
+	}
+}
+
+func TestNilNotifier(t *testing.T) {
+	n := New(nil)
+	if n != nil {
+		t.Fatal("notifier without hooks")
+	}
+	n.Notify("nobody hears this")
+	n.Close()
+}
@@ -0,0 +1,63 @@
+// Package wire frames the messages of the game's connections: a 4 byte big-endian length,
+// then the message. Unlike reading up to a newline, a peer can't make the other side buffer
+// more than MaxSize for a single message.
+package wire
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+const (
+	HeaderSize = 4
+	// Largest message either side sends or accepts, map data is the largest by far
+	MaxSize = 1 << 20
+)
+
+// ErrTooLarge is a frame longer than MaxSize. The connection can't be read any further, its peer
+// is broken or hostile.
+var ErrTooLarge = errors.New("protocol error: message too large")
+
+// Frame returns msg with its header, ready to be written as is.
+func Frame(msg []byte) []byte {
+	frame := make([]byte, HeaderSize, HeaderSize+len(msg))
+	binary.BigEndian.PutUint32(frame, uint32(len(msg)))
+	return append(frame, msg...)
+}
+
+// Reader reads framed messages, buffered like the bufio.Reader it wraps.
+type Reader struct {
+	r      *bufio.Reader
+	header [HeaderSize]byte
+}
+
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: bufio.NewReader(r)}
+}
+
+// Read returns the next message. io.EOF is only returned between messages, a connection
+// closed halfway through one is an io.ErrUnexpectedEOF.
+func (r *Reader) Read() ([]byte, error) {
+	if _, err := io.ReadFull(r.r, r.header[:]); err != nil {
+		return nil, err
+	}
+	n := binary.BigEndian.Uint32(r.header[:])
+	if n > MaxSize {
+		return nil, ErrTooLarge
+	}
+	msg := make([]byte, n)
+	if _, err := io.ReadFull(r.r, msg); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return msg, nil
+}
+
+func (r *Reader) ReadString() (string, error) {
+	msg, err := r.Read()
+	return string(msg), err
+}
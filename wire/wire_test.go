@@ -0,0 +1,48 @@
+package wire
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	for _, msg := range []string{`{"type":"hello"}`, "", strings.Repeat("x", MaxSize)} {
+		buf.Write(Frame([]byte(msg)))
+	}
+
+	r := NewReader(&buf)
+	for _, want := range []int{16, 0, MaxSize} {
+		msg, err := r.Read()
+		if err != nil || len(msg) != want {
+			t.Fatalf("Read() = %d bytes, %v, want %d bytes", len(msg), err, want)
+		}
+	}
+	if _, err := r.Read(); err != io.EOF {
+		t.Errorf("Read() at the end = %v, want EOF", err)
+	}
+}
+
+func TestTooLarge(t *testing.T) {
+	header := make([]byte, HeaderSize)
+	binary.BigEndian.PutUint32(header, MaxSize+1)
+	if _, err := NewReader(bytes.NewReader(header)).Read(); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("Read() of an oversized frame = %v, want ErrTooLarge", err)
+	}
+
+	// A client from before framing sends a line of JSON, which reads as a huge length
+	if _, err := NewReader(strings.NewReader(`{"type":"hello"}` + "\n")).Read(); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("Read() of a newline framed message = %v, want ErrTooLarge", err)
+	}
+}
+
+func TestTruncated(t *testing.T) {
+	frame := Frame([]byte("hello"))
+	if _, err := NewReader(bytes.NewReader(frame[:7])).Read(); err != io.ErrUnexpectedEOF {
+		t.Errorf("Read() of a cut off frame = %v, want ErrUnexpectedEOF", err)
+	}
+}
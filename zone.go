@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"shooter/hud"
+	"shooter/zone"
+)
+
+// setZone follows the server's zone, planning its circles again when it's a new one.
+func (g *Game) setZone(u ZoneUpdate) {
+	if g.zone == nil || g.zone.Seed != u.Seed {
+		g.zone = zone.New(g.level.Width, g.level.Height, u.Seed)
+	}
+	g.zoneElapsed, g.zoneSynced = u.Elapsed, g.now()
+}
+
+// zoneState is where the zone is now, false without one.
+func (g *Game) zoneState() (zone.State, bool) {
+	if g.zone == nil {
+		return zone.State{}, false
+	}
+	return g.zone.At(g.zoneElapsed + g.now().Sub(g.zoneSynced)), true
+}
+
+// zoneObjective is the countdown to the zone's next move.
+func zoneObjective(s zone.State) string {
+	secs := int(s.Remaining.Round(time.Second).Seconds())
+	switch {
+	case s.Remaining == 0:
+		return "Final zone"
+	case s.Shrinking:
+		return fmt.Sprintf("Zone shrinking %d:%02d", secs/60, secs%60)
+	default:
+		return fmt.Sprintf("Zone shrinks in %d:%02d", secs/60, secs%60)
+	}
+}
+
+// drawZone draws the zone edge over the fog, players need to find it in the dark.
+func (g *Game) drawZone(screen *ebiten.Image) {
+	if z, ok := g.zoneState(); ok {
+		hud.DrawZone(screen, z)
+	}
+}
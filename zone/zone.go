@@ -0,0 +1,94 @@
+// Package zone is the play area shrinking over a match, battle royale style.
+package zone
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+const (
+	// Number of times the zone shrinks, it stays at the last circle after that
+	Phases = 4
+	// How long each phase waits before shrinking and then shrinks
+	WaitTime   = 30 * time.Second
+	ShrinkTime = 30 * time.Second
+	// Radius of each circle relative to the one before
+	ShrinkFactor = 0.6
+	// Damage per second outside the zone in the first phase, it grows by the same each phase
+	BaseDPS = 4
+)
+
+type Circle struct {
+	X, Y, Radius float64
+}
+
+// Contains is true when the point is inside the circle.
+func (c Circle) Contains(x, y float64) bool {
+	return math.Hypot(x-c.X, y-c.Y) <= c.Radius
+}
+
+// lerp is the circle t of the way from c to to.
+func (c Circle) lerp(to Circle, t float64) Circle {
+	return Circle{
+		X:      c.X + (to.X-c.X)*t,
+		Y:      c.Y + (to.Y-c.Y)*t,
+		Radius: c.Radius + (to.Radius-c.Radius)*t,
+	}
+}
+
+// Zone is the schedule of circles for a level, the same for everyone using the same seed.
+type Zone struct {
+	Seed    int64
+	circles [Phases + 1]Circle
+}
+
+// New plans the circles, starting with one around the whole level. Each next circle lies
+// inside the one before at a random spot.
+func New(width, height float64, seed int64) *Zone {
+	z := &Zone{Seed: seed}
+	rng := rand.New(rand.NewSource(seed))
+	z.circles[0] = Circle{X: width / 2, Y: height / 2, Radius: math.Hypot(width, height) / 2}
+	for i := 1; i <= Phases; i++ {
+		prev := z.circles[i-1]
+		r := prev.Radius * ShrinkFactor
+		angle, d := rng.Float64()*2*math.Pi, rng.Float64()*(prev.Radius-r)
+		x, y := prev.X+math.Cos(angle)*d, prev.Y+math.Sin(angle)*d
+		// Keep the center in the level, the first circle is much larger than it
+		x, y = math.Max(0, math.Min(width, x)), math.Max(0, math.Min(height, y))
+		z.circles[i] = Circle{X: x, Y: y, Radius: r}
+	}
+	return z
+}
+
+// State is where the zone is at a moment of the match.
+type State struct {
+	Current Circle
+	// Where it shrinks to next, the same as Current once done
+	Next      Circle
+	Phase     int
+	Shrinking bool
+	// Until the zone starts or stops shrinking, 0 once done
+	Remaining time.Duration
+}
+
+// At is the state of the zone the elapsed time into the match.
+func (z *Zone) At(elapsed time.Duration) State {
+	phase := int(elapsed / (WaitTime + ShrinkTime))
+	if phase >= Phases {
+		last := z.circles[Phases]
+		return State{Current: last, Next: last, Phase: Phases}
+	}
+	from, to := z.circles[phase], z.circles[phase+1]
+	into := elapsed - time.Duration(phase)*(WaitTime+ShrinkTime)
+	if into < WaitTime {
+		return State{Current: from, Next: to, Phase: phase, Remaining: WaitTime - into}
+	}
+	t := float64(into-WaitTime) / float64(ShrinkTime)
+	return State{Current: from.lerp(to, t), Next: to, Phase: phase, Shrinking: true, Remaining: ShrinkTime - (into - WaitTime)}
+}
+
+// DPS is the damage per second outside the zone, growing each phase.
+func (s State) DPS() int {
+	return BaseDPS * (s.Phase + 1)
+}
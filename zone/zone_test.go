@@ -0,0 +1,48 @@
+package zone
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCirclesNest(t *testing.T) {
+	for seed := int64(0); seed < 50; seed++ {
+		z := New(1600, 900, seed)
+		for i := 1; i <= Phases; i++ {
+			prev, c := z.circles[i-1], z.circles[i]
+			if c.Radius >= prev.Radius {
+				t.Fatalf("seed %d: circle %d doesn't shrink: %+v after %+v", seed, i, c, prev)
+			}
+			if !prev.Contains(c.X, c.Y) {
+				t.Fatalf("seed %d: circle %d centered outside the one before", seed, i)
+			}
+		}
+	}
+}
+
+func TestAt(t *testing.T) {
+	z := New(1600, 900, 7)
+	if got := New(1600, 900, 7); *got != *z {
+		t.Error("same seed planned different circles")
+	}
+
+	start := z.At(0)
+	if start.Current != z.circles[0] || start.Next != z.circles[1] || start.Shrinking || start.Remaining != WaitTime {
+		t.Errorf("At(0) = %+v", start)
+	}
+	half := z.At(WaitTime + ShrinkTime/2)
+	want := (z.circles[0].Radius + z.circles[1].Radius) / 2
+	if !half.Shrinking || half.Current.Radius != want || half.Remaining != ShrinkTime/2 {
+		t.Errorf("halfway shrinking = %+v, want radius %v", half, want)
+	}
+	if second := z.At(WaitTime + ShrinkTime + time.Second); second.Phase != 1 || second.Current != z.circles[1] {
+		t.Errorf("second phase = %+v", second)
+	}
+	end := z.At(time.Hour)
+	if end.Current != z.circles[Phases] || end.Next != end.Current || end.Remaining != 0 {
+		t.Errorf("end = %+v", end)
+	}
+	if start.DPS() >= end.DPS() {
+		t.Error("damage doesn't grow")
+	}
+}